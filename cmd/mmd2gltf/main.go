@@ -0,0 +1,174 @@
+// Command mmd2gltf converts a PMX model, optionally with a VMD motion, to a binary glTF file.
+//
+//	mmd2gltf [-motion dance.vmd] [-scale 0.08] [-o model.glb] model.pmx
+//	mmd2gltf -check model.glb
+//
+// Textures are read relative to the model file. The output is checked by gltf.Validate,
+// and the command fails if issues are found.
+package main
+
+import (
+	"app/lib/mmd/gltf"
+	"app/lib/mmd/pmx"
+	"app/lib/mmd/vmd"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+
+	log.SetFlags(0)
+	log.SetPrefix("mmd2gltf: ")
+
+	motion := flag.String("motion", "", "VMD motion baked into the animation")
+	scale := flag.Float64("scale", gltf.DefaultScale, "meters per MMD unit")
+	out := flag.String("o", "", "output GLB file (default: the model file with .glb)")
+	check := flag.Bool("check", false, "validate GLB files instead of converting")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: mmd2gltf [-motion dance.vmd] [-scale 0.08] [-o model.glb] model.pmx")
+		fmt.Fprintln(flag.CommandLine.Output(), "       mmd2gltf -check model.glb ...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *check {
+		if flag.NArg() == 0 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		failed := false
+		for _, name := range flag.Args() {
+			if !validate(name) {
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	name := flag.Arg(0)
+	if *out == "" {
+		*out = strings.TrimSuffix(name, filepath.Ext(name)) + ".glb"
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	model, err := pmx.Decode(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+
+	o := gltf.Options{Scale: *scale, ReadFile: textureReader(filepath.Dir(name))}
+	if *motion != "" {
+		f, err := os.Open(*motion)
+		if err != nil {
+			log.Fatal(err)
+		}
+		o.Motion, err = vmd.Decode(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", *motion, err)
+		}
+		o.AnimationName = strings.TrimSuffix(filepath.Base(*motion), filepath.Ext(*motion))
+	}
+
+	file, warnings, err := gltf.Export(model, o)
+	for _, w := range warnings {
+		log.Println("warning:", w)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := file.WriteGLB(&buf); err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%s: %d bones, %d materials, %d morph targets, %d bytes",
+		*out, len(model.Bones), len(file.Document.Materials), morphTargets(file), buf.Len())
+
+	if !validate(*out) {
+		os.Exit(1)
+	}
+}
+
+// textureReader reads textures in dir. Names are matched ignoring case if not found,
+// since models made on Windows often refer to files in different cases.
+func textureReader(dir string) func(name string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		data, err := ioutil.ReadFile(path)
+		if !os.IsNotExist(err) {
+			return data, err
+		}
+
+		// 大文字と小文字を区別せずに、一階層ずつ探す
+		current := dir
+		for _, part := range strings.Split(name, "/") {
+			entries, err := ioutil.ReadDir(current)
+			if err != nil {
+				return nil, err
+			}
+			found := ""
+			for _, e := range entries {
+				if strings.EqualFold(e.Name(), part) {
+					found = e.Name()
+					break
+				}
+			}
+			if found == "" {
+				return nil, fmt.Errorf("%s: %w", path, os.ErrNotExist)
+			}
+			current = filepath.Join(current, found)
+		}
+		return ioutil.ReadFile(current)
+	}
+}
+
+// validate reports issues of the GLB file, and returns whether it has none.
+func validate(name string) bool {
+
+	f, err := os.Open(name)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	defer f.Close()
+
+	file, err := gltf.ReadGLB(f)
+	if err != nil {
+		log.Printf("%s: %v", name, err)
+		return false
+	}
+	issues := file.Validate()
+	for _, issue := range issues {
+		log.Printf("%s: %v", name, issue)
+	}
+	return len(issues) == 0
+}
+
+func morphTargets(f *gltf.File) int {
+	if len(f.Document.Meshes) == 0 || len(f.Document.Meshes[0].Primitives) == 0 {
+		return 0
+	}
+	return len(f.Document.Meshes[0].Primitives[0].Targets)
+}
@@ -11,4 +11,40 @@ const (
 	ChangeMotion
 	// ResetPose is ...
 	ResetPose
+	// TogglePlayback pauses or resumes the motion.
+	TogglePlayback
+	// SeekMotion moves the motion to the time in seconds passed as argument.
+	SeekMotion
+	// ChangePlaybackSpeed changes the motion speed to the rate passed as argument.
+	ChangePlaybackSpeed
+	// SetLoopStart marks the current time as start of A-B loop.
+	SetLoopStart
+	// SetLoopEnd marks the current time as end of A-B loop and starts the loop.
+	SetLoopEnd
+	// ClearLoop clears A-B loop.
+	ClearLoop
+	// ToggleCameraMotion turns on/off the camera motion.
+	ToggleCameraMotion
+	// ToggleOutline turns on/off outlines of the model.
+	ToggleOutline
+	// ToggleSelfShadow turns on/off shadows of the model.
+	ToggleSelfShadow
+	// ToggleSphereMap turns on/off sphere maps of the model.
+	ToggleSphereMap
+	// ChangeToon switches toon ramps of the model to the next shared toon.
+	ChangeToon
+	// ChangeExpression switches the facial expression of the model and the avatars to the next one.
+	ChangeExpression
+	// ChangePhysics switches physics of the model to the next mode and reloads the model.
+	ChangePhysics
+	// ToggleRecording starts recording the model on screen, or stops and saves it as a VMD file.
+	ToggleRecording
+	// CleanFootContacts fixes foot sliding of the motion on the model and saves it as a VMD file.
+	CleanFootContacts
+	// MirrorMotion saves the mirror images of the motion and its camera motion as VMD files.
+	MirrorMotion
+	// ExportBVH saves the motion on the model as a BVH file.
+	ExportBVH
+	// ToggleCredits shows or hides the credits of the model and the motion.
+	ToggleCredits
 )
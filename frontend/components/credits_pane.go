@@ -0,0 +1,32 @@
+package components
+
+import (
+	"app/frontend/store"
+
+	"github.com/nobonobo/spago"
+)
+
+//go:generate spago generate -c CreditsPane -p components credits_pane.html
+
+// CreditsPane shows the author, the terms of use and the statistics of the current model and motion.
+type CreditsPane struct {
+	spago.Core
+}
+
+// visibility hides the pane unless store.CreditsVisible.
+func (c *CreditsPane) visibility() string {
+	if store.CreditsVisible {
+		return ""
+	}
+	return "is-hidden"
+}
+
+// terms renders lines of the terms of use.
+func (c *CreditsPane) terms() spago.Markup {
+	lines := store.CreditsStore().ModelTerms
+	items := make([]spago.Markup, 0, len(lines))
+	for _, line := range lines {
+		items = append(items, spago.Tag("li", spago.T(line)))
+	}
+	return spago.Tag("ul", items...)
+}
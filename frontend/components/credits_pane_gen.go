@@ -0,0 +1,58 @@
+package components
+
+import (
+	"app/frontend/store"
+	"github.com/nobonobo/spago"
+)
+
+// Render ...
+func (c *CreditsPane) Render() spago.HTML {
+	return spago.Tag("div", 		
+		spago.A("class", spago.S(`content `, spago.S(c.visibility()), ``)),
+		spago.Tag("ul", 
+			spago.Tag("li", 
+				spago.T(`Model : `, spago.S(store.CreditsStore().ModelName), ``),
+			),
+			spago.Tag("li", 
+				spago.T(`English Name : `, spago.S(store.CreditsStore().ModelEnglishName), ``),
+			),
+			spago.Tag("li", 
+				spago.T(`Author : `, spago.S(store.CreditsStore().ModelAuthor), ``),
+			),
+			spago.Tag("li", 
+				spago.T(`Vertices : `, spago.S(store.CreditsStore().Vertices), ``),
+			),
+			spago.Tag("li", 
+				spago.T(`Materials : `, spago.S(store.CreditsStore().Materials), ``),
+			),
+			spago.Tag("li", 
+				spago.T(`Bones : `, spago.S(store.CreditsStore().Bones), ``),
+			),
+			spago.Tag("li", 
+				spago.T(`Morphs : `, spago.S(store.CreditsStore().Morphs), ``),
+			),
+		),
+		spago.Tag("p", 			
+			spago.A("style", spago.S(`white-space: pre-wrap;`)),
+			spago.T(``, spago.S(store.CreditsStore().ModelComment), ``),
+		),
+		c.terms(),
+		spago.Tag("ul", 
+			spago.Tag("li", 
+				spago.T(`Motion : `, spago.S(store.CreditsStore().MotionFile), ``),
+			),
+			spago.Tag("li", 
+				spago.T(`For Model : `, spago.S(store.CreditsStore().MotionModel), ``),
+			),
+			spago.Tag("li", 
+				spago.T(`Bone Keys : `, spago.S(store.CreditsStore().MotionBoneKeys), ``),
+			),
+			spago.Tag("li", 
+				spago.T(`Morph Keys : `, spago.S(store.CreditsStore().MotionMorphKeys), ``),
+			),
+			spago.Tag("li", 
+				spago.T(`Frames : `, spago.S(store.CreditsStore().MotionFrames), ``),
+			),
+		),
+	)
+}
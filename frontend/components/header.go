@@ -3,6 +3,7 @@ package components
 import (
 	"app/frontend/actions"
 	"app/frontend/store"
+	"fmt"
 	"syscall/js"
 
 	"github.com/nobonobo/spago"
@@ -79,6 +80,133 @@ func (c *Header) changeMotionToDance2(ev js.Value) {
 
 }
 
+func (c *Header) outlineLabel() string {
+	if store.OutlineEnabled {
+		return "Outline: On"
+	}
+	return "Outline: Off"
+}
+
+func (c *Header) toggleOutline(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ToggleOutline)
+}
+
+func (c *Header) selfShadowLabel() string {
+	if store.SelfShadowEnabled {
+		return "Shadow: On"
+	}
+	return "Shadow: Off"
+}
+
+func (c *Header) toggleSelfShadow(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ToggleSelfShadow)
+}
+
+func (c *Header) sphereMapLabel() string {
+	if store.CurrentModel.Material().SphereMap {
+		return "Sphere Map: On"
+	}
+	return "Sphere Map: Off"
+}
+
+func (c *Header) toggleSphereMap(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ToggleSphereMap)
+}
+
+func (c *Header) toonLabel() string {
+	if n := store.CurrentModel.Material().SharedToon; n > 0 {
+		return fmt.Sprintf("Toon: Shared %02d", n)
+	}
+	return "Toon: Model"
+}
+
+func (c *Header) changeToon(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ChangeToon)
+}
+
+func (c *Header) expressionLabel() string {
+	if name := store.Expressions[store.CurrentExpression]; name != "" {
+		return "Expression: " + name
+	}
+	return "Expression: None"
+}
+
+func (c *Header) changeExpression(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ChangeExpression)
+}
+
+func (c *Header) physicsLabel() string {
+	switch store.CurrentModel.Physics() {
+	case store.PhysicsAmmo:
+		return "Physics: Ammo.js"
+	case store.PhysicsSpringBone:
+		return "Physics: Spring Bone"
+	default:
+		return "Physics: Off"
+	}
+}
+
+func (c *Header) changePhysics(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ChangePhysics)
+}
+
+func (c *Header) creditsLabel() string {
+	if store.CreditsVisible {
+		return "Credits: On"
+	}
+	return "Credits: Off"
+}
+
+func (c *Header) toggleCredits(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ToggleCredits)
+}
+
+func (c *Header) cameraMotionLabel() string {
+	if store.CameraMotionEnabled {
+		return "Camera Motion: On"
+	}
+	return "Camera Motion: Off"
+}
+
+func (c *Header) toggleCameraMotion(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ToggleCameraMotion)
+}
+
+func (c *Header) recordingLabel() string {
+	if store.Recording {
+		return "Record: Stop and Save"
+	}
+	return "Record: Start"
+}
+
+func (c *Header) toggleRecording(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ToggleRecording)
+}
+
+func (c *Header) cleanFootContacts(ev js.Value) {
+
+	dispatcher.Dispatch(actions.CleanFootContacts)
+}
+
+func (c *Header) mirrorMotion(ev js.Value) {
+
+	dispatcher.Dispatch(actions.MirrorMotion)
+}
+
+func (c *Header) exportBVH(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ExportBVH)
+}
+
 func (c *Header) changeMotionToDance3(ev js.Value) {
 
 	store.CurrentMotion = store.Dance3
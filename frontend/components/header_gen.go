@@ -79,6 +79,44 @@ func (c *Header) Render() spago.HTML {
 								spago.Event("click", c.changeModelToMiku),
 								spago.T(`Miku`),
 							),
+							spago.Tag("hr", 								
+								spago.A("class", spago.S(`navbar-divider`)),
+							),
+							spago.Tag("a", 								
+								spago.A("class", spago.S(`navbar-item`)),
+								spago.Event("click", c.toggleOutline),
+								spago.T(``, spago.S(c.outlineLabel()), ``),
+							),
+							spago.Tag("a", 								
+								spago.A("class", spago.S(`navbar-item`)),
+								spago.Event("click", c.toggleSelfShadow),
+								spago.T(``, spago.S(c.selfShadowLabel()), ``),
+							),
+							spago.Tag("a", 								
+								spago.A("class", spago.S(`navbar-item`)),
+								spago.Event("click", c.toggleSphereMap),
+								spago.T(``, spago.S(c.sphereMapLabel()), ``),
+							),
+							spago.Tag("a", 								
+								spago.A("class", spago.S(`navbar-item`)),
+								spago.Event("click", c.changeToon),
+								spago.T(``, spago.S(c.toonLabel()), ``),
+							),
+							spago.Tag("a", 								
+								spago.A("class", spago.S(`navbar-item`)),
+								spago.Event("click", c.changeExpression),
+								spago.T(``, spago.S(c.expressionLabel()), ``),
+							),
+							spago.Tag("a", 								
+								spago.A("class", spago.S(`navbar-item`)),
+								spago.Event("click", c.changePhysics),
+								spago.T(``, spago.S(c.physicsLabel()), ``),
+							),
+							spago.Tag("a", 								
+								spago.A("class", spago.S(`navbar-item`)),
+								spago.Event("click", c.toggleCredits),
+								spago.T(``, spago.S(c.creditsLabel()), ``),
+							),
 						),
 					),
 					spago.Tag("div", 						
@@ -104,6 +142,34 @@ func (c *Header) Render() spago.HTML {
 								spago.Event("click", c.changeMotionToDance3),
 								spago.T(`Dance3`),
 							),
+							spago.Tag("hr", 								
+								spago.A("class", spago.S(`navbar-divider`)),
+							),
+							spago.Tag("a", 								
+								spago.A("class", spago.S(`navbar-item`)),
+								spago.Event("click", c.toggleCameraMotion),
+								spago.T(``, spago.S(c.cameraMotionLabel()), ``),
+							),
+							spago.Tag("a", 								
+								spago.A("class", spago.S(`navbar-item`)),
+								spago.Event("click", c.toggleRecording),
+								spago.T(``, spago.S(c.recordingLabel()), ``),
+							),
+							spago.Tag("a", 								
+								spago.A("class", spago.S(`navbar-item`)),
+								spago.Event("click", c.cleanFootContacts),
+								spago.T(`Fix Foot Sliding and Save`),
+							),
+							spago.Tag("a", 								
+								spago.A("class", spago.S(`navbar-item`)),
+								spago.Event("click", c.mirrorMotion),
+								spago.T(`Mirror and Save`),
+							),
+							spago.Tag("a", 								
+								spago.A("class", spago.S(`navbar-item`)),
+								spago.Event("click", c.exportBVH),
+								spago.T(`Export BVH`),
+							),
 						),
 					),
 				),
@@ -0,0 +1,100 @@
+package components
+
+import (
+	"app/frontend/actions"
+	"app/frontend/store"
+	"fmt"
+	"math"
+	"strconv"
+	"syscall/js"
+
+	"github.com/nobonobo/spago"
+	"github.com/nobonobo/spago/dispatcher"
+)
+
+//go:generate spago generate -c Timeline -p components timeline.html
+
+// Timeline is playback controls of the motion.
+type Timeline struct {
+	spago.Core
+}
+
+// NewTimeline creates Timeline.
+func NewTimeline() *Timeline {
+	return &Timeline{}
+}
+
+func (c *Timeline) playIcon() string {
+	if store.PlaybackStore().Paused {
+		return "fas fa-play"
+	}
+	return "fas fa-pause"
+}
+
+func (c *Timeline) timeLabel() string {
+	p := store.PlaybackStore()
+	return fmt.Sprintf("%v / %v", frameOf(p.Time), frameOf(p.Duration))
+}
+
+func (c *Timeline) loopLabel() string {
+	p := store.PlaybackStore()
+	if !p.Loop {
+		return "A-B"
+	}
+	return fmt.Sprintf("%v - %v", frameOf(p.LoopStart), frameOf(p.LoopEnd))
+}
+
+func (c *Timeline) speedClass(s float64) string {
+	if store.PlaybackStore().Speed == s {
+		return "button is-small is-info is-selected"
+	}
+	return "button is-small"
+}
+
+func frameOf(t float64) int {
+	return int(math.Floor(t * 30))
+}
+
+func (c *Timeline) togglePause(ev js.Value) {
+
+	dispatcher.Dispatch(actions.TogglePlayback)
+}
+
+func (c *Timeline) seek(ev js.Value) {
+
+	t, err := strconv.ParseFloat(ev.Get("target").Get("value").String(), 64)
+	if err != nil {
+		return
+	}
+	dispatcher.Dispatch(actions.SeekMotion, t)
+}
+
+func (c *Timeline) changeSpeedToQuarter(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ChangePlaybackSpeed, 0.25)
+}
+
+func (c *Timeline) changeSpeedToHalf(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ChangePlaybackSpeed, 0.5)
+}
+
+func (c *Timeline) changeSpeedToNormal(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ChangePlaybackSpeed, 1.0)
+}
+
+func (c *Timeline) setLoopStart(ev js.Value) {
+
+	dispatcher.Dispatch(actions.SetLoopStart)
+}
+
+func (c *Timeline) setLoopEnd(ev js.Value) {
+
+	dispatcher.Dispatch(actions.SetLoopEnd)
+}
+
+func (c *Timeline) clearLoop(ev js.Value) {
+
+	dispatcher.Dispatch(actions.ClearLoop)
+}
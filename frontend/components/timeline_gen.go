@@ -0,0 +1,93 @@
+package components
+
+import (
+	"app/frontend/store"
+	"github.com/nobonobo/spago"
+)
+
+// Render ...
+func (c *Timeline) Render() spago.HTML {
+	return spago.Tag("div", 		
+		spago.A("class", spago.S(`box p-2 mb-0`)),
+		spago.Tag("div", 			
+			spago.A("class", spago.S(`level is-mobile mb-1`)),
+			spago.Tag("div", 				
+				spago.A("class", spago.S(`level-left`)),
+				spago.Tag("div", 					
+					spago.A("class", spago.S(`level-item`)),
+					spago.Tag("button", 						
+						spago.A("class", spago.S(`button is-small`)),
+						spago.Event("click", c.togglePause),
+						spago.Tag("span", 							
+							spago.A("class", spago.S(`icon`)),
+							spago.Tag("i", 								
+								spago.A("class", spago.S(``, spago.S(c.playIcon()), ``)),
+							),
+						),
+					),
+				),
+				spago.Tag("div", 					
+					spago.A("class", spago.S(`level-item`)),
+					spago.Tag("span", 						
+						spago.A("class", spago.S(`is-size-7`)),
+						spago.T(``, spago.S(c.timeLabel()), ``),
+					),
+				),
+			),
+			spago.Tag("div", 				
+				spago.A("class", spago.S(`level-right`)),
+				spago.Tag("div", 					
+					spago.A("class", spago.S(`level-item`)),
+					spago.Tag("div", 						
+						spago.A("class", spago.S(`buttons has-addons mb-0`)),
+						spago.Tag("button", 							
+							spago.A("class", spago.S(``, spago.S(c.speedClass(0.25)), ``)),
+							spago.Event("click", c.changeSpeedToQuarter),
+							spago.T(`x0.25`),
+						),
+						spago.Tag("button", 							
+							spago.A("class", spago.S(``, spago.S(c.speedClass(0.5)), ``)),
+							spago.Event("click", c.changeSpeedToHalf),
+							spago.T(`x0.5`),
+						),
+						spago.Tag("button", 							
+							spago.A("class", spago.S(``, spago.S(c.speedClass(1)), ``)),
+							spago.Event("click", c.changeSpeedToNormal),
+							spago.T(`x1`),
+						),
+					),
+				),
+				spago.Tag("div", 					
+					spago.A("class", spago.S(`level-item`)),
+					spago.Tag("div", 						
+						spago.A("class", spago.S(`buttons has-addons mb-0`)),
+						spago.Tag("button", 							
+							spago.A("class", spago.S(`button is-small`)),
+							spago.Event("click", c.setLoopStart),
+							spago.T(`A`),
+						),
+						spago.Tag("button", 							
+							spago.A("class", spago.S(`button is-small`)),
+							spago.Event("click", c.setLoopEnd),
+							spago.T(`B`),
+						),
+						spago.Tag("button", 							
+							spago.A("class", spago.S(`button is-small`)),
+							spago.Event("click", c.clearLoop),
+							spago.T(``, spago.S(c.loopLabel()), ``),
+						),
+					),
+				),
+			),
+		),
+		spago.Tag("input", 			
+			spago.A("type", spago.S(`range`)),
+			spago.A("min", spago.S(`0`)),
+			spago.A("max", spago.S(``, spago.S(store.PlaybackStore().Duration), ``)),
+			spago.A("step", spago.S(`0.0333`)),
+			spago.A("value", spago.S(``, spago.S(store.PlaybackStore().Time), ``)),
+			spago.A("style", spago.S(`width: 100%;`)),
+			spago.Event("change", c.seek),
+		),
+	)
+}
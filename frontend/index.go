@@ -4,7 +4,6 @@ import (
 	"app/frontend/actions"
 	"app/frontend/views"
 	"log"
-	"syscall/js"
 
 	"github.com/nobonobo/spago"
 	"github.com/nobonobo/spago/dispatcher"
@@ -38,7 +37,89 @@ func init() {
 		topView.ResetPose()
 	})
 
-	loadScript("./assets/threejs/ex/js/libs/ammo.wasm.js")
+	dispatcher.Register(actions.TogglePlayback, func(args ...interface{}) {
+		topView.TogglePlayback()
+	})
+
+	dispatcher.Register(actions.SeekMotion, func(args ...interface{}) {
+		topView.SeekMotion(args[0].(float64))
+	})
+
+	dispatcher.Register(actions.ChangePlaybackSpeed, func(args ...interface{}) {
+		topView.ChangePlaybackSpeed(args[0].(float64))
+	})
+
+	dispatcher.Register(actions.SetLoopStart, func(args ...interface{}) {
+		topView.SetLoopStart()
+	})
+
+	dispatcher.Register(actions.SetLoopEnd, func(args ...interface{}) {
+		topView.SetLoopEnd()
+	})
+
+	dispatcher.Register(actions.ClearLoop, func(args ...interface{}) {
+		topView.ClearLoop()
+	})
+
+	dispatcher.Register(actions.ToggleCameraMotion, func(args ...interface{}) {
+		log.Println("Toggle camera motion.")
+		topView.ToggleCameraMotion()
+	})
+
+	dispatcher.Register(actions.ToggleOutline, func(args ...interface{}) {
+		log.Println("Toggle outline.")
+		topView.ToggleOutline()
+	})
+
+	dispatcher.Register(actions.ToggleSelfShadow, func(args ...interface{}) {
+		log.Println("Toggle self shadow.")
+		topView.ToggleSelfShadow()
+	})
+
+	dispatcher.Register(actions.ToggleSphereMap, func(args ...interface{}) {
+		log.Println("Toggle sphere map.")
+		topView.ToggleSphereMap()
+	})
+
+	dispatcher.Register(actions.ChangeToon, func(args ...interface{}) {
+		log.Println("Change toon.")
+		topView.ChangeToon()
+	})
+
+	dispatcher.Register(actions.ChangeExpression, func(args ...interface{}) {
+		log.Println("Change expression.")
+		topView.ChangeExpression()
+	})
+
+	dispatcher.Register(actions.ChangePhysics, func(args ...interface{}) {
+		log.Println("Change physics.")
+		topView.ChangePhysics()
+	})
+
+	dispatcher.Register(actions.ToggleRecording, func(args ...interface{}) {
+		log.Println("Toggle recording.")
+		topView.ToggleRecording()
+	})
+
+	dispatcher.Register(actions.CleanFootContacts, func(args ...interface{}) {
+		log.Println("Clean foot contacts.")
+		topView.CleanFootContacts()
+	})
+
+	dispatcher.Register(actions.MirrorMotion, func(args ...interface{}) {
+		log.Println("Mirror motion.")
+		topView.MirrorMotion()
+	})
+
+	dispatcher.Register(actions.ExportBVH, func(args ...interface{}) {
+		log.Println("Export BVH.")
+		topView.ExportBVH()
+	})
+
+	dispatcher.Register(actions.ToggleCredits, func(args ...interface{}) {
+		log.Println("Toggle credits.")
+		topView.ToggleCredits()
+	})
 
 }
 
@@ -60,22 +141,3 @@ func main() {
 
 	select {}
 }
-
-// loadScript synchronous javascript loader
-func loadScript(url string) {
-
-	document := js.Global().Get("document")
-
-	ch := make(chan bool)
-	script := document.Call("createElement", "script")
-	script.Set("src", url)
-	var fn js.Func
-	fn = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		defer fn.Release()
-		close(ch)
-		return nil
-	})
-	script.Call("addEventListener", "load", fn)
-	document.Get("head").Call("appendChild", script)
-	<-ch
-}
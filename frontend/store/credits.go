@@ -0,0 +1,34 @@
+package store
+
+// Credits is the information of the current model and motion shown in the credits pane.
+// Many MMD models require users to credit the author and follow the terms in the comment.
+type Credits struct {
+	ModelName        string
+	ModelEnglishName string
+	ModelAuthor      string
+	ModelComment     string
+	// ModelTerms are lines of the comment about the terms of use.
+	ModelTerms []string
+
+	Vertices  string
+	Materials string
+	Bones     string
+	Morphs    string
+
+	MotionFile string
+	// MotionModel is the name of the model the motion is made for.
+	MotionModel     string
+	MotionBoneKeys  string
+	MotionMorphKeys string
+	MotionFrames    string
+}
+
+var credits *Credits = &Credits{}
+
+// CreditsStore gets the information of the current model and motion.
+func CreditsStore() *Credits {
+	return credits
+}
+
+// CreditsVisible is a flag whether the credits pane is shown.
+var CreditsVisible bool = true
@@ -1,5 +1,7 @@
 package store
 
+import "app/lib/threejs/effect"
+
 // Model is MMD Model Number.
 type Model int
 
@@ -30,3 +32,96 @@ func (c Model) Path() string {
 	}
 
 }
+
+// ReadmePath gets the path of the readme distributed with the MMD model.
+func (c Model) ReadmePath() string {
+
+	switch c {
+	case Diluc:
+		return "./assets/models/mmd/diluc/readme.txt"
+	case Lisa:
+		return "./assets/models/mmd/lisa/readme.txt"
+	case Miku:
+		return "./assets/models/mmd/miku/readme.txt"
+	default:
+		return ""
+	}
+
+}
+
+// OutlineEnabled is a flag whether outlines of the model are drawn from PMX edge settings.
+var OutlineEnabled bool = true
+
+// SelfShadowEnabled is a flag whether the model casts shadows.
+var SelfShadowEnabled bool = true
+
+// OutlineThicknessScale is the scale multiplied to the edge size of all materials.
+var OutlineThicknessScale float64 = 1.0
+
+// MaterialOutlines is outline overrides by material name for each model.
+var MaterialOutlines map[Model]map[string]effect.OutlineParameters = make(map[Model]map[string]effect.OutlineParameters)
+
+// ModelMaterial is material settings of a model.
+type ModelMaterial struct {
+	// SphereMap is a flag whether sphere maps (.sph / .spa) are drawn.
+	SphereMap bool
+	// SharedToon is the shared toon number (1 - 10) used for all materials instead of toons of the model.
+	// If 0, toons of the model are used.
+	SharedToon int
+}
+
+// Expressions are names of MMD morphs switched by the expression menu. "" is no expression.
+// They are mapped to presets of VRM avatars by vrm.MMDMorphs, so avatars make the same expressions.
+var Expressions = []string{"", "笑い", "まばたき", "ウィンク", "怒り", "困る", "びっくり"}
+
+// CurrentExpression is the index of Expressions set to the model and the avatars.
+var CurrentExpression int
+
+// ModelMaterials is material settings for each model.
+var ModelMaterials map[Model]*ModelMaterial = make(map[Model]*ModelMaterial)
+
+// Material gets material settings of the model.
+func (c Model) Material() *ModelMaterial {
+
+	m, ok := ModelMaterials[c]
+	if !ok {
+		m = &ModelMaterial{SphereMap: true}
+		ModelMaterials[c] = m
+	}
+
+	return m
+}
+
+// Physics is how bones of hair and skirts are moved.
+type Physics int
+
+const (
+	// PhysicsAmmo moves them by rigid bodies with Ammo.js.
+	PhysicsAmmo Physics = iota
+	// PhysicsSpringBone moves them by springs in Go, which is lighter than Ammo.js.
+	PhysicsSpringBone
+	// PhysicsOff does not move them.
+	PhysicsOff
+)
+
+// ModelPhysics is physics of each model. Models not in it use Ammo.js.
+var ModelPhysics map[Model]Physics = make(map[Model]Physics)
+
+// Physics gets physics of the model.
+func (c Model) Physics() Physics {
+	return ModelPhysics[c]
+}
+
+// Prop is a glTF model placed on the stage with the MMD model.
+type Prop struct {
+	// Path is the .gltf or .glb file, or the .vrm file of an avatar which dances the current motion.
+	Path string
+	// Position is the position in MMD units.
+	Position [3]float64
+	// Scale is MMD units per meter of the file. If 0, 12.5 is used, since 1 MMD unit is about 8 cm.
+	Scale float64
+}
+
+// Props is glTF models placed on the stage. Their first animations are played in a loop.
+// VRM avatars play the current motion retargeted to their humanoid bones instead.
+var Props []Prop
@@ -1,6 +1,11 @@
 package store
 
-import "app/lib/threejs/animation"
+import (
+	"app/lib/mmd/beat"
+	"app/lib/mmd/vmd"
+	"app/lib/threejs"
+	"app/lib/threejs/animation"
+)
 
 // Motion is MMD Model animation number.
 type Motion int
@@ -15,6 +20,21 @@ const (
 )
 
 var (
+	_cameraMotions = []string{
+		"",
+		"./assets/models/mmd/vmds/wavefile_camera.vmd",
+		"",
+		"",
+	}
+
+	// _audios are songs of the motions, played with them after the delay suggested by beat alignment.
+	_audios = []string{
+		"",
+		"./assets/models/mmd/audios/wavefile_short.ogg",
+		"./assets/models/mmd/audios/みんなみっくみくにしてあげる.ogg",
+		"./assets/models/mmd/audios/ダブルラリアット.ogg",
+	}
+
 	_motions = []string{
 		"",
 		"./assets/models/mmd/vmds/wavefile_v2.vmd",
@@ -23,12 +43,69 @@ var (
 	}
 )
 
+// Motions is all motions to be loaded with a model.
+var Motions = []Motion{Dance1, Dance2, Dance3}
+
 // CurrentMotion is ..
 var CurrentMotion Motion = Dance1
 var MotionDictionay map[Motion]animation.Clip = make(map[Motion]animation.Clip)
 
+// CameraMotionDictionary is camera motion clips for the view camera.
+var CameraMotionDictionary map[Motion]animation.Clip = make(map[Motion]animation.Clip)
+
+// SceneMotionDictionary is keyframes of the light and the self shadow decoded from the motion and camera motion files.
+var SceneMotionDictionary map[Motion]*vmd.Motion = make(map[Motion]*vmd.Motion)
+
+// MotionAlignments is the delay and the tempo scale suggested by the beats of the songs and the motions.
+// They are detected when the songs are loaded, and kept until the page is reloaded.
+var MotionAlignments map[Motion]beat.Alignment = make(map[Motion]beat.Alignment)
+
+// SongDictionary is the decoded songs of the motions.
+var SongDictionary map[Motion]threejs.AudioBuffer = make(map[Motion]threejs.AudioBuffer)
+
+// LightHemisphereBlend is the ratio of the hemisphere light intensity kept while light keyframes of the motion are played.
+// 0 lights the scene only with the keyframes, as MMD does.
+var LightHemisphereBlend float64 = 0.5
+
+// Recording is a flag whether the pose of the model on screen is being recorded.
+var Recording bool
+
+// RecordBakePhysics is a flag whether bones moved by physics are recorded.
+var RecordBakePhysics bool = true
+
+// RecordReduceKeys is a flag whether keyframes of the recorded motion are reduced by fitting Bezier curves.
+var RecordReduceKeys bool = true
+
+// BVHMapping is the name of the preset of bvh.Presets used to export BVH files.
+var BVHMapping string = "cmu"
+
+// BVHTPose is a flag whether exported BVH files are in the T-pose instead of the A-pose of MMD.
+var BVHTPose bool = true
+
+// CameraMotionEnabled is a flag whether the camera motion is played with the motion.
+var CameraMotionEnabled bool = true
+
+// MotionFadeDuration is blend time in seconds when the motion is changed.
+// If 0, the next motion starts immediately.
+var MotionFadeDuration float64 = 1.0
+
+// MotionFadeWarp is a flag whether the time scales are warped while motions are blended.
+var MotionFadeWarp bool = false
+
 // Path gets MMD motion file path.
 func (c Motion) Path() string {
 
 	return _motions[c]
 }
+
+// CameraPath gets MMD camera motion file path. It returns empty string if the motion has no camera motion.
+func (c Motion) CameraPath() string {
+
+	return _cameraMotions[c]
+}
+
+// AudioPath gets the song file path of the motion. It returns empty string if the motion has no song.
+func (c Motion) AudioPath() string {
+
+	return _audios[c]
+}
@@ -0,0 +1,20 @@
+package store
+
+// Playback is state of the motion timeline.
+type Playback struct {
+	Time     float64
+	Duration float64
+	Paused   bool
+	Speed    float64
+
+	Loop      bool
+	LoopStart float64
+	LoopEnd   float64
+}
+
+var playback *Playback = &Playback{Speed: 1}
+
+// PlaybackStore gets ...
+func PlaybackStore() *Playback {
+	return playback
+}
@@ -4,21 +4,34 @@ import (
 	"app/frontend/actions"
 	"app/frontend/components"
 	"app/frontend/store"
+	"app/lib/mmd/beat"
+	"app/lib/mmd/bvh"
+	"app/lib/mmd/footlock"
+	"app/lib/mmd/pmx"
+	"app/lib/mmd/vmd"
+	"app/lib/mmd/vrm"
 	"app/lib/threejs"
 	"app/lib/threejs/animation"
 	"app/lib/threejs/camera"
 	"app/lib/threejs/control"
+	"app/lib/threejs/effect"
+	"app/lib/threejs/gltf"
 	"app/lib/threejs/light"
 	"app/lib/threejs/mmd"
 	"app/lib/threejs/object/sky"
 	"app/lib/threejs/object/water"
 	"app/lib/threejs/texture"
+	"bytes"
 	"context"
+	"errors"
 	"log"
 	"math"
 	"os"
+	"path"
 	"runtime/pprof"
 	"strconv"
+	"strings"
+	"sync"
 	"syscall/js"
 
 	"github.com/nobonobo/spago"
@@ -27,11 +40,20 @@ import (
 
 //go:generate spago generate -c Top -p views top.html
 
+// timelineRefreshInterval is interval in seconds to refresh the timeline while the motion is playing.
+const timelineRefreshInterval = 0.25
+
+// ammoPath is the script of Ammo.js, which is loaded when a model using it is loaded first.
+const ammoPath = "./assets/threejs/ex/js/libs/ammo.wasm.js"
+
+var ammoOnce sync.Once
+
 // Top  ...
 type Top struct {
 	spago.Core
 
-	header *components.Header
+	header   *components.Header
+	timeline *components.Timeline
 
 	canvasWidth  int
 	canvasHeight int
@@ -44,13 +66,43 @@ type Top struct {
 	control  control.OrbitControls
 	clock    threejs.Clock
 
-	// effector      *effect.OutlineEffect
+	effector *effect.OutlineEffect
 
 	animator      *mmd.AnimationHelper
+	director      *mmd.CameraDirector
+	playback      *mmd.PlaybackController
 	characterMesh threejs.SkinnedMesh
+	outline       *mmd.ModelOutline
+	materials     *mmd.ModelMaterials
+	shadow        *mmd.SelfShadow
+	lights        *mmd.LightDirector
+	attachments   *mmd.BoneAttachments
+	recorder      *mmd.Recorder
+	springs       *mmd.SpringBones
+	hemisphere    light.HemisphereLight
+	currentAction animation.Action
 	ocean         *water.Ocean
+	// listener hears song, the song of the motion played by animator.
+	listener threejs.AudioListener
+	song     threejs.Audio
+	// propMixers plays animations of the glTF props.
+	propMixers []animation.Mixer
+	// face is the facial morphs of the model, and avatarFaces are those of the VRM avatars.
+	face        mmd.Face
+	avatarFaces []mmd.Face
+	// cancelReload cancels loading credits of the previous ReloadModel, and cancelMotionCredits those of the previous motion.
+	cancelReload        context.CancelFunc
+	cancelMotionCredits context.CancelFunc
+
+	// sharedToons is the shared toon textures loaded by number.
+	sharedToons map[int]threejs.Texture
 	// clip          animation.Clip
 
+	// loopStart is time in seconds marked as start of A-B loop.
+	loopStart float64
+	// refreshElapsed is time in seconds since the timeline was refreshed.
+	refreshElapsed float64
+
 	renderFunction js.Func
 }
 
@@ -62,6 +114,8 @@ func NewTop() *Top {
 		canvasWidth:  0,
 		canvasHeight: 0,
 		header:       components.NewHeader(),
+		timeline:     components.NewTimeline(),
+		sharedToons:  make(map[int]threejs.Texture),
 	}
 
 	return top
@@ -70,6 +124,12 @@ func NewTop() *Top {
 // DisposeModel is ...
 func (c *Top) DisposeModel() {
 
+	// モデルの読み込みに失敗していても、ReloadModelで作られたCameraDirectorは破棄する
+	if c.director != nil {
+		c.director.Dispose()
+		c.director = nil
+	}
+
 	if c.animator == nil || c.characterMesh == nil {
 		log.Println("No model is loaded.")
 		return
@@ -79,24 +139,56 @@ func (c *Top) DisposeModel() {
 	if err == nil {
 		c.animator.RemoveMesh(c.characterMesh)
 	}
+	if c.animator.HasAudio() {
+		c.animator.RemoveAudio(c.song)
+	}
 
+	// 小物がモデルと共に破棄されないよう、先にボーンから外す
+	if c.attachments != nil {
+		c.attachments.DetachAll()
+	}
+	c.shadow.RemoveCaster(c.characterMesh)
 	c.scene.Remove(c.characterMesh)
 	c.characterMesh.DisposeAll()
 
+	// 録画中のモーションはモデルと共に破棄する
+	c.recorder = nil
+	store.Recording = false
+
 	c.characterMesh = nil
+	c.face = nil
+	c.springs = nil
+	c.outline = nil
+	c.materials = nil
+	c.attachments = nil
+	c.currentAction = nil
 	c.animator = nil
+	c.playback = nil
+
+	// Clipはメッシュに合わせて生成されているため、モデルと共に破棄する
+	store.MotionDictionay = make(map[store.Motion]animation.Clip)
 
 }
 
 // ReloadModel is ...
 func (c *Top) ReloadModel() {
 
+	// 遅れて終わった前の読み込みが、新しいモデルの情報を上書きしないようにする
+	if c.cancelReload != nil {
+		c.cancelReload()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancelReload = cancel
+
 	c.DisposeModel()
+	c.loadModelCredits(ctx)
 
 	mmdHelper := mmd.NewAnimationHelper(map[string]interface{}{
 		"afterglow": 2.0,
 	})
 	c.animator = mmdHelper
+	c.playback = mmd.NewPlaybackController(mmdHelper, 60)
+	c.director = mmd.NewCameraDirector(mmdHelper, c.camera.(camera.PerspectiveCamera), c.control, 1.0)
 
 	var fn js.Func
 	fn = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
@@ -104,12 +196,15 @@ func (c *Top) ReloadModel() {
 
 		// model
 		modelFile := store.CurrentModel.Path()
-		// vmdFiles := []string{store.CurrentMotion.Path()}
-		// cameraFiles := []string{"./assets/models/mmd/vmds/wavefile_camera.vmd"}
 
 		manager := threejs.NewLoadingManager()
 		manager.SetOnLoad(func() {
 			c.scene.AddMesh(c.characterMesh)
+			c.shadow.AddCaster(c.characterMesh)
+			c.attachments = mmd.NewBoneAttachments(c.characterMesh)
+			c.setupSpringBones()
+			c.setupOutline()
+			c.setupMaterials()
 		})
 
 		mmdLoader := mmd.NewLoaderWithManager(manager)
@@ -128,8 +223,10 @@ func (c *Top) ReloadModel() {
 						continue
 					}
 
-					if v.Mesh() != nil {
-						c.characterMesh = v.Mesh()
+					if mesh := v.Value(); mesh != nil {
+						c.characterMesh = mesh
+						c.face = mmd.NewFace(mesh)
+						c.applyExpression(c.face)
 						log.Println("Complete to loaded.")
 						continue
 					}
@@ -152,47 +249,162 @@ func (c *Top) ReloadModel() {
 				futurePose := mmdLoader.LoadVPDs(ctx, vpdFile, false)
 				for v := range futurePose {
 					if v.Err() != nil {
-						log.Printf("Loading vpd file %v was failure.\n", vpdFile)
-						break
+						log.Printf("Loading vpd file %v was failure: %v\n", v.URL(), v.Err())
+						continue
 					}
 
-					if v.Vpd() != nil {
-						c.animator.Pose(c.characterMesh, v.Vpd())
+					if vpd := v.Value(); vpd != nil {
+						c.animator.Pose(c.characterMesh, vpd)
 						log.Println("pose loaded.")
+						continue
 					}
 
-					// log.Printf("Loaded %v byte in %v.\n", v.Loaded(), v.Total())
+					log.Printf("Loaded %v byte in %v of %v files.\n", v.Loaded(), v.Total(), len(v.Files()))
 				}
 
 			}
 
 			log.Println("Next - Motion loading.")
+			// 読み込み完了順は不定のため、URLからモーションを引いて辞書に登録する
 			{
-				vmdFiles := []string{
-					store.Dance1.Path(),
-					store.Dance2.Path(),
-					store.Dance3.Path(),
+				motions := make(map[string][]store.Motion)
+				var urls []string
+				for _, motion := range store.Motions {
+					if _, ok := motions[motion.Path()]; !ok {
+						urls = append(urls, motion.Path())
+					}
+					motions[motion.Path()] = append(motions[motion.Path()], motion)
 				}
-				// animations := make(map[string]animation.Clip)
 
-				futureMotion := mmdLoader.LoadMotionAnimation(ctx, vmdFiles, c.characterMesh)
+				futureMotion := mmdLoader.LoadMotionAnimation(ctx, urls, c.characterMesh)
 				for v := range futureMotion {
 					if v.Err() != nil {
-						log.Printf("Loading motion file was failure.\n")
-						break
+						log.Printf("Loading motion file %v was failure: %v\n", v.URL(), v.Err())
+						continue
 					}
 
-					if v.Clip() != nil {
-						// c.animator.Pose(c.characterMesh, v.Vpd())
-						js.Global().Get("console").Call("log", v.Clip().JSValue())
-
+					if clip := v.Value(); clip != nil {
+						for _, motion := range motions[v.URL()] {
+							store.MotionDictionay[motion] = clip
+						}
 						log.Println("Motion loaded.")
+						continue
+					}
+
+					log.Printf("Loaded %v byte in %v of %v files.\n", v.Loaded(), v.Total(), len(v.Files()))
+				}
+			}
+
+			// カメラモーションはモデルに依存しないため、未読込のもののみ読み込む
+			log.Println("Next - Camera motion loading.")
+			{
+				motions := make(map[string][]store.Motion)
+				var urls []string
+				for _, motion := range store.Motions {
+					if _, ok := store.CameraMotionDictionary[motion]; ok || motion.CameraPath() == "" {
+						continue
+					}
+					if _, ok := motions[motion.CameraPath()]; !ok {
+						urls = append(urls, motion.CameraPath())
+					}
+					motions[motion.CameraPath()] = append(motions[motion.CameraPath()], motion)
+				}
+
+				futureCamera := mmdLoader.LoadCameraAnimation(ctx, urls, c.camera)
+				for v := range futureCamera {
+					if v.Err() != nil {
+						log.Printf("Loading camera motion file %v was failure: %v\n", v.URL(), v.Err())
+						continue
+					}
+
+					if clip := v.Value(); clip != nil {
+						for _, motion := range motions[v.URL()] {
+							store.CameraMotionDictionary[motion] = clip
+						}
+						log.Println("Camera motion loaded.")
+						continue
+					}
+
+					log.Printf("Loaded %v byte in %v of %v files.\n", v.Loaded(), v.Total(), len(v.Files()))
+				}
+			}
+
+			// ライトとセルフシャドウのキーフレームはClipに含まれないため、VMDを直接読み込む
+			log.Println("Next - Scene motion loading.")
+			dances := make(map[store.Motion]*vmd.Motion)
+			for _, motion := range store.Motions {
+				if _, ok := store.SceneMotionDictionary[motion]; ok {
+					continue
+				}
+
+				urls := []string{motion.Path()}
+				if motion.CameraPath() != "" {
+					urls = append(urls, motion.CameraPath())
+				}
+
+				scene := &vmd.Motion{}
+				var dance *vmd.Motion
+				for v := range mmd.LoadVMDs(ctx, urls) {
+					if v.Err() != nil {
+						log.Println(v.Err())
+						continue
+					}
+					m := v.Value()
+					if m == nil {
+						continue
+					}
+					if dance == nil {
+						dance = m
 					}
+					scene.Lights = append(scene.Lights, m.Lights...)
+					scene.SelfShadows = append(scene.SelfShadows, m.SelfShadows...)
+				}
+				scene.Sort()
+				store.SceneMotionDictionary[motion] = scene
+
+				if dance != nil {
+					dances[motion] = dance
+				}
+			}
 
-					// log.Printf("Loaded %v byte in %v.\n", v.Loaded(), v.Total())
+			// 曲はモデルに依存しないため、未読込のもののみ読み込む
+			log.Println("Next - Song loading.")
+			{
+				motions := make(map[string][]store.Motion)
+				var urls []string
+				for _, motion := range store.Motions {
+					if _, ok := store.SongDictionary[motion]; ok || motion.AudioPath() == "" {
+						continue
+					}
+					if _, ok := motions[motion.AudioPath()]; !ok {
+						urls = append(urls, motion.AudioPath())
+					}
+					motions[motion.AudioPath()] = append(motions[motion.AudioPath()], motion)
 				}
 
+				for v := range mmd.LoadSongs(ctx, urls) {
+					if v.Err() != nil {
+						log.Printf("Loading song file %v was failure: %v\n", v.URL(), v.Err())
+						continue
+					}
+
+					if song := v.Value(); song != nil {
+						for _, motion := range motions[v.URL()] {
+							if song.Buffer != nil {
+								store.SongDictionary[motion] = song.Buffer
+							}
+							if dance, ok := dances[motion]; ok {
+								alignMotion(motion, song.Audio, dance)
+							}
+						}
+						log.Println("Song loaded.")
+						continue
+					}
+
+					log.Printf("Loaded %v byte in %v of %v files.\n", v.Loaded(), v.Total(), len(v.Files()))
+				}
 			}
+
 			log.Println("Finish - ReloadModel.")
 
 		}()
@@ -203,13 +415,63 @@ func (c *Top) ReloadModel() {
 
 	})
 
-	// Ammo functionが呼ばれていない状態 = Function, コール後、Object
-	if js.Global().Get("Ammo").Type() == js.TypeFunction {
-		js.Global().Call("Ammo").Call("then", fn)
-	} else {
+	// Ammo.jsは重いため、使うモデルを初めて読み込む時に読み込む
+	if store.CurrentModel.Physics() != store.PhysicsAmmo {
 		fn.Invoke()
+		return
+	}
+
+	go func() {
+		ammoOnce.Do(func() {
+			loadScript(ammoPath)
+		})
+
+		// Ammo functionが呼ばれていない状態 = Function, コール後、Object
+		if js.Global().Get("Ammo").Type() == js.TypeFunction {
+			js.Global().Call("Ammo").Call("then", fn)
+		} else {
+			fn.Invoke()
+		}
+	}()
+
+}
+
+// setupSpringBones makes springs move bones of rigid bodies, if the current model uses spring bones instead of Ammo.js.
+func (c *Top) setupSpringBones() {
+
+	if c.characterMesh == nil || store.CurrentModel.Physics() != store.PhysicsSpringBone {
+		return
+	}
+
+	springs, err := mmd.NewSpringBones(c.characterMesh, nil)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	c.springs = springs
+}
+
+// ChangePhysics switches physics of the current model to the next mode.
+// The model is reloaded, since rigid bodies of Ammo.js are created when the model is added to the animation helper.
+func (c *Top) ChangePhysics() {
+
+	store.ModelPhysics[store.CurrentModel] = (store.CurrentModel.Physics() + 1) % (store.PhysicsOff + 1)
+	c.ReloadModel()
+
+	dispatcher.Dispatch(actions.Refresh)
+}
+
+// alignMotion detects beats of the song and the motion, and saves the suggested delay and tempo scale.
+func alignMotion(motion store.Motion, song *beat.Audio, dance *vmd.Motion) {
+
+	if _, ok := store.MotionAlignments[motion]; ok {
+		return
 	}
 
+	a := beat.Align(beat.DetectBeats(song), beat.DetectMotionBeats(dance))
+	store.MotionAlignments[motion] = a
+	log.Printf("Song %v is %.1f BPM and the motion is %.1f BPM. Suggested delay is %.3f seconds, and tempo scale is %.3f.\n",
+		motion.AudioPath(), a.BPM, a.MotionBPM, a.Delay, a.TempoScale)
 }
 
 // PlayMotion is ...
@@ -220,33 +482,12 @@ func (c *Top) PlayMotion() {
 		return
 	}
 
-	c.characterMesh.Pose()
-
-	mixer, err := c.animator.Mixer(c.characterMesh.(threejs.SkinnedMesh))
+	mixer, err := c.mixer()
 	if err != nil {
 		log.Println("getting mixer was failed.")
 		return
 	}
 
-	// if err != nil { // animation未登録
-	// 	var a []animation.Clip = []animation.Clip{}
-	// 	for _, v := range store.MotionDictionay {
-	// 		a = append(a, v)
-	// 	}
-
-	// 	c.animator.AddMesh(
-	// 		c.characterMesh,
-	// 		mmd.AnimationClips(a),
-	// 		mmd.Physics(true),
-	// 	)
-	// 	mixer, err = c.animator.Mixer(c.characterMesh.(threejs.SkinnedMesh))
-	// 	if err != nil {
-	// 		log.Println("getting mixer was failed.")
-	// 		return
-	// 	}
-	// }
-	mixer.StopAllAction()
-
 	motion, ok := store.MotionDictionay[store.CurrentMotion]
 	if !ok {
 		log.Println("motion is not found.")
@@ -259,11 +500,88 @@ func (c *Top) PlayMotion() {
 		return
 	}
 	action.SetLoop(animation.LoopOnce, 0)
-	action.Reset()
-	action.Play()
+	action.SetClampWhenFinished(true)
 
-	// js.Global().Get("console").Call("log", c.animator.JSValue())
+	// 前のモーションからブレンドして切り替えることで、IKや物理演算の対象ボーンが跳ねないようにする
+	animation.CrossFade(c.currentAction, action, store.MotionFadeDuration, store.MotionFadeWarp)
+	c.currentAction = action
 
+	c.playSong()
+	c.playback.Restart(motion.Duration(), action)
+	c.playback.Resume()
+	if c.cancelMotionCredits != nil {
+		c.cancelMotionCredits()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancelMotionCredits = cancel
+	c.loadMotionCredits(ctx)
+
+	if scene, ok := store.SceneMotionDictionary[store.CurrentMotion]; ok {
+		c.shadow.SetKeyframes(scene.SelfShadows)
+		c.lights.SetKeyframes(scene.Lights)
+	} else {
+		c.shadow.SetKeyframes(nil)
+		c.lights.SetKeyframes(nil)
+	}
+
+	c.playCameraMotion()
+
+}
+
+// playSong plays the song of the current motion with the motion.
+// The song starts after the delay which aligns its beats to the beats of the motion.
+func (c *Top) playSong() {
+
+	if c.animator.HasAudio() {
+		c.animator.RemoveAudio(c.song)
+	}
+
+	buffer, ok := store.SongDictionary[store.CurrentMotion]
+	if !ok {
+		return
+	}
+
+	delay := 0.0
+	if a, ok := store.MotionAlignments[store.CurrentMotion]; ok {
+		delay = a.Delay
+	}
+
+	// ヘッダーのクリックから呼ばれるため、ここで再生を許可する
+	c.listener.Resume()
+	c.song.SetBuffer(buffer)
+	c.animator.AddAudio(c.song, mmd.DelayTime(delay))
+}
+
+// mixer gets the mixer of the character mesh.
+// If the mesh is not registered to the animation helper yet, it is registered with all loaded motions.
+func (c *Top) mixer() (animation.Mixer, error) {
+
+	mixer, err := c.animator.Mixer(c.characterMesh)
+	if err == nil {
+		return mixer, nil
+	}
+
+	// animation未登録
+	var a []animation.Clip = []animation.Clip{}
+	for _, v := range store.MotionDictionay {
+		a = append(a, v)
+	}
+
+	c.animator.AddMesh(
+		c.characterMesh,
+		mmd.AnimationClips(a),
+		mmd.Physics(store.CurrentModel.Physics() == store.PhysicsAmmo),
+	)
+	mixer, err = c.animator.Mixer(c.characterMesh)
+	if err != nil {
+		return nil, err
+	}
+
+	// AnimationHelperは登録した全てのClipを再生するため、一旦すべて停止する
+	mixer.StopAllAction()
+	c.currentAction = nil
+
+	return mixer, nil
 }
 
 // ResetPose is ...
@@ -274,27 +592,19 @@ func (c *Top) ResetPose() {
 		return
 	}
 
-	mixer, err := c.animator.Mixer(c.characterMesh.(threejs.SkinnedMesh))
-	if err != nil { // animation未登録
-		var a []animation.Clip = []animation.Clip{}
-		for _, v := range store.MotionDictionay {
-			a = append(a, v)
-		}
-
-		c.animator.AddMesh(
-			c.characterMesh,
-			mmd.AnimationClips(a),
-			mmd.Physics(true),
-		)
-		mixer, err = c.animator.Mixer(c.characterMesh.(threejs.SkinnedMesh))
-		if err != nil {
-			log.Println("getting mixer was failed.")
-			return
-		}
+	mixer, err := c.mixer()
+	if err != nil {
+		log.Println("getting mixer was failed.")
+		return
 	}
 
 	mixer.StopAllAction()
 	mixer.SetTime(0)
+	c.currentAction = nil
+	c.playback.Restart(0)
+	if c.springs != nil {
+		c.springs.Reset()
+	}
 	js.Global().Get("console").Call("log", mixer.JSValue())
 
 	for _, v := range store.MotionDictionay {
@@ -318,6 +628,810 @@ func (c *Top) ResetPose() {
 
 }
 
+// ToggleCameraMotion turns on/off the camera motion of the current motion.
+func (c *Top) ToggleCameraMotion() {
+
+	store.CameraMotionEnabled = !store.CameraMotionEnabled
+
+	if c.director != nil {
+		if store.CameraMotionEnabled && c.currentAction != nil {
+			c.playCameraMotion()
+			// 再生中のモーションに同期させる
+			c.playback.Seek(c.playback.Time())
+		} else {
+			c.director.Stop()
+		}
+	}
+
+	dispatcher.Dispatch(actions.Refresh)
+}
+
+// playCameraMotion plays the camera motion of the current motion if it exists.
+func (c *Top) playCameraMotion() {
+
+	clip, ok := store.CameraMotionDictionary[store.CurrentMotion]
+	if !ok || !store.CameraMotionEnabled {
+		c.director.Stop()
+		return
+	}
+
+	c.director.Play(clip)
+}
+
+// ToggleOutline turns on/off outlines of the model.
+func (c *Top) ToggleOutline() {
+
+	store.OutlineEnabled = !store.OutlineEnabled
+
+	c.effector.SetEnabled(store.OutlineEnabled)
+	if c.outline != nil {
+		c.outline.SetEnabled(store.OutlineEnabled)
+	}
+
+	dispatcher.Dispatch(actions.Refresh)
+}
+
+// SetOutlineThicknessScale changes the scale multiplied to the edge size of all materials.
+func (c *Top) SetOutlineThicknessScale(v float64) {
+
+	store.OutlineThicknessScale = v
+
+	if c.outline != nil {
+		if err := c.outline.SetThicknessScale(v); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// SetMaterialOutline overrides the outline of the material of the current model.
+func (c *Top) SetMaterialOutline(name string, p effect.OutlineParameters) {
+
+	overrides, ok := store.MaterialOutlines[store.CurrentModel]
+	if !ok {
+		overrides = make(map[string]effect.OutlineParameters)
+		store.MaterialOutlines[store.CurrentModel] = overrides
+	}
+	overrides[name] = p
+
+	if c.outline != nil {
+		if err := c.outline.SetMaterialOutline(name, p); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// ResetMaterialOutline restores the PMX edge settings of the material of the current model.
+func (c *Top) ResetMaterialOutline(name string) {
+
+	delete(store.MaterialOutlines[store.CurrentModel], name)
+
+	if c.outline != nil {
+		c.outline.ResetMaterialOutline(name)
+	}
+}
+
+// setupOutline reads PMX edge settings of the loaded model and applies the outline settings in the store.
+func (c *Top) setupOutline() {
+
+	if c.characterMesh == nil {
+		return
+	}
+
+	outline, err := mmd.NewModelOutline(c.characterMesh)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	c.outline = outline
+
+	outline.SetEnabled(store.OutlineEnabled)
+	if err := outline.SetThicknessScale(store.OutlineThicknessScale); err != nil {
+		log.Println(err)
+	}
+	for name, p := range store.MaterialOutlines[store.CurrentModel] {
+		if err := outline.SetMaterialOutline(name, p); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// AttachProp parents the prop to the bone of the current model. The prop is detached when the model is disposed.
+func (c *Top) AttachProp(boneName string, prop threejs.Object3D, options ...mmd.AttachmentOption) error {
+
+	if c.attachments == nil {
+		return errors.New("no model is loaded")
+	}
+
+	_, err := c.attachments.Attach(boneName, prop, options...)
+	return err
+}
+
+// DetachProp removes the prop from the bone of the current model.
+func (c *Top) DetachProp(prop threejs.Object3D) {
+	if c.attachments == nil {
+		return
+	}
+	c.attachments.Detach(prop)
+}
+
+// loadProps places the glTF props of the store on the stage.
+func (c *Top) loadProps() {
+
+	if len(store.Props) == 0 {
+		return
+	}
+
+	loader := gltf.NewLoader()
+	go func() {
+		ctx := context.Background()
+
+		for _, prop := range store.Props {
+			for v := range loader.LoadModel(ctx, prop.Path) {
+				if v.Err() != nil {
+					log.Printf("Loading glTF file %v was failure: %v\n", prop.Path, v.Err())
+					continue
+				}
+				model := v.Value()
+				if model == nil {
+					continue
+				}
+
+				scene := model.Scene()
+				scale := prop.Scale
+				if scale == 0 {
+					scale = 12.5
+				}
+				scene.Scale().SetScalar(scale)
+				scene.Position().Set2(prop.Position[0], prop.Position[1], prop.Position[2])
+				c.scene.Add(scene)
+
+				if strings.HasSuffix(strings.ToLower(prop.Path), ".vrm") {
+					c.playAvatar(ctx, model, scene)
+					log.Printf("Avatar %v loaded.\n", prop.Path)
+					continue
+				}
+
+				if clips := model.Animations(); len(clips) > 0 {
+					mixer := animation.NewMixer(scene)
+					action, err := mixer.ClipAction(clips[0])
+					if err != nil {
+						log.Println(err)
+						continue
+					}
+					action.Play()
+					c.propMixers = append(c.propMixers, mixer)
+				}
+				log.Printf("Prop %v loaded.\n", prop.Path)
+			}
+		}
+	}()
+}
+
+// ToggleRecording starts recording the model on screen, or stops and saves the recorded motion as a VMD file.
+func (c *Top) ToggleRecording() {
+
+	if c.animator == nil || c.characterMesh == nil {
+		log.Println("No model is loaded.")
+		return
+	}
+
+	if c.recorder != nil && c.recorder.Recording() {
+		motion := c.recorder.Stop()
+		if store.RecordReduceKeys {
+			r := c.recorder.Report()
+			log.Printf("Keyframes are reduced from %d to %d (%.1fx). Max errors are %.4f in position, %.4f rad in rotation and %.4f in weight.\n",
+				r.Before, r.After, r.Ratio(), r.MaxPosition, r.MaxRotation, r.MaxWeight)
+		}
+		c.recorder = nil
+		store.Recording = false
+
+		var buf bytes.Buffer
+		if err := vmd.Encode(&buf, motion); err != nil {
+			log.Printf("Recorded motion could not be encoded: %v\n", err)
+			return
+		}
+		download("recorded.vmd", buf.Bytes())
+
+		dispatcher.Dispatch(actions.Refresh)
+		return
+	}
+
+	options := []mmd.RecorderOption{mmd.BakePhysics(store.RecordBakePhysics)}
+	if store.RecordReduceKeys {
+		options = append(options, mmd.FitKeys(vmd.DefaultTolerance))
+	}
+	recorder, err := mmd.NewRecorder(c.animator, c.characterMesh, options...)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	recorder.Start()
+	c.recorder = recorder
+	store.Recording = true
+
+	dispatcher.Dispatch(actions.Refresh)
+}
+
+// playAvatar plays the current motion on the VRM avatar, retargeted from the standard bones of MMD.
+func (c *Top) playAvatar(ctx context.Context, model gltf.GLTF, scene threejs.Object3D) {
+
+	avatar, err := gltf.NewVRM(model)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	// VRM 0.xは-Zを向くため、MMDモデルと同じ向きに回す
+	if avatar.Model().Version == 0 {
+		scene.Rotation().SetY(math.Pi)
+	}
+
+	var motion *vmd.Motion
+	for v := range mmd.LoadVMDs(ctx, []string{store.CurrentMotion.Path()}) {
+		if v.Err() != nil {
+			log.Println(v.Err())
+			return
+		}
+		if m := v.Value(); m != nil {
+			motion = m
+		}
+	}
+
+	clip, err := avatar.NewClip("motion", motion, vrm.Options{})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	mixer := animation.NewMixer(scene)
+	action, err := mixer.ClipAction(clip)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	action.Play()
+	c.propMixers = append(c.propMixers, mixer)
+
+	face := avatar.Face()
+	c.avatarFaces = append(c.avatarFaces, face)
+	c.applyExpression(face)
+}
+
+// ToggleCredits shows or hides the credits pane.
+func (c *Top) ToggleCredits() {
+
+	store.CreditsVisible = !store.CreditsVisible
+
+	dispatcher.Dispatch(actions.Refresh)
+}
+
+// loadModelCredits parses the current model in Go and shows its name, comment, terms of use and statistics.
+// The terms of use are also read from the readme distributed with the model.
+// Only PMX models are supported, and the file name is shown for others.
+// Results are dropped if ctx is canceled by the next reload.
+func (c *Top) loadModelCredits(ctx context.Context) {
+
+	modelPath, readmePath := store.CurrentModel.Path(), store.CurrentModel.ReadmePath()
+
+	credits := store.CreditsStore()
+	// モーションの情報は残して、モデルの情報だけ消す
+	*credits = store.Credits{
+		ModelName:       path.Base(modelPath),
+		MotionFile:      credits.MotionFile,
+		MotionModel:     credits.MotionModel,
+		MotionBoneKeys:  credits.MotionBoneKeys,
+		MotionMorphKeys: credits.MotionMorphKeys,
+		MotionFrames:    credits.MotionFrames,
+	}
+
+	go func() {
+		model := *credits
+
+		for v := range mmd.LoadPMXs(ctx, []string{modelPath}) {
+			if v.Err() != nil {
+				log.Println(v.Err())
+				continue
+			}
+			m := v.Value()
+			if m == nil {
+				continue
+			}
+			mc := m.Credits()
+			model.ModelName = m.Name
+			model.ModelEnglishName = m.EnglishName
+			model.ModelAuthor = mc.Author
+			model.ModelComment = m.Comment
+			model.ModelTerms = mc.Terms
+			model.Vertices = strconv.Itoa(len(m.Vertices))
+			model.Materials = strconv.Itoa(len(m.Materials))
+			model.Bones = strconv.Itoa(len(m.Bones))
+			model.Morphs = strconv.Itoa(len(m.Morphs))
+		}
+
+		for v := range mmd.LoadReadmes(ctx, []string{readmePath}) {
+			if v.Err() != nil {
+				log.Println(v.Err())
+				continue
+			}
+			rc := v.Value()
+			if rc == nil {
+				continue
+			}
+			if model.ModelAuthor == "" {
+				model.ModelAuthor = rc.Author
+			}
+			model.ModelTerms = append(model.ModelTerms, rc.Terms...)
+		}
+
+		// 次のモデルの読み込みが始まっていれば、古い結果は捨てる
+		if ctx.Err() != nil {
+			return
+		}
+		credits.ModelName = model.ModelName
+		credits.ModelEnglishName = model.ModelEnglishName
+		credits.ModelAuthor = model.ModelAuthor
+		credits.ModelComment = model.ModelComment
+		credits.ModelTerms = model.ModelTerms
+		credits.Vertices = model.Vertices
+		credits.Materials = model.Materials
+		credits.Bones = model.Bones
+		credits.Morphs = model.Morphs
+
+		dispatcher.Dispatch(actions.Refresh)
+	}()
+}
+
+// loadMotionCredits parses the current motion in Go and shows the model it is made for and its statistics.
+// Results are dropped if ctx is canceled by the next motion.
+func (c *Top) loadMotionCredits(ctx context.Context) {
+
+	motionPath := store.CurrentMotion.Path()
+
+	credits := store.CreditsStore()
+	credits.MotionFile = path.Base(motionPath)
+	credits.MotionModel = ""
+	credits.MotionBoneKeys = ""
+	credits.MotionMorphKeys = ""
+	credits.MotionFrames = ""
+
+	go func() {
+		var model, boneKeys, morphKeys, frames string
+
+		for v := range mmd.LoadVMDs(ctx, []string{motionPath}) {
+			if v.Err() != nil {
+				log.Println(v.Err())
+				continue
+			}
+			m := v.Value()
+			if m == nil {
+				continue
+			}
+			var last uint32
+			for _, f := range m.Bones {
+				if f.Frame > last {
+					last = f.Frame
+				}
+			}
+			for _, f := range m.Morphs {
+				if f.Frame > last {
+					last = f.Frame
+				}
+			}
+			model = m.ModelName
+			boneKeys = strconv.Itoa(len(m.Bones))
+			morphKeys = strconv.Itoa(len(m.Morphs))
+			frames = strconv.Itoa(int(last))
+		}
+
+		// 次のモーションに切り替わっていれば、古い結果は捨てる
+		if ctx.Err() != nil {
+			return
+		}
+		credits.MotionModel = model
+		credits.MotionBoneKeys = boneKeys
+		credits.MotionMorphKeys = morphKeys
+		credits.MotionFrames = frames
+
+		dispatcher.Dispatch(actions.Refresh)
+	}()
+}
+
+// CleanFootContacts fixes foot sliding of the current motion on the current model, and saves it as a VMD file.
+// Only PMX models are supported.
+func (c *Top) CleanFootContacts() {
+
+	modelPath, motionPath := store.CurrentModel.Path(), store.CurrentMotion.Path()
+
+	// 読み込みを待つため、JSのコールバックの外で実行する
+	go func() {
+		ctx := context.Background()
+
+		var model *pmx.Model
+		for v := range mmd.LoadPMXs(ctx, []string{modelPath}) {
+			if v.Err() != nil {
+				log.Println(v.Err())
+				return
+			}
+			if m := v.Value(); m != nil {
+				model = m
+			}
+		}
+
+		var motion *vmd.Motion
+		for v := range mmd.LoadVMDs(ctx, []string{motionPath}) {
+			if v.Err() != nil {
+				log.Println(v.Err())
+				return
+			}
+			if m := v.Value(); m != nil {
+				motion = m
+			}
+		}
+
+		config := footlock.DefaultConfig
+		// モーションが作られたモデルでなければ、標準モデルの骨格向けに作られたとみなす
+		source := bvh.StandardModel()
+		if motion.ModelName == model.Name {
+			source = model
+		}
+		config.Scale = footlock.ScaleBetween(source, model)
+		cleaned, contacts, err := footlock.Clean(model, motion, config)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		log.Printf("%d foot contacts are fixed.\n", len(contacts))
+
+		var buf bytes.Buffer
+		if err := vmd.Encode(&buf, cleaned); err != nil {
+			log.Printf("Cleaned motion could not be encoded: %v\n", err)
+			return
+		}
+		download("footlock.vmd", buf.Bytes())
+	}()
+}
+
+// MirrorMotion saves the mirror images of the current motion and its camera motion as VMD files.
+func (c *Top) MirrorMotion() {
+
+	urls := []string{store.CurrentMotion.Path()}
+	if store.CurrentMotion.CameraPath() != "" {
+		urls = append(urls, store.CurrentMotion.CameraPath())
+	}
+
+	// 読み込みを待つため、JSのコールバックの外で実行する
+	go func() {
+		for v := range mmd.LoadVMDs(context.Background(), urls) {
+			if v.Err() != nil {
+				log.Println(v.Err())
+				continue
+			}
+
+			m := v.Value()
+			if m == nil {
+				continue
+			}
+			m.Mirror()
+
+			var buf bytes.Buffer
+			if err := vmd.Encode(&buf, m); err != nil {
+				log.Printf("Mirrored motion could not be encoded: %v\n", err)
+				continue
+			}
+
+			name := "mirrored.vmd"
+			if m.ModelName == vmd.CameraModelName {
+				name = "mirrored_camera.vmd"
+			}
+			download(name, buf.Bytes())
+		}
+	}()
+}
+
+// ExportBVH saves the current motion on the current model as a BVH file.
+// The standard skeleton of MMD is used for models which are not PMX.
+func (c *Top) ExportBVH() {
+
+	modelPath, motionPath := store.CurrentModel.Path(), store.CurrentMotion.Path()
+
+	// 読み込みを待つため、JSのコールバックの外で実行する
+	go func() {
+		ctx := context.Background()
+
+		var model *pmx.Model
+		for v := range mmd.LoadPMXs(ctx, []string{modelPath}) {
+			if v.Err() != nil {
+				log.Printf("%v. The standard skeleton is used.\n", v.Err())
+				continue
+			}
+			if m := v.Value(); m != nil {
+				model = m
+			}
+		}
+
+		var motion *vmd.Motion
+		for v := range mmd.LoadVMDs(ctx, []string{motionPath}) {
+			if v.Err() != nil {
+				log.Println(v.Err())
+				return
+			}
+			if m := v.Value(); m != nil {
+				motion = m
+			}
+		}
+
+		m, err := bvh.FromVMD(motion, bvh.Options{
+			Mapping: bvh.Presets[store.BVHMapping],
+			Model:   model,
+			TPose:   store.BVHTPose,
+		})
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := bvh.Encode(&buf, m); err != nil {
+			log.Printf("BVH could not be encoded: %v\n", err)
+			return
+		}
+		download("motion.bvh", buf.Bytes())
+	}()
+}
+
+// download saves the data as a file with the browser.
+func download(name string, data []byte) {
+
+	u8 := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(u8, data)
+	blob := js.Global().Get("Blob").New([]interface{}{u8}, map[string]interface{}{
+		"type": "application/octet-stream",
+	})
+	url := js.Global().Get("URL").Call("createObjectURL", blob)
+
+	a := js.Global().Get("document").Call("createElement", "a")
+	a.Set("href", url)
+	a.Set("download", name)
+	a.Call("click")
+
+	js.Global().Get("URL").Call("revokeObjectURL", url)
+}
+
+// loadScript loads the javascript synchronously. Do not call it from JS callbacks, since it blocks.
+func loadScript(url string) {
+
+	document := js.Global().Get("document")
+
+	ch := make(chan bool)
+	script := document.Call("createElement", "script")
+	script.Set("src", url)
+	var fn js.Func
+	fn = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer fn.Release()
+		close(ch)
+		return nil
+	})
+	script.Call("addEventListener", "load", fn)
+	document.Get("head").Call("appendChild", script)
+	<-ch
+}
+
+// ToggleSelfShadow turns on/off shadows of the model.
+func (c *Top) ToggleSelfShadow() {
+
+	store.SelfShadowEnabled = !store.SelfShadowEnabled
+	c.shadow.SetEnabled(store.SelfShadowEnabled)
+
+	dispatcher.Dispatch(actions.Refresh)
+}
+
+// focus gets the point the view camera looks at.
+func (c *Top) focus() *threejs.Vector3 {
+
+	if c.director != nil && c.director.Playing() {
+		if target, err := c.animator.CameraTarget(); err == nil {
+			return target.Position()
+		}
+	}
+
+	return c.control.Target()
+}
+
+// ToggleSphereMap turns on/off sphere maps of the current model.
+func (c *Top) ToggleSphereMap() {
+
+	setting := store.CurrentModel.Material()
+	setting.SphereMap = !setting.SphereMap
+
+	if c.materials != nil {
+		c.materials.SetSphereMapEnabled(setting.SphereMap)
+	}
+
+	dispatcher.Dispatch(actions.Refresh)
+}
+
+// ChangeToon switches toon ramps of the current model to the next shared toon.
+// After the last shared toon, toons of the model are restored.
+func (c *Top) ChangeToon() {
+
+	setting := store.CurrentModel.Material()
+	setting.SharedToon = (setting.SharedToon + 1) % (mmd.SharedToonCount + 1)
+
+	c.applyToon()
+
+	dispatcher.Dispatch(actions.Refresh)
+}
+
+// ChangeExpression switches the facial expression of the model and the avatars to the next of store.Expressions.
+// Morphs which the motion animates are overwritten by the motion.
+func (c *Top) ChangeExpression() {
+
+	faces := c.avatarFaces
+	if c.face != nil {
+		faces = append([]mmd.Face{c.face}, faces...)
+	}
+
+	// 前の表情を戻してから次の表情にする
+	if prev := store.Expressions[store.CurrentExpression]; prev != "" {
+		for _, f := range faces {
+			f.SetWeight(prev, 0)
+		}
+	}
+	store.CurrentExpression = (store.CurrentExpression + 1) % len(store.Expressions)
+	for _, f := range faces {
+		c.applyExpression(f)
+	}
+
+	dispatcher.Dispatch(actions.Refresh)
+}
+
+// applyExpression sets the expression in the store to the face.
+func (c *Top) applyExpression(face mmd.Face) {
+	if name := store.Expressions[store.CurrentExpression]; name != "" {
+		face.SetWeight(name, 1)
+	}
+}
+
+// setupMaterials keeps toons and sphere maps of the loaded model and applies the material settings in the store.
+func (c *Top) setupMaterials() {
+
+	if c.characterMesh == nil {
+		return
+	}
+
+	materials, err := mmd.NewModelMaterials(c.characterMesh)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	c.materials = materials
+
+	materials.SetSphereMapEnabled(store.CurrentModel.Material().SphereMap)
+	c.applyToon()
+}
+
+// applyToon sets the shared toon in the store to the current model, loading it if needed.
+func (c *Top) applyToon() {
+
+	if c.materials == nil {
+		return
+	}
+
+	n := store.CurrentModel.Material().SharedToon
+	if n == 0 {
+		c.materials.SetToon(nil)
+		return
+	}
+	if tx, ok := c.sharedToons[n]; ok {
+		c.materials.SetToon(tx)
+		return
+	}
+
+	path, err := mmd.SharedToonPath(n)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	materials := c.materials
+	go func() {
+		for v := range mmd.LoadToonTextures(context.Background(), []string{path}) {
+			if v.Err() != nil {
+				log.Println(v.Err())
+				continue
+			}
+			tx := v.Value()
+			if tx == nil {
+				continue
+			}
+			c.sharedToons[n] = tx
+
+			// 読み込み中にモデルや設定が変わった場合は反映しない
+			if c.materials == materials && store.CurrentModel.Material().SharedToon == n {
+				materials.SetToon(tx)
+			}
+		}
+	}()
+}
+
+// TogglePlayback pauses or resumes the motion.
+func (c *Top) TogglePlayback() {
+
+	if c.playback == nil {
+		return
+	}
+
+	if c.playback.Paused() {
+		c.playback.Resume()
+	} else {
+		c.playback.Pause()
+	}
+	c.updateStoreForPlayback()
+}
+
+// SeekMotion moves the motion to t seconds.
+func (c *Top) SeekMotion(t float64) {
+
+	if c.playback == nil {
+		return
+	}
+
+	c.playback.Seek(t)
+	if c.springs != nil {
+		c.springs.Reset()
+	}
+	c.updateStoreForPlayback()
+}
+
+// ChangePlaybackSpeed changes the motion speed.
+func (c *Top) ChangePlaybackSpeed(s float64) {
+
+	if c.playback == nil {
+		return
+	}
+
+	if err := c.playback.SetSpeed(s); err != nil {
+		log.Println(err)
+		return
+	}
+	c.updateStoreForPlayback()
+}
+
+// SetLoopStart marks the current time as start of A-B loop.
+func (c *Top) SetLoopStart() {
+
+	if c.playback == nil {
+		return
+	}
+
+	c.loopStart = c.playback.Time()
+	c.playback.ClearLoopRange()
+	c.updateStoreForPlayback()
+}
+
+// SetLoopEnd marks the current time as end of A-B loop and starts the loop.
+func (c *Top) SetLoopEnd() {
+
+	if c.playback == nil {
+		return
+	}
+
+	if err := c.playback.SetLoopRange(c.loopStart, c.playback.Time()); err != nil {
+		log.Println(err)
+		return
+	}
+	c.updateStoreForPlayback()
+}
+
+// ClearLoop clears A-B loop.
+func (c *Top) ClearLoop() {
+
+	if c.playback == nil {
+		return
+	}
+
+	c.playback.ClearLoopRange()
+	c.updateStoreForPlayback()
+}
+
 // Mount is ...
 func (c *Top) Mount() {
 	if !c.init {
@@ -355,6 +1469,14 @@ func (c *Top) initSceneAndRenderer() {
 		c.renderer = renderer
 	}
 
+	// Outline
+	{
+		effector := effect.NewOutlineEffect(c.renderer)
+		effector.SetEnabled(store.OutlineEnabled)
+
+		c.effector = effector
+	}
+
 	// Camera
 	{
 		const (
@@ -369,6 +1491,11 @@ func (c *Top) initSceneAndRenderer() {
 		camera.Up().SetZ(1)
 		camera.LookAtXYZ(0, 0, 0)
 		c.camera = camera
+
+		// モーションの曲を再生する
+		c.listener = threejs.NewAudioListener()
+		camera.Add(c.listener)
+		c.song = threejs.NewAudio(c.listener)
 	}
 
 	// Scene
@@ -471,28 +1598,29 @@ func (c *Top) initSceneAndRenderer() {
 		light := light.NewHemisphereLight(skyColor, groundColor, lightIntensity)
 
 		c.scene.AddLight(light)
+		c.hemisphere = light
 	}
 
 	// DirectionalLight
 	{
-		// const (
-		// 	lightColor     = threejs.ColorValue(0xffffff)
-		// 	lightIntensity = threejs.LightIntensity(2)
-		// 	width          = 12.0
-		// 	height         = 4.0
-		// )
-		// light := lights.NewDirectionalLight(lightColor, lightIntensity)
-		// // light.SetCastShadow(true)
-		// light.Position().Set2(-15, 40, 15)
-		// light.Target().Position().Set2(-4, 0, -4)
-
-		// light.Shadow().Camera().SetLeft(-20)
-		// light.Shadow().Camera().SetRight(20)
-		// light.Shadow().Camera().SetTop(20)
-		// light.Shadow().Camera().SetBottom(-20)
-
-		// c.scene.AddLight(light)
-		// c.scene.Add(light.Target())
+		const (
+			lightColor     = threejs.ColorValue(0xffffff)
+			lightIntensity = threejs.LightIntensity(2)
+		)
+		light := light.NewDirectionalLight(lightColor, lightIntensity)
+		light.Position().Set2(-15, 40, 15)
+
+		c.scene.AddLight(light)
+		c.scene.Add(light.Target())
+
+		// シャドウカメラの範囲はモデルに合わせてSelfShadowが調整する
+		shadow := mmd.NewSelfShadow(c.renderer, light, mmd.ShadowMapSize(2048))
+		shadow.SetEnabled(store.SelfShadowEnabled)
+		shadow.AddReceiver(c.ocean)
+		c.shadow = shadow
+
+		// モーションのライトのキーフレームで向きと色を変える
+		c.lights = mmd.NewLightDirector(light, mmd.HemisphereBlend(c.hemisphere, store.LightHemisphereBlend))
 
 		// cameraHelper := camera.NewCameraHelper(light.Shadow().Camera())
 		// scene.Add(cameraHelper)
@@ -501,14 +1629,18 @@ func (c *Top) initSceneAndRenderer() {
 		// c.scene.Add(helper)
 	}
 
+	// Props
+	c.loadProps()
+
 	// Change Model
 	dispatcher.Dispatch(actions.ChangeModel)
 
 }
 
-// resizeRendererToDisplaySize resizes render display size.
-func (c *Top) resizeRendererToDisplaySize(renderer threejs.Renderer) (sizeChanged bool) {
-	canvas := renderer.DomElement()
+// resizeRendererToDisplaySize resizes the canvas of c.renderer to its parent node.
+// The size is set through c.effector, which passes it to the renderer.
+func (c *Top) resizeRendererToDisplaySize() (sizeChanged bool) {
+	canvas := c.renderer.DomElement()
 	height := canvas.Get("height").Int()
 
 	// キャンバスのheightが0だった場合、キャンバスサイズを再設定する
@@ -524,7 +1656,9 @@ func (c *Top) resizeRendererToDisplaySize(renderer threejs.Renderer) (sizeChange
 		clientWidth := (w * pixelRatio)
 		clientHeight := (h * pixelRatio)
 
-		renderer.SetSize(clientWidth, clientHeight, false)
+		// devicePixelRatioはキャンバスサイズに含めているため、レンダラーのpixelRatioは1のままにする
+		// OutlineEffectはレンダラーにサイズを委譲するため、エフェクト経由で設定する
+		c.effector.SetSize(clientWidth, clientHeight, false)
 		c.canvasWidth = int(clientWidth)
 		c.canvasHeight = int(clientHeight)
 
@@ -536,7 +1670,7 @@ func (c *Top) resizeRendererToDisplaySize(renderer threejs.Renderer) (sizeChange
 
 func (c *Top) render(this js.Value, args []js.Value) interface{} {
 
-	if sizeChanged := c.resizeRendererToDisplaySize(c.renderer); sizeChanged {
+	if sizeChanged := c.resizeRendererToDisplaySize(); sizeChanged {
 		canvas := c.renderer.DomElement()
 		clientWidth := canvas.Get("clientWidth").Float()
 		clientHeight := canvas.Get("clientHeight").Float()
@@ -544,24 +1678,69 @@ func (c *Top) render(this js.Value, args []js.Value) interface{} {
 		c.camera.(camera.PerspectiveCamera).UpdateProjectionMatrix()
 	}
 
+	delta := c.clock.Delta()
+
 	// Update Matrix
-	c.control.Update()
+	if c.director != nil {
+		c.director.Update(delta)
+	} else {
+		c.control.Update()
+	}
 
 	// Update time and animation
-	delta := c.clock.Delta()
-	c.animator.Update(delta)
+	if c.playback != nil {
+		c.playback.Update(delta)
+	}
+	// 揺れものはアニメーションの姿勢から動かすため、再生の後に更新する
+	if c.springs != nil {
+		c.springs.Update(delta)
+	}
+	if c.recorder != nil {
+		c.recorder.Update(delta)
+	}
+	for _, mixer := range c.propMixers {
+		mixer.Update(delta)
+	}
 	c.ocean.SetTime(c.ocean.Time() + delta)
 
+	// Update light and shadow
+	// ライトの向きを変えてからシャドウカメラを合わせる
+	frame := 0.0
+	if c.playback != nil {
+		frame = c.playback.Time() * vmd.FramesPerSecond
+	}
+	c.lights.Update(frame)
+	c.shadow.Update(frame, c.focus())
+
 	// Render
-	// c.effector.Render(c.scene, c.camera)
-	c.renderer.Render(c.scene, c.camera)
+	// 輪郭線が無効の場合、OutlineEffectは通常の描画のみ行う
+	c.effector.Render(c.scene, c.camera)
 
 	// Update Store
 	c.updateStoreForRendererInfo()
 
+	// 再生位置の表示は一定間隔で更新する
+	c.refreshElapsed += delta
+	if c.refreshElapsed > timelineRefreshInterval && c.playback != nil && !c.playback.Paused() {
+		c.refreshElapsed = 0
+		c.updateStoreForPlayback()
+	}
+
 	return nil
 }
 
+func (c *Top) updateStoreForPlayback() {
+
+	p := store.PlaybackStore()
+	p.Time = c.playback.Time()
+	p.Duration = c.playback.Duration()
+	p.Paused = c.playback.Paused()
+	p.Speed = c.playback.Speed()
+	p.LoopStart, p.LoopEnd, p.Loop = c.playback.LoopRange()
+
+	dispatcher.Dispatch(actions.Refresh)
+}
+
 func (c *Top) updateStoreForRendererInfo() {
 
 	info := c.renderer.JSValue().Get("info")
@@ -601,6 +1780,9 @@ func (c *Top) refresh(ev js.Value) {
 
 func (c *Top) resetCameraPosition(ev js.Value) {
 
+	if c.director != nil {
+		c.director.Stop()
+	}
 	c.control.Reset()
 
 }
@@ -23,6 +23,11 @@ func (c *Top) Render() spago.HTML {
 						spago.A("style", spago.S(`width: 200px;`)),
 						spago.C(&components.RendererInfoPane{}),
 					),
+					spago.Tag("div", 						
+						spago.A("class", spago.S(`is-overlay`)),
+						spago.A("style", spago.S(`left: auto; width: 320px;`)),
+						spago.C(&components.CreditsPane{}),
+					),
 				),
 				spago.Tag("canvas", 					
 					spago.A("id", spago.S(`cv`)),
@@ -33,6 +38,7 @@ func (c *Top) Render() spago.HTML {
 			),
 			spago.Tag("div", 				
 				spago.A("class", spago.S(`hero-foot`)),
+				spago.C(c.timeline),
 				spago.Tag("nav", 					
 					spago.A("class", spago.S(`tabs`)),
 					spago.Tag("div", 						
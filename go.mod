@@ -1,5 +1,11 @@
 module app
 
-go 1.15
+go 1.18
 
-require github.com/nobonobo/spago v1.0.14
+require (
+	github.com/jfreymuth/oggvorbis v1.0.5
+	github.com/nobonobo/spago v1.0.14
+	golang.org/x/text v0.3.7
+)
+
+require github.com/jfreymuth/vorbis v1.0.2 // indirect
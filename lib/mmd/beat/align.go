@@ -0,0 +1,41 @@
+package beat
+
+import "math"
+
+// Alignment is the playback setting which puts beats of a motion on beats of its music.
+type Alignment struct {
+	BPM       float64
+	MotionBPM float64
+	// TempoScale is the speed of the motion which would keep its beats on the music. It is near 1, with the tempo folded by octaves.
+	TempoScale float64
+	// Delay is the time in seconds the music starts after the motion, as mmd.DelayTime takes.
+	// It aligns the first beats with the motion played at speed 1, as the music is played with it.
+	Delay float64
+}
+
+// Align suggests the tempo scale and the delay which align the motion beats to the music beats.
+// The delay is less than a beat, since beats cannot tell which bar the motion starts from.
+func Align(music Beats, motion Beats) Alignment {
+
+	a := Alignment{BPM: music.BPM, MotionBPM: motion.BPM, TempoScale: 1}
+	if music.BPM <= 0 || motion.BPM <= 0 {
+		return a
+	}
+
+	// 倍や半分のテンポは同じ拍子として扱う
+	s := music.BPM / motion.BPM
+	for s > 1.5 {
+		s /= 2
+	}
+	for s < 0.75 {
+		s *= 2
+	}
+	a.TempoScale = s
+
+	period := music.Period()
+	a.Delay = math.Mod(motion.Phase-music.Phase, period)
+	if a.Delay < 0 {
+		a.Delay += period
+	}
+	return a
+}
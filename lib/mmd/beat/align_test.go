@@ -0,0 +1,83 @@
+package beat
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAlign(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		music  Beats
+		motion Beats
+		want   Alignment
+	}{
+		{
+			name:   "same tempo",
+			music:  Beats{BPM: 120, Phase: 0.1},
+			motion: Beats{BPM: 120, Phase: 0.4},
+			want:   Alignment{BPM: 120, MotionBPM: 120, TempoScale: 1, Delay: 0.3},
+		},
+		{
+			// 曲の拍が先にある場合は次の拍まで遅らせる
+			name:   "music beats first",
+			music:  Beats{BPM: 120, Phase: 0.4},
+			motion: Beats{BPM: 120, Phase: 0.1},
+			want:   Alignment{BPM: 120, MotionBPM: 120, TempoScale: 1, Delay: 0.2},
+		},
+		{
+			name:   "half tempo is folded",
+			music:  Beats{BPM: 120, Phase: 0.1},
+			motion: Beats{BPM: 60, Phase: 0.4},
+			want:   Alignment{BPM: 120, MotionBPM: 60, TempoScale: 1, Delay: 0.3},
+		},
+		{
+			// 遅延は等速で再生されるモーションに合わせる
+			name:   "different tempo",
+			music:  Beats{BPM: 100, Phase: 0.2},
+			motion: Beats{BPM: 120, Phase: 0.5},
+			want:   Alignment{BPM: 100, MotionBPM: 120, TempoScale: 100.0 / 120, Delay: 0.3},
+		},
+		{
+			name:   "delay is less than a beat",
+			music:  Beats{BPM: 120, Phase: 0},
+			motion: Beats{BPM: 120, Phase: 1.2},
+			want:   Alignment{BPM: 120, MotionBPM: 120, TempoScale: 1, Delay: 0.2},
+		},
+		{
+			name:   "no music beats",
+			music:  Beats{},
+			motion: Beats{BPM: 120, Phase: 0.4},
+			want:   Alignment{MotionBPM: 120, TempoScale: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Align(tt.music, tt.motion)
+			if a.BPM != tt.want.BPM || a.MotionBPM != tt.want.MotionBPM ||
+				math.Abs(a.TempoScale-tt.want.TempoScale) > 1e-9 || math.Abs(a.Delay-tt.want.Delay) > 1e-9 {
+				t.Errorf("Align() = %+v, want %+v", a, tt.want)
+			}
+		})
+	}
+}
+
+// TestAlignDetected aligns a click track and a motion of 100 BPM, and checks that the delayed clicks fall on the motion beats.
+func TestAlignDetected(t *testing.T) {
+
+	music := DetectBeats(clicks(22050, 100, 0.35, 20))
+	motion := DetectMotionBeats(steps(18, 9, 30))
+	a := Align(music, motion)
+
+	// クリックは0.35秒から、モーションの拍は9フレーム目から0.6秒ごと
+	d := math.Mod(a.Delay+0.35-9.0/30, 0.6)
+	if d > 0.3 {
+		d -= 0.6
+	}
+	// 解析フレームの長さだけクリックが早く検出されることがある
+	if d < -0.01 || d > float64(frameSize)/22050+0.01 {
+		t.Errorf("delayed clicks are %v seconds from the motion beats, with %+v", d, a)
+	}
+}
@@ -0,0 +1,164 @@
+// Package beat estimates tempo and beats of music and dance motions, and aligns motions to their music.
+//
+// Audio is analyzed as PCM samples, usually decoded by the browser (see mmd.LoadSongs) before they are passed here.
+// WAV and Ogg Vorbis files can also be decoded in Go, for browsers which do not support them.
+package beat
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/jfreymuth/oggvorbis"
+)
+
+// Audio is monaural PCM audio.
+type Audio struct {
+	SampleRate int
+	// Samples are in [-1, 1].
+	Samples []float32
+}
+
+// Duration gets the length in seconds.
+func (a *Audio) Duration() float64 {
+	return float64(len(a.Samples)) / float64(a.SampleRate)
+}
+
+// NewAudioFromChannels mixes the channels to monaural audio.
+func NewAudioFromChannels(sampleRate int, channels [][]float32) *Audio {
+
+	a := &Audio{SampleRate: sampleRate}
+	if len(channels) == 0 {
+		return a
+	}
+
+	a.Samples = make([]float32, len(channels[0]))
+	for _, c := range channels {
+		for i := range a.Samples {
+			if i < len(c) {
+				a.Samples[i] += c[i] / float32(len(channels))
+			}
+		}
+	}
+	return a
+}
+
+// WAV format tags.
+const (
+	wavePCM        = 1
+	waveFloat      = 3
+	waveExtensible = 0xfffe
+)
+
+// ErrInvalidWAV is returned when the data is not a WAV file.
+var ErrInvalidWAV = errors.New("beat: invalid WAV file")
+
+// DecodeWAV reads a WAV file of integer PCM (8, 16, 24 or 32 bits) or 32-bit float, and mixes it to monaural audio.
+func DecodeWAV(r io.Reader) (*Audio, error) {
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 12 || string(b[:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
+		return nil, ErrInvalidWAV
+	}
+
+	var format, channels, bits int
+	var sampleRate int
+	var data []byte
+
+	// チャンクは偶数バイトに揃えられている
+	for pos := 12; pos+8 <= len(b); {
+		id := string(b[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(b[pos+4:]))
+		body := b[pos+8:]
+		if size > len(body) {
+			size = len(body)
+		}
+		body = body[:size]
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, ErrInvalidWAV
+			}
+			format = int(binary.LittleEndian.Uint16(body))
+			channels = int(binary.LittleEndian.Uint16(body[2:]))
+			sampleRate = int(binary.LittleEndian.Uint32(body[4:]))
+			bits = int(binary.LittleEndian.Uint16(body[14:]))
+			// 拡張形式は実際の形式をGUIDの先頭に持つ
+			if format == waveExtensible && size >= 26 {
+				format = int(binary.LittleEndian.Uint16(body[24:]))
+			}
+		case "data":
+			data = body
+		}
+
+		pos += 8 + size + size%2
+	}
+
+	if channels <= 0 || sampleRate <= 0 || data == nil {
+		return nil, ErrInvalidWAV
+	}
+
+	var sample func(b []byte) float32
+	switch {
+	case format == wavePCM && bits == 8:
+		sample = func(b []byte) float32 { return (float32(b[0]) - 128) / 128 }
+	case format == wavePCM && bits == 16:
+		sample = func(b []byte) float32 { return float32(int16(binary.LittleEndian.Uint16(b))) / (1 << 15) }
+	case format == wavePCM && bits == 24:
+		sample = func(b []byte) float32 {
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+			return float32(v) / (1 << 23)
+		}
+	case format == wavePCM && bits == 32:
+		sample = func(b []byte) float32 { return float32(int32(binary.LittleEndian.Uint32(b))) / (1 << 31) }
+	case format == waveFloat && bits == 32:
+		sample = func(b []byte) float32 { return math.Float32frombits(binary.LittleEndian.Uint32(b)) }
+	default:
+		return nil, fmt.Errorf("beat: unsupported WAV format %d with %d bits", format, bits)
+	}
+
+	width := bits / 8
+	frames := len(data) / (width * channels)
+	a := &Audio{SampleRate: sampleRate, Samples: make([]float32, frames)}
+	for i := range a.Samples {
+		var v float32
+		for c := 0; c < channels; c++ {
+			v += sample(data[(i*channels+c)*width:])
+		}
+		a.Samples[i] = v / float32(channels)
+	}
+
+	return a, nil
+}
+
+// DecodeOgg reads an Ogg Vorbis file and mixes it to monaural audio.
+func DecodeOgg(r io.Reader) (*Audio, error) {
+
+	samples, format, err := oggvorbis.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("beat: invalid Ogg Vorbis file: %w", err)
+	}
+	if format.Channels <= 0 || format.SampleRate <= 0 {
+		return nil, fmt.Errorf("beat: invalid Ogg Vorbis format with %d channels", format.Channels)
+	}
+
+	// サンプルはチャンネルごとに交互に並ぶ
+	channels := format.Channels
+	a := &Audio{SampleRate: format.SampleRate, Samples: make([]float32, len(samples)/channels)}
+	for i := range a.Samples {
+		var v float32
+		for c := 0; c < channels; c++ {
+			v += samples[i*channels+c]
+		}
+		a.Samples[i] = v / float32(channels)
+	}
+
+	return a, nil
+}
@@ -0,0 +1,213 @@
+package beat
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Beats is the tempo and beat positions of music or a motion.
+type Beats struct {
+	// BPM is beats per minute.
+	BPM float64
+	// Phase is the time in seconds of the first beat.
+	Phase float64
+	// Times are the beats in seconds.
+	Times []float64
+}
+
+// Period gets the time in seconds between beats.
+func (b Beats) Period() float64 {
+	return 60 / b.BPM
+}
+
+// Range of tempo searched. Tempos near PreferredBPM are preferred to their halves and doubles.
+var (
+	MinBPM       = 60.0
+	MaxBPM       = 200.0
+	PreferredBPM = 120.0
+)
+
+const (
+	// frameSize and hopSize are samples of an analysis frame and between frames.
+	frameSize = 1024
+	hopSize   = 512
+)
+
+// DetectBeats estimates the tempo and beats of the audio from the spectral flux.
+func DetectBeats(a *Audio) Beats {
+	return estimate(onsets(a), float64(a.SampleRate)/hopSize)
+}
+
+// onsets gets the strength of note onsets of each frame, as the increase of the log spectrum.
+func onsets(a *Audio) []float64 {
+
+	window := make([]float64, frameSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/frameSize)
+	}
+
+	n := (len(a.Samples) - frameSize) / hopSize
+	if n <= 0 {
+		return nil
+	}
+
+	env := make([]float64, n)
+	prev := make([]float64, frameSize/2)
+	buf := make([]complex128, frameSize)
+	for f := 0; f < n; f++ {
+		for i := range buf {
+			buf[i] = complex(float64(a.Samples[f*hopSize+i])*window[i], 0)
+		}
+		fft(buf)
+
+		var flux float64
+		for k := range prev {
+			m := math.Log1p(10 * cmplx.Abs(buf[k]))
+			if d := m - prev[k]; d > 0 && f > 0 {
+				flux += d
+			}
+			prev[k] = m
+		}
+		env[f] = flux
+	}
+
+	return normalize(env, float64(a.SampleRate)/hopSize)
+}
+
+// normalize subtracts the moving average of half a second and keeps the positive part, so that only peaks remain.
+func normalize(env []float64, rate float64) []float64 {
+
+	w := int(rate / 4)
+	if w < 1 {
+		w = 1
+	}
+
+	prefix := make([]float64, len(env)+1)
+	for i, v := range env {
+		prefix[i+1] = prefix[i] + v
+	}
+
+	out := make([]float64, len(env))
+	for i := range env {
+		lo, hi := i-w, i+w+1
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(env) {
+			hi = len(env)
+		}
+		mean := (prefix[hi] - prefix[lo]) / float64(hi-lo)
+		out[i] = math.Max(0, env[i]-mean)
+	}
+	return out
+}
+
+// estimate finds the tempo by the autocorrelation of the onset envelope sampled at rate,
+// and the phase which puts beats on the strongest onsets.
+func estimate(env []float64, rate float64) Beats {
+
+	minLag := int(math.Floor(rate * 60 / MaxBPM))
+	maxLag := int(math.Ceil(rate * 60 / MinBPM))
+	if minLag < 1 {
+		minLag = 1
+	}
+	if maxLag >= len(env) {
+		maxLag = len(env) - 1
+	}
+	if minLag >= maxLag {
+		return Beats{}
+	}
+
+	correlation := make([]float64, maxLag+3)
+	for lag := minLag - 1; lag <= maxLag+2 && lag < len(env); lag++ {
+		if lag < 1 {
+			continue
+		}
+		var s float64
+		for i := lag; i < len(env); i++ {
+			s += env[i] * env[i-lag]
+		}
+		correlation[lag] = s / float64(len(env)-lag)
+	}
+
+	score := make([]float64, maxLag+2)
+	best := -1
+	for lag := minLag; lag <= maxLag+1; lag++ {
+		// 周期が整数でない場合は相関が隣の遅れに分かれるため、両隣の半分を加える
+		s := correlation[lag] + 0.5*(correlation[lag-1]+correlation[lag+1])
+
+		// 倍や半分のテンポに誤らないよう、好ましいテンポの周りを重くする
+		bpm := rate * 60 / float64(lag)
+		d := math.Log2(bpm / PreferredBPM)
+		score[lag] = s * math.Exp(-0.5*d*d)
+
+		if lag <= maxLag && (best < 0 || score[lag] > score[best]) {
+			best = lag
+		}
+	}
+
+	// 放物線で補間して小数の周期にする
+	period := float64(best)
+	if best > minLag && best+1 < len(score) {
+		a, b, c := score[best-1], score[best], score[best+1]
+		if d := a - 2*b + c; d < 0 {
+			period += 0.5 * (a - c) / d
+		}
+	}
+
+	// 長い曲で拍がずれないよう、周期の近くで拍の上の強さの和が最大になる周期と位相を探す
+	var phase float64
+	strongest := -1.0
+	center := period
+	for p := center * 0.98; p <= center*1.02; p += center * 0.001 {
+		for o := 0; o < int(math.Ceil(p)); o++ {
+			var s float64
+			for t := float64(o); int(t+0.5) < len(env); t += p {
+				s += env[int(t+0.5)]
+			}
+			if s > strongest {
+				strongest = s
+				period = p
+				phase = float64(o)
+			}
+		}
+	}
+
+	b := Beats{
+		BPM:   rate * 60 / period,
+		Phase: phase / rate,
+	}
+	duration := float64(len(env)) / rate
+	for t := b.Phase; t < duration; t += period / rate {
+		b.Times = append(b.Times, t)
+	}
+	return b
+}
+
+// fft transforms x in place. The length must be a power of 2.
+func fft(x []complex128) {
+
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], x[start+k+size/2]*wk
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				wk *= w
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package beat
+
+import (
+	"math"
+	"testing"
+)
+
+// clicks makes a click track of seconds, with a click of a decaying 1 kHz tone on every beat.
+func clicks(sampleRate int, bpm float64, phase float64, seconds float64) *Audio {
+
+	a := &Audio{SampleRate: sampleRate, Samples: make([]float32, int(seconds*float64(sampleRate)))}
+	for t := phase; t < seconds; t += 60 / bpm {
+		start := int(t * float64(sampleRate))
+		for i := 0; i < sampleRate/50 && start+i < len(a.Samples); i++ {
+			x := float64(i) / float64(sampleRate)
+			a.Samples[start+i] = float32(math.Sin(2*math.Pi*1000*x) * math.Exp(-x*200))
+		}
+	}
+	return a
+}
+
+func TestDetectBeats(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		bpm   float64
+		phase float64
+	}{
+		{"120 BPM", 120, 0.3},
+		{"95 BPM", 95, 0.1},
+		{"150 BPM", 150, 0.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := DetectBeats(clicks(22050, tt.bpm, tt.phase, 20))
+
+			if math.Abs(b.BPM-tt.bpm) > tt.bpm*0.01 {
+				t.Errorf("BPM = %v, want %v", b.BPM, tt.bpm)
+			}
+			// 解析フレームの長さだけ拍より早く検出されることがある
+			if d := tt.phase - b.Phase; d < -0.01 || d > float64(frameSize)/22050+0.01 {
+				t.Errorf("Phase = %v, want %v", b.Phase, tt.phase)
+			}
+			if n := int((20 - tt.phase) * tt.bpm / 60); len(b.Times) < n-1 || len(b.Times) > n+1 {
+				t.Errorf("%d beats, want %d", len(b.Times), n)
+			}
+		})
+	}
+}
+
+func TestDetectBeatsSilence(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		audio *Audio
+	}{
+		{"empty", &Audio{SampleRate: 22050}},
+		{"shorter than a frame", &Audio{SampleRate: 22050, Samples: make([]float32, frameSize)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if b := DetectBeats(tt.audio); b.BPM != 0 || len(b.Times) != 0 {
+				t.Errorf("DetectBeats() = %+v, want no beats", b)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package beat
+
+import (
+	"app/lib/mmd/vmd"
+	"math"
+)
+
+// PositionWeight converts bone movement in MMD units to the same scale as rotation in radians
+// when the speed of a motion is measured.
+var PositionWeight = 0.1
+
+// DetectMotionBeats estimates the tempo and beats of a dance motion from peaks of the speed of all bones.
+func DetectMotionBeats(m *vmd.Motion) Beats {
+	return estimate(normalize(speeds(m), vmd.FramesPerSecond), vmd.FramesPerSecond)
+}
+
+// speeds gets the sum of the angular and translational speed of the bones in each frame.
+func speeds(m *vmd.Motion) []float64 {
+
+	sorted := &vmd.Motion{Bones: append([]vmd.BoneFrame(nil), m.Bones...)}
+	sorted.Sort()
+
+	var last uint32
+	for _, f := range sorted.Bones {
+		if f.Frame > last {
+			last = f.Frame
+		}
+	}
+
+	env := make([]float64, last+1)
+	for _, frames := range sorted.BoneTracks() {
+		if len(frames) < 2 {
+			continue
+		}
+
+		prev, _ := vmd.BoneAt(frames, 0)
+		for f := 1; f <= int(last); f++ {
+			// キーフレームの範囲外は動かない
+			if uint32(f) > frames[len(frames)-1].Frame {
+				break
+			}
+			cur, _ := vmd.BoneAt(frames, float64(f))
+			env[f] += angle(prev.Rotation, cur.Rotation) + PositionWeight*distance(prev.Position, cur.Position)
+			prev = cur
+		}
+	}
+
+	return env
+}
+
+func angle(a [4]float32, b [4]float32) float64 {
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return 2 * math.Acos(math.Min(1, math.Abs(dot)))
+}
+
+func distance(a [3]float32, b [3]float32) float64 {
+	var d float64
+	for i := range a {
+		v := float64(a[i]) - float64(b[i])
+		d += v * v
+	}
+	return math.Sqrt(d)
+}
@@ -0,0 +1,65 @@
+package beat
+
+import (
+	"app/lib/mmd/vmd"
+	"math"
+	"testing"
+)
+
+// steps makes a motion whose bone swings left and right within a frame on every beat, and rests between beats.
+func steps(beatFrames int, phaseFrames int, beats int) *vmd.Motion {
+
+	swing := func(i int) [4]float32 {
+		a := math.Pi / 8
+		if i%2 == 1 {
+			a = -a
+		}
+		return [4]float32{0, float32(math.Sin(a / 2)), 0, float32(math.Cos(a / 2))}
+	}
+
+	m := &vmd.Motion{}
+	add := func(frame int, rotation [4]float32) {
+		f := vmd.BoneFrame{Name: "上半身", Frame: uint32(frame), Rotation: rotation}
+		for ch := vmd.ChannelX; ch <= vmd.ChannelRotation; ch++ {
+			f.SetInterpolation(ch, vmd.LinearBezier)
+		}
+		m.Bones = append(m.Bones, f)
+	}
+
+	add(0, swing(0))
+	for i := 1; i <= beats; i++ {
+		f := phaseFrames + (i-1)*beatFrames
+		add(f-1, swing(i-1))
+		add(f, swing(i))
+	}
+	return m
+}
+
+func TestDetectMotionBeats(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		beatFrames  int
+		phaseFrames int
+	}{
+		// 30fpsで18フレームごとは100 BPM
+		{"100 BPM", 18, 5},
+		{"120 BPM", 15, 12},
+		{"150 BPM", 12, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := DetectMotionBeats(steps(tt.beatFrames, tt.phaseFrames, 40))
+
+			bpm := 60 * vmd.FramesPerSecond / float64(tt.beatFrames)
+			if math.Abs(b.BPM-bpm) > bpm*0.01 {
+				t.Errorf("BPM = %v, want %v", b.BPM, bpm)
+			}
+			phase := float64(tt.phaseFrames) / vmd.FramesPerSecond
+			if math.Abs(b.Phase-phase) > 1/vmd.FramesPerSecond {
+				t.Errorf("Phase = %v, want %v", b.Phase, phase)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Package bvh is BVH (Biovision Hierarchy) files of motion capture, and conversion between them and VMD.
+//
+// BVH files are right-handed with Y up, and characters face +Z, as MMD models do in the coordinate system of three.js.
+// Rotations are in degrees and applied in the order of the channels of each joint.
+package bvh
+
+import (
+	"app/lib/mmd/math3d"
+	"math"
+)
+
+// Channel is a value of a joint animated in each frame.
+type Channel int
+
+// Channels of positions and rotations around the axes.
+const (
+	XPosition Channel = iota
+	YPosition
+	ZPosition
+	XRotation
+	YRotation
+	ZRotation
+)
+
+var channelNames = []string{"Xposition", "Yposition", "Zposition", "Xrotation", "Yrotation", "Zrotation"}
+
+// String gets the name in BVH files.
+func (c Channel) String() string {
+	return channelNames[c]
+}
+
+// Joint is a joint of the hierarchy.
+type Joint struct {
+	Name string
+	// Offset is the rest position relative to the parent joint.
+	Offset   [3]float64
+	Channels []Channel
+	Children []*Joint
+	// EndSite is the offset of the end of the joint. It is nil unless the joint has no children.
+	EndSite *[3]float64
+}
+
+// Motion is content of a BVH file.
+type Motion struct {
+	Root *Joint
+	// FrameTime is seconds per frame.
+	FrameTime float64
+	// Frames are values of the channels of all joints in the order of Joints.
+	Frames [][]float64
+}
+
+// Joints gets the joints in depth-first order, which is the order of channels in frames.
+func (m *Motion) Joints() []*Joint {
+	var joints []*Joint
+	var visit func(j *Joint)
+	visit = func(j *Joint) {
+		joints = append(joints, j)
+		for _, c := range j.Children {
+			visit(c)
+		}
+	}
+	if m.Root != nil {
+		visit(m.Root)
+	}
+	return joints
+}
+
+// rotation gets the rotation of the channel values in degrees.
+func rotation(channels []Channel, values []float64) math3d.Quaternion {
+	q := math3d.IdentityQuaternion()
+	for i, c := range channels {
+		var axis math3d.Vector3
+		switch c {
+		case XRotation:
+			axis = math3d.NewVector3(1, 0, 0)
+		case YRotation:
+			axis = math3d.NewVector3(0, 1, 0)
+		case ZRotation:
+			axis = math3d.NewVector3(0, 0, 1)
+		default:
+			continue
+		}
+		q = q.Mul(math3d.NewQuaternionFromAxisAngle(axis, values[i]*math.Pi/180))
+	}
+	return q
+}
+
+// setRotation sets rotation channels of values to the rotation in degrees.
+// Channels other than rotation are kept.
+func setRotation(channels []Channel, values []float64, q math3d.Quaternion) {
+
+	var axes []byte
+	var indices []int
+	for i, c := range channels {
+		if c >= XRotation {
+			axes = append(axes, "XYZ"[c-XRotation])
+			indices = append(indices, i)
+		}
+	}
+	if len(axes) != 3 {
+		return
+	}
+
+	orders := map[string]math3d.EulerOrder{
+		"XYZ": math3d.XYZ, "YXZ": math3d.YXZ, "ZXY": math3d.ZXY,
+		"ZYX": math3d.ZYX, "YZX": math3d.YZX, "XZY": math3d.XZY,
+	}
+	order, ok := orders[string(axes)]
+	if !ok {
+		return
+	}
+
+	x, y, z := q.Euler(order)
+	angles := map[byte]float64{'X': x, 'Y': y, 'Z': z}
+	for k, a := range axes {
+		values[indices[k]] = angles[a] * 180 / math.Pi
+	}
+}
@@ -0,0 +1,324 @@
+package bvh
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/pmx"
+	"app/lib/mmd/pose"
+	"app/lib/mmd/retarget"
+	"app/lib/mmd/skeleton"
+	"app/lib/mmd/vmd"
+	"errors"
+	"math"
+)
+
+// Options is parameters of the conversion between BVH and VMD.
+type Options struct {
+	Mapping Mapping
+	// Model is the MMD model the motion is for. If nil, StandardModel is used.
+	Model *pmx.Model
+	// Scale is MMD units per BVH unit. If 0, it is estimated from the heights of the mapped skeletons,
+	// or DefaultScale is used when FromVMD generates the hierarchy.
+	Scale float64
+
+	// Hierarchy is the joints FromVMD writes, such as the root joint of a BVH file of the rig of another tool.
+	// If nil, the hierarchy is generated from the mapped bones of the model.
+	Hierarchy *Joint
+	// TPose makes the arms of the generated hierarchy horizontal, for tools which expect the T-pose.
+	// The rest pose of MMD models is the A-pose.
+	TPose bool
+}
+
+// DefaultScale is MMD units per centimeter. 1 MMD unit is about 8 cm.
+var DefaultScale = 0.125
+
+// ErrNoMapping is returned when no joints are mapped to bones of the model.
+var ErrNoMapping = errors.New("bvh: no joints are mapped to bones")
+
+// ToVMD converts the BVH motion to a VMD motion of the model, resampled at 30 fps.
+//
+// Mapped bones get rotations which turn them in the same directions in the model as the joints,
+// so differences of the rest poses, such as the T-pose and the A-pose, are corrected.
+// The bone mapped from the root joint gets translations. IK of the model is turned off,
+// since the legs are posed directly. The keyframes are at every frame; see vmd.Motion.Fit to reduce them.
+func ToVMD(m *Motion, o Options) (*vmd.Motion, error) {
+
+	model := o.Model
+	if model == nil {
+		model = StandardModel()
+	}
+	s, err := skeleton.NewFromPMX(model)
+	if err != nil {
+		return nil, err
+	}
+
+	joints := m.Joints()
+	source := newRigFromJoints(joints)
+	target := newRigFromSkeleton(s)
+	r := retarget.New(&source.Rig, &target.Rig, o.Mapping.invert(), nil)
+	if len(r.Pairs) == 0 {
+		return nil, ErrNoMapping
+	}
+
+	scale := o.Scale
+	if scale == 0 {
+		sourceHeight, targetHeight := r.Heights()
+		scale = targetHeight / sourceHeight
+	}
+	standing := source.standing()
+
+	out := &vmd.Motion{ModelName: model.Name}
+	if len(m.Frames) == 0 || m.FrameTime <= 0 {
+		return out, nil
+	}
+
+	world := make([]math3d.Quaternion, len(joints))
+	duration := float64(len(m.Frames)-1) * m.FrameTime
+	// Frame Timeは丸めて書かれることが多いため、半フレームの余裕を持たせる
+	for k := 0; float64(k)/vmd.FramesPerSecond <= duration+m.FrameTime/2; k++ {
+		n := int(math.Round(float64(k) / vmd.FramesPerSecond / m.FrameTime))
+		if n >= len(m.Frames) {
+			n = len(m.Frames) - 1
+		}
+		values := m.Frames[n]
+
+		for i, offset := range source.channels {
+			j := joints[i]
+			q := rotation(j.Channels, values[offset:offset+len(j.Channels)])
+			if p := source.Parents[i]; p >= 0 {
+				q = world[p].Mul(q)
+			}
+			world[i] = q
+		}
+
+		locals := r.Retarget(func(i int) math3d.Quaternion { return world[i] })
+		for i, p := range r.Pairs {
+			// 右手系から左手系に変換する
+			q := locals[i].FlipZ()
+			f := vmd.BoneFrame{
+				Name:     target.Names[p.Target],
+				Frame:    uint32(k),
+				Rotation: [4]float32{float32(q.X), float32(q.Y), float32(q.Z), float32(q.W)},
+			}
+			if p.Source == 0 {
+				d := rootPosition(joints[0], values).Sub(math3d.NewVector3(0, standing, 0)).Scale(scale).FlipZ()
+				f.Position = [3]float32{float32(d.X), float32(d.Y), float32(d.Z)}
+			}
+			for ch := vmd.ChannelX; ch <= vmd.ChannelRotation; ch++ {
+				f.SetInterpolation(ch, vmd.LinearBezier)
+			}
+			out.Bones = append(out.Bones, f)
+		}
+	}
+
+	// 脚はIKではなく回転で動かす
+	ik := vmd.IKFrame{Frame: 0, Show: true}
+	for _, b := range model.Bones {
+		if b.Flags&pmx.BoneIK != 0 {
+			ik.IKs = append(ik.IKs, vmd.IKState{Name: b.Name, Enabled: false})
+		}
+	}
+	if len(ik.IKs) > 0 {
+		out.IKs = []vmd.IKFrame{ik}
+	}
+
+	out.Sort()
+	return out, nil
+}
+
+// FromVMD converts the VMD motion of the model to a BVH motion at 30 fps.
+// The motion is evaluated with IK and 付与 of the model, and mapped joints get rotations which turn them
+// in the same directions as the bones. The root joint gets translations of the bone mapped to it.
+func FromVMD(v *vmd.Motion, o Options) (*Motion, error) {
+
+	model := o.Model
+	if model == nil {
+		model = StandardModel()
+	}
+	e, err := pose.New(model)
+	if err != nil {
+		return nil, err
+	}
+	e.SetMotion(v)
+	s := e.Skeleton()
+	source := newRigFromSkeleton(s)
+
+	scale := o.Scale
+	root := o.Hierarchy
+	if root == nil {
+		if scale == 0 {
+			scale = DefaultScale
+		}
+		if root, err = hierarchy(model, s, o.Mapping, scale, o.TPose); err != nil {
+			return nil, err
+		}
+	}
+
+	out := &Motion{Root: root, FrameTime: 1.0 / vmd.FramesPerSecond}
+	joints := out.Joints()
+	target := newRigFromJoints(joints)
+	r := retarget.New(&source.Rig, &target.Rig, o.Mapping, nil)
+	if len(r.Pairs) == 0 {
+		return nil, ErrNoMapping
+	}
+	if scale == 0 {
+		sourceHeight, targetHeight := r.Heights()
+		scale = sourceHeight / targetHeight
+	}
+	standing := target.standing()
+
+	var last uint32
+	for _, f := range v.Bones {
+		if f.Frame > last {
+			last = f.Frame
+		}
+	}
+
+	var channels int
+	for _, j := range joints {
+		channels += len(j.Channels)
+	}
+
+	for f := 0; f <= int(last); f++ {
+		_, world := e.Evaluate(float64(f))
+		locals := r.Retarget(func(i int) math3d.Quaternion { return world[i].Rotation })
+
+		values := make([]float64, channels)
+		for i, j := range joints {
+			setPosition(j.Channels, values[target.channels[i]:], math3d.NewVector3(j.Offset[0], j.Offset[1], j.Offset[2]))
+		}
+		for i, p := range r.Pairs {
+			j := joints[p.Target]
+			offset := target.channels[p.Target]
+			setRotation(j.Channels, values[offset:offset+len(j.Channels)], locals[i])
+
+			if p.Target == 0 {
+				d := world[p.Source].Position.Sub(source.Rest[p.Source]).Scale(1 / scale).Add(math3d.NewVector3(0, standing, 0))
+				setPosition(j.Channels, values[offset:], d)
+			}
+		}
+		out.Frames = append(out.Frames, values)
+	}
+
+	return out, nil
+}
+
+// rootPosition gets the position of the root joint from the position channels, or the offset without them.
+func rootPosition(j *Joint, values []float64) math3d.Vector3 {
+	p := j.Offset
+	for i, c := range j.Channels {
+		if c <= ZPosition {
+			p[c-XPosition] = values[i]
+		}
+	}
+	return math3d.NewVector3(p[0], p[1], p[2])
+}
+
+// setPosition sets position channels of values to p.
+func setPosition(channels []Channel, values []float64, p math3d.Vector3) {
+	for i, c := range channels {
+		switch c {
+		case XPosition:
+			values[i] = p.X
+		case YPosition:
+			values[i] = p.Y
+		case ZPosition:
+			values[i] = p.Z
+		}
+	}
+}
+
+// hierarchy generates joints of the mapped bones of the model in the rest pose.
+func hierarchy(model *pmx.Model, s *skeleton.Skeleton, mapping Mapping, scale float64, tpose bool) (*Joint, error) {
+
+	names := mapping.invert()
+	rest := s.RestWorldPositions()
+	joints := make(map[int]*Joint)
+	var root *Joint
+
+	for _, i := range s.Order() {
+		name, ok := names[s.Bones[i].Name]
+		if !ok {
+			continue
+		}
+
+		j := &Joint{Name: name, Channels: []Channel{ZRotation, XRotation, YRotation}}
+		p := s.Bones[i].Parent
+		for p >= 0 && joints[p] == nil {
+			p = s.Bones[p].Parent
+		}
+		if p < 0 {
+			if root != nil {
+				return nil, errors.New("bvh: mapped bones have several roots")
+			}
+			j.Channels = []Channel{XPosition, YPosition, ZPosition, ZRotation, XRotation, YRotation}
+			root = j
+		} else {
+			d := rest[i].Sub(rest[p]).Scale(1 / scale)
+			j.Offset = [3]float64{d.X, d.Y, d.Z}
+			joints[p].Children = append(joints[p].Children, j)
+		}
+		joints[i] = j
+	}
+	if root == nil {
+		return nil, ErrNoMapping
+	}
+
+	// 末端の関節はボーンの先を終端にする
+	for i, j := range joints {
+		if len(j.Children) > 0 {
+			continue
+		}
+		b := model.Bones[i]
+		tail := math3d.NewVector3(float64(b.TailOffset[0]), float64(b.TailOffset[1]), float64(b.TailOffset[2])).FlipZ()
+		if b.Flags&pmx.BoneTailIsBone != 0 && b.TailBone >= 0 && b.TailBone < len(rest) {
+			tail = rest[b.TailBone].Sub(rest[i])
+		}
+		tail = tail.Scale(1 / scale)
+		j.EndSite = &[3]float64{tail.X, tail.Y, tail.Z}
+	}
+
+	if tpose {
+		for i, j := range joints {
+			if name := s.Bones[i].Name; name == "左腕" || name == "右腕" {
+				raise(j)
+			}
+		}
+	}
+
+	return root, nil
+}
+
+// raise rotates the joints below j so that the arm points horizontally.
+func raise(j *Joint) {
+
+	var d [3]float64
+	switch {
+	case len(j.Children) > 0:
+		d = j.Children[0].Offset
+	case j.EndSite != nil:
+		d = *j.EndSite
+	}
+	dir := math3d.NewVector3(d[0], d[1], d[2])
+	if dir.Length() < 1e-9 {
+		return
+	}
+	horizontal := math3d.NewVector3(math.Copysign(1, dir.X), 0, 0)
+	q := math3d.NewQuaternionFromUnitVectors(dir.Normalize(), horizontal)
+
+	rotate := func(v [3]float64) [3]float64 {
+		r := q.Rotate(math3d.NewVector3(v[0], v[1], v[2]))
+		return [3]float64{r.X, r.Y, r.Z}
+	}
+	var visit func(j *Joint)
+	visit = func(j *Joint) {
+		for _, c := range j.Children {
+			c.Offset = rotate(c.Offset)
+			visit(c)
+		}
+		if j.EndSite != nil {
+			v := rotate(*j.EndSite)
+			j.EndSite = &v
+		}
+	}
+	visit(j)
+}
@@ -0,0 +1,188 @@
+package bvh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidHeader is returned when the data is not a BVH file.
+var ErrInvalidHeader = errors.New("bvh: invalid header")
+
+// Decode reads a BVH file.
+func Decode(r io.Reader) (*Motion, error) {
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	s.Split(bufio.ScanWords)
+	d := &decoder{s: s}
+
+	if d.next() != "HIERARCHY" || d.next() != "ROOT" {
+		return nil, ErrInvalidHeader
+	}
+
+	m := &Motion{}
+	var err error
+	if m.Root, err = d.joint(); err != nil {
+		return nil, err
+	}
+
+	if err := d.expect("MOTION"); err != nil {
+		return nil, err
+	}
+	if err := d.expect("Frames:"); err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(d.next())
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("bvh: invalid number of frames")
+	}
+	if err := d.expect("Frame"); err != nil {
+		return nil, err
+	}
+	if err := d.expect("Time:"); err != nil {
+		return nil, err
+	}
+	if m.FrameTime, err = d.float(); err != nil {
+		return nil, err
+	}
+
+	var channels int
+	for _, j := range m.Joints() {
+		channels += len(j.Channels)
+	}
+	// チャンネルがなければフレームは入力を消費しないため、フレーム数だけメモリを確保してしまう
+	if channels == 0 && n > 0 {
+		return nil, fmt.Errorf("bvh: %d frames have no channels", n)
+	}
+
+	for f := 0; f < n; f++ {
+		values := make([]float64, channels)
+		for i := range values {
+			if values[i], err = d.float(); err != nil {
+				return nil, fmt.Errorf("bvh: frame %d: %w", f, err)
+			}
+		}
+		m.Frames = append(m.Frames, values)
+	}
+
+	return m, nil
+}
+
+type decoder struct {
+	s *bufio.Scanner
+}
+
+// next gets the next word, or empty string at the end.
+func (d *decoder) next() string {
+	if !d.s.Scan() {
+		return ""
+	}
+	return d.s.Text()
+}
+
+func (d *decoder) expect(word string) error {
+	if w := d.next(); w != word {
+		if w == "" {
+			return io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("bvh: %q is expected, but %q is found", word, w)
+	}
+	return nil
+}
+
+func (d *decoder) float() (float64, error) {
+	w := d.next()
+	if w == "" {
+		return 0, io.ErrUnexpectedEOF
+	}
+	v, err := strconv.ParseFloat(w, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bvh: invalid number %q", w)
+	}
+	return v, nil
+}
+
+func (d *decoder) offset() ([3]float64, error) {
+	var v [3]float64
+	if err := d.expect("OFFSET"); err != nil {
+		return v, err
+	}
+	for i := range v {
+		var err error
+		if v[i], err = d.float(); err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
+
+// joint reads a joint after ROOT or JOINT.
+func (d *decoder) joint() (*Joint, error) {
+
+	j := &Joint{Name: d.next()}
+	if err := d.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if j.Offset, err = d.offset(); err != nil {
+		return nil, err
+	}
+
+	if err := d.expect("CHANNELS"); err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(d.next())
+	if err != nil || n < 0 || n > 6 {
+		return nil, fmt.Errorf("bvh: invalid number of channels of %v", j.Name)
+	}
+	for i := 0; i < n; i++ {
+		w := d.next()
+		c := -1
+		for k, name := range channelNames {
+			if strings.EqualFold(w, name) {
+				c = k
+			}
+		}
+		if c < 0 {
+			return nil, fmt.Errorf("bvh: unknown channel %q", w)
+		}
+		j.Channels = append(j.Channels, Channel(c))
+	}
+
+	for {
+		switch w := d.next(); w {
+		case "JOINT":
+			c, err := d.joint()
+			if err != nil {
+				return nil, err
+			}
+			j.Children = append(j.Children, c)
+		case "End":
+			if err := d.expect("Site"); err != nil {
+				return nil, err
+			}
+			if err := d.expect("{"); err != nil {
+				return nil, err
+			}
+			v, err := d.offset()
+			if err != nil {
+				return nil, err
+			}
+			if err := d.expect("}"); err != nil {
+				return nil, err
+			}
+			j.EndSite = &v
+		case "}":
+			return j, nil
+		case "":
+			return nil, io.ErrUnexpectedEOF
+		default:
+			return nil, fmt.Errorf("bvh: unexpected %q in %v", w, j.Name)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package bvh
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+
+	text := `HIERARCHY
+ROOT Hips
+{
+  OFFSET 0 0 0
+  CHANNELS 6 Xposition Yposition Zposition Zrotation Xrotation Yrotation
+  JOINT Chest
+  {
+    OFFSET 0 5 0
+    CHANNELS 3 Zrotation Xrotation Yrotation
+    End Site
+    {
+      OFFSET 0 5 0
+    }
+  }
+}
+MOTION
+Frames: 2
+Frame Time: 0.0333333
+0 10 0 0 0 0 0 0 0
+1 10 0 0 90 0 0 0 45
+`
+
+	m, err := Decode(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Root.Name != "Hips" || len(m.Root.Children) != 1 || m.Root.Children[0].Name != "Chest" {
+		t.Errorf("hierarchy = %+v", m.Root)
+	}
+	if len(m.Frames) != 2 || len(m.Frames[1]) != 9 || m.Frames[1][4] != 90 || m.Frames[1][8] != 45 {
+		t.Errorf("Frames = %v", m.Frames)
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+
+	hierarchy := func(channels string) string {
+		return "HIERARCHY\nROOT Hips\n{\n  OFFSET 0 0 0\n  CHANNELS " + channels + "\n  End Site\n  {\n    OFFSET 0 1 0\n  }\n}\nMOTION\n"
+	}
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"empty", ""},
+		{"no channels with huge frames", hierarchy("0") + "Frames: 2147483647\nFrame Time: 0.0333333\n"},
+		{"no channels with a frame", hierarchy("0") + "Frames: 1\nFrame Time: 0.0333333\n"},
+		{"negative frames", hierarchy("1 Xrotation") + "Frames: -1\nFrame Time: 0.0333333\n"},
+		{"frames past the end", hierarchy("1 Xrotation") + "Frames: 2147483647\nFrame Time: 0.0333333\n0\n"},
+		{"too many channels", hierarchy("7 Xrotation Xrotation Xrotation Xrotation Xrotation Xrotation Xrotation")},
+		{"unknown channel", hierarchy("1 Wrotation")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Decode(strings.NewReader(tt.text))
+			if err == nil {
+				t.Fatalf("Decode() = %+v, want an error", m)
+			}
+		})
+	}
+}
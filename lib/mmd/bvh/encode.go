@@ -0,0 +1,68 @@
+package bvh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Encode writes the motion as a BVH file.
+func Encode(w io.Writer, m *Motion) error {
+
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintln(b, "HIERARCHY")
+	if m.Root != nil {
+		writeJoint(b, m.Root, "ROOT", 0)
+	}
+
+	fmt.Fprintln(b, "MOTION")
+	fmt.Fprintf(b, "Frames: %d\n", len(m.Frames))
+	fmt.Fprintf(b, "Frame Time: %v\n", strconv.FormatFloat(m.FrameTime, 'f', 6, 64))
+	for _, values := range m.Frames {
+		for i, v := range values {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(strconv.FormatFloat(v, 'f', 6, 64))
+		}
+		b.WriteByte('\n')
+	}
+
+	return b.Flush()
+}
+
+func writeJoint(b *bufio.Writer, j *Joint, kind string, depth int) {
+
+	indent := strings.Repeat("\t", depth)
+	fmt.Fprintf(b, "%v%v %v\n", indent, kind, j.Name)
+	fmt.Fprintf(b, "%v{\n", indent)
+	fmt.Fprintf(b, "%v\tOFFSET %v\n", indent, formatVector(j.Offset))
+
+	channels := make([]string, len(j.Channels))
+	for i, c := range j.Channels {
+		channels[i] = c.String()
+	}
+	fmt.Fprintf(b, "%v\tCHANNELS %d %v\n", indent, len(j.Channels), strings.Join(channels, " "))
+
+	for _, c := range j.Children {
+		writeJoint(b, c, "JOINT", depth+1)
+	}
+	if j.EndSite != nil {
+		fmt.Fprintf(b, "%v\tEnd Site\n", indent)
+		fmt.Fprintf(b, "%v\t{\n", indent)
+		fmt.Fprintf(b, "%v\t\tOFFSET %v\n", indent, formatVector(*j.EndSite))
+		fmt.Fprintf(b, "%v\t}\n", indent)
+	}
+
+	fmt.Fprintf(b, "%v}\n", indent)
+}
+
+func formatVector(v [3]float64) string {
+	return fmt.Sprintf("%v %v %v",
+		strconv.FormatFloat(v[0], 'f', 6, 64),
+		strconv.FormatFloat(v[1], 'f', 6, 64),
+		strconv.FormatFloat(v[2], 'f', 6, 64))
+}
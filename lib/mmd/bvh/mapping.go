@@ -0,0 +1,97 @@
+package bvh
+
+// Mapping is names of MMD bones by BVH joint.
+// Joints missing in the mapping keep their rest poses, and MMD bones missing in it are not animated.
+// The root joint is mapped to the bone which takes translations of the motion, usually センター.
+type Mapping map[string]string
+
+// CMU is the mapping of the skeleton of the CMU motion capture database in BVH.
+var CMU = Mapping{
+	"Hips":          "センター",
+	"Spine":         "上半身",
+	"Spine1":        "上半身2",
+	"Neck":          "首",
+	"Head":          "頭",
+	"LeftShoulder":  "左肩",
+	"LeftArm":       "左腕",
+	"LeftForeArm":   "左ひじ",
+	"LeftHand":      "左手首",
+	"RightShoulder": "右肩",
+	"RightArm":      "右腕",
+	"RightForeArm":  "右ひじ",
+	"RightHand":     "右手首",
+	"LeftUpLeg":     "左足",
+	"LeftLeg":       "左ひざ",
+	"LeftFoot":      "左足首",
+	"LeftToeBase":   "左つま先",
+	"RightUpLeg":    "右足",
+	"RightLeg":      "右ひざ",
+	"RightFoot":     "右足首",
+	"RightToeBase":  "右つま先",
+}
+
+// Mixamo is the mapping of skeletons exported from Mixamo.
+var Mixamo = Mapping{
+	"mixamorig:Hips":          "センター",
+	"mixamorig:Spine":         "上半身",
+	"mixamorig:Spine2":        "上半身2",
+	"mixamorig:Neck":          "首",
+	"mixamorig:Head":          "頭",
+	"mixamorig:LeftShoulder":  "左肩",
+	"mixamorig:LeftArm":       "左腕",
+	"mixamorig:LeftForeArm":   "左ひじ",
+	"mixamorig:LeftHand":      "左手首",
+	"mixamorig:RightShoulder": "右肩",
+	"mixamorig:RightArm":      "右腕",
+	"mixamorig:RightForeArm":  "右ひじ",
+	"mixamorig:RightHand":     "右手首",
+	"mixamorig:LeftUpLeg":     "左足",
+	"mixamorig:LeftLeg":       "左ひざ",
+	"mixamorig:LeftFoot":      "左足首",
+	"mixamorig:LeftToeBase":   "左つま先",
+	"mixamorig:RightUpLeg":    "右足",
+	"mixamorig:RightLeg":      "右ひざ",
+	"mixamorig:RightFoot":     "右足首",
+	"mixamorig:RightToeBase":  "右つま先",
+}
+
+// Biped is the mapping of Biped skeletons of 3ds Max.
+var Biped = Mapping{
+	"Bip01":            "センター",
+	"Bip01 Spine":      "上半身",
+	"Bip01 Spine1":     "上半身2",
+	"Bip01 Neck":       "首",
+	"Bip01 Head":       "頭",
+	"Bip01 L Clavicle": "左肩",
+	"Bip01 L UpperArm": "左腕",
+	"Bip01 L Forearm":  "左ひじ",
+	"Bip01 L Hand":     "左手首",
+	"Bip01 R Clavicle": "右肩",
+	"Bip01 R UpperArm": "右腕",
+	"Bip01 R Forearm":  "右ひじ",
+	"Bip01 R Hand":     "右手首",
+	"Bip01 L Thigh":    "左足",
+	"Bip01 L Calf":     "左ひざ",
+	"Bip01 L Foot":     "左足首",
+	"Bip01 L Toe0":     "左つま先",
+	"Bip01 R Thigh":    "右足",
+	"Bip01 R Calf":     "右ひざ",
+	"Bip01 R Foot":     "右足首",
+	"Bip01 R Toe0":     "右つま先",
+}
+
+// Presets are the mappings by name.
+var Presets = map[string]Mapping{
+	"cmu":    CMU,
+	"mixamo": Mixamo,
+	"biped":  Biped,
+}
+
+// invert gets BVH joint names by MMD bone.
+func (m Mapping) invert() map[string]string {
+	bones := make(map[string]string, len(m))
+	for joint, bone := range m {
+		bones[bone] = joint
+	}
+	return bones
+}
@@ -0,0 +1,69 @@
+package bvh
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/retarget"
+	"app/lib/mmd/skeleton"
+	"math"
+)
+
+// rig is joints or bones in the rest pose, in the right-handed coordinate system.
+type rig struct {
+	retarget.Rig
+	// channels is the index of the first channel of each joint in frames. It is nil for bones.
+	channels []int
+}
+
+func newRigFromJoints(joints []*Joint) *rig {
+
+	r := &rig{
+		Rig: retarget.Rig{
+			Names:   make([]string, len(joints)),
+			Parents: make([]int, len(joints)),
+			Rest:    make([]math3d.Vector3, len(joints)),
+			Order:   make([]int, len(joints)),
+		},
+		channels: make([]int, len(joints)),
+	}
+
+	index := make(map[*Joint]int, len(joints))
+	var channels int
+	for i, j := range joints {
+		index[j] = i
+		r.Names[i] = j.Name
+		r.Parents[i] = -1
+		r.Order[i] = i
+		r.channels[i] = channels
+		channels += len(j.Channels)
+	}
+
+	// 関節は深さ優先の順のため、親が先に来る
+	for i, j := range joints {
+		offset := math3d.NewVector3(j.Offset[0], j.Offset[1], j.Offset[2])
+		if i == 0 {
+			offset = math3d.Vector3{}
+		}
+		r.Rest[i] = offset
+		for _, c := range j.Children {
+			r.Parents[index[c]] = i
+		}
+		if p := r.Parents[i]; p >= 0 {
+			r.Rest[i] = r.Rest[p].Add(offset)
+		}
+	}
+
+	return r
+}
+
+func newRigFromSkeleton(s *skeleton.Skeleton) *rig {
+	return &rig{Rig: *retarget.NewRigFromSkeleton(s)}
+}
+
+// standing gets the height of the root above the lowest joint in the rest pose.
+func (r *rig) standing() float64 {
+	low := 0.0
+	for _, p := range r.Rest {
+		low = math.Min(low, p.Y-r.Rest[0].Y)
+	}
+	return -low
+}
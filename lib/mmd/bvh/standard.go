@@ -0,0 +1,90 @@
+package bvh
+
+import (
+	"app/lib/mmd/pmx"
+	"math"
+)
+
+// standardBone is a bone of StandardModel.
+type standardBone struct {
+	name     string
+	parent   string
+	position [3]float32
+	tail     [3]float32
+}
+
+// standardBones are the main bones of the standard models of MMD in the A-pose, in the left-handed MMD coordinate system.
+var standardBones = []standardBone{
+	{name: "全ての親", position: [3]float32{0, 0, 0}},
+	{name: "センター", parent: "全ての親", position: [3]float32{0, 8, 0}},
+	{name: "グルーブ", parent: "センター", position: [3]float32{0, 8.2, 0}},
+	{name: "上半身", parent: "グルーブ", position: [3]float32{0, 11.7, 0.2}},
+	{name: "上半身2", parent: "上半身", position: [3]float32{0, 12.8, 0.3}},
+	{name: "首", parent: "上半身2", position: [3]float32{0, 15.2, 0.5}},
+	{name: "頭", parent: "首", position: [3]float32{0, 16.2, 0.3}, tail: [3]float32{0, 1.6, 0}},
+	{name: "下半身", parent: "グルーブ", position: [3]float32{0, 11.7, 0.2}},
+	{name: "左肩", parent: "上半身2", position: [3]float32{0.4, 14.9, 0.6}},
+	{name: "左腕", parent: "左肩", position: [3]float32{1.3, 14.6, 0.7}},
+	{name: "左ひじ", parent: "左腕", position: [3]float32{3.0, 13.3, 0.8}},
+	{name: "左手首", parent: "左ひじ", position: [3]float32{4.5, 12.1, 0.6}, tail: [3]float32{0.8, -0.6, 0}},
+	{name: "右肩", parent: "上半身2", position: [3]float32{-0.4, 14.9, 0.6}},
+	{name: "右腕", parent: "右肩", position: [3]float32{-1.3, 14.6, 0.7}},
+	{name: "右ひじ", parent: "右腕", position: [3]float32{-3.0, 13.3, 0.8}},
+	{name: "右手首", parent: "右ひじ", position: [3]float32{-4.5, 12.1, 0.6}, tail: [3]float32{-0.8, -0.6, 0}},
+	{name: "左足", parent: "下半身", position: [3]float32{0.9, 10.7, 0.3}},
+	{name: "左ひざ", parent: "左足", position: [3]float32{1.0, 6.3, 0.1}},
+	{name: "左足首", parent: "左ひざ", position: [3]float32{1.1, 1.3, 0.6}},
+	{name: "左つま先", parent: "左足首", position: [3]float32{1.1, 0.2, -1.0}, tail: [3]float32{0, 0, -0.5}},
+	{name: "右足", parent: "下半身", position: [3]float32{-0.9, 10.7, 0.3}},
+	{name: "右ひざ", parent: "右足", position: [3]float32{-1.0, 6.3, 0.1}},
+	{name: "右足首", parent: "右ひざ", position: [3]float32{-1.1, 1.3, 0.6}},
+	{name: "右つま先", parent: "右足首", position: [3]float32{-1.1, 0.2, -1.0}, tail: [3]float32{0, 0, -0.5}},
+	{name: "左足ＩＫ", parent: "全ての親", position: [3]float32{1.1, 1.3, 0.6}},
+	{name: "右足ＩＫ", parent: "全ての親", position: [3]float32{-1.1, 1.3, 0.6}},
+}
+
+// StandardModel creates a model of the main bones and the leg IK of the standard models of MMD,
+// used when no model is given to convert motions.
+func StandardModel() *pmx.Model {
+
+	index := make(map[string]int, len(standardBones))
+	for i, b := range standardBones {
+		index[b.name] = i
+	}
+
+	m := &pmx.Model{Name: "標準モデル", EnglishName: "Standard"}
+	for _, b := range standardBones {
+		parent := -1
+		if b.parent != "" {
+			parent = index[b.parent]
+		}
+		m.Bones = append(m.Bones, pmx.Bone{
+			Name:          b.name,
+			Position:      b.position,
+			Parent:        parent,
+			Flags:         pmx.BoneRotatable | pmx.BoneMovable | pmx.BoneVisible | pmx.BoneOperable,
+			TailBone:      -1,
+			TailOffset:    b.tail,
+			InheritParent: -1,
+			ExternalKey:   -1,
+		})
+	}
+
+	// ひざは内側に曲がらないよう制限する
+	const toRadians = math.Pi / 180
+	for _, side := range []string{"左", "右"} {
+		ik := &m.Bones[index[side+"足ＩＫ"]]
+		ik.Flags |= pmx.BoneIK
+		ik.IK = &pmx.IK{
+			Target:     index[side+"足首"],
+			Loops:      40,
+			LimitAngle: 2,
+			Links: []pmx.IKLink{
+				{Bone: index[side+"ひざ"], Limited: true, Min: [3]float32{-180 * toRadians, 0, 0}, Max: [3]float32{-0.5 * toRadians, 0, 0}},
+				{Bone: index[side+"足"]},
+			},
+		}
+	}
+
+	return m
+}
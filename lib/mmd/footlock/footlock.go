@@ -0,0 +1,396 @@
+// Package footlock cleans up foot sliding of motions played on a model of another size than they are made for.
+//
+// The motion is evaluated on the target model with package pose. Frames where an ankle stays low and slow
+// are contacts, and the leg IK bone is fixed at one place on the floor during each contact.
+// センター is lowered where the legs of the model cannot reach the fixed places.
+// The corrected motion keeps the other bones as they are.
+package footlock
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/pmx"
+	"app/lib/mmd/pose"
+	"app/lib/mmd/skeleton"
+	"app/lib/mmd/vmd"
+	"errors"
+	"math"
+)
+
+// Foot is names of the bones of a leg.
+type Foot struct {
+	IK    string
+	Leg   string
+	Knee  string
+	Ankle string
+	// Toe is the toe IK bone. Its translation is scaled with the leg IK bone.
+	Toe string
+}
+
+// Feet are legs of standard MMD models.
+var Feet = []Foot{
+	{IK: "左足ＩＫ", Leg: "左足", Knee: "左ひざ", Ankle: "左足首", Toe: "左つま先ＩＫ"},
+	{IK: "右足ＩＫ", Leg: "右足", Knee: "右ひざ", Ankle: "右足首", Toe: "右つま先ＩＫ"},
+}
+
+// Root bones whose translations are scaled. CenterBone is lowered when the legs do not reach the floor.
+var (
+	RootBones  = []string{"全ての親", "センター", "グルーブ"}
+	CenterBone = "センター"
+)
+
+// Config is parameters of the cleanup.
+type Config struct {
+	// Scale multiplies translations of the root bones and the leg IK bones.
+	// It is the leg length of the target model divided by that of the model the motion is made for (see ScaleBetween).
+	Scale float64
+
+	// ContactHeight is the height of the ankle above its rest height under which the foot may touch the floor, in MMD units.
+	ContactHeight float64
+	// ContactSpeed is the horizontal speed of the ankle under which the foot may touch the floor, in MMD units per frame.
+	ContactSpeed float64
+	// MinFrames is the shortest contact. Shorter ones are ignored.
+	MinFrames int
+	// BlendFrames is the number of frames before and after a contact where the foot moves to and from the fixed place.
+	BlendFrames int
+	// Reach is the ratio of the leg length kept between the hip and the fixed ankle, slightly less than 1 to keep knees bent.
+	Reach float64
+}
+
+// DefaultConfig suits motions of models of usual size.
+var DefaultConfig = Config{
+	Scale:         1,
+	ContactHeight: 0.6,
+	ContactSpeed:  0.08,
+	MinFrames:     4,
+	BlendFrames:   4,
+	Reach:         0.995,
+}
+
+// Contact is a time the foot is fixed on the floor.
+type Contact struct {
+	// IK is the name of the leg IK bone.
+	IK string
+	// Start and End are the first and the last frames of the contact.
+	Start uint32
+	End   uint32
+	// Position is the fixed place of the leg IK bone in the model, in the left-handed MMD coordinate system.
+	Position [3]float32
+}
+
+// ErrNoFeet is returned when the model has none of Feet.
+var ErrNoFeet = errors.New("footlock: model has no leg IK bones")
+
+// LegLength gets the length from the hip to the ankle of the model, averaged over Feet.
+// It returns 0 if the model has no legs.
+func LegLength(m *pmx.Model) float64 {
+
+	names := make(map[string]int, len(m.Bones))
+	for i, b := range m.Bones {
+		names[b.Name] = i
+	}
+	position := func(name string) (math3d.Vector3, bool) {
+		i, ok := names[name]
+		if !ok {
+			return math3d.Vector3{}, false
+		}
+		p := m.Bones[i].Position
+		return math3d.NewVector3(float64(p[0]), float64(p[1]), float64(p[2])), true
+	}
+
+	var sum float64
+	var n int
+	for _, f := range Feet {
+		leg, ok1 := position(f.Leg)
+		knee, ok2 := position(f.Knee)
+		ankle, ok3 := position(f.Ankle)
+		if !ok1 || !ok2 || !ok3 {
+			continue
+		}
+		sum += leg.Distance(knee) + knee.Distance(ankle)
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// ScaleBetween gets Config.Scale for a motion made for source played on target.
+// It returns 1 if either model has no legs.
+func ScaleBetween(source *pmx.Model, target *pmx.Model) float64 {
+	s, t := LegLength(source), LegLength(target)
+	if s <= 0 || t <= 0 {
+		return 1
+	}
+	return t / s
+}
+
+// foot is bone indices of a leg in the target model.
+type foot struct {
+	Foot
+	ik, leg, knee, ankle int
+}
+
+// Clean returns the motion corrected for the target model, and the contacts found.
+// The root bones and the leg IK bones are scaled, and the leg IK bones and CenterBone are baked every frame
+// and reduced with vmd.DefaultTolerance. m is not modified.
+func Clean(target *pmx.Model, m *vmd.Motion, c Config) (*vmd.Motion, []Contact, error) {
+
+	e, err := pose.New(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	s := e.Skeleton()
+
+	var feet []foot
+	for _, f := range Feet {
+		ik, ok1 := s.Index(f.IK)
+		leg, ok2 := s.Index(f.Leg)
+		knee, ok3 := s.Index(f.Knee)
+		ankle, ok4 := s.Index(f.Ankle)
+		if ok1 && ok2 && ok3 && ok4 {
+			feet = append(feet, foot{Foot: f, ik: ik, leg: leg, knee: knee, ankle: ankle})
+		}
+	}
+	if len(feet) == 0 {
+		return nil, nil, ErrNoFeet
+	}
+
+	out := scale(m, c.Scale)
+	var last uint32
+	for _, f := range out.Bones {
+		if f.Frame > last {
+			last = f.Frame
+		}
+	}
+	n := int(last) + 1
+
+	e.SetMotion(out)
+	worlds := make([][]skeleton.Transform, n)
+	for f := range worlds {
+		_, worlds[f] = e.Evaluate(float64(f))
+	}
+
+	rest := s.RestWorldPositions()
+	targets := make([][]math3d.Vector3, len(feet))
+	var contacts []Contact
+	for i, ft := range feet {
+		var cs []Contact
+		targets[i], cs = lock(ft, worlds, rest, c)
+		contacts = append(contacts, cs...)
+	}
+
+	// 脚が固定した位置に届かない場合はセンターを下げる
+	if center, ok := s.Index(CenterBone); ok {
+		lowering := make([]float64, n)
+		lowered := false
+		for f := range lowering {
+			for i, ft := range feet {
+				l := rest[ft.leg].Distance(rest[ft.knee]) + rest[ft.knee].Distance(rest[ft.ankle])
+				lowering[f] = math.Max(lowering[f], lower(worlds[f][ft.leg].Position, targets[i][f], l*c.Reach))
+			}
+			lowered = lowered || lowering[f] > 0
+		}
+
+		if lowered {
+			bake(out, s, center, worlds, func(f int) math3d.Vector3 {
+				return worlds[f][center].Position.Sub(math3d.NewVector3(0, lowering[f], 0))
+			})
+
+			// センターの変更が足IKの親に及ぶ場合に備えて評価し直す
+			e.SetMotion(out)
+			for f := range worlds {
+				_, worlds[f] = e.Evaluate(float64(f))
+			}
+		}
+	}
+
+	for i, ft := range feet {
+		t := targets[i]
+		bake(out, s, ft.ik, worlds, func(f int) math3d.Vector3 { return t[f] })
+	}
+
+	return out, contacts, nil
+}
+
+// scale copies the motion, scaling translations of the root bones and the leg IK bones.
+func scale(m *vmd.Motion, s float64) *vmd.Motion {
+
+	// Sortは並べ替えるため、mと配列を共有しないようすべて複製する
+	out := *m
+	out.Bones = append([]vmd.BoneFrame(nil), m.Bones...)
+	out.Morphs = append([]vmd.MorphFrame(nil), m.Morphs...)
+	out.Cameras = append([]vmd.CameraFrame(nil), m.Cameras...)
+	out.Lights = append([]vmd.LightFrame(nil), m.Lights...)
+	out.SelfShadows = append([]vmd.SelfShadowFrame(nil), m.SelfShadows...)
+	out.IKs = append([]vmd.IKFrame(nil), m.IKs...)
+	out.Sort()
+	if s == 0 || s == 1 {
+		return &out
+	}
+
+	scaled := make(map[string]bool)
+	for _, name := range RootBones {
+		scaled[name] = true
+	}
+	for _, f := range Feet {
+		scaled[f.IK] = true
+		scaled[f.Toe] = true
+	}
+
+	for i := range out.Bones {
+		f := &out.Bones[i]
+		if scaled[f.Name] {
+			for k := range f.Position {
+				f.Position[k] *= float32(s)
+			}
+		}
+	}
+	return &out
+}
+
+// lock finds contacts of the foot and gets the position of the leg IK bone in each frame.
+func lock(ft foot, worlds [][]skeleton.Transform, rest []math3d.Vector3, c Config) ([]math3d.Vector3, []Contact) {
+
+	n := len(worlds)
+	ground := rest[ft.ik].Y
+
+	// 足首が低く、水平方向にほとんど動かないフレームを接地とする
+	touching := make([]bool, n)
+	for f := range touching {
+		ankle := worlds[f][ft.ankle].Position
+		if ankle.Y-rest[ft.ankle].Y >= c.ContactHeight {
+			continue
+		}
+		speed := 0.0
+		if f > 0 {
+			speed = math.Max(speed, horizontal(ankle, worlds[f-1][ft.ankle].Position))
+		}
+		if f+1 < n {
+			speed = math.Max(speed, horizontal(ankle, worlds[f+1][ft.ankle].Position))
+		}
+		touching[f] = speed < c.ContactSpeed
+	}
+
+	targets := make([]math3d.Vector3, n)
+	for f := range targets {
+		targets[f] = worlds[f][ft.ik].Position
+	}
+
+	var contacts []Contact
+	// offsets are from the animated position, and weights are how much of the offsets are applied.
+	offsets := make([]math3d.Vector3, n)
+	weights := make([]float64, n)
+	for start := 0; start < n; {
+		if !touching[start] {
+			start++
+			continue
+		}
+		end := start
+		for end+1 < n && touching[end+1] {
+			end++
+		}
+
+		if end-start+1 >= c.MinFrames {
+			// 接地中の平均の位置に固定し、床より下には沈めない
+			var p math3d.Vector3
+			low := math.Inf(1)
+			for f := start; f <= end; f++ {
+				p = p.Add(targets[f])
+				low = math.Min(low, targets[f].Y)
+			}
+			p = p.Scale(1 / float64(end-start+1))
+			p.Y = math.Max(low, ground)
+
+			for f := start; f <= end; f++ {
+				offsets[f] = p.Sub(targets[f])
+				weights[f] = 1
+			}
+			// 前後のフレームで固定した位置へ滑らかに移る
+			for d := 1; d <= c.BlendFrames; d++ {
+				w := 1 - float64(d)/float64(c.BlendFrames+1)
+				if f := start - d; f >= 0 && w > weights[f] {
+					offsets[f] = offsets[start]
+					weights[f] = w
+				}
+				if f := end + d; f < n && w > weights[f] {
+					offsets[f] = offsets[end]
+					weights[f] = w
+				}
+			}
+
+			contacts = append(contacts, Contact{
+				IK:       ft.IK,
+				Start:    uint32(start),
+				End:      uint32(end),
+				Position: [3]float32{float32(p.X), float32(p.Y), -float32(p.Z)},
+			})
+		}
+		start = end + 1
+	}
+
+	for f := range targets {
+		targets[f] = targets[f].Add(offsets[f].Scale(weights[f]))
+		targets[f].Y = math.Max(targets[f].Y, ground)
+	}
+	return targets, contacts
+}
+
+// lower gets how far the hip is lowered so that the ankle at target is within reach.
+func lower(hip math3d.Vector3, target math3d.Vector3, reach float64) float64 {
+
+	v := target.Sub(hip)
+	h := v.X*v.X + v.Z*v.Z
+	if v.Length() <= reach || h >= reach*reach {
+		return 0
+	}
+	return math.Max(0, -math.Sqrt(reach*reach-h)-v.Y)
+}
+
+func horizontal(a math3d.Vector3, b math3d.Vector3) float64 {
+	return math.Hypot(a.X-b.X, a.Z-b.Z)
+}
+
+// bake replaces keyframes of the bone with ones at every frame which put the bone at position(frame) in the model.
+// Rotations are kept, and the keyframes are reduced.
+func bake(m *vmd.Motion, s *skeleton.Skeleton, bone int, worlds [][]skeleton.Transform, position func(frame int) math3d.Vector3) {
+
+	name := s.Bones[bone].Name
+	frames := m.BoneTracks()[name]
+	parent := s.Bones[bone].Parent
+
+	baked := &vmd.Motion{}
+	for f := range worlds {
+		p := position(f)
+		if parent >= 0 {
+			w := worlds[f][parent]
+			p = w.Rotation.Conjugate().Rotate(p.Sub(w.Position))
+		}
+		p = p.Sub(s.Bones[bone].Position)
+
+		k := vmd.BoneFrame{
+			Name:  name,
+			Frame: uint32(f),
+			// 右手系から左手系に変換する
+			Position: [3]float32{float32(p.X), float32(p.Y), -float32(p.Z)},
+			Rotation: [4]float32{0, 0, 0, 1},
+		}
+		if v, ok := vmd.BoneAt(frames, float64(f)); ok {
+			k.Rotation = v.Rotation
+		}
+		for ch := vmd.ChannelX; ch <= vmd.ChannelRotation; ch++ {
+			k.SetInterpolation(ch, vmd.LinearBezier)
+		}
+		baked.Bones = append(baked.Bones, k)
+	}
+	baked.Reduce(vmd.DefaultTolerance)
+
+	bones := m.Bones[:0]
+	for _, f := range m.Bones {
+		if f.Name != name {
+			bones = append(bones, f)
+		}
+	}
+	m.Bones = append(bones, baked.Bones...)
+	m.Sort()
+}
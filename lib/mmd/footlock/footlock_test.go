@@ -0,0 +1,36 @@
+package footlock
+
+import (
+	"app/lib/mmd/vmd"
+	"testing"
+)
+
+func TestScaleKeepsInput(t *testing.T) {
+
+	// 並べ替えが必要な順で各キーフレームを並べる
+	m := &vmd.Motion{
+		Bones:       []vmd.BoneFrame{{Name: "センター", Frame: 10, Position: [3]float32{1, 2, 3}}, {Name: "センター", Frame: 0}},
+		Morphs:      []vmd.MorphFrame{{Name: "あ", Frame: 10}, {Name: "あ", Frame: 0}},
+		Cameras:     []vmd.CameraFrame{{Frame: 10}, {Frame: 0}},
+		Lights:      []vmd.LightFrame{{Frame: 10}, {Frame: 0}},
+		SelfShadows: []vmd.SelfShadowFrame{{Frame: 10}, {Frame: 0}},
+		IKs:         []vmd.IKFrame{{Frame: 10}, {Frame: 0}},
+	}
+
+	out := scale(m, 2)
+
+	if m.Bones[0].Frame != 10 || m.Bones[0].Position != [3]float32{1, 2, 3} {
+		t.Errorf("input bones are changed: %+v", m.Bones)
+	}
+	if m.Morphs[0].Frame != 10 || m.Cameras[0].Frame != 10 || m.Lights[0].Frame != 10 ||
+		m.SelfShadows[0].Frame != 10 || m.IKs[0].Frame != 10 {
+		t.Errorf("input keyframes are sorted: %+v", m)
+	}
+	if out.Morphs[0].Frame != 0 || out.Cameras[0].Frame != 0 || out.Lights[0].Frame != 0 ||
+		out.SelfShadows[0].Frame != 0 || out.IKs[0].Frame != 0 {
+		t.Errorf("output keyframes are not sorted: %+v", out)
+	}
+	if out.Bones[1].Position != [3]float32{2, 4, 6} {
+		t.Errorf("scaled position = %v, want [2 4 6]", out.Bones[1].Position)
+	}
+}
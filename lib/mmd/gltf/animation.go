@@ -0,0 +1,102 @@
+package gltf
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/pose"
+	"app/lib/mmd/vmd"
+)
+
+// animation bakes the motion into an animation of the bone nodes and the morph target weights.
+// Only bones and morphs which move get channels.
+func (e *exporter) animation(v *vmd.Motion, name string) error {
+
+	engine, err := pose.New(e.model)
+	if err != nil {
+		return err
+	}
+	engine.SetMotion(v)
+
+	sorted := &vmd.Motion{Morphs: append([]vmd.MorphFrame(nil), v.Morphs...)}
+	sorted.Sort()
+	morphs := sorted.MorphTracks()
+
+	var last uint32
+	for _, f := range v.Bones {
+		if f.Frame > last {
+			last = f.Frame
+		}
+	}
+	for _, f := range v.Morphs {
+		if f.Frame > last {
+			last = f.Frame
+		}
+	}
+	frames := int(last) + 1
+
+	bones := len(e.skeleton.Bones)
+	translations := make([][]float32, bones)
+	rotations := make([][]float32, bones)
+	moved, turned := make([]bool, bones), make([]bool, bones)
+	times := make([]float32, frames)
+
+	for f := 0; f < frames; f++ {
+		times[f] = float32(f) / vmd.FramesPerSecond
+		local, _ := engine.Evaluate(float64(f))
+		for i, t := range local {
+			p := t.Position.Scale(e.scale)
+			translations[i] = append(translations[i], float32(p.X), float32(p.Y), float32(p.Z))
+			moved[i] = moved[i] || t.Position.Distance(e.skeleton.Bones[i].Position) > 1e-6
+
+			// 線形補間で遠回りしないよう、前のフレームと同じ側の符号にする
+			q := t.Rotation.Normalize()
+			if r := rotations[i]; len(r) > 0 {
+				prev := math3d.Quaternion{X: float64(r[len(r)-4]), Y: float64(r[len(r)-3]), Z: float64(r[len(r)-2]), W: float64(r[len(r)-1])}
+				if prev.Dot(q) < 0 {
+					q = math3d.Quaternion{X: -q.X, Y: -q.Y, Z: -q.Z, W: -q.W}
+				}
+			}
+			rotations[i] = append(rotations[i], float32(q.X), float32(q.Y), float32(q.Z), float32(q.W))
+			turned[i] = turned[i] || q.Angle(math3d.IdentityQuaternion()) > 1e-6
+		}
+	}
+
+	doc := e.b.doc
+	input := e.b.floats(times, "SCALAR", 0, true)
+	a := Animation{Name: name}
+	channel := func(node int, path string, output int) {
+		a.Samplers = append(a.Samplers, AnimationSampler{Input: input, Interpolation: "LINEAR", Output: output})
+		a.Channels = append(a.Channels, Channel{Sampler: len(a.Samplers) - 1, Target: ChannelTarget{Node: node, Path: path}})
+	}
+
+	for i := 0; i < bones; i++ {
+		if moved[i] {
+			channel(i, "translation", e.b.floats(translations[i], "VEC3", 0, false))
+		}
+		if turned[i] {
+			channel(i, "rotation", e.b.floats(rotations[i], "VEC4", 0, false))
+		}
+	}
+
+	// モーフは全ての対象の重みを毎フレーム並べる
+	animated := false
+	for _, m := range e.targets {
+		if len(morphs[e.model.Morphs[m].Name]) > 0 {
+			animated = true
+		}
+	}
+	if animated {
+		weights := make([]float32, 0, frames*len(e.targets))
+		for f := 0; f < frames; f++ {
+			for _, m := range e.targets {
+				w, _ := vmd.MorphAt(morphs[e.model.Morphs[m].Name], float64(f))
+				weights = append(weights, w)
+			}
+		}
+		channel(e.meshNode, "weights", e.b.floats(weights, "SCALAR", 0, false))
+	}
+
+	if len(a.Channels) > 0 {
+		doc.Animations = append(doc.Animations, a)
+	}
+	return nil
+}
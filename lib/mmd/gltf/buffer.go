@@ -0,0 +1,103 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// builder appends binary data of accessors to the buffer of a document.
+type builder struct {
+	doc *Document
+	bin []byte
+}
+
+// view appends the data as a buffer view aligned to 4 bytes, and returns its index.
+func (b *builder) view(data []byte, target int) int {
+	for len(b.bin)%4 != 0 {
+		b.bin = append(b.bin, 0)
+	}
+	b.doc.BufferViews = append(b.doc.BufferViews, BufferView{
+		ByteOffset: len(b.bin),
+		ByteLength: len(data),
+		Target:     target,
+	})
+	b.bin = append(b.bin, data...)
+	return len(b.doc.BufferViews) - 1
+}
+
+// floats appends float values of the accessor type in a buffer view, and returns the index of the accessor.
+// min and max are written when bounds is true.
+func (b *builder) floats(values []float32, typ string, target int, bounds bool) int {
+
+	view := b.view(encodeFloats(values), target)
+	a := Accessor{
+		BufferView:    &view,
+		ComponentType: Float,
+		Count:         len(values) / components(typ),
+		Type:          typ,
+	}
+	if bounds {
+		a.Min, a.Max = minMax(values, components(typ))
+	}
+	return b.accessor(a)
+}
+
+// indices appends unsigned integers of the smallest component type for values up to limit,
+// and returns the index of the accessor.
+func (b *builder) indices(values []int, limit int, target int) int {
+
+	data, componentType := encodeIndices(values, limit)
+	view := b.view(data, target)
+	return b.accessor(Accessor{
+		BufferView:    &view,
+		ComponentType: componentType,
+		Count:         len(values),
+		Type:          "SCALAR",
+	})
+}
+
+func (b *builder) accessor(a Accessor) int {
+	b.doc.Accessors = append(b.doc.Accessors, a)
+	return len(b.doc.Accessors) - 1
+}
+
+func encodeFloats(values []float32) []byte {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(v))
+	}
+	return data
+}
+
+// encodeIndices encodes values below limit as unsigned shorts if they fit, or unsigned ints.
+func encodeIndices(values []int, limit int) ([]byte, int) {
+
+	// 65535はプリミティブの区切りとして予約されているため使わない
+	if limit <= 65535 {
+		data := make([]byte, 2*len(values))
+		for i, v := range values {
+			binary.LittleEndian.PutUint16(data[2*i:], uint16(v))
+		}
+		return data, UnsignedShort
+	}
+
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], uint32(v))
+	}
+	return data, UnsignedInt
+}
+
+// minMax gets the bounds of each component of the values.
+func minMax(values []float32, n int) ([]float64, []float64) {
+
+	min, max := make([]float64, n), make([]float64, n)
+	for j := 0; j < n; j++ {
+		min[j], max[j] = math.Inf(1), math.Inf(-1)
+	}
+	for i, v := range values {
+		min[i%n] = math.Min(min[i%n], float64(v))
+		max[i%n] = math.Max(max[i%n], float64(v))
+	}
+	return min, max
+}
@@ -0,0 +1,469 @@
+package gltf
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/pmx"
+	"app/lib/mmd/skeleton"
+	"app/lib/mmd/vmd"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DefaultScale is meters per MMD unit. 1 MMD unit is about 8 cm.
+var DefaultScale = 0.08
+
+// ErrNoFaces is returned for models without triangles, since a glTF mesh needs a primitive.
+var ErrNoFaces = errors.New("gltf: model has no faces")
+
+// Options is parameters of Export.
+type Options struct {
+	// Scale is meters per MMD unit. If 0, DefaultScale is used.
+	Scale float64
+
+	// ReadFile reads a texture by the path in the model, relative to the model file with "/" as separators.
+	// If nil, textures are not embedded.
+	ReadFile func(name string) ([]byte, error)
+
+	// Motion is baked into an animation at 30 fps, evaluated with IK and 付与 of the model. If nil, no animation is written.
+	Motion *vmd.Motion
+	// AnimationName is the name of the animation. If empty, "motion" is used.
+	AnimationName string
+}
+
+// File is a glTF document with its binary buffer.
+type File struct {
+	Document Document
+	Buffer   []byte
+}
+
+// Export converts the model to glTF.
+//
+// Bones become nodes with a skin, materials become primitives of a mesh, and vertex and group morphs become morph targets.
+// Textures which can not be read or converted are left out; their errors are returned as warnings with the textures of
+// the other materials exported. Bone, UV and material morphs, physics and toon shading have no counterparts and are not exported.
+func Export(m *pmx.Model, o Options) (*File, []error, error) {
+
+	scale := o.Scale
+	if scale == 0 {
+		scale = DefaultScale
+	}
+	s, err := skeleton.NewFromPMX(m)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e := &exporter{
+		model:    m,
+		skeleton: s,
+		scale:    scale,
+		textures: make(map[int]int),
+		b: &builder{doc: &Document{
+			Asset: Asset{Version: "2.0", Generator: "app/lib/mmd/gltf", Copyright: credit(m)},
+		}},
+	}
+	doc := e.b.doc
+
+	nodes := e.bones()
+	if err := e.mesh(o.ReadFile); err != nil {
+		return nil, e.warnings, err
+	}
+	if o.Motion != nil {
+		name := o.AnimationName
+		if name == "" {
+			name = "motion"
+		}
+		if err := e.animation(o.Motion, name); err != nil {
+			return nil, e.warnings, err
+		}
+	}
+
+	doc.Scenes = []Scene{{Name: m.Name, Nodes: append(nodes, e.meshNode)}}
+	doc.Buffers = []Buffer{{ByteLength: len(e.b.bin)}}
+	return &File{Document: *doc, Buffer: e.b.bin}, e.warnings, nil
+}
+
+// credit gets the comment of the model, which has the author and the terms of use.
+func credit(m *pmx.Model) string {
+	if c := strings.TrimSpace(m.Comment); c != "" {
+		return c
+	}
+	return strings.TrimSpace(m.EnglishComment)
+}
+
+// exporter is the state of an export.
+type exporter struct {
+	model    *pmx.Model
+	skeleton *skeleton.Skeleton
+	scale    float64
+	b        *builder
+
+	meshNode int
+	// targets are indices of the morphs which are morph targets.
+	targets []int
+	// textures are indices of glTF textures by index of textures of the model.
+	textures map[int]int
+	// transparent is whether the glTF textures have transparent pixels.
+	transparent []bool
+	warnings    []error
+}
+
+// bones adds nodes of the bones and the skin, and returns the root nodes.
+// Nodes of bones have the same indices as the bones.
+func (e *exporter) bones() []int {
+
+	doc := e.b.doc
+	var roots []int
+	for i, b := range e.skeleton.Bones {
+		p := b.Position.Scale(e.scale)
+		n := Node{
+			Name:        b.Name,
+			Children:    e.skeleton.Children(i),
+			Translation: []float64{p.X, p.Y, p.Z},
+		}
+		if en := e.model.Bones[i].EnglishName; en != "" {
+			n.Extras = &NodeExtra{EnglishName: en}
+		}
+		doc.Nodes = append(doc.Nodes, n)
+		if b.Parent < 0 {
+			roots = append(roots, i)
+		}
+	}
+
+	e.meshNode = len(doc.Nodes)
+	mesh := 0
+	doc.Nodes = append(doc.Nodes, Node{Name: e.model.Name, Mesh: &mesh})
+	if len(e.skeleton.Bones) == 0 {
+		return roots
+	}
+
+	// 初期姿勢のボーンは回転していないため、逆バインド行列は平行移動だけになる
+	rest := e.skeleton.RestWorldPositions()
+	matrices := make([]float32, 0, 16*len(rest))
+	joints := make([]int, len(rest))
+	for i, p := range rest {
+		p = p.Scale(-e.scale)
+		matrices = append(matrices,
+			1, 0, 0, 0,
+			0, 1, 0, 0,
+			0, 0, 1, 0,
+			float32(p.X), float32(p.Y), float32(p.Z), 1,
+		)
+		joints[i] = i
+	}
+	inverse := e.b.floats(matrices, "MAT4", 0, false)
+	doc.Skins = []Skin{{Name: e.model.Name, InverseBindMatrices: &inverse, Joints: joints}}
+	skin := 0
+	doc.Nodes[e.meshNode].Skin = &skin
+
+	return roots
+}
+
+// mesh adds the mesh with a primitive for each material, and the materials.
+func (e *exporter) mesh(read func(name string) ([]byte, error)) error {
+
+	m := e.model
+	n := len(m.Vertices)
+	positions := make([]float32, 0, 3*n)
+	normals := make([]float32, 0, 3*n)
+	uvs := make([]float32, 0, 2*n)
+	for _, v := range m.Vertices {
+		p := vector(v.Position).FlipZ().Scale(e.scale)
+		positions = append(positions, float32(p.X), float32(p.Y), float32(p.Z))
+
+		normal := vector(v.Normal).FlipZ()
+		if normal.Length() < 1e-6 {
+			normal = math3d.NewVector3(0, 1, 0)
+		}
+		normal = normal.Normalize()
+		normals = append(normals, float32(normal.X), float32(normal.Y), float32(normal.Z))
+
+		uvs = append(uvs, v.UV[0], v.UV[1])
+	}
+
+	attributes := map[string]int{
+		"POSITION":   e.b.floats(positions, "VEC3", ArrayBuffer, true),
+		"NORMAL":     e.b.floats(normals, "VEC3", ArrayBuffer, false),
+		"TEXCOORD_0": e.b.floats(uvs, "VEC2", ArrayBuffer, false),
+	}
+	if len(m.Bones) > 0 {
+		joints, weights := e.weights()
+		view := e.b.view(joints, ArrayBuffer)
+		attributes["JOINTS_0"] = e.b.accessor(Accessor{BufferView: &view, ComponentType: UnsignedShort, Count: n, Type: "VEC4"})
+		attributes["WEIGHTS_0"] = e.b.floats(weights, "VEC4", ArrayBuffer, false)
+	}
+
+	targets, names := e.morphTargets()
+
+	mesh := Mesh{Name: m.Name}
+	start := 0
+	for _, material := range m.Materials {
+		end := start + material.IndexCount
+		if end > len(m.Indices) {
+			return fmt.Errorf("gltf: material %q has indices out of range", material.Name)
+		}
+		faces := m.Indices[start:end]
+		start = end
+		if len(faces) < 3 {
+			continue
+		}
+
+		// 左手系から右手系に変換すると裏返るため、頂点の順を入れ替える
+		indices := make([]int, 0, len(faces))
+		for k := 0; k+2 < len(faces); k += 3 {
+			a, b, c := faces[k], faces[k+1], faces[k+2]
+			if a < 0 || a >= n || b < 0 || b >= n || c < 0 || c >= n {
+				return fmt.Errorf("gltf: material %q has vertex index out of range", material.Name)
+			}
+			indices = append(indices, a, c, b)
+		}
+
+		index := e.b.indices(indices, n, ElementArrayBuffer)
+		mat := e.material(material, read)
+		mesh.Primitives = append(mesh.Primitives, Primitive{
+			Attributes: attributes,
+			Indices:    &index,
+			Material:   &mat,
+			Targets:    targets,
+		})
+	}
+	if len(mesh.Primitives) == 0 {
+		return ErrNoFaces
+	}
+
+	if len(targets) > 0 {
+		mesh.Weights = make([]float64, len(targets))
+		mesh.Extras = &MeshExtra{TargetNames: names}
+	}
+	e.b.doc.Meshes = []Mesh{mesh}
+	return nil
+}
+
+// weights gets JOINTS_0 as unsigned shorts and WEIGHTS_0 of the vertices.
+// SDEF is exported as BDEF2 and QDEF as BDEF4, the linear blending glTF has.
+func (e *exporter) weights() ([]byte, []float32) {
+
+	bones := len(e.model.Bones)
+	joints := make([]byte, 0, 8*len(e.model.Vertices))
+	weights := make([]float32, 0, 4*len(e.model.Vertices))
+
+	for _, v := range e.model.Vertices {
+		var w [4]float64
+		switch v.Deform {
+		case pmx.BDEF1:
+			w[0] = 1
+		case pmx.BDEF2, pmx.SDEF:
+			w[0], w[1] = float64(v.Weights[0]), 1-float64(v.Weights[0])
+		default:
+			for k := range w {
+				w[k] = float64(v.Weights[k])
+			}
+		}
+
+		// 同じボーンは一つにまとめ、無効なボーンと負の重みは除く
+		var b [4]int
+		var used int
+		var sum float64
+		for k := 0; k < 4; k++ {
+			bone := v.Bones[k]
+			if bone < 0 || bone >= bones || w[k] <= 0 {
+				continue
+			}
+			merged := false
+			for j := 0; j < used; j++ {
+				if b[j] == bone {
+					w[j] += w[k]
+					merged = true
+				}
+			}
+			if !merged {
+				b[used], w[used] = bone, w[k]
+				used++
+			}
+			sum += w[k]
+		}
+		if used == 0 {
+			b[0], w[0], used, sum = 0, 1, 1, 1
+		}
+
+		var f [4]float32
+		var rest float32
+		for k := 1; k < used; k++ {
+			f[k] = float32(w[k] / sum)
+			rest += f[k]
+		}
+		// 合計が1になるように最初の重みを決める
+		f[0] = 1 - rest
+		for k := 0; k < 4; k++ {
+			j := 0
+			if k < used {
+				j = b[k]
+			}
+			joints = append(joints, byte(j), byte(j>>8))
+		}
+		weights = append(weights, f[:]...)
+	}
+
+	return joints, weights
+}
+
+// morphTargets gets morph targets of vertex morphs and group morphs of them, shared by the primitives.
+func (e *exporter) morphTargets() ([]map[string]int, []string) {
+
+	m := e.model
+	var targets []map[string]int
+	var names []string
+	for i, morph := range m.Morphs {
+		offsets := make(map[int]math3d.Vector3)
+		e.vertexOffsets(i, 1, offsets, make(map[int]bool))
+		if len(offsets) == 0 {
+			continue
+		}
+
+		vertices := make([]int, 0, len(offsets))
+		for v := range offsets {
+			vertices = append(vertices, v)
+		}
+		sort.Ints(vertices)
+
+		values := make([]float32, 0, 3*len(vertices))
+		for _, v := range vertices {
+			d := offsets[v].FlipZ().Scale(e.scale)
+			values = append(values, float32(d.X), float32(d.Y), float32(d.Z))
+		}
+
+		// 動かない頂点の変位は0なので、動く頂点だけを疎に書く
+		indexData, indexType := encodeIndices(vertices, len(m.Vertices))
+		a := Accessor{
+			ComponentType: Float,
+			Count:         len(m.Vertices),
+			Type:          "VEC3",
+			Sparse: &Sparse{
+				Count:   len(vertices),
+				Indices: SparseIndices{BufferView: e.b.view(indexData, 0), ComponentType: indexType},
+				Values:  SparseValues{BufferView: e.b.view(encodeFloats(values), 0)},
+			},
+		}
+		a.Min, a.Max = minMax(values, 3)
+		if len(vertices) < len(m.Vertices) {
+			for k := 0; k < 3; k++ {
+				a.Min[k], a.Max[k] = math.Min(a.Min[k], 0), math.Max(a.Max[k], 0)
+			}
+		}
+
+		targets = append(targets, map[string]int{"POSITION": e.b.accessor(a)})
+		names = append(names, morph.Name)
+		e.targets = append(e.targets, i)
+	}
+	return targets, names
+}
+
+// vertexOffsets adds offsets of vertices of the morph i at the weight, following group morphs.
+func (e *exporter) vertexOffsets(i int, weight float64, offsets map[int]math3d.Vector3, visiting map[int]bool) {
+
+	if i < 0 || i >= len(e.model.Morphs) || visiting[i] {
+		return
+	}
+	visiting[i] = true
+	defer delete(visiting, i)
+
+	morph := e.model.Morphs[i]
+	switch morph.Type {
+	case pmx.VertexMorph:
+		for _, o := range morph.Vertices {
+			if o.Vertex < 0 || o.Vertex >= len(e.model.Vertices) {
+				continue
+			}
+			offsets[o.Vertex] = offsets[o.Vertex].Add(vector(o.Offset).Scale(weight))
+		}
+	case pmx.GroupMorph:
+		for _, g := range morph.Groups {
+			e.vertexOffsets(g.Morph, weight*float64(g.Weight), offsets, visiting)
+		}
+	}
+}
+
+// material adds the material and its texture, and returns the index of the material.
+func (e *exporter) material(material pmx.Material, read func(name string) ([]byte, error)) int {
+
+	metallic := 0.0
+	// Blinn-Phongの鏡面反射係数から粗さを近似する
+	roughness := math.Sqrt(2 / (math.Max(float64(material.SpecularPower), 0) + 2))
+	mat := Material{
+		Name: material.Name,
+		PBRMetallicRoughness: &PBRMetallicRoughness{
+			BaseColorFactor: []float64{
+				clamp01(material.Diffuse[0]), clamp01(material.Diffuse[1]),
+				clamp01(material.Diffuse[2]), clamp01(material.Diffuse[3]),
+			},
+			MetallicFactor:  &metallic,
+			RoughnessFactor: &roughness,
+		},
+		DoubleSided: material.Flags&pmx.MaterialDoubleSided != 0,
+	}
+
+	alpha := material.Diffuse[3] < 1
+	if read != nil && material.Texture >= 0 && material.Texture < len(e.model.Textures) {
+		if t, transparent, ok := e.texture(material.Texture, read); ok {
+			mat.PBRMetallicRoughness.BaseColorTexture = &TextureInfo{Index: t}
+			alpha = alpha || transparent
+		}
+	}
+	if alpha {
+		mat.AlphaMode = "BLEND"
+	}
+
+	doc := e.b.doc
+	doc.Materials = append(doc.Materials, mat)
+	return len(doc.Materials) - 1
+}
+
+// texture adds the texture i of the model once, and returns the index of the glTF texture
+// and whether it has transparent pixels.
+func (e *exporter) texture(i int, read func(name string) ([]byte, error)) (int, bool, bool) {
+
+	doc := e.b.doc
+	if t, ok := e.textures[i]; ok {
+		if t < 0 {
+			return 0, false, false
+		}
+		return t, e.transparent[t], true
+	}
+	e.textures[i] = -1
+
+	name := strings.ReplaceAll(e.model.Textures[i], "\\", "/")
+	data, err := read(name)
+	if err != nil {
+		e.warnings = append(e.warnings, fmt.Errorf("gltf: texture %q: %w", name, err))
+		return 0, false, false
+	}
+	data, mimeType, transparent, err := embedImage(data)
+	if err != nil {
+		e.warnings = append(e.warnings, fmt.Errorf("gltf: texture %q: %w", name, err))
+		return 0, false, false
+	}
+
+	if len(doc.Samplers) == 0 {
+		// 線形補間で、繰り返す
+		doc.Samplers = []Sampler{{MagFilter: 9729, MinFilter: 9987, WrapS: 10497, WrapT: 10497}}
+	}
+	view := e.b.view(data, 0)
+	doc.Images = append(doc.Images, Image{Name: name, BufferView: &view, MimeType: mimeType})
+	sampler, source := 0, len(doc.Images)-1
+	doc.Textures = append(doc.Textures, Texture{Name: name, Sampler: &sampler, Source: &source})
+
+	t := len(doc.Textures) - 1
+	e.textures[i] = t
+	e.transparent = append(e.transparent, transparent)
+	return t, transparent, true
+}
+
+func vector(v [3]float32) math3d.Vector3 {
+	return math3d.NewVector3(float64(v[0]), float64(v[1]), float64(v[2]))
+}
+
+func clamp01(v float32) float64 {
+	return math.Max(0, math.Min(1, float64(v)))
+}
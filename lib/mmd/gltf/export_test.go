@@ -0,0 +1,303 @@
+package gltf
+
+import (
+	"app/lib/mmd/pmx"
+	"app/lib/mmd/vmd"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// bone makes a bone of PMX without tails, 付与 and IK.
+func bone(name string, parent int, position [3]float32) pmx.Bone {
+	return pmx.Bone{
+		Name:          name,
+		Position:      position,
+		Parent:        parent,
+		Flags:         pmx.BoneRotatable | pmx.BoneMovable | pmx.BoneVisible | pmx.BoneOperable,
+		TailBone:      -1,
+		InheritParent: -1,
+		ExternalKey:   -1,
+	}
+}
+
+// vertex makes a vertex facing -Z of MMD, which is the front of models.
+func vertex(x float32, y float32, deform pmx.DeformType, bones [4]int, weights [4]float32) pmx.Vertex {
+	return pmx.Vertex{
+		Position: [3]float32{x, y, 0},
+		Normal:   [3]float32{0, 0, -1},
+		UV:       [2]float32{x / 2, 1 - y/20},
+		Deform:   deform,
+		Bones:    bones,
+		Weights:  weights,
+	}
+}
+
+// model makes a two-bone quad with a texture of each format MMD models use, a vertex morph and a group of it.
+func model() *pmx.Model {
+
+	material := func(name string, texture int) pmx.Material {
+		return pmx.Material{
+			Name:          name,
+			Diffuse:       [4]float32{1, 1, 1, 1},
+			SpecularPower: 5,
+			Flags:         pmx.MaterialDoubleSided,
+			Texture:       texture,
+			SphereTexture: -1,
+			Toon:          -1,
+			IndexCount:    3,
+		}
+	}
+
+	return &pmx.Model{
+		Version: 2,
+		Name:    "テスト",
+		Comment: "作者：テスト\n改変可",
+		Vertices: []pmx.Vertex{
+			vertex(0, 0, pmx.BDEF1, [4]int{0, -1, -1, -1}, [4]float32{1}),
+			vertex(2, 0, pmx.BDEF2, [4]int{0, 1, -1, -1}, [4]float32{0.7}),
+			vertex(2, 20, pmx.BDEF4, [4]int{1, 1, 0, -1}, [4]float32{0.25, 0.25, 0.5, 0}),
+			vertex(0, 20, pmx.SDEF, [4]int{1, 0, -1, -1}, [4]float32{0.6}),
+		},
+		Indices:  []int{0, 1, 2, 0, 2, 3},
+		Textures: []string{"tex\\body.png", "face.bmp"},
+		Materials: []pmx.Material{
+			material("体", 0),
+			material("顔", 1),
+		},
+		Bones: []pmx.Bone{
+			bone("センター", -1, [3]float32{0, 0, 0}),
+			bone("頭", 0, [3]float32{0, 10, 0}),
+		},
+		Morphs: []pmx.Morph{
+			{Name: "あ", Panel: 3, Type: pmx.VertexMorph, Vertices: []pmx.VertexOffset{{Vertex: 3, Offset: [3]float32{0, -1, 0}}}},
+			{Name: "グループ", Panel: 4, Type: pmx.GroupMorph, Groups: []pmx.GroupOffset{{Morph: 0, Weight: 0.5}}},
+		},
+	}
+}
+
+// textures makes the files of the textures of model.
+func textures(t *testing.T) map[string][]byte {
+
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	img.SetNRGBA(1, 1, color.NRGBA{0xff, 0, 0, 0x80})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2x2の24ビットのビットマップ。行は4バイト境界に揃える
+	bmp := make([]byte, 54+8*2)
+	le := binary.LittleEndian
+	copy(bmp, "BM")
+	le.PutUint32(bmp[2:], uint32(len(bmp)))
+	le.PutUint32(bmp[10:], 54)
+	le.PutUint32(bmp[14:], 40)
+	le.PutUint32(bmp[18:], 2)
+	le.PutUint32(bmp[22:], 2)
+	le.PutUint16(bmp[26:], 1)
+	le.PutUint16(bmp[28:], 24)
+	copy(bmp[54:], []byte{0, 0, 0xff, 0, 0xff, 0, 0, 0, 0xff, 0, 0, 0xff, 0xff, 0xff, 0, 0})
+
+	return map[string][]byte{"tex/body.png": buf.Bytes(), "face.bmp": bmp}
+}
+
+func motion() *vmd.Motion {
+
+	m := &vmd.Motion{ModelName: "テスト"}
+	for _, f := range []struct {
+		frame uint32
+		angle float64
+	}{{0, 0}, {15, math.Pi / 4}, {30, -math.Pi / 4}} {
+		b := vmd.BoneFrame{Name: "頭", Frame: f.frame, Rotation: [4]float32{0, 0, float32(math.Sin(f.angle / 2)), float32(math.Cos(f.angle / 2))}}
+		for ch := vmd.ChannelX; ch <= vmd.ChannelRotation; ch++ {
+			b.SetInterpolation(ch, vmd.LinearBezier)
+		}
+		m.Bones = append(m.Bones, b)
+	}
+	m.Bones = append(m.Bones, vmd.BoneFrame{Name: "センター", Frame: 30, Position: [3]float32{0, 1, -2}, Rotation: [4]float32{0, 0, 0, 1}})
+	m.Morphs = []vmd.MorphFrame{{Name: "あ", Frame: 0}, {Name: "あ", Frame: 10, Weight: 1}, {Name: "グループ", Frame: 20, Weight: 1}}
+	return m
+}
+
+func export(t *testing.T) *File {
+
+	files := textures(t)
+	f, warnings, err := Export(model(), Options{
+		ReadFile: func(name string) ([]byte, error) {
+			b, ok := files[name]
+			if !ok {
+				return nil, errors.New("not found")
+			}
+			return b, nil
+		},
+		Motion: motion(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v, want none", warnings)
+	}
+	return f
+}
+
+func TestExport(t *testing.T) {
+
+	f := export(t)
+
+	if issues := f.Validate(); len(issues) != 0 {
+		t.Errorf("Validate() = %v, want no issues", issues)
+	}
+
+	d := f.Document
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"nodes", len(d.Nodes), 3},
+		{"bone names", []string{d.Nodes[0].Name, d.Nodes[1].Name}, []string{"センター", "頭"}},
+		// 0.08メートル毎単位で、頭は10単位上にある
+		{"bone translation", d.Nodes[1].Translation, []float64{0, 10 * DefaultScale, 0}},
+		{"children", d.Nodes[0].Children, []int{1}},
+		{"scene", d.Scenes[0].Nodes, []int{0, 2}},
+		{"joints", d.Skins[0].Joints, []int{0, 1}},
+		{"primitives", len(d.Meshes[0].Primitives), 2},
+		{"target names", d.Meshes[0].Extras.TargetNames, []string{"あ", "グループ"}},
+		{"images", []string{d.Images[0].MimeType, d.Images[1].MimeType}, []string{"image/png", "image/png"}},
+		{"transparent texture", d.Materials[0].AlphaMode, "BLEND"},
+		{"opaque texture", d.Materials[1].AlphaMode, ""},
+		{"copyright", d.Asset.Copyright, "作者：テスト\n改変可"},
+		{"animations", len(d.Animations), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	// 動くボーンの回転と平行移動、モーフの重みのチャンネルがある
+	targets := make(map[ChannelTarget]bool)
+	for _, c := range d.Animations[0].Channels {
+		targets[c.Target] = true
+	}
+	for _, want := range []ChannelTarget{{Node: 1, Path: "rotation"}, {Node: 0, Path: "translation"}, {Node: 2, Path: "weights"}} {
+		if !targets[want] {
+			t.Errorf("animation has no channel of %+v", want)
+		}
+	}
+}
+
+func TestGLB(t *testing.T) {
+
+	f := export(t)
+
+	var buf bytes.Buffer
+	if err := f.WriteGLB(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len()%4 != 0 {
+		t.Errorf("GLB is %d bytes, want a multiple of 4", buf.Len())
+	}
+
+	got, err := ReadGLB(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Document, f.Document) {
+		t.Errorf("ReadGLB() document = %+v, want %+v", got.Document, f.Document)
+	}
+	// バイナリチャンクは4バイト境界まで0で埋められる
+	if !bytes.HasPrefix(got.Buffer, f.Buffer) || len(got.Buffer)-len(f.Buffer) > 3 {
+		t.Errorf("ReadGLB() buffer is %d bytes, want %d with padding", len(got.Buffer), len(f.Buffer))
+	}
+	if issues := got.Validate(); len(issues) != 0 {
+		t.Errorf("Validate() after ReadGLB = %v, want no issues", issues)
+	}
+}
+
+func TestReadGLBInvalid(t *testing.T) {
+
+	var buf bytes.Buffer
+	if err := export(t).WriteGLB(&buf); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not GLB", []byte("{\"asset\":{\"version\":\"2.0\"}}    ")},
+		{"truncated", b[:len(b)-4]},
+		{"version 1", append(append([]byte{}, b[:4]...), append([]byte{1, 0, 0, 0}, b[8:]...)...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadGLB(bytes.NewReader(tt.data)); !errors.Is(err, ErrInvalidGLB) {
+				t.Errorf("ReadGLB() error = %v, want %v", err, ErrInvalidGLB)
+			}
+		})
+	}
+}
+
+func TestWeights(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		deform  pmx.DeformType
+		bones   [4]int
+		weights [4]float32
+		joints  [4]uint16
+		want    [4]float32
+	}{
+		{"BDEF1", pmx.BDEF1, [4]int{1, -1, -1, -1}, [4]float32{1}, [4]uint16{1, 0, 0, 0}, [4]float32{1, 0, 0, 0}},
+		{"BDEF2", pmx.BDEF2, [4]int{0, 1, -1, -1}, [4]float32{0.25}, [4]uint16{0, 1, 0, 0}, [4]float32{0.25, 0.75, 0, 0}},
+		{"SDEF as BDEF2", pmx.SDEF, [4]int{1, 0, -1, -1}, [4]float32{0.6}, [4]uint16{1, 0, 0, 0}, [4]float32{0.6, 0.4, 0, 0}},
+		// 同じボーンの重みは合わせる
+		{"BDEF4 duplicate bones", pmx.BDEF4, [4]int{1, 1, 0, -1}, [4]float32{0.25, 0.25, 0.5, 0}, [4]uint16{1, 0, 0, 0}, [4]float32{0.5, 0.5, 0, 0}},
+		{"BDEF4 all the same bone", pmx.BDEF4, [4]int{1, 1, 1, 1}, [4]float32{0.25, 0.25, 0.25, 0.25}, [4]uint16{1, 0, 0, 0}, [4]float32{1, 0, 0, 0}},
+		{"BDEF4 duplicates apart", pmx.BDEF4, [4]int{0, 1, 0, 1}, [4]float32{0.1, 0.2, 0.3, 0.4}, [4]uint16{0, 1, 0, 0}, [4]float32{0.4, 0.6, 0, 0}},
+		{"BDEF4 normalized", pmx.BDEF4, [4]int{0, 1, 2, 3}, [4]float32{0.5, 0.5, 0.5, 0.5}, [4]uint16{0, 1, 2, 3}, [4]float32{0.25, 0.25, 0.25, 0.25}},
+		{"QDEF as BDEF4", pmx.QDEF, [4]int{3, 2, -1, -1}, [4]float32{0.5, 0.5}, [4]uint16{3, 2, 0, 0}, [4]float32{0.5, 0.5, 0, 0}},
+		{"invalid bones", pmx.BDEF4, [4]int{-1, 9, 2, -1}, [4]float32{0.5, 0.3, 0.2, 0}, [4]uint16{2, 0, 0, 0}, [4]float32{1, 0, 0, 0}},
+		{"no valid bones", pmx.BDEF2, [4]int{9, -1, -1, -1}, [4]float32{1}, [4]uint16{0, 0, 0, 0}, [4]float32{1, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &pmx.Model{
+				Bones:    []pmx.Bone{bone("a", -1, [3]float32{}), bone("b", 0, [3]float32{}), bone("c", 0, [3]float32{}), bone("d", 0, [3]float32{})},
+				Vertices: []pmx.Vertex{{Deform: tt.deform, Bones: tt.bones, Weights: tt.weights}},
+			}
+			joints, weights := (&exporter{model: m}).weights()
+
+			var gotJoints [4]uint16
+			for k := range gotJoints {
+				gotJoints[k] = binary.LittleEndian.Uint16(joints[k*2:])
+			}
+			if gotJoints != tt.joints {
+				t.Errorf("joints = %v, want %v", gotJoints, tt.joints)
+			}
+			for k := range tt.want {
+				if math.Abs(float64(weights[k]-tt.want[k])) > 1e-6 {
+					t.Errorf("weights = %v, want %v", weights, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
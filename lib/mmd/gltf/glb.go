@@ -0,0 +1,100 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+// GLB chunk types and the magic at the beginning.
+const (
+	glbMagic     = 0x46546c67 // "glTF"
+	glbVersion   = 2
+	chunkJSON    = 0x4e4f534a // "JSON"
+	chunkBinary  = 0x004e4942 // "BIN\x00"
+	glbHeaderLen = 12
+)
+
+// ErrInvalidGLB is returned when the data is not a GLB file.
+var ErrInvalidGLB = errors.New("gltf: invalid GLB")
+
+// WriteGLB writes the file as binary glTF, with the JSON chunk and the buffer in the binary chunk.
+func (f *File) WriteGLB(w io.Writer) error {
+
+	doc, err := json.Marshal(f.Document)
+	if err != nil {
+		return err
+	}
+	// チャンクは4バイト境界に揃え、JSONは空白、バイナリは0で埋める
+	for len(doc)%4 != 0 {
+		doc = append(doc, ' ')
+	}
+	bin := f.Buffer
+	for len(bin)%4 != 0 {
+		bin = append(bin, 0)
+	}
+
+	length := glbHeaderLen + 8 + len(doc)
+	if len(bin) > 0 {
+		length += 8 + len(bin)
+	}
+
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	binary.Write(&buf, le, [3]uint32{glbMagic, glbVersion, uint32(length)})
+	binary.Write(&buf, le, [2]uint32{uint32(len(doc)), chunkJSON})
+	buf.Write(doc)
+	if len(bin) > 0 {
+		binary.Write(&buf, le, [2]uint32{uint32(len(bin)), chunkBinary})
+		buf.Write(bin)
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// ReadGLB reads a binary glTF file.
+func ReadGLB(r io.Reader) (*File, error) {
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	le := binary.LittleEndian
+	if len(b) < glbHeaderLen+8 || le.Uint32(b) != glbMagic || le.Uint32(b[4:]) != glbVersion {
+		return nil, ErrInvalidGLB
+	}
+	if n := int(le.Uint32(b[8:])); n != len(b) {
+		return nil, ErrInvalidGLB
+	}
+
+	f := &File{}
+	for offset, i := glbHeaderLen, 0; offset < len(b); i++ {
+		if offset+8 > len(b) {
+			return nil, ErrInvalidGLB
+		}
+		n, typ := int(le.Uint32(b[offset:])), le.Uint32(b[offset+4:])
+		offset += 8
+		if n < 0 || offset+n > len(b) || n%4 != 0 {
+			return nil, ErrInvalidGLB
+		}
+		chunk := b[offset : offset+n]
+		offset += n
+
+		switch {
+		case i == 0 && typ == chunkJSON:
+			if err := json.Unmarshal(chunk, &f.Document); err != nil {
+				return nil, err
+			}
+		case i == 1 && typ == chunkBinary:
+			f.Buffer = chunk
+		case i == 0:
+			// 最初のチャンクはJSONでなければならない
+			return nil, ErrInvalidGLB
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,250 @@
+// Package gltf exports MMD models to glTF 2.0 binary files (GLB) in Go, without three.js.
+//
+// Models are converted to the right-handed coordinate system of glTF in meters, facing +Z.
+// The exporter only uses core glTF 2.0 without extensions, so that any engine reads the files.
+// It runs anywhere Go runs, such as servers and the command line.
+package gltf
+
+// Component types of accessors.
+const (
+	UnsignedByte  = 5121
+	UnsignedShort = 5123
+	UnsignedInt   = 5125
+	Float         = 5126
+)
+
+// Targets of buffer views.
+const (
+	ArrayBuffer        = 34962
+	ElementArrayBuffer = 34963
+)
+
+// Document is the JSON part of a glTF file. Only the properties the exporter writes are defined.
+type Document struct {
+	Asset       Asset        `json:"asset"`
+	Scene       int          `json:"scene"`
+	Scenes      []Scene      `json:"scenes"`
+	Nodes       []Node       `json:"nodes,omitempty"`
+	Meshes      []Mesh       `json:"meshes,omitempty"`
+	Skins       []Skin       `json:"skins,omitempty"`
+	Materials   []Material   `json:"materials,omitempty"`
+	Textures    []Texture    `json:"textures,omitempty"`
+	Images      []Image      `json:"images,omitempty"`
+	Samplers    []Sampler    `json:"samplers,omitempty"`
+	Animations  []Animation  `json:"animations,omitempty"`
+	Accessors   []Accessor   `json:"accessors,omitempty"`
+	BufferViews []BufferView `json:"bufferViews,omitempty"`
+	Buffers     []Buffer     `json:"buffers,omitempty"`
+}
+
+// Asset is metadata of the file.
+type Asset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator,omitempty"`
+	Copyright string `json:"copyright,omitempty"`
+}
+
+// Scene is the root nodes of a scene.
+type Scene struct {
+	Name  string `json:"name,omitempty"`
+	Nodes []int  `json:"nodes"`
+}
+
+// Node is a node of the scene graph. Bones and the mesh are nodes.
+type Node struct {
+	Name        string     `json:"name,omitempty"`
+	Children    []int      `json:"children,omitempty"`
+	Translation []float64  `json:"translation,omitempty"`
+	Rotation    []float64  `json:"rotation,omitempty"`
+	Mesh        *int       `json:"mesh,omitempty"`
+	Skin        *int       `json:"skin,omitempty"`
+	Weights     []float64  `json:"weights,omitempty"`
+	Extras      *NodeExtra `json:"extras,omitempty"`
+}
+
+// NodeExtra is application specific data of a node.
+type NodeExtra struct {
+	// EnglishName is the English name of the bone.
+	EnglishName string `json:"englishName,omitempty"`
+}
+
+// Mesh is a mesh with a primitive for each material.
+type Mesh struct {
+	Name       string      `json:"name,omitempty"`
+	Primitives []Primitive `json:"primitives"`
+	Weights    []float64   `json:"weights,omitempty"`
+	Extras     *MeshExtra  `json:"extras,omitempty"`
+}
+
+// MeshExtra is application specific data of a mesh.
+type MeshExtra struct {
+	// TargetNames is names of the morph targets, which most engines read.
+	TargetNames []string `json:"targetNames,omitempty"`
+}
+
+// Primitive is triangles drawn with a material.
+type Primitive struct {
+	Attributes map[string]int   `json:"attributes"`
+	Indices    *int             `json:"indices,omitempty"`
+	Material   *int             `json:"material,omitempty"`
+	Targets    []map[string]int `json:"targets,omitempty"`
+}
+
+// Skin is joints which deform a mesh.
+type Skin struct {
+	Name                string `json:"name,omitempty"`
+	InverseBindMatrices *int   `json:"inverseBindMatrices,omitempty"`
+	Skeleton            *int   `json:"skeleton,omitempty"`
+	Joints              []int  `json:"joints"`
+}
+
+// Material is a metallic-roughness material.
+type Material struct {
+	Name                 string                `json:"name,omitempty"`
+	PBRMetallicRoughness *PBRMetallicRoughness `json:"pbrMetallicRoughness,omitempty"`
+	AlphaMode            string                `json:"alphaMode,omitempty"`
+	AlphaCutoff          *float64              `json:"alphaCutoff,omitempty"`
+	DoubleSided          bool                  `json:"doubleSided,omitempty"`
+}
+
+// PBRMetallicRoughness is parameters of a metallic-roughness material.
+// MetallicFactor is a pointer since the default is 1, not 0.
+type PBRMetallicRoughness struct {
+	BaseColorFactor  []float64    `json:"baseColorFactor,omitempty"`
+	BaseColorTexture *TextureInfo `json:"baseColorTexture,omitempty"`
+	MetallicFactor   *float64     `json:"metallicFactor,omitempty"`
+	RoughnessFactor  *float64     `json:"roughnessFactor,omitempty"`
+}
+
+// TextureInfo refers to a texture.
+type TextureInfo struct {
+	Index int `json:"index"`
+}
+
+// Texture is an image with a sampler.
+type Texture struct {
+	Name    string `json:"name,omitempty"`
+	Sampler *int   `json:"sampler,omitempty"`
+	Source  *int   `json:"source,omitempty"`
+}
+
+// Image is an image embedded in a buffer view.
+type Image struct {
+	Name       string `json:"name,omitempty"`
+	BufferView *int   `json:"bufferView,omitempty"`
+	MimeType   string `json:"mimeType,omitempty"`
+}
+
+// Sampler is filtering and wrapping of textures.
+type Sampler struct {
+	MagFilter int `json:"magFilter,omitempty"`
+	MinFilter int `json:"minFilter,omitempty"`
+	WrapS     int `json:"wrapS,omitempty"`
+	WrapT     int `json:"wrapT,omitempty"`
+}
+
+// Animation is channels which animate nodes.
+type Animation struct {
+	Name     string             `json:"name,omitempty"`
+	Channels []Channel          `json:"channels"`
+	Samplers []AnimationSampler `json:"samplers"`
+}
+
+// Channel animates a property of a node by a sampler.
+type Channel struct {
+	Sampler int           `json:"sampler"`
+	Target  ChannelTarget `json:"target"`
+}
+
+// ChannelTarget is the property of the node: "translation", "rotation" or "weights".
+type ChannelTarget struct {
+	Node int    `json:"node"`
+	Path string `json:"path"`
+}
+
+// AnimationSampler is keyframes of times in Input and values in Output.
+type AnimationSampler struct {
+	Input         int    `json:"input"`
+	Interpolation string `json:"interpolation,omitempty"`
+	Output        int    `json:"output"`
+}
+
+// Accessor is typed elements in a buffer view.
+type Accessor struct {
+	BufferView    *int      `json:"bufferView,omitempty"`
+	ByteOffset    int       `json:"byteOffset,omitempty"`
+	ComponentType int       `json:"componentType"`
+	Normalized    bool      `json:"normalized,omitempty"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float64 `json:"min,omitempty"`
+	Max           []float64 `json:"max,omitempty"`
+	Sparse        *Sparse   `json:"sparse,omitempty"`
+}
+
+// Sparse is elements of an accessor which differ from zero or from its buffer view.
+type Sparse struct {
+	Count   int           `json:"count"`
+	Indices SparseIndices `json:"indices"`
+	Values  SparseValues  `json:"values"`
+}
+
+// SparseIndices is indices of the elements in a buffer view.
+type SparseIndices struct {
+	BufferView    int `json:"bufferView"`
+	ByteOffset    int `json:"byteOffset,omitempty"`
+	ComponentType int `json:"componentType"`
+}
+
+// SparseValues is values of the elements in a buffer view.
+type SparseValues struct {
+	BufferView int `json:"bufferView"`
+	ByteOffset int `json:"byteOffset,omitempty"`
+}
+
+// BufferView is a range of a buffer.
+type BufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset,omitempty"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride,omitempty"`
+	Target     int `json:"target,omitempty"`
+}
+
+// Buffer is binary data. The buffer of a GLB file has no URI.
+type Buffer struct {
+	ByteLength int    `json:"byteLength"`
+	URI        string `json:"uri,omitempty"`
+}
+
+// components gets the number of components of the accessor type.
+func components(t string) int {
+	switch t {
+	case "SCALAR":
+		return 1
+	case "VEC2":
+		return 2
+	case "VEC3":
+		return 3
+	case "VEC4", "MAT2":
+		return 4
+	case "MAT3":
+		return 9
+	case "MAT4":
+		return 16
+	}
+	return 0
+}
+
+// componentSize gets the size in bytes of the component type.
+func componentSize(t int) int {
+	switch t {
+	case UnsignedByte, 5120:
+		return 1
+	case UnsignedShort, 5122:
+		return 2
+	case UnsignedInt, Float:
+		return 4
+	}
+	return 0
+}
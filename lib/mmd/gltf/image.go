@@ -0,0 +1,296 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// ErrUnsupportedImage is returned for textures in formats which can not be converted, such as DDS.
+var ErrUnsupportedImage = errors.New("gltf: unsupported image format")
+
+// embedImage converts texture data to PNG or JPEG, the formats of core glTF.
+// It also gets whether the image has transparent pixels.
+func embedImage(data []byte) (out []byte, mimeType string, alpha bool, err error) {
+
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, "", false, err
+		}
+		return data, "image/png", transparent(img), nil
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8, 0xff}):
+		if _, err := jpeg.DecodeConfig(bytes.NewReader(data)); err != nil {
+			return nil, "", false, err
+		}
+		return data, "image/jpeg", false, nil
+	}
+
+	var img image.Image
+	switch {
+	case bytes.HasPrefix(data, []byte("BM")):
+		img, err = decodeBMP(data)
+	case bytes.HasPrefix(data, []byte("GIF8")):
+		img, _, err = image.Decode(bytes.NewReader(data))
+	case bytes.HasPrefix(data, []byte("DDS ")):
+		err = ErrUnsupportedImage
+	default:
+		// TGAには識別子がないため、最後に試す
+		img, err = decodeTGA(data)
+	}
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", false, err
+	}
+	return buf.Bytes(), "image/png", transparent(img), nil
+}
+
+// transparent gets whether the image has pixels which are not opaque.
+func transparent(img image.Image) bool {
+
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return !o.Opaque()
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a < 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeBMP reads uncompressed Windows bitmaps of 1, 4, 8, 24 and 32 bits per pixel,
+// which are common textures of MMD models.
+func decodeBMP(data []byte) (image.Image, error) {
+
+	if len(data) < 26 {
+		return nil, ErrUnsupportedImage
+	}
+	le := binary.LittleEndian
+	pixels := int(le.Uint32(data[10:]))
+	header := int(le.Uint32(data[14:]))
+
+	var width, height, bits, compression int
+	switch {
+	case header == 12:
+		width, height = int(int16(le.Uint16(data[18:]))), int(int16(le.Uint16(data[20:])))
+		bits = int(le.Uint16(data[24:]))
+	case header >= 40 && len(data) >= 14+40:
+		width, height = int(int32(le.Uint32(data[18:]))), int(int32(le.Uint32(data[22:])))
+		bits = int(le.Uint16(data[28:]))
+		compression = int(le.Uint32(data[30:]))
+	default:
+		return nil, ErrUnsupportedImage
+	}
+
+	// 高さが負の場合は上から下に並ぶ
+	topDown := height < 0
+	if topDown {
+		height = -height
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("gltf: invalid bitmap size %dx%d", width, height)
+	}
+
+	// 32ビットのマスクはBI_BITFIELDSの場合のみ読む
+	masks := [4]uint32{0xff0000, 0xff00, 0xff, 0xff000000}
+	switch {
+	case compression == 0:
+	case compression == 3 && bits == 32 && len(data) >= 14+40+12:
+		masks[0], masks[1], masks[2] = le.Uint32(data[54:]), le.Uint32(data[58:]), le.Uint32(data[62:])
+		masks[3] = 0
+		if header >= 56 {
+			masks[3] = le.Uint32(data[66:])
+		}
+	default:
+		return nil, fmt.Errorf("%w: compressed bitmap", ErrUnsupportedImage)
+	}
+
+	var palette color.Palette
+	if bits <= 8 {
+		entry := 4
+		if header == 12 {
+			entry = 3
+		}
+		n := 1 << uint(bits)
+		if header >= 40 {
+			if used := int(le.Uint32(data[46:])); used > 0 && used < n {
+				n = used
+			}
+		}
+		offset := 14 + header
+		if offset+n*entry > len(data) {
+			return nil, fmt.Errorf("gltf: too short bitmap palette")
+		}
+		for i := 0; i < n; i++ {
+			p := data[offset+i*entry:]
+			palette = append(palette, color.RGBA{p[2], p[1], p[0], 0xff})
+		}
+	}
+
+	stride := (width*bits + 31) / 32 * 4
+	if bits != 1 && bits != 4 && bits != 8 && bits != 24 && bits != 32 {
+		return nil, fmt.Errorf("%w: %d bits bitmap", ErrUnsupportedImage, bits)
+	}
+	if pixels < 0 || pixels+stride*height > len(data) {
+		return nil, fmt.Errorf("gltf: too short bitmap")
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	alpha := false
+	for y := 0; y < height; y++ {
+		row := data[pixels+stride*y:]
+		dy := height - 1 - y
+		if topDown {
+			dy = y
+		}
+		for x := 0; x < width; x++ {
+			var c color.NRGBA
+			switch bits {
+			case 1, 4, 8:
+				bit := x * bits
+				i := int(row[bit/8]>>uint(8-bits-bit%8)) & (1<<uint(bits) - 1)
+				if i < len(palette) {
+					r, g, b, _ := palette[i].RGBA()
+					c = color.NRGBA{byte(r >> 8), byte(g >> 8), byte(b >> 8), 0xff}
+				}
+			case 24:
+				p := row[x*3:]
+				c = color.NRGBA{p[2], p[1], p[0], 0xff}
+			case 32:
+				v := le.Uint32(row[x*4:])
+				c = color.NRGBA{mask(v, masks[0]), mask(v, masks[1]), mask(v, masks[2]), mask(v, masks[3])}
+				alpha = alpha || c.A != 0
+			}
+			img.SetNRGBA(x, dy, c)
+		}
+	}
+
+	// アルファが全て0の32ビット画像は、アルファを使っていないとみなす
+	if bits == 32 && (!alpha || masks[3] == 0) {
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 0xff
+		}
+	}
+	return img, nil
+}
+
+// mask extracts the 8 bits value of the mask from v.
+func mask(v uint32, m uint32) byte {
+	if m == 0 {
+		return 0
+	}
+	shift := uint(0)
+	for m&1 == 0 {
+		m >>= 1
+		shift++
+	}
+	return byte(uint64(v>>shift&m) * 255 / uint64(m))
+}
+
+// decodeTGA reads Truevision TGA images of true colors or grayscale, with or without RLE compression.
+func decodeTGA(data []byte) (image.Image, error) {
+
+	if len(data) < 18 {
+		return nil, ErrUnsupportedImage
+	}
+	le := binary.LittleEndian
+	id, mapType, imageType := int(data[0]), data[1], data[2]
+	mapLength, mapBits := int(le.Uint16(data[5:])), int(data[7])
+	width, height := int(le.Uint16(data[12:])), int(le.Uint16(data[14:]))
+	bits, descriptor := int(data[16]), data[17]
+
+	gray := imageType == 3 || imageType == 11
+	rle := imageType == 10 || imageType == 11
+	switch {
+	case mapType > 1, imageType != 2 && imageType != 3 && imageType != 10 && imageType != 11:
+		return nil, ErrUnsupportedImage
+	case gray && bits != 8, !gray && bits != 24 && bits != 32:
+		return nil, fmt.Errorf("%w: %d bits TGA", ErrUnsupportedImage, bits)
+	case width == 0 || height == 0:
+		return nil, fmt.Errorf("gltf: invalid TGA size %dx%d", width, height)
+	}
+
+	offset := 18 + id
+	if mapType == 1 {
+		offset += mapLength * ((mapBits + 7) / 8)
+	}
+	size := bits / 8
+	n := width * height
+	pixels := make([]byte, 0, n*size)
+
+	for len(pixels) < n*size {
+		if offset >= len(data) {
+			return nil, fmt.Errorf("gltf: too short TGA")
+		}
+		if !rle {
+			end := offset + n*size
+			if end > len(data) {
+				return nil, fmt.Errorf("gltf: too short TGA")
+			}
+			pixels = append(pixels, data[offset:end]...)
+			break
+		}
+
+		// 上位ビットが立っていれば同じ画素の繰り返し
+		h := int(data[offset])
+		offset++
+		count := h&0x7f + 1
+		if h&0x80 != 0 {
+			if offset+size > len(data) {
+				return nil, fmt.Errorf("gltf: too short TGA")
+			}
+			for i := 0; i < count; i++ {
+				pixels = append(pixels, data[offset:offset+size]...)
+			}
+			offset += size
+		} else {
+			if offset+count*size > len(data) {
+				return nil, fmt.Errorf("gltf: too short TGA")
+			}
+			pixels = append(pixels, data[offset:offset+count*size]...)
+			offset += count * size
+		}
+	}
+	pixels = pixels[:n*size]
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	alphaBits := descriptor & 0x0f
+	for i := 0; i < n; i++ {
+		x, y := i%width, i/width
+		// 既定では左下から並ぶ
+		if descriptor&0x20 == 0 {
+			y = height - 1 - y
+		}
+		if descriptor&0x10 != 0 {
+			x = width - 1 - x
+		}
+		p := pixels[i*size:]
+		c := color.NRGBA{A: 0xff}
+		switch {
+		case gray:
+			c.R, c.G, c.B = p[0], p[0], p[0]
+		default:
+			c.R, c.G, c.B = p[2], p[1], p[0]
+			if size == 4 && alphaBits > 0 {
+				c.A = p[3]
+			}
+		}
+		img.SetNRGBA(x, y, c)
+	}
+	return img, nil
+}
@@ -0,0 +1,831 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Issue is a violation of glTF 2.0 found by Validate, with the code the Khronos glTF validator reports for it.
+type Issue struct {
+	Code string
+	// Pointer is the JSON pointer of the object, such as "/accessors/3".
+	Pointer string
+	Message string
+}
+
+func (i *Issue) Error() string {
+	return fmt.Sprintf("gltf: %s at %s: %s", i.Code, i.Pointer, i.Message)
+}
+
+// unitThreshold is the error of the length of unit vectors and quaternions allowed.
+const unitThreshold = 5e-5
+
+// Validate checks the file by rules of the Khronos glTF validator which can be checked offline:
+// references and ranges of indices, layouts of buffer views and accessors, accessor bounds,
+// vertex attributes (unit normals, normalized weights, joints of the skin), morph targets,
+// embedded images, and animation samplers. It returns nil if no issues are found.
+// Buffers with URIs are not read, so accessors in them are only checked for their layouts.
+func (f *File) Validate() []*Issue {
+
+	v := &validator{f: f, d: &f.Document, data: make(map[int][]float64)}
+	v.asset()
+	v.buffers()
+	v.accessors()
+	v.nodes()
+	v.meshes()
+	v.skins()
+	v.materials()
+	v.textures()
+	v.animations()
+	return v.issues
+}
+
+type validator struct {
+	f      *File
+	d      *Document
+	issues []*Issue
+	// data is values of accessors read, by index.
+	data map[int][]float64
+}
+
+func (v *validator) report(code string, pointer string, format string, args ...interface{}) {
+	v.issues = append(v.issues, &Issue{Code: code, Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+// index checks that i refers to one of n objects.
+func (v *validator) index(pointer string, i int, n int) bool {
+	if i < 0 || i >= n {
+		v.report("UNRESOLVED_REFERENCE", pointer, "unresolved reference: %d", i)
+		return false
+	}
+	return true
+}
+
+func (v *validator) asset() {
+	if v.d.Asset.Version != "2.0" {
+		v.report("UNKNOWN_ASSET_MAJOR_VERSION", "/asset/version", "unknown glTF version %q", v.d.Asset.Version)
+	}
+	if len(v.d.Scenes) > 0 {
+		v.index("/scene", v.d.Scene, len(v.d.Scenes))
+	}
+}
+
+// bufferLength gets the length of the buffer which can be read, or -1 for external buffers.
+func (v *validator) bufferLength(i int) int {
+	if i == 0 && len(v.d.Buffers) > 0 && v.d.Buffers[0].URI == "" {
+		return len(v.f.Buffer)
+	}
+	return -1
+}
+
+func (v *validator) buffers() {
+
+	for i, b := range v.d.Buffers {
+		pointer := fmt.Sprintf("/buffers/%d", i)
+		switch {
+		case b.URI == "" && i > 0:
+			v.report("BUFFER_MISSING_GLB_DATA", pointer, "only the first buffer can be in the GLB binary chunk")
+		case b.URI == "" && b.ByteLength > len(v.f.Buffer):
+			v.report("BUFFER_GLB_CHUNK_TOO_BIG", pointer, "byteLength %d exceeds the binary chunk of %d bytes", b.ByteLength, len(v.f.Buffer))
+		case b.URI == "" && len(v.f.Buffer)-b.ByteLength > 3:
+			v.report("BUFFER_GLB_CHUNK_TOO_BIG", pointer, "binary chunk of %d bytes is larger than byteLength %d with padding", len(v.f.Buffer), b.ByteLength)
+		}
+	}
+
+	for i, bv := range v.d.BufferViews {
+		pointer := fmt.Sprintf("/bufferViews/%d", i)
+		if !v.index(pointer+"/buffer", bv.Buffer, len(v.d.Buffers)) {
+			continue
+		}
+		if bv.ByteOffset < 0 || bv.ByteLength < 1 || bv.ByteOffset+bv.ByteLength > v.d.Buffers[bv.Buffer].ByteLength {
+			v.report("BUFFER_VIEW_TOO_LONG", pointer, "range %d+%d is out of the buffer", bv.ByteOffset, bv.ByteLength)
+		}
+		if bv.ByteStride != 0 && (bv.ByteStride < 4 || bv.ByteStride > 252 || bv.ByteStride%4 != 0) {
+			v.report("VALUE_NOT_IN_RANGE", pointer+"/byteStride", "invalid byteStride %d", bv.ByteStride)
+		}
+		if bv.Target != 0 && bv.Target != ArrayBuffer && bv.Target != ElementArrayBuffer {
+			v.report("VALUE_NOT_IN_LIST", pointer+"/target", "invalid target %d", bv.Target)
+		}
+	}
+}
+
+func (v *validator) accessors() {
+
+	for i, a := range v.d.Accessors {
+		pointer := fmt.Sprintf("/accessors/%d", i)
+		n, size := components(a.Type), componentSize(a.ComponentType)
+		switch {
+		case n == 0:
+			v.report("VALUE_NOT_IN_LIST", pointer+"/type", "invalid type %q", a.Type)
+			continue
+		case size == 0:
+			v.report("VALUE_NOT_IN_LIST", pointer+"/componentType", "invalid componentType %d", a.ComponentType)
+			continue
+		case a.Count < 1:
+			v.report("VALUE_NOT_IN_RANGE", pointer+"/count", "count must be positive")
+			continue
+		case a.Normalized && (a.ComponentType == Float || a.ComponentType == UnsignedInt):
+			v.report("ACCESSOR_NORMALIZED_INVALID", pointer, "only byte and short components can be normalized")
+		}
+		if (a.Min != nil && len(a.Min) != n) || (a.Max != nil && len(a.Max) != n) {
+			v.report("ACCESSOR_MIN_MAX_INVALID", pointer, "min and max must have %d components", n)
+			continue
+		}
+
+		if a.BufferView != nil {
+			if !v.index(pointer+"/bufferView", *a.BufferView, len(v.d.BufferViews)) {
+				continue
+			}
+			bv := v.d.BufferViews[*a.BufferView]
+			stride := bv.ByteStride
+			if stride == 0 {
+				stride = n * size
+			}
+			if (a.ByteOffset+bv.ByteOffset)%size != 0 {
+				v.report("ACCESSOR_TOTAL_OFFSET_ALIGNMENT", pointer, "offset %d is not a multiple of the component size %d", a.ByteOffset+bv.ByteOffset, size)
+			}
+			if a.ByteOffset+stride*(a.Count-1)+n*size > bv.ByteLength {
+				v.report("ACCESSOR_TOO_LONG", pointer, "%d elements do not fit in the buffer view", a.Count)
+				continue
+			}
+		}
+		if a.Sparse != nil && !v.sparse(pointer+"/sparse", a) {
+			continue
+		}
+
+		values, ok := v.read(i)
+		if !ok || a.Min == nil && a.Max == nil {
+			continue
+		}
+		min, max := minMaxFloat64(values, n)
+		for k := 0; k < n; k++ {
+			if a.Min != nil && float32(a.Min[k]) != float32(min[k]) {
+				v.report("ACCESSOR_MIN_MISMATCH", pointer+"/min", "declared minimum %v of component %d does not match the actual %v", a.Min[k], k, min[k])
+			}
+			if a.Max != nil && float32(a.Max[k]) != float32(max[k]) {
+				v.report("ACCESSOR_MAX_MISMATCH", pointer+"/max", "declared maximum %v of component %d does not match the actual %v", a.Max[k], k, max[k])
+			}
+		}
+	}
+}
+
+// sparse checks the sparse storage of the accessor.
+func (v *validator) sparse(pointer string, a Accessor) bool {
+
+	s := a.Sparse
+	if s.Count < 1 || s.Count > a.Count {
+		v.report("VALUE_NOT_IN_RANGE", pointer+"/count", "invalid sparse count %d", s.Count)
+		return false
+	}
+	if s.Indices.ComponentType != UnsignedByte && s.Indices.ComponentType != UnsignedShort && s.Indices.ComponentType != UnsignedInt {
+		v.report("VALUE_NOT_IN_LIST", pointer+"/indices/componentType", "invalid componentType %d", s.Indices.ComponentType)
+		return false
+	}
+
+	views := []struct {
+		pointer string
+		view    int
+		offset  int
+		length  int
+	}{
+		{pointer + "/indices", s.Indices.BufferView, s.Indices.ByteOffset, s.Count * componentSize(s.Indices.ComponentType)},
+		{pointer + "/values", s.Values.BufferView, s.Values.ByteOffset, s.Count * components(a.Type) * componentSize(a.ComponentType)},
+	}
+	for _, w := range views {
+		if !v.index(w.pointer+"/bufferView", w.view, len(v.d.BufferViews)) {
+			return false
+		}
+		bv := v.d.BufferViews[w.view]
+		if bv.ByteStride != 0 || bv.Target != 0 {
+			v.report("BUFFER_VIEW_INVALID_BYTE_STRIDE", w.pointer, "buffer views of sparse accessors can not have byteStride or target")
+		}
+		if w.offset+w.length > bv.ByteLength {
+			v.report("ACCESSOR_SPARSE_INDICES_TOO_LONG", w.pointer, "sparse data does not fit in the buffer view")
+			return false
+		}
+	}
+
+	indices, ok := v.raw(s.Indices.BufferView, s.Indices.ByteOffset, 0, s.Indices.ComponentType, 1, s.Count, false)
+	if !ok {
+		return true
+	}
+	for k, index := range indices {
+		switch {
+		case index >= float64(a.Count):
+			v.report("ACCESSOR_SPARSE_INDEX_OOB", pointer+"/indices", "index %v at %d is out of %d elements", index, k, a.Count)
+			return false
+		case k > 0 && index <= indices[k-1]:
+			v.report("ACCESSOR_SPARSE_INDICES_NON_INCREASING", pointer+"/indices", "indices are not strictly increasing at %d", k)
+			return false
+		}
+	}
+	return true
+}
+
+// read gets the values of the accessor i with sparse substitution, or false if they are not in the GLB buffer.
+func (v *validator) read(i int) ([]float64, bool) {
+
+	if values, ok := v.data[i]; ok {
+		return values, values != nil
+	}
+	v.data[i] = nil
+
+	a := v.d.Accessors[i]
+	n := components(a.Type)
+	if n == 0 || componentSize(a.ComponentType) == 0 || a.Count < 1 {
+		return nil, false
+	}
+	values := make([]float64, n*a.Count)
+	if a.BufferView != nil {
+		stride := v.d.BufferViews[*a.BufferView].ByteStride
+		var ok bool
+		if values, ok = v.raw(*a.BufferView, a.ByteOffset, stride, a.ComponentType, n, a.Count, a.Normalized); !ok {
+			return nil, false
+		}
+	}
+
+	if s := a.Sparse; s != nil {
+		indices, ok := v.raw(s.Indices.BufferView, s.Indices.ByteOffset, 0, s.Indices.ComponentType, 1, s.Count, false)
+		if !ok {
+			return nil, false
+		}
+		sparse, ok := v.raw(s.Values.BufferView, s.Values.ByteOffset, 0, a.ComponentType, n, s.Count, a.Normalized)
+		if !ok {
+			return nil, false
+		}
+		for k, index := range indices {
+			if index >= float64(a.Count) {
+				return nil, false
+			}
+			copy(values[int(index)*n:int(index)*n+n], sparse[k*n:k*n+n])
+		}
+	}
+
+	v.data[i] = values
+	return values, true
+}
+
+// raw reads count elements of n components from the buffer view.
+func (v *validator) raw(view int, offset int, stride int, componentType int, n int, count int, normalized bool) ([]float64, bool) {
+
+	bv := v.d.BufferViews[view]
+	length := v.bufferLength(bv.Buffer)
+	if length < 0 || bv.ByteOffset+bv.ByteLength > length {
+		return nil, false
+	}
+	size := componentSize(componentType)
+	if stride == 0 {
+		stride = n * size
+	}
+
+	b := v.f.Buffer[bv.ByteOffset : bv.ByteOffset+bv.ByteLength]
+	le := binary.LittleEndian
+	values := make([]float64, 0, n*count)
+	for e := 0; e < count; e++ {
+		for k := 0; k < n; k++ {
+			p := offset + e*stride + k*size
+			if p+size > len(b) {
+				return nil, false
+			}
+			var x float64
+			switch componentType {
+			case 5120:
+				x = float64(int8(b[p]))
+				if normalized {
+					x = math.Max(x/127, -1)
+				}
+			case UnsignedByte:
+				x = float64(b[p])
+				if normalized {
+					x /= 255
+				}
+			case 5122:
+				x = float64(int16(le.Uint16(b[p:])))
+				if normalized {
+					x = math.Max(x/32767, -1)
+				}
+			case UnsignedShort:
+				x = float64(le.Uint16(b[p:]))
+				if normalized {
+					x /= 65535
+				}
+			case UnsignedInt:
+				x = float64(le.Uint32(b[p:]))
+			case Float:
+				x = float64(math.Float32frombits(le.Uint32(b[p:])))
+				if math.IsNaN(x) || math.IsInf(x, 0) {
+					v.report("ACCESSOR_INVALID_FLOAT", fmt.Sprintf("/bufferViews/%d", view), "element %d has NaN or infinity", e)
+					return nil, false
+				}
+			}
+			values = append(values, x)
+		}
+	}
+	return values, true
+}
+
+func (v *validator) nodes() {
+
+	parents := make([]int, len(v.d.Nodes))
+	for i := range parents {
+		parents[i] = -1
+	}
+
+	for i, n := range v.d.Nodes {
+		pointer := fmt.Sprintf("/nodes/%d", i)
+		for _, c := range n.Children {
+			if !v.index(pointer+"/children", c, len(v.d.Nodes)) {
+				continue
+			}
+			if parents[c] >= 0 {
+				v.report("NODE_PARENT_OVERRIDDEN", fmt.Sprintf("/nodes/%d", c), "node has parents %d and %d", parents[c], i)
+			}
+			parents[c] = i
+		}
+	}
+
+	for i, n := range v.d.Nodes {
+		pointer := fmt.Sprintf("/nodes/%d", i)
+		if n.Translation != nil && len(n.Translation) != 3 {
+			v.report("ARRAY_LENGTH_NOT_IN_LIST", pointer+"/translation", "translation must have 3 values")
+		}
+		if n.Rotation != nil {
+			if len(n.Rotation) != 4 {
+				v.report("ARRAY_LENGTH_NOT_IN_LIST", pointer+"/rotation", "rotation must have 4 values")
+			} else if l := math.Sqrt(n.Rotation[0]*n.Rotation[0] + n.Rotation[1]*n.Rotation[1] + n.Rotation[2]*n.Rotation[2] + n.Rotation[3]*n.Rotation[3]); math.Abs(l-1) > unitThreshold {
+				v.report("ROTATION_NON_UNIT", pointer+"/rotation", "rotation is not a unit quaternion")
+			}
+		}
+		if n.Mesh != nil && v.index(pointer+"/mesh", *n.Mesh, len(v.d.Meshes)) && n.Weights != nil {
+			if targets := len(v.d.Meshes[*n.Mesh].Primitives[0].Targets); len(n.Weights) != targets {
+				v.report("NODE_WEIGHTS_INVALID", pointer+"/weights", "%d weights for %d morph targets", len(n.Weights), targets)
+			}
+		}
+		if n.Skin != nil {
+			v.index(pointer+"/skin", *n.Skin, len(v.d.Skins))
+			if n.Mesh == nil {
+				v.report("NODE_SKIN_WITH_NON_SKINNED_MESH", pointer, "node with a skin has no mesh")
+			}
+			if parents[i] >= 0 {
+				v.report("NODE_SKINNED_MESH_NON_ROOT", pointer, "skinned mesh node is not a root node")
+			}
+			if n.Translation != nil || n.Rotation != nil {
+				v.report("NODE_SKINNED_MESH_LOCAL_TRANSFORMS", pointer, "transforms of skinned mesh nodes are ignored")
+			}
+		}
+	}
+
+	// 親を辿って自分に戻るノードは循環している
+	for i := range v.d.Nodes {
+		for p, steps := parents[i], 0; p >= 0 && steps <= len(parents); p, steps = parents[p], steps+1 {
+			if p == i {
+				v.report("NODE_LOOP", fmt.Sprintf("/nodes/%d", i), "node is its own ancestor")
+				break
+			}
+		}
+	}
+
+	for i, s := range v.d.Scenes {
+		for _, n := range s.Nodes {
+			if v.index(fmt.Sprintf("/scenes/%d/nodes", i), n, len(v.d.Nodes)) && parents[n] >= 0 {
+				v.report("SCENE_NON_ROOT_NODE", fmt.Sprintf("/scenes/%d/nodes", i), "node %d is not a root node", n)
+			}
+		}
+	}
+}
+
+// skinOf gets the skin of a node with the mesh, or -1.
+func (v *validator) skinOf(mesh int) int {
+	for _, n := range v.d.Nodes {
+		if n.Mesh != nil && *n.Mesh == mesh && n.Skin != nil && *n.Skin >= 0 && *n.Skin < len(v.d.Skins) {
+			return *n.Skin
+		}
+	}
+	return -1
+}
+
+func (v *validator) meshes() {
+
+	for i, m := range v.d.Meshes {
+		pointer := fmt.Sprintf("/meshes/%d", i)
+		if len(m.Primitives) == 0 {
+			v.report("EMPTY_ENTITY", pointer+"/primitives", "mesh has no primitives")
+			continue
+		}
+		targets := len(m.Primitives[0].Targets)
+		if m.Weights != nil && len(m.Weights) != targets {
+			v.report("MESH_INVALID_WEIGHTS_COUNT", pointer+"/weights", "%d weights for %d morph targets", len(m.Weights), targets)
+		}
+		if m.Extras != nil && m.Extras.TargetNames != nil && len(m.Extras.TargetNames) != targets {
+			v.report("MESH_INVALID_WEIGHTS_COUNT", pointer+"/extras/targetNames", "%d names for %d morph targets", len(m.Extras.TargetNames), targets)
+		}
+
+		skin := v.skinOf(i)
+		for k, p := range m.Primitives {
+			v.primitive(fmt.Sprintf("%s/primitives/%d", pointer, k), p, skin)
+			if len(p.Targets) != targets {
+				v.report("MESH_PRIMITIVES_UNEQUAL_TARGETS_COUNT", fmt.Sprintf("%s/primitives/%d/targets", pointer, k), "primitives have different numbers of morph targets")
+			}
+		}
+	}
+}
+
+func (v *validator) primitive(pointer string, p Primitive, skin int) {
+
+	// 頂点数はPOSITIONの要素数とする
+	vertices := -1
+	if a, ok := p.Attributes["POSITION"]; !ok {
+		v.report("MESH_PRIMITIVE_NO_POSITION", pointer+"/attributes", "no POSITION attribute")
+	} else if a >= 0 && a < len(v.d.Accessors) {
+		vertices = v.d.Accessors[a].Count
+	}
+
+	names := make([]string, 0, len(p.Attributes))
+	for name := range p.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		a := p.Attributes[name]
+		ap := pointer + "/attributes/" + name
+		if !v.index(ap, a, len(v.d.Accessors)) {
+			return
+		}
+		acc := v.d.Accessors[a]
+		if vertices >= 0 && acc.Count != vertices {
+			v.report("MESH_PRIMITIVE_UNEQUAL_ACCESSOR_COUNT", ap, "%d elements for %d vertices", acc.Count, vertices)
+		}
+		if acc.BufferView != nil && v.d.BufferViews[*acc.BufferView].Target == ElementArrayBuffer {
+			v.report("BUFFER_VIEW_TARGET_MISMATCH", ap, "vertex attribute in an index buffer view")
+		}
+
+		format := v.attributeFormat(name, acc)
+		if format != "" {
+			v.report("MESH_PRIMITIVE_ATTRIBUTES_ACCESSOR_INVALID_FORMAT", ap, "%s must be %s", name, format)
+			continue
+		}
+		values, ok := v.read(a)
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "POSITION":
+			if acc.Min == nil || acc.Max == nil {
+				v.report("MESH_PRIMITIVE_POSITION_ACCESSOR_WITHOUT_BOUNDS", ap, "POSITION must have min and max")
+			}
+		case "NORMAL":
+			v.units(ap, values, 3)
+		case "WEIGHTS_0":
+			for e := 0; e+4 <= len(values); e += 4 {
+				sum := 0.0
+				for _, w := range values[e : e+4] {
+					if w < 0 {
+						v.report("ACCESSOR_WEIGHTS_NEGATIVE", ap, "negative weight at %d", e/4)
+					}
+					sum += w
+				}
+				if math.Abs(sum-1) > 5e-7 {
+					v.report("ACCESSOR_WEIGHTS_NON_NORMALIZED", ap, "weights of element %d sum to %v", e/4, sum)
+					break
+				}
+			}
+		case "JOINTS_0":
+			v.joints(ap, values, p, skin)
+		}
+	}
+
+	for t, target := range p.Targets {
+		a, ok := target["POSITION"]
+		tp := fmt.Sprintf("%s/targets/%d/POSITION", pointer, t)
+		if !ok || !v.index(tp, a, len(v.d.Accessors)) {
+			continue
+		}
+		acc := v.d.Accessors[a]
+		switch {
+		case acc.Count != vertices:
+			v.report("MESH_PRIMITIVE_MORPH_TARGET_INVALID_ATTRIBUTE_COUNT", tp, "morph target has %d elements for %d vertices", acc.Count, vertices)
+		case acc.Min == nil || acc.Max == nil:
+			v.report("MESH_PRIMITIVE_POSITION_ACCESSOR_WITHOUT_BOUNDS", tp, "POSITION of a morph target must have min and max")
+		}
+	}
+
+	if p.Material != nil {
+		v.index(pointer+"/material", *p.Material, len(v.d.Materials))
+	}
+	if p.Indices == nil || !v.index(pointer+"/indices", *p.Indices, len(v.d.Accessors)) {
+		return
+	}
+	acc := v.d.Accessors[*p.Indices]
+	switch {
+	case acc.Type != "SCALAR" || acc.Normalized || acc.ComponentType == Float || acc.ComponentType == 5120 || acc.ComponentType == 5122:
+		v.report("MESH_PRIMITIVE_INDICES_ACCESSOR_INVALID_FORMAT", pointer+"/indices", "indices must be unsigned integers")
+		return
+	case acc.BufferView == nil:
+		v.report("MESH_PRIMITIVE_INDICES_ACCESSOR_WITHOUT_BYTESTRIDE", pointer+"/indices", "indices must be in a buffer view")
+		return
+	case v.d.BufferViews[*acc.BufferView].ByteStride != 0:
+		v.report("MESH_PRIMITIVE_INDICES_ACCESSOR_WITHOUT_BYTESTRIDE", pointer+"/indices", "indices can not have byteStride")
+	case v.d.BufferViews[*acc.BufferView].Target == ArrayBuffer:
+		v.report("BUFFER_VIEW_TARGET_MISMATCH", pointer+"/indices", "indices in a vertex buffer view")
+	}
+	if acc.Count%3 != 0 {
+		v.report("MESH_PRIMITIVE_INCOMPATIBLE_MODE", pointer+"/indices", "%d indices are not triangles", acc.Count)
+	}
+
+	indices, ok := v.read(*p.Indices)
+	if !ok {
+		return
+	}
+	restart := float64(uint32(1)<<uint(8*componentSize(acc.ComponentType)) - 1)
+	for k, index := range indices {
+		switch {
+		case index == restart:
+			v.report("ACCESSOR_INDEX_PRIMITIVE_RESTART", pointer+"/indices", "index at %d is the primitive restart value", k)
+			return
+		case vertices >= 0 && index >= float64(vertices):
+			v.report("ACCESSOR_INDEX_OOB", pointer+"/indices", "index %v at %d is out of %d vertices", index, k, vertices)
+			return
+		}
+	}
+}
+
+// attributeFormat gets the formats allowed for the attribute if the accessor does not match, or "".
+func (v *validator) attributeFormat(name string, a Accessor) string {
+
+	float := a.ComponentType == Float
+	unsigned := (a.ComponentType == UnsignedByte || a.ComponentType == UnsignedShort) && a.Normalized
+	switch name {
+	case "POSITION", "NORMAL":
+		if a.Type != "VEC3" || !float {
+			return "VEC3 of floats"
+		}
+	case "TEXCOORD_0", "TEXCOORD_1":
+		if a.Type != "VEC2" || !float && !unsigned {
+			return "VEC2 of floats or normalized unsigned bytes or shorts"
+		}
+	case "JOINTS_0":
+		if a.Type != "VEC4" || a.Normalized || a.ComponentType != UnsignedByte && a.ComponentType != UnsignedShort {
+			return "VEC4 of unsigned bytes or shorts"
+		}
+	case "WEIGHTS_0":
+		if a.Type != "VEC4" || !float && !unsigned {
+			return "VEC4 of floats or normalized unsigned bytes or shorts"
+		}
+	}
+	return ""
+}
+
+// units checks that elements of n components are unit vectors.
+func (v *validator) units(pointer string, values []float64, n int) {
+	for e := 0; e+n <= len(values); e += n {
+		var l float64
+		for _, x := range values[e : e+n] {
+			l += x * x
+		}
+		if math.Abs(math.Sqrt(l)-1) > unitThreshold {
+			v.report("ACCESSOR_NON_UNIT", pointer, "element %d is not unit length", e/n)
+			return
+		}
+	}
+}
+
+// joints checks that the joints are in the skin and not repeated with weights.
+func (v *validator) joints(pointer string, values []float64, p Primitive, skin int) {
+
+	if skin < 0 {
+		v.report("NODE_SKINNED_MESH_WITHOUT_SKIN", pointer, "skinned mesh is not used with a skin")
+		return
+	}
+	var weights []float64
+	if a, ok := p.Attributes["WEIGHTS_0"]; ok && a >= 0 && a < len(v.d.Accessors) {
+		weights, _ = v.read(a)
+	}
+
+	n := float64(len(v.d.Skins[skin].Joints))
+	for e := 0; e+4 <= len(values); e += 4 {
+		for k, j := range values[e : e+4] {
+			if j >= n {
+				v.report("ACCESSOR_JOINTS_INDEX_OOB", pointer, "joint %v of element %d is out of %v joints", j, e/4, n)
+				return
+			}
+			if weights == nil || e+4 > len(weights) || weights[e+k] == 0 {
+				continue
+			}
+			for l := 0; l < k; l++ {
+				if values[e+l] == j && weights[e+l] != 0 {
+					v.report("ACCESSOR_JOINTS_INDEX_DUPLICATE", pointer, "joint %v is repeated in element %d", j, e/4)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) skins() {
+
+	for i, s := range v.d.Skins {
+		pointer := fmt.Sprintf("/skins/%d", i)
+		if len(s.Joints) == 0 {
+			v.report("EMPTY_ENTITY", pointer+"/joints", "skin has no joints")
+		}
+		seen := make(map[int]bool, len(s.Joints))
+		for _, j := range s.Joints {
+			if v.index(pointer+"/joints", j, len(v.d.Nodes)) && seen[j] {
+				v.report("DUPLICATE_ELEMENTS", pointer+"/joints", "joint %d is repeated", j)
+			}
+			seen[j] = true
+		}
+		if s.InverseBindMatrices == nil || !v.index(pointer+"/inverseBindMatrices", *s.InverseBindMatrices, len(v.d.Accessors)) {
+			continue
+		}
+		a := v.d.Accessors[*s.InverseBindMatrices]
+		if a.Type != "MAT4" || a.ComponentType != Float {
+			v.report("SKIN_IBM_INVALID_FORMAT", pointer+"/inverseBindMatrices", "inverse bind matrices must be MAT4 of floats")
+		} else if a.Count != len(s.Joints) {
+			v.report("INVALID_IBM_ACCESSOR_COUNT", pointer+"/inverseBindMatrices", "%d matrices for %d joints", a.Count, len(s.Joints))
+		}
+	}
+}
+
+func (v *validator) materials() {
+
+	for i, m := range v.d.Materials {
+		pointer := fmt.Sprintf("/materials/%d", i)
+		switch m.AlphaMode {
+		case "", "OPAQUE", "BLEND", "MASK":
+		default:
+			v.report("VALUE_NOT_IN_LIST", pointer+"/alphaMode", "invalid alphaMode %q", m.AlphaMode)
+		}
+		if m.AlphaCutoff != nil && m.AlphaMode != "MASK" {
+			v.report("MATERIAL_ALPHA_CUTOFF_INVALID_MODE", pointer+"/alphaCutoff", "alphaCutoff is only for MASK")
+		}
+
+		pbr := m.PBRMetallicRoughness
+		if pbr == nil {
+			continue
+		}
+		if pbr.BaseColorFactor != nil {
+			if len(pbr.BaseColorFactor) != 4 {
+				v.report("ARRAY_LENGTH_NOT_IN_LIST", pointer+"/pbrMetallicRoughness/baseColorFactor", "baseColorFactor must have 4 values")
+			}
+			for _, c := range pbr.BaseColorFactor {
+				if c < 0 || c > 1 {
+					v.report("VALUE_NOT_IN_RANGE", pointer+"/pbrMetallicRoughness/baseColorFactor", "value %v is out of [0, 1]", c)
+					break
+				}
+			}
+		}
+		for name, f := range map[string]*float64{"metallicFactor": pbr.MetallicFactor, "roughnessFactor": pbr.RoughnessFactor} {
+			if f != nil && (*f < 0 || *f > 1) {
+				v.report("VALUE_NOT_IN_RANGE", pointer+"/pbrMetallicRoughness/"+name, "value %v is out of [0, 1]", *f)
+			}
+		}
+		if pbr.BaseColorTexture != nil {
+			v.index(pointer+"/pbrMetallicRoughness/baseColorTexture/index", pbr.BaseColorTexture.Index, len(v.d.Textures))
+		}
+	}
+}
+
+func (v *validator) textures() {
+
+	for i, t := range v.d.Textures {
+		pointer := fmt.Sprintf("/textures/%d", i)
+		if t.Sampler != nil {
+			v.index(pointer+"/sampler", *t.Sampler, len(v.d.Samplers))
+		}
+		if t.Source != nil {
+			v.index(pointer+"/source", *t.Source, len(v.d.Images))
+		}
+	}
+
+	signatures := map[string][]byte{
+		"image/png":  []byte("\x89PNG\r\n\x1a\n"),
+		"image/jpeg": {0xff, 0xd8, 0xff},
+	}
+	for i, img := range v.d.Images {
+		pointer := fmt.Sprintf("/images/%d", i)
+		if img.BufferView == nil {
+			continue
+		}
+		signature, ok := signatures[img.MimeType]
+		if !ok {
+			v.report("VALUE_NOT_IN_LIST", pointer+"/mimeType", "invalid mimeType %q", img.MimeType)
+			continue
+		}
+		if !v.index(pointer+"/bufferView", *img.BufferView, len(v.d.BufferViews)) {
+			continue
+		}
+		bv := v.d.BufferViews[*img.BufferView]
+		if length := v.bufferLength(bv.Buffer); length < 0 || bv.ByteOffset+bv.ByteLength > length {
+			continue
+		}
+		if !bytes.HasPrefix(v.f.Buffer[bv.ByteOffset:bv.ByteOffset+bv.ByteLength], signature) {
+			v.report("IMAGE_MIME_TYPE_INVALID", pointer, "data is not %s", img.MimeType)
+		}
+	}
+}
+
+func (v *validator) animations() {
+
+	for i, a := range v.d.Animations {
+		pointer := fmt.Sprintf("/animations/%d", i)
+		if len(a.Channels) == 0 {
+			v.report("EMPTY_ENTITY", pointer+"/channels", "animation has no channels")
+		}
+
+		targets := make(map[ChannelTarget]bool)
+		for k, c := range a.Channels {
+			cp := fmt.Sprintf("%s/channels/%d", pointer, k)
+			if !v.index(cp+"/sampler", c.Sampler, len(a.Samplers)) || !v.index(cp+"/target/node", c.Target.Node, len(v.d.Nodes)) {
+				continue
+			}
+			if targets[c.Target] {
+				v.report("ANIMATION_DUPLICATE_TARGETS", cp+"/target", "node %d %s is animated twice", c.Target.Node, c.Target.Path)
+			}
+			targets[c.Target] = true
+
+			s := a.Samplers[c.Sampler]
+			sp := fmt.Sprintf("%s/samplers/%d", pointer, c.Sampler)
+			if !v.index(sp+"/input", s.Input, len(v.d.Accessors)) || !v.index(sp+"/output", s.Output, len(v.d.Accessors)) {
+				continue
+			}
+			v.sampler(sp, s, c.Target)
+		}
+	}
+}
+
+// sampler checks the keyframes of the sampler for the target.
+func (v *validator) sampler(pointer string, s AnimationSampler, target ChannelTarget) {
+
+	input, output := v.d.Accessors[s.Input], v.d.Accessors[s.Output]
+	if input.Type != "SCALAR" || input.ComponentType != Float {
+		v.report("ANIMATION_SAMPLER_INPUT_ACCESSOR_INVALID_FORMAT", pointer+"/input", "input must be SCALAR of floats")
+		return
+	}
+	if input.Min == nil || input.Max == nil {
+		v.report("ANIMATION_SAMPLER_INPUT_ACCESSOR_WITHOUT_BOUNDS", pointer+"/input", "input must have min and max")
+	}
+	if times, ok := v.read(s.Input); ok {
+		for k, t := range times {
+			if t < 0 {
+				v.report("ACCESSOR_ANIMATION_INPUT_NEGATIVE", pointer+"/input", "negative time at %d", k)
+				break
+			}
+			if k > 0 && t <= times[k-1] {
+				v.report("ACCESSOR_ANIMATION_INPUT_NON_INCREASING", pointer+"/input", "times are not strictly increasing at %d", k)
+				break
+			}
+		}
+	}
+
+	elements := 1
+	if s.Interpolation == "CUBICSPLINE" {
+		elements = 3
+	}
+	switch target.Path {
+	case "translation", "scale":
+		if output.Type != "VEC3" || output.ComponentType != Float {
+			v.report("ANIMATION_SAMPLER_OUTPUT_ACCESSOR_INVALID_FORMAT", pointer+"/output", "%s output must be VEC3 of floats", target.Path)
+			return
+		}
+	case "rotation":
+		if output.Type != "VEC4" {
+			v.report("ANIMATION_SAMPLER_OUTPUT_ACCESSOR_INVALID_FORMAT", pointer+"/output", "rotation output must be VEC4")
+			return
+		}
+		if values, ok := v.read(s.Output); ok && s.Interpolation != "CUBICSPLINE" {
+			v.units(pointer+"/output", values, 4)
+		}
+	case "weights":
+		n := v.d.Nodes[target.Node]
+		if n.Mesh == nil || *n.Mesh < 0 || *n.Mesh >= len(v.d.Meshes) || len(v.d.Meshes[*n.Mesh].Primitives) == 0 {
+			v.report("ANIMATION_CHANNEL_TARGET_NODE_WEIGHTS_NO_MORPHS", pointer, "node %d has no morph targets", target.Node)
+			return
+		}
+		elements *= len(v.d.Meshes[*n.Mesh].Primitives[0].Targets)
+	default:
+		v.report("VALUE_NOT_IN_LIST", pointer, "invalid path %q", target.Path)
+		return
+	}
+
+	if output.Count != input.Count*elements {
+		v.report("ANIMATION_SAMPLER_OUTPUT_ACCESSOR_INVALID_COUNT", pointer+"/output", "%d outputs for %d inputs", output.Count, input.Count)
+	}
+}
+
+func minMaxFloat64(values []float64, n int) ([]float64, []float64) {
+	min, max := make([]float64, n), make([]float64, n)
+	for j := 0; j < n; j++ {
+		min[j], max[j] = math.Inf(1), math.Inf(-1)
+	}
+	for i, x := range values {
+		min[i%n] = math.Min(min[i%n], x)
+		max[i%n] = math.Max(max[i%n], x)
+	}
+	return min, max
+}
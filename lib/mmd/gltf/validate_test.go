@@ -0,0 +1,55 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		modify func(f *File)
+		want   string
+	}{
+		{"glTF 1.0", func(f *File) { f.Document.Asset.Version = "1.0" }, "UNKNOWN_ASSET_MAJOR_VERSION"},
+		{"wrong min", func(f *File) {
+			a := &f.Document.Accessors[f.Document.Meshes[0].Primitives[0].Attributes["POSITION"]]
+			a.Min = []float64{-1, a.Min[1], a.Min[2]}
+		}, "ACCESSOR_MIN_MISMATCH"},
+		{"node loop", func(f *File) { f.Document.Nodes[1].Children = []int{0} }, "NODE_LOOP"},
+		{"two parents", func(f *File) { f.Document.Nodes[2].Children = []int{1} }, "NODE_PARENT_OVERRIDDEN"},
+		{"short binary chunk", func(f *File) { f.Buffer = f.Buffer[:len(f.Buffer)-8] }, "BUFFER_GLB_CHUNK_TOO_BIG"},
+		{"unknown material", func(f *File) {
+			m := 99
+			f.Document.Meshes[0].Primitives[0].Material = &m
+		}, "UNRESOLVED_REFERENCE"},
+		{"non-unit rotation", func(f *File) { f.Document.Nodes[1].Rotation = []float64{0, 0, 0, 2} }, "ROTATION_NON_UNIT"},
+		{"missing target name", func(f *File) {
+			m := &f.Document.Meshes[0]
+			m.Extras.TargetNames = m.Extras.TargetNames[:1]
+		}, "MESH_INVALID_WEIGHTS_COUNT"},
+		{"weights not normalized", func(f *File) {
+			d := f.Document
+			a := d.Accessors[d.Meshes[0].Primitives[0].Attributes["WEIGHTS_0"]]
+			offset := d.BufferViews[*a.BufferView].ByteOffset + a.ByteOffset
+			binary.LittleEndian.PutUint32(f.Buffer[offset:], math.Float32bits(0.5))
+		}, "ACCESSOR_WEIGHTS_NON_NORMALIZED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := export(t)
+			tt.modify(f)
+
+			issues := f.Validate()
+			for _, i := range issues {
+				if i.Code == tt.want {
+					return
+				}
+			}
+			t.Errorf("Validate() = %v, want %s", issues, tt.want)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+// Package ik is the CCD inverse kinematics of MMD models handled in Go, without three.js.
+//
+// It follows CCDIKSolver of three.js with the IK settings MMDLoader makes,
+// so that poses computed here match those on screen.
+package ik
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/pmx"
+	"app/lib/mmd/skeleton"
+	"fmt"
+	"math"
+)
+
+// Chain is IK of an IK bone.
+type Chain struct {
+	// Bone is the IK bone, whose position the effector reaches.
+	Bone int
+	// Effector is the bone moved to the IK bone, such as an ankle.
+	Effector int
+	// Iterations is the largest number of loops.
+	Iterations int
+	// MaxAngle is the largest angle in radians that a link rotates in an iteration. It is not limited if 0.
+	MaxAngle float64
+	// Links are the bones rotated, from the one nearest to the effector.
+	Links []Link
+}
+
+// Link is a bone rotated by IK.
+type Link struct {
+	Bone int
+
+	// Axis, if it is not zero, restricts the rotation to the axis in the bone,
+	// as MMDLoader does for knees of PMD models, which have no angle limits.
+	Axis math3d.Vector3
+
+	// Limited is true if Min and Max limit the Euler angles (XYZ order) of the rotation.
+	Limited bool
+	Min     math3d.Vector3
+	Max     math3d.Vector3
+}
+
+// NewChainsFromPMX makes chains of the IK bones of the PMX model in the order of the bones.
+// Angle limits are converted to the right-handed coordinate system in the same way as MMDLoader.
+// Links without angle limits rotate freely, since MMDLoader restricts knees to the X axis only for PMD models.
+func NewChainsFromPMX(m *pmx.Model) []Chain {
+
+	var chains []Chain
+	for i, b := range m.Bones {
+		if b.IK == nil {
+			continue
+		}
+
+		c := Chain{
+			Bone:       i,
+			Effector:   b.IK.Target,
+			Iterations: b.IK.Loops,
+			MaxAngle:   float64(b.IK.LimitAngle),
+		}
+		for _, l := range b.IK.Links {
+			link := Link{Bone: l.Bone}
+			if l.Limited {
+				// 左手系から右手系に変換すると、X軸とY軸の回転は向きと上下限が入れ替わる
+				link.Limited = true
+				link.Min = math3d.NewVector3(-float64(l.Max[0]), -float64(l.Max[1]), float64(l.Min[2]))
+				link.Max = math3d.NewVector3(-float64(l.Min[0]), -float64(l.Min[1]), float64(l.Max[2]))
+			}
+			c.Links = append(c.Links, link)
+		}
+		chains = append(chains, c)
+	}
+
+	return chains
+}
+
+// Solver solves IK chains of a skeleton.
+type Solver struct {
+	skeleton *skeleton.Skeleton
+	chains   []Chain
+	enabled  []bool
+}
+
+// NewSolver creates Solver. All chains are enabled.
+func NewSolver(s *skeleton.Skeleton, chains []Chain) (*Solver, error) {
+
+	valid := func(i int) bool {
+		return i >= 0 && i < len(s.Bones)
+	}
+	for _, c := range chains {
+		if !valid(c.Bone) || !valid(c.Effector) {
+			return nil, fmt.Errorf("ik: chain has invalid bones %d and %d", c.Bone, c.Effector)
+		}
+		for _, l := range c.Links {
+			if !valid(l.Bone) {
+				return nil, fmt.Errorf("ik: chain of bone %q has invalid link %d", s.Bones[c.Bone].Name, l.Bone)
+			}
+		}
+	}
+
+	enabled := make([]bool, len(chains))
+	for i := range enabled {
+		enabled[i] = true
+	}
+
+	return &Solver{skeleton: s, chains: chains, enabled: enabled}, nil
+}
+
+// Chains gets the chains.
+func (s *Solver) Chains() []Chain {
+	return s.chains
+}
+
+// SetEnabled turns on/off the chain of the IK bone, as IK keyframes of VMD do.
+func (s *Solver) SetEnabled(bone int, enabled bool) {
+	for i, c := range s.chains {
+		if c.Bone == bone {
+			s.enabled[i] = enabled
+		}
+	}
+}
+
+// Enabled gets whether the chain of the IK bone is enabled.
+func (s *Solver) Enabled(bone int) bool {
+	for i, c := range s.chains {
+		if c.Bone == bone {
+			return s.enabled[i]
+		}
+	}
+	return false
+}
+
+// Update solves all enabled chains in order, rotating the links in local, the local transforms of the bones.
+func (s *Solver) Update(local []skeleton.Transform) {
+	world := s.skeleton.World(local)
+	for i := range s.chains {
+		if s.enabled[i] {
+			s.Solve(i, local, world)
+		}
+	}
+}
+
+// Solve solves the i-th chain even if it is disabled.
+// world must be the transforms of local in the model, and the moved bones are updated in it.
+func (s *Solver) Solve(i int, local []skeleton.Transform, world []skeleton.Transform) {
+
+	c := s.chains[i]
+	target := world[c.Bone].Position
+
+	iterations := c.Iterations
+	if iterations <= 0 {
+		iterations = 1
+	}
+
+	for n := 0; n < iterations; n++ {
+		rotated := false
+
+		for _, l := range c.Links {
+			link := world[l.Bone]
+			inverse := link.Rotation.Conjugate()
+
+			// リンクのローカル座標で、エフェクタと目標の向きを比べる
+			effectorVec := inverse.Rotate(world[c.Effector].Position.Sub(link.Position)).Normalize()
+			targetVec := inverse.Rotate(target.Sub(link.Position)).Normalize()
+
+			angle := math.Acos(math.Max(-1, math.Min(1, targetVec.Dot(effectorVec))))
+			if angle < 1e-5 {
+				continue
+			}
+			if c.MaxAngle > 0 && angle > c.MaxAngle {
+				angle = c.MaxAngle
+			}
+
+			axis := effectorVec.Cross(targetVec).Normalize()
+			q := local[l.Bone].Rotation.Mul(math3d.NewQuaternionFromAxisAngle(axis, angle))
+
+			if l.Axis != (math3d.Vector3{}) {
+				// three.jsと同じく、回転量だけ残して軸に揃える
+				w := math.Min(q.W, 1)
+				sin := math.Sqrt(1 - w*w)
+				q = math3d.Quaternion{X: l.Axis.X * sin, Y: l.Axis.Y * sin, Z: l.Axis.Z * sin, W: w}
+			}
+
+			if l.Limited {
+				x, y, z := q.Euler(math3d.XYZ)
+				x = math.Min(math.Max(x, l.Min.X), l.Max.X)
+				y = math.Min(math.Max(y, l.Min.Y), l.Max.Y)
+				z = math.Min(math.Max(z, l.Min.Z), l.Max.Z)
+				q = math3d.NewQuaternionFromEuler(x, y, z, math3d.XYZ)
+			}
+
+			local[l.Bone].Rotation = q
+			s.skeleton.UpdateWorld(l.Bone, local, world)
+			rotated = true
+		}
+
+		if !rotated {
+			break
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package ik
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/pmx"
+	"app/lib/mmd/skeleton"
+	"math"
+	"testing"
+)
+
+// leg makes a leg of a hip, a knee and an ankle 5 apart below the hip at (0, 10, 0), and an IK bone.
+func leg(t *testing.T) *skeleton.Skeleton {
+	s, err := skeleton.New([]skeleton.Bone{
+		{Name: "足", Parent: -1, Position: math3d.NewVector3(0, 10, 0)},
+		{Name: "ひざ", Parent: 0, Position: math3d.NewVector3(0, -5, 0)},
+		{Name: "足首", Parent: 1, Position: math3d.NewVector3(0, -5, 0)},
+		{Name: "足ＩＫ", Parent: -1, Position: math3d.NewVector3(0, 0, 0)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSolveConverges(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		target math3d.Vector3
+		chain  Chain
+	}{
+		{
+			name:   "bent forward",
+			target: math3d.NewVector3(0, 2, 3),
+			chain:  Chain{Bone: 3, Effector: 2, Iterations: 40, Links: []Link{{Bone: 1}, {Bone: 0}}},
+		},
+		{
+			name:   "sideways and forward",
+			target: math3d.NewVector3(2, 3, 2),
+			chain:  Chain{Bone: 3, Effector: 2, Iterations: 40, Links: []Link{{Bone: 1}, {Bone: 0}}},
+		},
+		{
+			name:   "limited step angle",
+			target: math3d.NewVector3(0, 4, 4),
+			chain:  Chain{Bone: 3, Effector: 2, Iterations: 200, MaxAngle: 0.1, Links: []Link{{Bone: 1}, {Bone: 0}}},
+		},
+		{
+			name:   "knee on the X axis",
+			target: math3d.NewVector3(0, 3, 2),
+			chain: Chain{Bone: 3, Effector: 2, Iterations: 40, Links: []Link{
+				{Bone: 1, Axis: math3d.NewVector3(1, 0, 0)},
+				{Bone: 0},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := leg(t)
+			solver, err := NewSolver(s, []Chain{tt.chain})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			local := s.RestPose()
+			local[3].Position = tt.target
+			solver.Update(local)
+
+			world := s.World(local)
+			if d := world[2].Position.Distance(tt.target); d > 1e-2 {
+				t.Errorf("effector is at %v, %v away from the target %v", world[2].Position, d, tt.target)
+			}
+			// 骨の長さは変わらない
+			if d := world[1].Position.Distance(world[0].Position); math.Abs(d-5) > 1e-6 {
+				t.Errorf("thigh length = %v, want 5", d)
+			}
+		})
+	}
+}
+
+func TestSolveAngleLimits(t *testing.T) {
+
+	// 原点の関節から+Zに5伸びるボーンを、X軸回りに回す
+	s, err := skeleton.New([]skeleton.Bone{
+		{Name: "joint", Parent: -1},
+		{Name: "tip", Parent: 0, Position: math3d.NewVector3(0, 0, 5)},
+		{Name: "IK", Parent: -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		angle float64
+		min   float64
+		max   float64
+		want  float64
+	}{
+		{"within limits", 0.3, -0.5, 0.5, 0.3},
+		{"above the max", 1.0, -0.5, 0.5, 0.5},
+		{"below the min", -1.0, -0.5, 0.5, -0.5},
+		{"positive range only", -0.4, 0.1, 1.0, 0.1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := Chain{Bone: 2, Effector: 1, Iterations: 20, Links: []Link{{
+				Bone:    0,
+				Limited: true,
+				Min:     math3d.NewVector3(tt.min, 0, 0),
+				Max:     math3d.NewVector3(tt.max, 0, 0),
+			}}}
+			solver, err := NewSolver(s, []Chain{chain})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			local := s.RestPose()
+			local[2].Position = math3d.NewVector3(0, -5*math.Sin(tt.angle), 5*math.Cos(tt.angle))
+			solver.Update(local)
+
+			x, y, z := local[0].Rotation.Euler(math3d.XYZ)
+			if math.Abs(x-tt.want) > 1e-3 || math.Abs(y) > 1e-6 || math.Abs(z) > 1e-6 {
+				t.Errorf("rotation = (%v, %v, %v), want (%v, 0, 0)", x, y, z, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolverDisabled(t *testing.T) {
+
+	s := leg(t)
+	solver, err := NewSolver(s, []Chain{{Bone: 3, Effector: 2, Iterations: 40, Links: []Link{{Bone: 1}, {Bone: 0}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver.SetEnabled(3, false)
+
+	local := s.RestPose()
+	local[3].Position = math3d.NewVector3(0, 2, 3)
+	solver.Update(local)
+
+	for i, l := range local[:3] {
+		if l.Rotation != math3d.IdentityQuaternion() {
+			t.Errorf("bone %d is rotated by a disabled chain: %v", i, l.Rotation)
+		}
+	}
+}
+
+func TestNewChainsFromPMX(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		link    pmx.IKLink
+		limited bool
+		min     math3d.Vector3
+		max     math3d.Vector3
+	}{
+		{
+			name: "knee without limits rotates freely",
+			link: pmx.IKLink{Bone: 1},
+		},
+		{
+			name:    "limits are converted to the right-handed system",
+			link:    pmx.IKLink{Bone: 1, Limited: true, Min: [3]float32{-3, -1, -0.5}, Max: [3]float32{-0.5, 1, 0.25}},
+			limited: true,
+			min:     math3d.NewVector3(0.5, -1, -0.5),
+			max:     math3d.NewVector3(3, 1, 0.25),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &pmx.Model{Bones: []pmx.Bone{
+				{Name: "足", Parent: -1},
+				{Name: "左ひざ", Parent: 0},
+				{Name: "左足首", Parent: 1},
+				{Name: "左足ＩＫ", Parent: -1, IK: &pmx.IK{Target: 2, Loops: 40, LimitAngle: 2, Links: []pmx.IKLink{tt.link}}},
+			}}
+
+			chains := NewChainsFromPMX(m)
+			if len(chains) != 1 || len(chains[0].Links) != 1 {
+				t.Fatalf("got chains %v, want a chain of a link", chains)
+			}
+			c := chains[0]
+			if c.Bone != 3 || c.Effector != 2 || c.Iterations != 40 || c.MaxAngle != 2 {
+				t.Errorf("chain = %+v", c)
+			}
+
+			l := c.Links[0]
+			if l.Axis != (math3d.Vector3{}) {
+				t.Errorf("Axis = %v, want none", l.Axis)
+			}
+			if l.Limited != tt.limited || l.Min != tt.min || l.Max != tt.max {
+				t.Errorf("limits = %v %v %v, want %v %v %v", l.Limited, l.Min, l.Max, tt.limited, tt.min, tt.max)
+			}
+		})
+	}
+}
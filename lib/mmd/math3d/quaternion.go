@@ -0,0 +1,307 @@
+package math3d
+
+import "math"
+
+// EulerOrder is order of rotations of Euler angles, same as three.js.
+// For "XYZ", the rotation matrix is Rx * Ry * Rz.
+type EulerOrder int
+
+const (
+	// XYZ is three.js default order.
+	XYZ EulerOrder = iota
+	// YXZ is yaw, pitch, and roll.
+	YXZ
+	// ZXY is order used by MMD for bone angle limits.
+	ZXY
+	// ZYX is ...
+	ZYX
+	// YZX is ...
+	YZX
+	// XZY is ...
+	XZY
+)
+
+// Quaternion is a rotation.
+type Quaternion struct {
+	X, Y, Z, W float64
+}
+
+// IdentityQuaternion returns quaternion of no rotation.
+func IdentityQuaternion() Quaternion {
+	return Quaternion{W: 1}
+}
+
+// NewQuaternionFromAxisAngle creates quaternion rotating angle radians around the normalized axis.
+func NewQuaternionFromAxisAngle(axis Vector3, angle float64) Quaternion {
+	s := math.Sin(angle / 2)
+	return Quaternion{axis.X * s, axis.Y * s, axis.Z * s, math.Cos(angle / 2)}
+}
+
+// NewQuaternionFromEuler creates quaternion from Euler angles in radians.
+func NewQuaternionFromEuler(x float64, y float64, z float64, order EulerOrder) Quaternion {
+	c1, s1 := math.Cos(x/2), math.Sin(x/2)
+	c2, s2 := math.Cos(y/2), math.Sin(y/2)
+	c3, s3 := math.Cos(z/2), math.Sin(z/2)
+
+	switch order {
+	case YXZ:
+		return Quaternion{
+			s1*c2*c3 + c1*s2*s3,
+			c1*s2*c3 - s1*c2*s3,
+			c1*c2*s3 - s1*s2*c3,
+			c1*c2*c3 + s1*s2*s3,
+		}
+	case ZXY:
+		return Quaternion{
+			s1*c2*c3 - c1*s2*s3,
+			c1*s2*c3 + s1*c2*s3,
+			c1*c2*s3 + s1*s2*c3,
+			c1*c2*c3 - s1*s2*s3,
+		}
+	case ZYX:
+		return Quaternion{
+			s1*c2*c3 - c1*s2*s3,
+			c1*s2*c3 + s1*c2*s3,
+			c1*c2*s3 - s1*s2*c3,
+			c1*c2*c3 + s1*s2*s3,
+		}
+	case YZX:
+		return Quaternion{
+			s1*c2*c3 + c1*s2*s3,
+			c1*s2*c3 + s1*c2*s3,
+			c1*c2*s3 - s1*s2*c3,
+			c1*c2*c3 - s1*s2*s3,
+		}
+	case XZY:
+		return Quaternion{
+			s1*c2*c3 - c1*s2*s3,
+			c1*s2*c3 - s1*c2*s3,
+			c1*c2*s3 + s1*s2*c3,
+			c1*c2*c3 + s1*s2*s3,
+		}
+	default:
+		return Quaternion{
+			s1*c2*c3 + c1*s2*s3,
+			c1*s2*c3 - s1*c2*s3,
+			c1*c2*s3 + s1*s2*c3,
+			c1*c2*c3 - s1*s2*s3,
+		}
+	}
+}
+
+// NewQuaternionFromUnitVectors creates quaternion rotating the unit vector from to the unit vector to.
+func NewQuaternionFromUnitVectors(from Vector3, to Vector3) Quaternion {
+	r := from.Dot(to) + 1
+	if r < 1e-12 {
+		// 逆向きの場合は直交する任意の軸で180度回転する
+		var q Quaternion
+		if math.Abs(from.X) > math.Abs(from.Z) {
+			q = Quaternion{-from.Y, from.X, 0, 0}
+		} else {
+			q = Quaternion{0, -from.Z, from.Y, 0}
+		}
+		return q.Normalize()
+	}
+
+	c := from.Cross(to)
+	return Quaternion{c.X, c.Y, c.Z, r}.Normalize()
+}
+
+// NewQuaternionFromBasis creates quaternion of the rotation matrix whose columns are the orthonormal axes x, y and z.
+func NewQuaternionFromBasis(x Vector3, y Vector3, z Vector3) Quaternion {
+
+	m11, m12, m13 := x.X, y.X, z.X
+	m21, m22, m23 := x.Y, y.Y, z.Y
+	m31, m32, m33 := x.Z, y.Z, z.Z
+
+	var q Quaternion
+	switch trace := m11 + m22 + m33; {
+	case trace > 0:
+		s := 0.5 / math.Sqrt(trace+1)
+		q = Quaternion{(m32 - m23) * s, (m13 - m31) * s, (m21 - m12) * s, 0.25 / s}
+	case m11 > m22 && m11 > m33:
+		s := 2 * math.Sqrt(1+m11-m22-m33)
+		q = Quaternion{0.25 * s, (m12 + m21) / s, (m13 + m31) / s, (m32 - m23) / s}
+	case m22 > m33:
+		s := 2 * math.Sqrt(1+m22-m11-m33)
+		q = Quaternion{(m12 + m21) / s, 0.25 * s, (m23 + m32) / s, (m13 - m31) / s}
+	default:
+		s := 2 * math.Sqrt(1+m33-m11-m22)
+		q = Quaternion{(m13 + m31) / s, (m23 + m32) / s, 0.25 * s, (m21 - m12) / s}
+	}
+
+	return q.Normalize()
+}
+
+// Mul returns q * a, which rotates by a first and then by q.
+func (q Quaternion) Mul(a Quaternion) Quaternion {
+	return Quaternion{
+		q.X*a.W + q.W*a.X + q.Y*a.Z - q.Z*a.Y,
+		q.Y*a.W + q.W*a.Y + q.Z*a.X - q.X*a.Z,
+		q.Z*a.W + q.W*a.Z + q.X*a.Y - q.Y*a.X,
+		q.W*a.W - q.X*a.X - q.Y*a.Y - q.Z*a.Z,
+	}
+}
+
+// Conjugate returns inverse rotation of the unit quaternion.
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{-q.X, -q.Y, -q.Z, q.W}
+}
+
+// Dot returns dot product.
+func (q Quaternion) Dot(a Quaternion) float64 {
+	return q.X*a.X + q.Y*a.Y + q.Z*a.Z + q.W*a.W
+}
+
+// Length returns the norm.
+func (q Quaternion) Length() float64 {
+	return math.Sqrt(q.Dot(q))
+}
+
+// Normalize returns unit quaternion.
+func (q Quaternion) Normalize() Quaternion {
+	l := q.Length()
+	if l == 0 {
+		return IdentityQuaternion()
+	}
+	return Quaternion{q.X / l, q.Y / l, q.Z / l, q.W / l}
+}
+
+// Rotate rotates the vector.
+func (q Quaternion) Rotate(v Vector3) Vector3 {
+	// t = 2 * cross(q.xyz, v), v' = v + w * t + cross(q.xyz, t)
+	u := Vector3{q.X, q.Y, q.Z}
+	t := u.Cross(v).Scale(2)
+	return v.Add(t.Scale(q.W)).Add(u.Cross(t))
+}
+
+// Slerp interpolates spherically from q to a.
+func (q Quaternion) Slerp(a Quaternion, t float64) Quaternion {
+	if t <= 0 {
+		return q
+	}
+	if t >= 1 {
+		return a
+	}
+
+	cos := q.Dot(a)
+	if cos < 0 {
+		a = Quaternion{-a.X, -a.Y, -a.Z, -a.W}
+		cos = -cos
+	}
+
+	if cos >= 1-1e-9 {
+		return Quaternion{
+			q.X + (a.X-q.X)*t,
+			q.Y + (a.Y-q.Y)*t,
+			q.Z + (a.Z-q.Z)*t,
+			q.W + (a.W-q.W)*t,
+		}.Normalize()
+	}
+
+	theta := math.Acos(cos)
+	sin := math.Sin(theta)
+	ka := math.Sin((1-t)*theta) / sin
+	kb := math.Sin(t*theta) / sin
+
+	return Quaternion{
+		q.X*ka + a.X*kb,
+		q.Y*ka + a.Y*kb,
+		q.Z*ka + a.Z*kb,
+		q.W*ka + a.W*kb,
+	}
+}
+
+// Angle returns rotation angle in radians between q and a.
+func (q Quaternion) Angle(a Quaternion) float64 {
+	d := q.Normalize().Conjugate().Mul(a.Normalize())
+	return 2 * math.Atan2(Vector3{d.X, d.Y, d.Z}.Length(), math.Abs(d.W))
+}
+
+// Euler returns Euler angles in radians in the order.
+func (q Quaternion) Euler(order EulerOrder) (x float64, y float64, z float64) {
+
+	m := q.matrix()
+	m11, m12, m13 := m[0], m[1], m[2]
+	m21, m22, m23 := m[3], m[4], m[5]
+	m31, m32, m33 := m[6], m[7], m[8]
+
+	const threshold = 0.9999999
+
+	switch order {
+	case YXZ:
+		x = math.Asin(-clamp(m23, -1, 1))
+		if math.Abs(m23) < threshold {
+			y = math.Atan2(m13, m33)
+			z = math.Atan2(m21, m22)
+		} else {
+			y = math.Atan2(-m31, m11)
+		}
+	case ZXY:
+		x = math.Asin(clamp(m32, -1, 1))
+		if math.Abs(m32) < threshold {
+			y = math.Atan2(-m31, m33)
+			z = math.Atan2(-m12, m22)
+		} else {
+			z = math.Atan2(m21, m11)
+		}
+	case ZYX:
+		y = math.Asin(-clamp(m31, -1, 1))
+		if math.Abs(m31) < threshold {
+			x = math.Atan2(m32, m33)
+			z = math.Atan2(m21, m11)
+		} else {
+			z = math.Atan2(-m12, m22)
+		}
+	case YZX:
+		z = math.Asin(clamp(m21, -1, 1))
+		if math.Abs(m21) < threshold {
+			x = math.Atan2(-m23, m22)
+			y = math.Atan2(-m31, m11)
+		} else {
+			y = math.Atan2(m13, m33)
+		}
+	case XZY:
+		z = math.Asin(-clamp(m12, -1, 1))
+		if math.Abs(m12) < threshold {
+			x = math.Atan2(m32, m22)
+			y = math.Atan2(m13, m11)
+		} else {
+			x = math.Atan2(-m23, m33)
+		}
+	default:
+		y = math.Asin(clamp(m13, -1, 1))
+		if math.Abs(m13) < threshold {
+			x = math.Atan2(-m23, m33)
+			z = math.Atan2(-m12, m11)
+		} else {
+			x = math.Atan2(m32, m22)
+		}
+	}
+
+	return x, y, z
+}
+
+// matrix returns row-major 3x3 rotation matrix.
+func (q Quaternion) matrix() [9]float64 {
+	x, y, z, w := q.X, q.Y, q.Z, q.W
+	x2, y2, z2 := x+x, y+y, z+z
+	xx, xy, xz := x*x2, x*y2, x*z2
+	yy, yz, zz := y*y2, y*z2, z*z2
+	wx, wy, wz := w*x2, w*y2, w*z2
+
+	return [9]float64{
+		1 - (yy + zz), xy - wz, xz + wy,
+		xy + wz, 1 - (xx + zz), yz - wx,
+		xz - wy, yz + wx, 1 - (xx + yy),
+	}
+}
+
+// FlipZ converts between the left-handed MMD coordinate system and the right-handed three.js one.
+func (q Quaternion) FlipZ() Quaternion {
+	return Quaternion{-q.X, -q.Y, q.Z, q.W}
+}
+
+func clamp(v float64, min float64, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
@@ -0,0 +1,82 @@
+// Package math3d is vector and rotation math for MMD data handled in Go, without three.js.
+// It follows the right-handed coordinate system of three.js unless noted.
+package math3d
+
+import "math"
+
+// Vector3 is a 3D vector.
+type Vector3 struct {
+	X, Y, Z float64
+}
+
+// NewVector3 creates Vector3.
+func NewVector3(x float64, y float64, z float64) Vector3 {
+	return Vector3{X: x, Y: y, Z: z}
+}
+
+// Add returns v + a.
+func (v Vector3) Add(a Vector3) Vector3 {
+	return Vector3{v.X + a.X, v.Y + a.Y, v.Z + a.Z}
+}
+
+// Sub returns v - a.
+func (v Vector3) Sub(a Vector3) Vector3 {
+	return Vector3{v.X - a.X, v.Y - a.Y, v.Z - a.Z}
+}
+
+// Scale returns v * s.
+func (v Vector3) Scale(s float64) Vector3 {
+	return Vector3{v.X * s, v.Y * s, v.Z * s}
+}
+
+// Mul returns component-wise product.
+func (v Vector3) Mul(a Vector3) Vector3 {
+	return Vector3{v.X * a.X, v.Y * a.Y, v.Z * a.Z}
+}
+
+// Dot returns dot product.
+func (v Vector3) Dot(a Vector3) float64 {
+	return v.X*a.X + v.Y*a.Y + v.Z*a.Z
+}
+
+// Cross returns cross product.
+func (v Vector3) Cross(a Vector3) Vector3 {
+	return Vector3{
+		v.Y*a.Z - v.Z*a.Y,
+		v.Z*a.X - v.X*a.Z,
+		v.X*a.Y - v.Y*a.X,
+	}
+}
+
+// Length returns Euclidean length.
+func (v Vector3) Length() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+// Normalize returns unit vector. Zero vector is returned as is.
+func (v Vector3) Normalize() Vector3 {
+	l := v.Length()
+	if l == 0 {
+		return v
+	}
+	return v.Scale(1 / l)
+}
+
+// Lerp interpolates from v to a.
+func (v Vector3) Lerp(a Vector3, t float64) Vector3 {
+	return Vector3{
+		v.X + (a.X-v.X)*t,
+		v.Y + (a.Y-v.Y)*t,
+		v.Z + (a.Z-v.Z)*t,
+	}
+}
+
+// Distance returns distance between v and a.
+func (v Vector3) Distance(a Vector3) float64 {
+	return v.Sub(a).Length()
+}
+
+// FlipZ converts between the left-handed MMD coordinate system and the right-handed three.js one.
+func (v Vector3) FlipZ() Vector3 {
+	return Vector3{v.X, v.Y, -v.Z}
+}
@@ -0,0 +1,137 @@
+// Package mmdcamera converts between MMD camera parameters and three.js camera transforms.
+//
+// An MMD camera is described by a target point, a distance from it, Euler rotation and a FOV,
+// in the left-handed MMD coordinate system.
+// A three.js camera is described by its position, the point it looks at and the up vector.
+package mmdcamera
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/vmd"
+	"math"
+)
+
+// DefaultFov is FOV of the MMD camera in its initial state.
+const DefaultFov = 30
+
+// CameraRig is a camera described by MMD camera parameters.
+type CameraRig struct {
+	// Target is the point the camera orbits around, in MMD coordinates.
+	Target math3d.Vector3
+	// Distance from the target. It is negative when the camera is in front of the target, as in MMD.
+	Distance float64
+	// Rotation is Euler angles in radians, as in MMD.
+	Rotation math3d.Vector3
+	// Fov is vertical field of view in degrees.
+	Fov float64
+	// Orthographic is true when perspective is turned off.
+	Orthographic bool
+}
+
+// Transform is a camera pose in the right-handed three.js coordinate system.
+type Transform struct {
+	Position math3d.Vector3
+	// Target is the point the camera looks at.
+	Target math3d.Vector3
+	Up     math3d.Vector3
+	// Fov is vertical field of view in degrees.
+	Fov float64
+}
+
+// NewCameraRig creates CameraRig in the initial state of MMD.
+func NewCameraRig() *CameraRig {
+	return &CameraRig{
+		Target:   math3d.NewVector3(0, 10, 0),
+		Distance: -45,
+		Fov:      DefaultFov,
+	}
+}
+
+// NewCameraRigFromFrame creates CameraRig from a VMD camera keyframe.
+func NewCameraRigFromFrame(f vmd.CameraFrame) *CameraRig {
+	return &CameraRig{
+		Target:       math3d.NewVector3(float64(f.Position[0]), float64(f.Position[1]), float64(f.Position[2])),
+		Distance:     float64(f.Distance),
+		Rotation:     math3d.NewVector3(float64(f.Rotation[0]), float64(f.Rotation[1]), float64(f.Rotation[2])),
+		Fov:          float64(f.Fov),
+		Orthographic: f.Orthographic,
+	}
+}
+
+// NewCameraRigFromTransform creates CameraRig from a three.js camera pose.
+// The distance is always negative, so the camera looks at the target.
+func NewCameraRigFromTransform(t Transform) *CameraRig {
+
+	offset := t.Position.Sub(t.Target)
+	d := offset.Length()
+
+	// カメラ座標系の軸を求める。Z軸は注視点からカメラへ向かう方向
+	z := math3d.NewVector3(0, 0, 1)
+	if d > 1e-9 {
+		z = offset.Scale(1 / d)
+	}
+	x := t.Up.Cross(z)
+	if x.Length() < 1e-9 {
+		// 真上または真下を向いている場合は任意の直交軸を使う
+		x = math3d.NewVector3(0, 0, -1).Cross(z)
+		if x.Length() < 1e-9 {
+			x = math3d.NewVector3(1, 0, 0)
+		}
+	}
+	x = x.Normalize()
+	y := z.Cross(x)
+
+	q := math3d.NewQuaternionFromBasis(x, y, z)
+	ex, ey, ez := q.Euler(math3d.XYZ)
+
+	return &CameraRig{
+		Target:   t.Target.FlipZ(),
+		Distance: -d,
+		Rotation: math3d.NewVector3(ex, ey, -ez),
+		Fov:      t.Fov,
+	}
+}
+
+// rotation gets the rotation of the rig in three.js coordinates.
+func (r *CameraRig) rotation() math3d.Quaternion {
+	return math3d.NewQuaternionFromEuler(r.Rotation.X, r.Rotation.Y, -r.Rotation.Z, math3d.XYZ)
+}
+
+// Transform gets the three.js camera pose of the rig.
+func (r *CameraRig) Transform() Transform {
+
+	q := r.rotation()
+	target := r.Target.FlipZ()
+
+	return Transform{
+		Position: target.Add(q.Rotate(math3d.NewVector3(0, 0, -r.Distance))),
+		Target:   target,
+		Up:       q.Rotate(math3d.NewVector3(0, 1, 0)),
+		Fov:      r.Fov,
+	}
+}
+
+// Unwrap adds multiples of 2π to the rotation to make it closest to the previous rig.
+// MMD interpolates Euler angles linearly, so call it before recording consecutive keyframes.
+func (r *CameraRig) Unwrap(prev *CameraRig) {
+	r.Rotation.X = unwrap(r.Rotation.X, prev.Rotation.X)
+	r.Rotation.Y = unwrap(r.Rotation.Y, prev.Rotation.Y)
+	r.Rotation.Z = unwrap(r.Rotation.Z, prev.Rotation.Z)
+}
+
+func unwrap(v float64, prev float64) float64 {
+	return v + 2*math.Pi*math.Round((prev-v)/(2*math.Pi))
+}
+
+// Keyframe gets a VMD camera keyframe of the rig with linear interpolation.
+func (r *CameraRig) Keyframe(frame uint32) vmd.CameraFrame {
+	return vmd.CameraFrame{
+		Frame:            frame,
+		Distance:         float32(r.Distance),
+		Position:         [3]float32{float32(r.Target.X), float32(r.Target.Y), float32(r.Target.Z)},
+		Rotation:         [3]float32{float32(r.Rotation.X), float32(r.Rotation.Y), float32(r.Rotation.Z)},
+		RawInterpolation: vmd.LinearCameraInterpolation,
+		Fov:              uint32(math.Max(1, math.Round(r.Fov))),
+		Orthographic:     r.Orthographic,
+	}
+}
@@ -0,0 +1,54 @@
+//go:build js && wasm
+// +build js,wasm
+
+package mmdcamera
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/vmd"
+	"app/lib/threejs"
+	"app/lib/threejs/camera"
+	"app/lib/threejs/control"
+)
+
+// Apply moves the camera to the pose of the rig.
+func (r *CameraRig) Apply(c camera.PerspectiveCamera) {
+
+	t := r.Transform()
+
+	c.Up().Set2(t.Up.X, t.Up.Y, t.Up.Z)
+	c.Position().Set2(t.Position.X, t.Position.Y, t.Position.Z)
+	c.LookAtXYZ(t.Target.X, t.Target.Y, t.Target.Z)
+
+	if c.Fov() != t.Fov {
+		c.SetFov(t.Fov)
+		c.UpdateProjectionMatrix()
+	}
+}
+
+// ApplyToView moves the camera to the pose of the rig and sets the orbit target to the rig target.
+func (r *CameraRig) ApplyToView(c camera.PerspectiveCamera, ctrl control.OrbitControls) {
+	r.Apply(c)
+
+	t := r.Transform()
+	ctrl.Target().Set2(t.Target.X, t.Target.Y, t.Target.Z)
+}
+
+// NewCameraRigFromView creates CameraRig from the current view of the camera orbiting the control target.
+func NewCameraRigFromView(c camera.PerspectiveCamera, ctrl control.OrbitControls) *CameraRig {
+	return NewCameraRigFromTransform(Transform{
+		Position: vector3(c.Position()),
+		Target:   vector3(ctrl.Target()),
+		Up:       vector3(c.Up()),
+		Fov:      c.Fov(),
+	})
+}
+
+// SnapshotView gets a VMD camera keyframe of the current view.
+func SnapshotView(c camera.PerspectiveCamera, ctrl control.OrbitControls, frame uint32) vmd.CameraFrame {
+	return NewCameraRigFromView(c, ctrl).Keyframe(frame)
+}
+
+func vector3(v *threejs.Vector3) math3d.Vector3 {
+	return math3d.NewVector3(v.X(), v.Y(), v.Z())
+}
@@ -0,0 +1,104 @@
+package mmdcamera
+
+import (
+	"app/lib/mmd/math3d"
+	"math"
+	"testing"
+)
+
+func TestNewCameraRigFromTransform(t *testing.T) {
+
+	// 同じ向きを表す角度のうち、Yが[-π/2, π/2]のものが返る
+	tests := []struct {
+		name string
+		rig  CameraRig
+	}{
+		{
+			name: "initial state",
+			rig:  *NewCameraRig(),
+		},
+		{
+			name: "orbit",
+			rig:  CameraRig{Target: math3d.NewVector3(1, 12, -3), Distance: -30, Rotation: math3d.NewVector3(0.3, -1.2, 0), Fov: 45},
+		},
+		{
+			name: "roll",
+			rig:  CameraRig{Target: math3d.NewVector3(0, 10, 0), Distance: -45, Rotation: math3d.NewVector3(0.2, 0.5, 0.7), Fov: 30},
+		},
+		{
+			name: "roll upside down",
+			rig:  CameraRig{Target: math3d.NewVector3(0, 10, 0), Distance: -20, Rotation: math3d.NewVector3(2.8, 0.5, -2.8), Fov: 30},
+		},
+		{
+			// 真下から見上げる
+			name: "looking straight up",
+			rig:  CameraRig{Target: math3d.NewVector3(0, 15, 0), Distance: -25, Rotation: math3d.NewVector3(math.Pi/2, 0, 0), Fov: 30},
+		},
+		{
+			name: "looking straight down with roll",
+			rig:  CameraRig{Target: math3d.NewVector3(2, 0, 1), Distance: -25, Rotation: math3d.NewVector3(-math.Pi/2, 0, 0.6), Fov: 30},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewCameraRigFromTransform(tt.rig.Transform())
+
+			if d := got.Target.Distance(tt.rig.Target); d > 1e-9 {
+				t.Errorf("Target = %v, want %v", got.Target, tt.rig.Target)
+			}
+			if math.Abs(got.Distance-tt.rig.Distance) > 1e-9 {
+				t.Errorf("Distance = %v, want %v", got.Distance, tt.rig.Distance)
+			}
+			if got.Fov != tt.rig.Fov {
+				t.Errorf("Fov = %v, want %v", got.Fov, tt.rig.Fov)
+			}
+			for _, a := range [][2]float64{
+				{got.Rotation.X, tt.rig.Rotation.X},
+				{got.Rotation.Y, tt.rig.Rotation.Y},
+				{got.Rotation.Z, tt.rig.Rotation.Z},
+			} {
+				if math.Abs(unwrap(a[0], a[1])-a[1]) > 1e-9 {
+					t.Errorf("Rotation = %v, want %v", got.Rotation, tt.rig.Rotation)
+					break
+				}
+			}
+		})
+	}
+}
+
+// TestNewCameraRigFromTransformUp converts poses of three.js cameras whose up is +Y, including those looking straight up or down.
+func TestNewCameraRigFromTransformUp(t *testing.T) {
+
+	up := math3d.NewVector3(0, 1, 0)
+	tests := []struct {
+		name      string
+		transform Transform
+	}{
+		{"front", Transform{Position: math3d.NewVector3(0, 10, 45), Target: math3d.NewVector3(0, 10, 0), Up: up, Fov: 30}},
+		{"side", Transform{Position: math3d.NewVector3(-30, 12, 0), Target: math3d.NewVector3(0, 10, 0), Up: up, Fov: 30}},
+		{"straight up", Transform{Position: math3d.NewVector3(0, -20, 0), Target: math3d.NewVector3(0, 10, 0), Up: up, Fov: 30}},
+		{"straight down", Transform{Position: math3d.NewVector3(1, 40, 2), Target: math3d.NewVector3(1, 10, 2), Up: up, Fov: 30}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewCameraRigFromTransform(tt.transform).Transform()
+
+			if d := got.Position.Distance(tt.transform.Position); d > 1e-9 || math.IsNaN(d) {
+				t.Errorf("Position = %v, want %v", got.Position, tt.transform.Position)
+			}
+			if d := got.Target.Distance(tt.transform.Target); d > 1e-9 {
+				t.Errorf("Target = %v, want %v", got.Target, tt.transform.Target)
+			}
+			// 上方向は視線に直交するように傾けたものになる
+			forward := tt.transform.Target.Sub(tt.transform.Position).Normalize()
+			if d := got.Up.Dot(forward); math.Abs(d) > 1e-9 || math.Abs(got.Up.Length()-1) > 1e-9 {
+				t.Errorf("Up = %v is not a unit vector perpendicular to %v", got.Up, forward)
+			}
+			if math.Abs(1-math.Abs(forward.Y)) > 1e-9 && got.Up.Dot(tt.transform.Up) <= 0 {
+				t.Errorf("Up = %v, want toward %v", got.Up, tt.transform.Up)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package pmx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Credits is the author and the terms of use found in the comments of a model.
+// Comments are free text, so they are picked by keywords, and the comments themselves should be shown with them.
+type Credits struct {
+	// Author is the creator of the model, or empty if the comments do not say.
+	Author string
+	// Terms are lines of the comments about the terms of use, such as permissions and prohibitions.
+	Terms []string
+}
+
+// authorLabels are labels which precede the author at the start of a line, such as "作者：" and "Author:".
+var authorLabels = []string{
+	"モデル制作者", "モデル製作者", "モデル作成者", "モデル制作", "モデル製作", "モデル作成", "モデリング",
+	"制作者", "製作者", "作成者", "制作", "製作", "作成", "作者",
+	"model by", "modeled by", "modelled by", "created by", "made by", "author",
+}
+
+// labelSeparators are characters between a label and the author.
+const labelSeparators = " 　:：・/／=＝-－」』】]）)>＞"
+
+// lineDecorations are bullets and brackets before labels, such as "・作者：" and "【作者】".
+const lineDecorations = " 　・■□◆◇●○★☆※*＊-－#＃「『【[（(<＜"
+
+// termKeywords are words in lines about the terms of use.
+var termKeywords = []string{
+	"規約", "利用", "使用", "禁止", "許可", "許諾", "改変", "配布", "商用", "クレジット", "readme", "りーどみー",
+	"license", "terms", "prohibit", "permitted", "allowed", "commercial", "credit", "redistribut",
+}
+
+// Credits finds the author and the terms of use in the Japanese and English comments.
+func (m *Model) Credits() Credits {
+	return FindCredits(m.Comment + "\n" + m.EnglishComment)
+}
+
+// FindCredits finds the author and the terms of use in text, such as comments of a model or its readme.
+func FindCredits(text string) Credits {
+
+	var c Credits
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if c.Author == "" {
+			if author, ok := findAuthor(line); ok {
+				c.Author = author
+				continue
+			}
+		}
+		lower := strings.ToLower(line)
+		for _, k := range termKeywords {
+			if strings.Contains(lower, k) {
+				c.Terms = append(c.Terms, line)
+				break
+			}
+		}
+	}
+	return c
+}
+
+// DecodeReadme reads a readme distributed with a model and finds the credits in it.
+// The text is read as Shift_JIS as most readmes of MMD models are written, or as UTF-8 if it is valid UTF-8.
+func DecodeReadme(r io.Reader) (Credits, error) {
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Credits{}, err
+	}
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+
+	// Shift_JISの日本語がUTF-8として正しいことはまずない
+	if !utf8.Valid(b) {
+		if b, err = japanese.ShiftJIS.NewDecoder().Bytes(b); err != nil {
+			return Credits{}, fmt.Errorf("pmx: invalid readme text: %w", err)
+		}
+	}
+	return FindCredits(string(b)), nil
+}
+
+// findAuthor gets the text after an author label at the start of the line.
+func findAuthor(line string) (string, bool) {
+
+	s := strings.TrimLeft(line, lineDecorations)
+	for _, k := range authorLabels {
+		if len(s) <= len(k) || !strings.EqualFold(s[:len(k)], k) {
+			continue
+		}
+		// 「作成日」のように続く語がある場合はラベルではない
+		rest := s[len(k):]
+		if r, _ := utf8.DecodeRuneInString(rest); !strings.ContainsRune(labelSeparators, r) {
+			continue
+		}
+		author := strings.TrimLeft(rest, labelSeparators)
+		author = strings.TrimRight(author, " 　「『【(（")
+		if author != "" {
+			return author, true
+		}
+	}
+	return "", false
+}
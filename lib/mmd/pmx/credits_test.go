@@ -0,0 +1,119 @@
+package pmx
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestFindCredits(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		text   string
+		author string
+		terms  []string
+	}{
+		{
+			name:   "label with a colon",
+			text:   "作者：山田\r\n改変は自由です",
+			author: "山田",
+			terms:  []string{"改変は自由です"},
+		},
+		{
+			name:   "label in brackets",
+			text:   "【モデル制作】 山田",
+			author: "山田",
+		},
+		{
+			name:   "bulleted label",
+			text:   "・作成者 = 山田",
+			author: "山田",
+		},
+		{
+			name:   "English label",
+			text:   "Model by Yamada\nCommercial use is prohibited.",
+			author: "Yamada",
+			terms:  []string{"Commercial use is prohibited."},
+		},
+		{
+			name:   "lowercase of the line changes its length",
+			text:   "AUTHOR: İzmir",
+			author: "İzmir",
+		},
+		{
+			name: "label followed by another word",
+			text: "作成日: 2020/01/01\n制作環境: PMXEditor",
+		},
+		{
+			name: "keyword in the middle of a line",
+			text: "このモデルは山田が作成しました",
+		},
+		{
+			name:   "first author only",
+			text:   "作者: 山田\n作者: 田中",
+			author: "山田",
+		},
+		{
+			name:  "label without an author",
+			text:  "作者：\n利用規約はreadmeを読んでください",
+			terms: []string{"利用規約はreadmeを読んでください"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := FindCredits(tt.text)
+			if c.Author != tt.author {
+				t.Errorf("Author = %q, want %q", c.Author, tt.author)
+			}
+			if !reflect.DeepEqual(c.Terms, tt.terms) {
+				t.Errorf("Terms = %q, want %q", c.Terms, tt.terms)
+			}
+		})
+	}
+}
+
+func TestModelCredits(t *testing.T) {
+
+	m := &Model{Comment: "作者：山田", EnglishComment: "Author: Yamada\nNo redistribution."}
+	c := m.Credits()
+	if c.Author != "山田" {
+		t.Errorf("Author = %q, want the Japanese one", c.Author)
+	}
+	if !reflect.DeepEqual(c.Terms, []string{"No redistribution."}) {
+		t.Errorf("Terms = %q", c.Terms)
+	}
+}
+
+func TestDecodeReadme(t *testing.T) {
+
+	text := "作者：山田\r\n商用利用は禁止です\r\n"
+	sjis, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"Shift_JIS", sjis},
+		{"UTF-8", []byte(text)},
+		{"UTF-8 with BOM", append([]byte("\xef\xbb\xbf"), text...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := DecodeReadme(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Author != "山田" || !reflect.DeepEqual(c.Terms, []string{"商用利用は禁止です"}) {
+				t.Errorf("DecodeReadme() = %+v", c)
+			}
+		})
+	}
+}
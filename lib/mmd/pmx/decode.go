@@ -0,0 +1,517 @@
+package pmx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"unicode/utf16"
+)
+
+// headerSignature is the signature at the beginning of PMX files.
+const headerSignature = "PMX "
+
+var (
+	// ErrInvalidHeader is returned when the data is not a PMX file.
+	ErrInvalidHeader = errors.New("pmx: invalid header")
+	// ErrUnsupportedVersion is returned for versions other than 2.0 and 2.1.
+	ErrUnsupportedVersion = errors.New("pmx: unsupported version")
+)
+
+// globals is the data sizes declared in the header.
+type globals struct {
+	utf8          bool
+	additionalUVs int
+	vertexIndex   int
+	textureIndex  int
+	materialIndex int
+	boneIndex     int
+	morphIndex    int
+	bodyIndex     int
+}
+
+// Decode reads a PMX file. Soft bodies of PMX 2.1 are not read.
+func Decode(r io.Reader) (*Model, error) {
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 9 || !bytes.HasPrefix(b, []byte(headerSignature)) {
+		return nil, ErrInvalidHeader
+	}
+
+	d := &decoder{b: b, pos: 4}
+	m := &Model{Version: d.float()}
+	if m.Version < 2 || m.Version > 2.1+1e-4 {
+		return nil, ErrUnsupportedVersion
+	}
+
+	// 後続のデータの大きさはヘッダーで宣言される
+	n := int(d.byte())
+	g := d.bytes(n)
+	if d.err == nil && n < 8 {
+		return nil, fmt.Errorf("pmx: too short globals: %d", n)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	d.g = globals{
+		utf8:          g[0] == 1,
+		additionalUVs: int(g[1]),
+		vertexIndex:   int(g[2]),
+		textureIndex:  int(g[3]),
+		materialIndex: int(g[4]),
+		boneIndex:     int(g[5]),
+		morphIndex:    int(g[6]),
+		bodyIndex:     int(g[7]),
+	}
+	if d.g.additionalUVs > 4 {
+		return nil, fmt.Errorf("pmx: too many additional UVs: %d", d.g.additionalUVs)
+	}
+	for _, size := range g[2:8] {
+		if size != 1 && size != 2 && size != 4 {
+			return nil, fmt.Errorf("pmx: invalid index size: %d", size)
+		}
+	}
+	m.AdditionalUVs = d.g.additionalUVs
+
+	m.Name = d.text()
+	m.EnglishName = d.text()
+	m.Comment = d.text()
+	m.EnglishComment = d.text()
+
+	m.Vertices = d.vertices()
+	m.Indices = d.indices()
+	m.Textures = d.textures()
+	m.Materials = d.materials()
+	m.Bones = d.bones()
+	m.Morphs = d.morphs()
+	m.DisplayFrames = d.displayFrames()
+	m.RigidBodies = d.rigidBodies()
+	m.Joints = d.joints()
+
+	if d.err != nil {
+		return nil, d.err
+	}
+	return m, nil
+}
+
+// decoder keeps the first error, so that reads can be chained.
+// Values read after an error are zero.
+type decoder struct {
+	b   []byte
+	pos int
+	g   globals
+	err error
+}
+
+// bytes reads n bytes. It returns nil after an error, or if n is negative or runs past the data.
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.b)-d.pos < n {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := d.b[d.pos : d.pos+n]
+	d.pos += n
+	return b
+}
+
+// fixed reads a value of n bytes, which are zero after an error.
+func (d *decoder) fixed(n int) []byte {
+	if b := d.bytes(n); b != nil {
+		return b
+	}
+	return make([]byte, n)
+}
+
+func (d *decoder) byte() byte {
+	return d.fixed(1)[0]
+}
+
+func (d *decoder) int32() int32 {
+	return int32(binary.LittleEndian.Uint32(d.fixed(4)))
+}
+
+func (d *decoder) float() float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(d.fixed(4)))
+}
+
+func (d *decoder) floats(dst []float32) {
+	for i := range dst {
+		dst[i] = d.float()
+	}
+}
+
+// count reads the number of records, each of which has at least minLength bytes.
+func (d *decoder) count(minLength int) int {
+	n := int(d.int32())
+	// 壊れたファイルで巨大な領域を確保しないよう、残りの長さで上限を確かめる
+	if d.err == nil && (n < 0 || n > (len(d.b)-d.pos)/minLength) {
+		d.err = fmt.Errorf("pmx: invalid count: %d", n)
+	}
+	if d.err != nil {
+		return 0
+	}
+	return n
+}
+
+// index reads a signed index of the size. -1 refers to nothing.
+func (d *decoder) index(size int) int {
+	switch size {
+	case 1:
+		return int(int8(d.byte()))
+	case 2:
+		return int(int16(binary.LittleEndian.Uint16(d.fixed(2))))
+	default:
+		return int(d.int32())
+	}
+}
+
+// vertexIndex reads a vertex index, which is unsigned unless it has 4 bytes.
+func (d *decoder) vertexIndex() int {
+	switch d.g.vertexIndex {
+	case 1:
+		return int(d.byte())
+	case 2:
+		return int(binary.LittleEndian.Uint16(d.fixed(2)))
+	default:
+		return int(d.int32())
+	}
+}
+
+func (d *decoder) text() string {
+	n := int(d.int32())
+	if d.err != nil {
+		return ""
+	}
+	if n < 0 || n > len(d.b)-d.pos {
+		d.err = fmt.Errorf("pmx: invalid text length: %d", n)
+		return ""
+	}
+	b := d.bytes(n)
+	if d.g.utf8 {
+		return string(b)
+	}
+
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u))
+}
+
+func (d *decoder) vertices() []Vertex {
+
+	n := d.count(4 * 9)
+	vertices := make([]Vertex, n)
+	for i := range vertices {
+		v := &vertices[i]
+		d.floats(v.Position[:])
+		d.floats(v.Normal[:])
+		d.floats(v.UV[:])
+		for j := 0; j < d.g.additionalUVs; j++ {
+			d.floats(v.AdditionalUVs[j][:])
+		}
+
+		v.Deform = DeformType(d.byte())
+		v.Bones = [4]int{-1, -1, -1, -1}
+		switch v.Deform {
+		case BDEF1:
+			v.Bones[0] = d.index(d.g.boneIndex)
+			v.Weights[0] = 1
+		case BDEF2, SDEF:
+			v.Bones[0] = d.index(d.g.boneIndex)
+			v.Bones[1] = d.index(d.g.boneIndex)
+			v.Weights[0] = d.float()
+			v.Weights[1] = 1 - v.Weights[0]
+			if v.Deform == SDEF {
+				d.floats(v.SDEFC[:])
+				d.floats(v.SDEFR0[:])
+				d.floats(v.SDEFR1[:])
+			}
+		case BDEF4, QDEF:
+			for j := range v.Bones {
+				v.Bones[j] = d.index(d.g.boneIndex)
+			}
+			d.floats(v.Weights[:])
+		default:
+			if d.err == nil {
+				d.err = fmt.Errorf("pmx: invalid deform type: %d", v.Deform)
+			}
+			return nil
+		}
+
+		v.EdgeScale = d.float()
+	}
+
+	return vertices
+}
+
+func (d *decoder) indices() []int {
+	n := d.count(d.g.vertexIndex)
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = d.vertexIndex()
+	}
+	return indices
+}
+
+func (d *decoder) textures() []string {
+	n := d.count(4)
+	textures := make([]string, n)
+	for i := range textures {
+		textures[i] = d.text()
+	}
+	return textures
+}
+
+func (d *decoder) materials() []Material {
+
+	n := d.count(4 * 18)
+	materials := make([]Material, n)
+	for i := range materials {
+		m := &materials[i]
+		m.Name = d.text()
+		m.EnglishName = d.text()
+		d.floats(m.Diffuse[:])
+		d.floats(m.Specular[:])
+		m.SpecularPower = d.float()
+		d.floats(m.Ambient[:])
+		m.Flags = MaterialFlag(d.byte())
+		d.floats(m.EdgeColor[:])
+		m.EdgeSize = d.float()
+		m.Texture = d.index(d.g.textureIndex)
+		m.SphereTexture = d.index(d.g.textureIndex)
+		m.SphereMode = SphereMode(d.byte())
+
+		// 共有トゥーンの場合は番号が1バイトで続く
+		m.SharedToon = d.byte() == 1
+		if m.SharedToon {
+			m.Toon = int(d.byte())
+		} else {
+			m.Toon = d.index(d.g.textureIndex)
+		}
+
+		m.Memo = d.text()
+		m.IndexCount = int(d.int32())
+	}
+
+	return materials
+}
+
+func (d *decoder) bones() []Bone {
+
+	n := d.count(4 * 6)
+	bones := make([]Bone, n)
+	for i := range bones {
+		b := &bones[i]
+		b.Name = d.text()
+		b.EnglishName = d.text()
+		d.floats(b.Position[:])
+		b.Parent = d.index(d.g.boneIndex)
+		b.Layer = int(d.int32())
+		b.Flags = BoneFlag(binary.LittleEndian.Uint16(d.fixed(2)))
+
+		b.TailBone = -1
+		if b.Flags&BoneTailIsBone != 0 {
+			b.TailBone = d.index(d.g.boneIndex)
+		} else {
+			d.floats(b.TailOffset[:])
+		}
+
+		b.InheritParent = -1
+		if b.Flags&(BoneInheritRotation|BoneInheritTranslation) != 0 {
+			b.InheritParent = d.index(d.g.boneIndex)
+			b.InheritWeight = d.float()
+		}
+		if b.Flags&BoneFixedAxis != 0 {
+			d.floats(b.FixedAxis[:])
+		}
+		if b.Flags&BoneLocalAxis != 0 {
+			d.floats(b.LocalX[:])
+			d.floats(b.LocalZ[:])
+		}
+		if b.Flags&BoneExternalParent != 0 {
+			b.ExternalKey = int(d.int32())
+		}
+
+		if b.Flags&BoneIK != 0 {
+			ik := &IK{
+				Target:     d.index(d.g.boneIndex),
+				Loops:      int(d.int32()),
+				LimitAngle: d.float(),
+			}
+			links := d.count(d.g.boneIndex + 1)
+			ik.Links = make([]IKLink, links)
+			for j := range ik.Links {
+				l := &ik.Links[j]
+				l.Bone = d.index(d.g.boneIndex)
+				l.Limited = d.byte() == 1
+				if l.Limited {
+					d.floats(l.Min[:])
+					d.floats(l.Max[:])
+				}
+			}
+			b.IK = ik
+		}
+	}
+
+	return bones
+}
+
+func (d *decoder) morphs() []Morph {
+
+	n := d.count(4 * 3)
+	morphs := make([]Morph, n)
+	for i := range morphs {
+		m := &morphs[i]
+		m.Name = d.text()
+		m.EnglishName = d.text()
+		m.Panel = int(d.byte())
+		m.Type = MorphType(d.byte())
+
+		switch m.Type {
+		case GroupMorph, FlipMorph:
+			m.Groups = make([]GroupOffset, d.count(d.g.morphIndex+4))
+			for j := range m.Groups {
+				m.Groups[j] = GroupOffset{Morph: d.index(d.g.morphIndex), Weight: d.float()}
+			}
+		case VertexMorph:
+			m.Vertices = make([]VertexOffset, d.count(d.g.vertexIndex+4*3))
+			for j := range m.Vertices {
+				o := &m.Vertices[j]
+				o.Vertex = d.vertexIndex()
+				d.floats(o.Offset[:])
+			}
+		case BoneMorph:
+			m.Bones = make([]BoneOffset, d.count(d.g.boneIndex+4*7))
+			for j := range m.Bones {
+				o := &m.Bones[j]
+				o.Bone = d.index(d.g.boneIndex)
+				d.floats(o.Translation[:])
+				d.floats(o.Rotation[:])
+			}
+		case UVMorph, AdditionalUV1Morph, AdditionalUV2Morph, AdditionalUV3Morph, AdditionalUV4Morph:
+			m.UVs = make([]UVOffset, d.count(d.g.vertexIndex+4*4))
+			for j := range m.UVs {
+				o := &m.UVs[j]
+				o.Vertex = d.vertexIndex()
+				d.floats(o.Offset[:])
+			}
+		case MaterialMorph:
+			m.Materials = make([]MaterialOffset, d.count(d.g.materialIndex+1+4*28))
+			for j := range m.Materials {
+				o := &m.Materials[j]
+				o.Material = d.index(d.g.materialIndex)
+				o.Add = d.byte() == 1
+				d.floats(o.Diffuse[:])
+				d.floats(o.Specular[:])
+				o.SpecularPower = d.float()
+				d.floats(o.Ambient[:])
+				d.floats(o.EdgeColor[:])
+				o.EdgeSize = d.float()
+				d.floats(o.Texture[:])
+				d.floats(o.SphereTexture[:])
+				d.floats(o.Toon[:])
+			}
+		case ImpulseMorph:
+			m.Impulses = make([]ImpulseOffset, d.count(d.g.bodyIndex+1+4*6))
+			for j := range m.Impulses {
+				o := &m.Impulses[j]
+				o.RigidBody = d.index(d.g.bodyIndex)
+				o.Local = d.byte() == 1
+				d.floats(o.Velocity[:])
+				d.floats(o.Torque[:])
+			}
+		default:
+			if d.err == nil {
+				d.err = fmt.Errorf("pmx: invalid morph type: %d", m.Type)
+			}
+			return nil
+		}
+	}
+
+	return morphs
+}
+
+func (d *decoder) displayFrames() []DisplayFrame {
+
+	n := d.count(4*3 + 1)
+	frames := make([]DisplayFrame, n)
+	for i := range frames {
+		f := &frames[i]
+		f.Name = d.text()
+		f.EnglishName = d.text()
+		f.Special = d.byte() == 1
+
+		f.Elements = make([]DisplayElement, d.count(2))
+		for j := range f.Elements {
+			e := &f.Elements[j]
+			e.Morph = d.byte() == 1
+			if e.Morph {
+				e.Index = d.index(d.g.morphIndex)
+			} else {
+				e.Index = d.index(d.g.boneIndex)
+			}
+		}
+	}
+
+	return frames
+}
+
+func (d *decoder) rigidBodies() []RigidBody {
+
+	n := d.count(4 * 17)
+	bodies := make([]RigidBody, n)
+	for i := range bodies {
+		b := &bodies[i]
+		b.Name = d.text()
+		b.EnglishName = d.text()
+		b.Bone = d.index(d.g.boneIndex)
+		b.Group = int(d.byte())
+		b.NoCollision = binary.LittleEndian.Uint16(d.fixed(2))
+		b.Shape = RigidBodyShape(d.byte())
+		d.floats(b.Size[:])
+		d.floats(b.Position[:])
+		d.floats(b.Rotation[:])
+		b.Mass = d.float()
+		b.LinearDamping = d.float()
+		b.AngularDamping = d.float()
+		b.Restitution = d.float()
+		b.Friction = d.float()
+		b.Mode = RigidBodyMode(d.byte())
+	}
+
+	return bodies
+}
+
+func (d *decoder) joints() []Joint {
+
+	n := d.count(4 * 26)
+	joints := make([]Joint, n)
+	for i := range joints {
+		j := &joints[i]
+		j.Name = d.text()
+		j.EnglishName = d.text()
+		j.Type = int(d.byte())
+		j.RigidBodyA = d.index(d.g.bodyIndex)
+		j.RigidBodyB = d.index(d.g.bodyIndex)
+		d.floats(j.Position[:])
+		d.floats(j.Rotation[:])
+		d.floats(j.PositionMin[:])
+		d.floats(j.PositionMax[:])
+		d.floats(j.RotationMin[:])
+		d.floats(j.RotationMax[:])
+		d.floats(j.SpringPosition[:])
+		d.floats(j.SpringRotation[:])
+	}
+
+	return joints
+}
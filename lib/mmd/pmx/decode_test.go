@@ -0,0 +1,75 @@
+package pmx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// header builds the header of a PMX 2.0 file with UTF-16 texts and 4 byte indices.
+func header() []byte {
+	var b bytes.Buffer
+	b.WriteString(headerSignature)
+	binary.Write(&b, binary.LittleEndian, math.Float32bits(2))
+	b.WriteByte(8)
+	b.Write([]byte{0, 0, 4, 4, 4, 4, 4, 4})
+	return b.Bytes()
+}
+
+func int32s(values ...int32) []byte {
+	var b bytes.Buffer
+	for _, v := range values {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	return b.Bytes()
+}
+
+func TestDecodeMalformed(t *testing.T) {
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"signature only", []byte(headerSignature)},
+		{"no globals", append([]byte(headerSignature), 0, 0, 0, 0x40)},
+		{"no name", header()},
+		{"negative text length", append(header(), int32s(-5)...)},
+		{"minimum text length", append(header(), int32s(math.MinInt32)...)},
+		{"oversized text length", append(header(), int32s(math.MaxInt32)...)},
+		{"text past the end", append(header(), append(int32s(8), 'a', 0)...)},
+		{"negative vertex count", append(header(), int32s(0, 0, 0, 0, -1)...)},
+		{"oversized vertex count", append(header(), int32s(0, 0, 0, 0, math.MaxInt32)...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Decode(bytes.NewReader(tt.data))
+			if err == nil {
+				t.Fatalf("Decode() = %v, want an error", m)
+			}
+		})
+	}
+}
+
+func TestDecodeTexts(t *testing.T) {
+
+	// 名前以外は空で、各要素の数は0
+	data := header()
+	data = append(data, int32s(4)...)
+	data = append(data, 'M', 0, 'A', 0)
+	data = append(data, int32s(0, 0, 0)...)
+	data = append(data, int32s(0, 0, 0, 0, 0, 0, 0, 0, 0)...)
+
+	m, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "MA" {
+		t.Errorf("Name = %q, want %q", m.Name, "MA")
+	}
+	if len(m.Vertices) != 0 || len(m.Bones) != 0 {
+		t.Errorf("got %d vertices and %d bones, want none", len(m.Vertices), len(m.Bones))
+	}
+}
@@ -0,0 +1,382 @@
+// Package pmx is data model of PMX (Polygon Model eXtended) files of MMD models.
+//
+// Values are stored as they are in the file, in the left-handed MMD coordinate system.
+// Indices which refer to nothing are -1.
+package pmx
+
+// Model is content of a PMX file.
+type Model struct {
+	// Version is 2.0 or 2.1.
+	Version float32
+
+	Name           string
+	EnglishName    string
+	Comment        string
+	EnglishComment string
+
+	// AdditionalUVs is the number of additional UVs of vertices, from 0 to 4.
+	AdditionalUVs int
+
+	Vertices []Vertex
+	// Indices is vertex indices of triangles, three for each face.
+	Indices       []int
+	Textures      []string
+	Materials     []Material
+	Bones         []Bone
+	Morphs        []Morph
+	DisplayFrames []DisplayFrame
+	RigidBodies   []RigidBody
+	Joints        []Joint
+}
+
+// DeformType is how bones deform a vertex.
+type DeformType byte
+
+const (
+	// BDEF1 follows a bone.
+	BDEF1 DeformType = iota
+	// BDEF2 blends two bones linearly by Weights[0] and 1 - Weights[0].
+	BDEF2
+	// BDEF4 blends four bones linearly.
+	BDEF4
+	// SDEF blends two bones spherically.
+	SDEF
+	// QDEF blends four bones with dual quaternions. It is in PMX 2.1.
+	QDEF
+)
+
+// Vertex is a vertex of the model.
+type Vertex struct {
+	Position      [3]float32
+	Normal        [3]float32
+	UV            [2]float32
+	AdditionalUVs [4][4]float32
+
+	Deform DeformType
+	// Bones and Weights are up to four bones. Unused ones have index -1 and weight 0.
+	Bones   [4]int
+	Weights [4]float32
+	// SDEFC, SDEFR0 and SDEFR1 are parameters of SDEF.
+	SDEFC  [3]float32
+	SDEFR0 [3]float32
+	SDEFR1 [3]float32
+
+	EdgeScale float32
+}
+
+// MaterialFlag is drawing flags of a material.
+type MaterialFlag byte
+
+const (
+	// MaterialDoubleSided draws back faces.
+	MaterialDoubleSided MaterialFlag = 1 << iota
+	// MaterialGroundShadow casts the shadow on the ground.
+	MaterialGroundShadow
+	// MaterialCastShadow casts the self shadow.
+	MaterialCastShadow
+	// MaterialReceiveShadow receives the self shadow.
+	MaterialReceiveShadow
+	// MaterialEdge draws the outline.
+	MaterialEdge
+)
+
+// SphereMode is how a sphere map is combined.
+type SphereMode byte
+
+const (
+	// SphereNone has no sphere map.
+	SphereNone SphereMode = iota
+	// SphereMultiply multiplies the sphere map (.sph).
+	SphereMultiply
+	// SphereAdd adds the sphere map (.spa).
+	SphereAdd
+	// SphereSubTexture uses the additional UV 1 for the sphere texture.
+	SphereSubTexture
+)
+
+// Material is a material of the model.
+type Material struct {
+	Name        string
+	EnglishName string
+
+	Diffuse       [4]float32
+	Specular      [3]float32
+	SpecularPower float32
+	Ambient       [3]float32
+
+	Flags     MaterialFlag
+	EdgeColor [4]float32
+	EdgeSize  float32
+
+	// Texture and SphereTexture are indices of Textures.
+	Texture       int
+	SphereTexture int
+	SphereMode    SphereMode
+	// SharedToon is true if Toon is the number of a shared toon, toon01.bmp to toon10.bmp from 0.
+	// Otherwise Toon is an index of Textures.
+	SharedToon bool
+	Toon       int
+
+	Memo string
+	// IndexCount is the number of vertex indices of the material, following those of the previous materials.
+	IndexCount int
+}
+
+// BoneFlag is flags of a bone.
+type BoneFlag uint16
+
+const (
+	// BoneTailIsBone means TailBone is used instead of TailOffset.
+	BoneTailIsBone BoneFlag = 1 << iota
+	// BoneRotatable allows rotation.
+	BoneRotatable
+	// BoneMovable allows translation.
+	BoneMovable
+	// BoneVisible shows the bone.
+	BoneVisible
+	// BoneOperable allows users to operate the bone.
+	BoneOperable
+	// BoneIK has IK.
+	BoneIK
+	_
+	// BoneLocalInherit inherits the local transform of InheritParent.
+	BoneLocalInherit
+	// BoneInheritRotation inherits rotation of InheritParent.
+	BoneInheritRotation
+	// BoneInheritTranslation inherits translation of InheritParent.
+	BoneInheritTranslation
+	// BoneFixedAxis rotates only around FixedAxis.
+	BoneFixedAxis
+	// BoneLocalAxis has LocalX and LocalZ.
+	BoneLocalAxis
+	// BoneAfterPhysics deforms after physics.
+	BoneAfterPhysics
+	// BoneExternalParent has ExternalKey.
+	BoneExternalParent
+)
+
+// Bone is a bone of the model.
+type Bone struct {
+	Name        string
+	EnglishName string
+
+	// Position is the rest position in the model.
+	Position [3]float32
+	Parent   int
+	// Layer is the deformation layer. Bones of lower layers deform first.
+	Layer int
+	Flags BoneFlag
+
+	TailBone   int
+	TailOffset [3]float32
+
+	// InheritParent and InheritWeight are the bone given rotation or translation by 付与.
+	InheritParent int
+	InheritWeight float32
+
+	FixedAxis [3]float32
+	LocalX    [3]float32
+	LocalZ    [3]float32
+
+	ExternalKey int
+
+	// IK is nil unless the bone has BoneIK.
+	IK *IK
+}
+
+// IK is inverse kinematics of an IK bone.
+type IK struct {
+	// Target is the bone which reaches the IK bone.
+	Target int
+	Loops  int
+	// LimitAngle is the largest angle in radians that a link rotates in an iteration.
+	LimitAngle float32
+	// Links are from the bone nearest to Target.
+	Links []IKLink
+}
+
+// IKLink is a bone rotated by IK.
+type IKLink struct {
+	Bone int
+	// Limited is true if Min and Max limit the Euler angles in radians.
+	Limited bool
+	Min     [3]float32
+	Max     [3]float32
+}
+
+// MorphType is what a morph changes.
+type MorphType byte
+
+const (
+	// GroupMorph applies other morphs.
+	GroupMorph MorphType = iota
+	// VertexMorph moves vertices.
+	VertexMorph
+	// BoneMorph moves and rotates bones.
+	BoneMorph
+	// UVMorph moves UVs.
+	UVMorph
+	// AdditionalUV1Morph to AdditionalUV4Morph move additional UVs.
+	AdditionalUV1Morph
+	AdditionalUV2Morph
+	AdditionalUV3Morph
+	AdditionalUV4Morph
+	// MaterialMorph changes materials.
+	MaterialMorph
+	// FlipMorph applies one of other morphs. It is in PMX 2.1.
+	FlipMorph
+	// ImpulseMorph gives impulses to rigid bodies. It is in PMX 2.1.
+	ImpulseMorph
+)
+
+// Morph is a morph of the model. Offsets of its type are filled.
+type Morph struct {
+	Name        string
+	EnglishName string
+
+	// Panel is the panel in MMD: 1 for eyebrows, 2 for eyes, 3 for mouth and 4 for others.
+	Panel int
+	Type  MorphType
+
+	Groups    []GroupOffset
+	Vertices  []VertexOffset
+	Bones     []BoneOffset
+	UVs       []UVOffset
+	Materials []MaterialOffset
+	Impulses  []ImpulseOffset
+}
+
+// GroupOffset is a morph applied by a group or flip morph.
+type GroupOffset struct {
+	Morph  int
+	Weight float32
+}
+
+// VertexOffset is movement of a vertex.
+type VertexOffset struct {
+	Vertex int
+	Offset [3]float32
+}
+
+// BoneOffset is movement and rotation of a bone.
+type BoneOffset struct {
+	Bone        int
+	Translation [3]float32
+	// Rotation is a quaternion (x, y, z, w).
+	Rotation [4]float32
+}
+
+// UVOffset is movement of a UV or an additional UV.
+type UVOffset struct {
+	Vertex int
+	Offset [4]float32
+}
+
+// MaterialOffset is change of a material.
+type MaterialOffset struct {
+	// Material is -1 for all materials.
+	Material int
+	// Add is true if the values are added, and false if they are multiplied.
+	Add bool
+
+	Diffuse       [4]float32
+	Specular      [3]float32
+	SpecularPower float32
+	Ambient       [3]float32
+	EdgeColor     [4]float32
+	EdgeSize      float32
+	Texture       [4]float32
+	SphereTexture [4]float32
+	Toon          [4]float32
+}
+
+// ImpulseOffset is an impulse given to a rigid body.
+type ImpulseOffset struct {
+	RigidBody int
+	Local     bool
+	Velocity  [3]float32
+	Torque    [3]float32
+}
+
+// DisplayFrame is a group of bones and morphs shown in MMD.
+type DisplayFrame struct {
+	Name        string
+	EnglishName string
+	// Special is true for the root and expression frames.
+	Special  bool
+	Elements []DisplayElement
+}
+
+// DisplayElement is a bone or a morph in a display frame.
+type DisplayElement struct {
+	Morph bool
+	Index int
+}
+
+// RigidBodyShape is the shape of a rigid body.
+type RigidBodyShape byte
+
+const (
+	// Sphere has the radius in Size[0].
+	Sphere RigidBodyShape = iota
+	// Box has the half extents in Size.
+	Box
+	// Capsule has the radius in Size[0] and the height in Size[1].
+	Capsule
+)
+
+// RigidBodyMode is how a rigid body moves.
+type RigidBodyMode byte
+
+const (
+	// FollowBone moves with the bone.
+	FollowBone RigidBodyMode = iota
+	// Physics moves the bone by physics.
+	Physics
+	// PhysicsWithBone moves the bone by physics, keeping the position of the bone.
+	PhysicsWithBone
+)
+
+// RigidBody is a rigid body of the model.
+type RigidBody struct {
+	Name        string
+	EnglishName string
+
+	Bone        int
+	Group       int
+	NoCollision uint16
+
+	Shape RigidBodyShape
+	Size  [3]float32
+	// Position is the center in the model, and Rotation is Euler angles in radians.
+	Position [3]float32
+	Rotation [3]float32
+
+	Mass           float32
+	LinearDamping  float32
+	AngularDamping float32
+	Restitution    float32
+	Friction       float32
+
+	Mode RigidBodyMode
+}
+
+// Joint is a constraint between two rigid bodies.
+type Joint struct {
+	Name        string
+	EnglishName string
+
+	// Type is 0 for a spring 6DOF joint. Other types are in PMX 2.1.
+	Type       int
+	RigidBodyA int
+	RigidBodyB int
+
+	Position       [3]float32
+	Rotation       [3]float32
+	PositionMin    [3]float32
+	PositionMax    [3]float32
+	RotationMin    [3]float32
+	RotationMax    [3]float32
+	SpringPosition [3]float32
+	SpringRotation [3]float32
+}
@@ -0,0 +1,213 @@
+// Package pose evaluates poses of a PMX model playing a VMD motion in Go, without three.js.
+//
+// Bones deform in the order MMD uses: bones deformed after physics last, then by deformation layer, then by index.
+// Each bone takes its 付与 (inherited rotation and translation) and solves its IK in that order.
+// Physics is not simulated, and bones moved by rigid bodies keep the animated pose.
+// Poses are in the right-handed coordinate system of three.js.
+package pose
+
+import (
+	"app/lib/mmd/ik"
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/pmx"
+	"app/lib/mmd/skeleton"
+	"app/lib/mmd/vmd"
+	"sort"
+)
+
+// Engine evaluates poses of a model.
+type Engine struct {
+	model    *pmx.Model
+	skeleton *skeleton.Skeleton
+	solver   *ik.Solver
+	// chains are indices of IK chains by bone, and -1 for bones without IK.
+	chains []int
+	order  []int
+
+	bones []*state
+
+	tracks map[string][]vmd.BoneFrame
+	iks    []vmd.IKFrame
+}
+
+// state is transforms of a bone being deformed.
+type state struct {
+	animatedTranslation math3d.Vector3
+	animatedRotation    math3d.Quaternion
+	ikRotation          math3d.Quaternion
+	inheritTranslation  math3d.Vector3
+	inheritRotation     math3d.Quaternion
+}
+
+// New creates Engine of the model in the rest pose.
+func New(m *pmx.Model) (*Engine, error) {
+
+	s, err := skeleton.NewFromPMX(m)
+	if err != nil {
+		return nil, err
+	}
+
+	solver, err := ik.NewSolver(s, ik.NewChainsFromPMX(m))
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		model:    m,
+		skeleton: s,
+		solver:   solver,
+		chains:   make([]int, len(m.Bones)),
+		order:    make([]int, len(m.Bones)),
+		bones:    make([]*state, len(m.Bones)),
+	}
+	for i := range e.chains {
+		e.chains[i] = -1
+		e.order[i] = i
+		e.bones[i] = &state{}
+	}
+	for i, c := range solver.Chains() {
+		e.chains[c.Bone] = i
+	}
+
+	// 物理後変形、変形階層、ボーン番号の順に変形する
+	sort.SliceStable(e.order, func(i, j int) bool {
+		a, b := m.Bones[e.order[i]], m.Bones[e.order[j]]
+		if after := a.Flags&pmx.BoneAfterPhysics != 0; after != (b.Flags&pmx.BoneAfterPhysics != 0) {
+			return !after
+		}
+		return a.Layer < b.Layer
+	})
+
+	return e, nil
+}
+
+// Skeleton gets the skeleton of the model.
+func (e *Engine) Skeleton() *skeleton.Skeleton {
+	return e.skeleton
+}
+
+// SetMotion sets the motion to evaluate. If m is nil, the model keeps the rest pose.
+func (e *Engine) SetMotion(m *vmd.Motion) {
+
+	e.tracks = nil
+	e.iks = nil
+	if m == nil {
+		return
+	}
+
+	// 元のモーションを並べ替えないよう複製する
+	sorted := &vmd.Motion{
+		Bones: append([]vmd.BoneFrame(nil), m.Bones...),
+		IKs:   append([]vmd.IKFrame(nil), m.IKs...),
+	}
+	sorted.Sort()
+	e.tracks = sorted.BoneTracks()
+	e.iks = sorted.IKs
+}
+
+// Evaluate gets the local and world transforms of the bones at the frame.
+func (e *Engine) Evaluate(frame float64) (local []skeleton.Transform, world []skeleton.Transform) {
+
+	local = e.skeleton.RestPose()
+	world = e.skeleton.World(local)
+
+	for i, b := range e.model.Bones {
+		st := e.bones[i]
+		*st = state{
+			animatedRotation: math3d.IdentityQuaternion(),
+			ikRotation:       math3d.IdentityQuaternion(),
+			inheritRotation:  math3d.IdentityQuaternion(),
+		}
+
+		if f, ok := vmd.BoneAt(e.tracks[b.Name], frame); ok {
+			// 左手系から右手系に変換する
+			st.animatedTranslation = math3d.NewVector3(float64(f.Position[0]), float64(f.Position[1]), -float64(f.Position[2]))
+			st.animatedRotation = math3d.Quaternion{
+				X: -float64(f.Rotation[0]),
+				Y: -float64(f.Rotation[1]),
+				Z: float64(f.Rotation[2]),
+				W: float64(f.Rotation[3]),
+			}.Normalize()
+		}
+		e.updateLocal(i, local)
+	}
+
+	for _, i := range e.order {
+		b := e.model.Bones[i]
+
+		if b.InheritParent >= 0 && b.InheritParent < len(e.bones) {
+			e.inherit(i)
+			e.updateLocal(i, local)
+		}
+		e.skeleton.UpdateWorld(i, local, world)
+
+		c := e.chains[i]
+		if c < 0 || !vmd.IKEnabledAt(e.iks, b.Name, frame) {
+			continue
+		}
+
+		// IKによる回転を付与の元として分けて持つ
+		links := e.solver.Chains()[c].Links
+		before := make([]math3d.Quaternion, len(links))
+		for n, l := range links {
+			before[n] = local[l.Bone].Rotation
+		}
+		e.solver.Solve(c, local, world)
+		for n, l := range links {
+			st := e.bones[l.Bone]
+			st.ikRotation = local[l.Bone].Rotation.Mul(before[n].Conjugate()).Mul(st.ikRotation).Normalize()
+		}
+	}
+
+	return local, world
+}
+
+// Matrices gets the world matrices of the bones at the frame, in column-major order as Matrix4 of three.js.
+func (e *Engine) Matrices(frame float64) [][16]float64 {
+	_, world := e.Evaluate(frame)
+	matrices := make([][16]float64, len(world))
+	for i, t := range world {
+		matrices[i] = t.Matrix()
+	}
+	return matrices
+}
+
+// inherit computes the rotation and translation the bone i inherits.
+func (e *Engine) inherit(i int) {
+
+	b := e.model.Bones[i]
+	st := e.bones[i]
+	p := b.InheritParent
+	parent := e.bones[p]
+	inherits := e.model.Bones[p].InheritParent >= 0 && b.Flags&pmx.BoneLocalInherit == 0
+
+	if b.Flags&pmx.BoneInheritRotation != 0 {
+		q := parent.animatedRotation
+		if inherits && e.model.Bones[p].Flags&pmx.BoneInheritRotation != 0 {
+			q = parent.inheritRotation
+		}
+		q = parent.ikRotation.Mul(q)
+		st.inheritRotation = math3d.IdentityQuaternion().Slerp(q, float64(b.InheritWeight))
+		// Slerpは比率が範囲外の場合に端の値を返すため、負の比率は逆回転にする
+		if b.InheritWeight < 0 {
+			st.inheritRotation = math3d.IdentityQuaternion().Slerp(q.Conjugate(), -float64(b.InheritWeight))
+		}
+	}
+
+	if b.Flags&pmx.BoneInheritTranslation != 0 {
+		t := parent.animatedTranslation
+		if inherits && e.model.Bones[p].Flags&pmx.BoneInheritTranslation != 0 {
+			t = parent.inheritTranslation
+		}
+		st.inheritTranslation = t.Scale(float64(b.InheritWeight))
+	}
+}
+
+// updateLocal sets the local transform of the bone i from its state.
+func (e *Engine) updateLocal(i int, local []skeleton.Transform) {
+	st := e.bones[i]
+	local[i] = skeleton.Transform{
+		Position: e.skeleton.Bones[i].Position.Add(st.animatedTranslation).Add(st.inheritTranslation),
+		Rotation: st.ikRotation.Mul(st.animatedRotation).Mul(st.inheritRotation),
+	}
+}
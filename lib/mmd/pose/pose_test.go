@@ -0,0 +1,161 @@
+package pose
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/pmx"
+	"app/lib/mmd/vmd"
+	"app/lib/mmd/vpd"
+	"math"
+	"os"
+	"testing"
+)
+
+// model makes a body with bones given 付与 from 上半身 and センター.
+func model() *pmx.Model {
+	return &pmx.Model{Bones: []pmx.Bone{
+		{Name: "センター", Parent: -1, InheritParent: -1},
+		{Name: "上半身", Parent: 0, Position: [3]float32{0, 10, 0}, InheritParent: -1},
+		{Name: "首", Parent: 1, Position: [3]float32{0, 15, 0}, InheritParent: -1},
+		{Name: "付与回転", Parent: 0, Position: [3]float32{5, 10, 0}, Flags: pmx.BoneInheritRotation, InheritParent: 1, InheritWeight: 0.5},
+		{Name: "逆付与", Parent: 0, Position: [3]float32{-5, 10, 0}, Flags: pmx.BoneInheritRotation, InheritParent: 1, InheritWeight: -1},
+		{Name: "付与移動", Parent: 0, Position: [3]float32{5, 0, 0}, Flags: pmx.BoneInheritTranslation, InheritParent: 0, InheritWeight: 2},
+	}}
+}
+
+// motionFromPose makes a motion holding the pose at frame 0.
+func motionFromPose(p *vpd.Pose) *vmd.Motion {
+	m := &vmd.Motion{}
+	for _, b := range p.Bones {
+		m.Bones = append(m.Bones, vmd.BoneFrame{Name: b.Name, Position: b.Position, Rotation: b.Rotation})
+	}
+	return m
+}
+
+func readVPD(t *testing.T, path string) *vmd.Motion {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	p, err := vpd.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return motionFromPose(p)
+}
+
+func readVMD(t *testing.T, path string) *vmd.Motion {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	m, err := vmd.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func rotation(x float64, y float64, z float64, angle float64) math3d.Quaternion {
+	return math3d.NewQuaternionFromAxisAngle(math3d.NewVector3(x, y, z), angle)
+}
+
+// bone is the expected local transform of a bone.
+type bone struct {
+	index    int
+	position math3d.Vector3
+	rotation math3d.Quaternion
+}
+
+func TestEvaluate(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		motion func(t *testing.T) *vmd.Motion
+		frame  float64
+		want   []bone
+	}{
+		{
+			// pose.vpd: センター (1, 2, 3), 上半身 X軸回り90度, 首 (0.1, 0.2, 0.3, 0.927362)
+			name:   "pose.vpd",
+			motion: func(t *testing.T) *vmd.Motion { return readVPD(t, "testdata/pose.vpd") },
+			want: []bone{
+				{0, math3d.NewVector3(1, 2, -3), math3d.IdentityQuaternion()},
+				{1, math3d.NewVector3(0, 10, 0), rotation(1, 0, 0, -math.Pi/2)},
+				{2, math3d.NewVector3(0, 5, 0), math3d.Quaternion{X: -0.1, Y: -0.2, Z: 0.3, W: 0.927362}.Normalize()},
+				{3, math3d.NewVector3(5, 10, 0), rotation(1, 0, 0, -math.Pi/4)},
+				{4, math3d.NewVector3(-5, 10, 0), rotation(1, 0, 0, math.Pi/2)},
+				{5, math3d.NewVector3(7, 4, -6), math3d.IdentityQuaternion()},
+			},
+		},
+		{
+			// motion.vmd: 0から10フレームまでに、センターが (10, 0, 10) に動き、上半身がY軸回りに90度回る
+			name:   "motion.vmd halfway",
+			motion: func(t *testing.T) *vmd.Motion { return readVMD(t, "testdata/motion.vmd") },
+			frame:  5,
+			want: []bone{
+				{0, math3d.NewVector3(5, 0, -5), math3d.IdentityQuaternion()},
+				{1, math3d.NewVector3(0, 10, 0), rotation(0, 1, 0, -math.Pi/4)},
+				{3, math3d.NewVector3(5, 10, 0), rotation(0, 1, 0, -math.Pi/8)},
+				{4, math3d.NewVector3(-5, 10, 0), rotation(0, 1, 0, math.Pi/4)},
+				{5, math3d.NewVector3(15, 0, -10), math3d.IdentityQuaternion()},
+			},
+		},
+		{
+			name:   "motion.vmd after the last keyframe",
+			motion: func(t *testing.T) *vmd.Motion { return readVMD(t, "testdata/motion.vmd") },
+			frame:  20,
+			want: []bone{
+				{0, math3d.NewVector3(10, 0, -10), math3d.IdentityQuaternion()},
+				{1, math3d.NewVector3(0, 10, 0), rotation(0, 1, 0, -math.Pi/2)},
+				{3, math3d.NewVector3(5, 10, 0), rotation(0, 1, 0, -math.Pi/4)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(model())
+			if err != nil {
+				t.Fatal(err)
+			}
+			e.SetMotion(tt.motion(t))
+
+			local, _ := e.Evaluate(tt.frame)
+			for _, w := range tt.want {
+				l := local[w.index]
+				if d := l.Position.Distance(w.position); d > 1e-4 {
+					t.Errorf("bone %d position = %v, want %v", w.index, l.Position, w.position)
+				}
+				if a := l.Rotation.Angle(w.rotation); a > 1e-4 {
+					t.Errorf("bone %d rotation = %v, want %v", w.index, l.Rotation, w.rotation)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateWorld(t *testing.T) {
+
+	e, err := New(model())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.SetMotion(readVPD(t, "testdata/pose.vpd"))
+
+	// 上半身は右手系でX軸回りに-90度回るため、首は-Z側に倒れる
+	_, world := e.Evaluate(0)
+	want := math3d.NewVector3(1, 12, -8)
+	if d := world[2].Position.Distance(want); d > 1e-4 {
+		t.Errorf("首 is at %v, want %v", world[2].Position, want)
+	}
+
+	// モーションがなければ初期姿勢になる
+	e.SetMotion(nil)
+	_, world = e.Evaluate(0)
+	want = math3d.NewVector3(0, 15, 0)
+	if d := world[2].Position.Distance(want); d > 1e-9 {
+		t.Errorf("首 is at %v in the rest pose, want %v", world[2].Position, want)
+	}
+}
@@ -0,0 +1,167 @@
+// Package retarget maps rotations between skeletons whose rest poses point their bones in different directions,
+// such as MMD models, BVH motions and VRM avatars.
+//
+// Rigs are in the right-handed coordinate system of three.js.
+package retarget
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/skeleton"
+	"math"
+)
+
+// Rig is bones or joints in the rest pose.
+type Rig struct {
+	Names   []string
+	Parents []int
+	// Rest is the positions in the model.
+	Rest []math3d.Vector3
+	// Order is indices with parents before their children.
+	Order []int
+}
+
+// NewRigFromSkeleton creates Rig of the bones of the skeleton.
+func NewRigFromSkeleton(s *skeleton.Skeleton) *Rig {
+
+	r := &Rig{
+		Names:   make([]string, len(s.Bones)),
+		Parents: make([]int, len(s.Bones)),
+		Rest:    s.RestWorldPositions(),
+		Order:   s.Order(),
+	}
+	for i, b := range s.Bones {
+		r.Names[i] = b.Name
+		r.Parents[i] = b.Parent
+	}
+	return r
+}
+
+// Descends gets whether a is a descendant of b.
+func (r *Rig) Descends(a int, b int) bool {
+	for p := r.Parents[a]; p >= 0; p = r.Parents[p] {
+		if p == b {
+			return true
+		}
+	}
+	return false
+}
+
+// Pair is a bone of the source mapped to one of the target.
+type Pair struct {
+	Source int
+	Target int
+	// Parent is the pair of the nearest mapped ancestor of the target, or -1.
+	Parent int
+	// Correction turns the target in the rest pose to the directions of the source in the rest pose.
+	Correction math3d.Quaternion
+}
+
+// Retargeter converts rotations of a source rig to a target rig with different rest directions.
+type Retargeter struct {
+	Pairs []Pair
+
+	source *Rig
+	target *Rig
+}
+
+// New maps the rigs with names, the names of the source by name of the target.
+// Targets with empty names are not mapped.
+//
+// follows reports whether the direction to the target child shows the direction of the target parent,
+// which the correction of the parent turns to the source. If it is nil, the first mapped child is used.
+func New(source *Rig, target *Rig, names map[string]string, follows func(parent int, child int) bool) *Retargeter {
+
+	// 同名のボーンは先頭を優先する
+	sources := make(map[string]int, len(source.Names))
+	for i, name := range source.Names {
+		if _, ok := sources[name]; !ok {
+			sources[name] = i
+		}
+	}
+
+	r := &Retargeter{source: source, target: target}
+	pairs := make(map[int]int)
+	for _, t := range target.Order {
+		if target.Names[t] == "" {
+			continue
+		}
+		s, ok := sources[names[target.Names[t]]]
+		if !ok {
+			continue
+		}
+
+		parent := -1
+		for p := target.Parents[t]; p >= 0; p = target.Parents[p] {
+			if i, ok := pairs[p]; ok {
+				parent = i
+				break
+			}
+		}
+
+		pairs[t] = len(r.Pairs)
+		r.Pairs = append(r.Pairs, Pair{Source: s, Target: t, Parent: parent, Correction: math3d.IdentityQuaternion()})
+	}
+
+	// 子の方向が一致するように、ターゲットの初期姿勢をソースの初期姿勢に向ける補正を求める
+	for i := range r.Pairs {
+		p := &r.Pairs[i]
+		if p.Parent >= 0 {
+			p.Correction = r.Pairs[p.Parent].Correction
+		}
+
+		for _, c := range r.Pairs[i+1:] {
+			if c.Parent != i || !source.Descends(c.Source, p.Source) {
+				continue
+			}
+			if follows != nil && !follows(p.Target, c.Target) {
+				continue
+			}
+			dt := target.Rest[c.Target].Sub(target.Rest[p.Target])
+			ds := source.Rest[c.Source].Sub(source.Rest[p.Source])
+			if dt.Length() > 1e-6 && ds.Length() > 1e-6 {
+				p.Correction = math3d.NewQuaternionFromUnitVectors(dt.Normalize(), ds.Normalize())
+			}
+			break
+		}
+	}
+
+	return r
+}
+
+// Retarget gets local rotations of the targets of the pairs from world rotations of the source.
+// Targets not mapped keep the rest pose.
+func (r *Retargeter) Retarget(world func(source int) math3d.Quaternion) []math3d.Quaternion {
+
+	global := make([]math3d.Quaternion, len(r.Pairs))
+	locals := make([]math3d.Quaternion, len(r.Pairs))
+	for i, p := range r.Pairs {
+		global[i] = world(p.Source).Mul(p.Correction).Normalize()
+		locals[i] = global[i]
+		if p.Parent >= 0 {
+			locals[i] = global[p.Parent].Conjugate().Mul(global[i]).Normalize()
+		}
+	}
+	return locals
+}
+
+// Heights gets the vertical extents of the mapped bones of the source and the target in the rest pose.
+// Their ratio is the scale of translations from the source to the target.
+func (r *Retargeter) Heights() (source float64, target float64) {
+
+	extent := func(rig *Rig, index func(p Pair) int) float64 {
+		low, high := math.Inf(1), math.Inf(-1)
+		for _, p := range r.Pairs {
+			y := rig.Rest[index(p)].Y
+			low = math.Min(low, y)
+			high = math.Max(high, y)
+		}
+		if high <= low {
+			return 1
+		}
+		return high - low
+	}
+
+	source = extent(r.source, func(p Pair) int { return p.Source })
+	target = extent(r.target, func(p Pair) int { return p.Target })
+	return source, target
+}
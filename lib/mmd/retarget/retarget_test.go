@@ -0,0 +1,102 @@
+package retarget
+
+import (
+	"app/lib/mmd/math3d"
+	"math"
+	"testing"
+)
+
+// arms makes a source arm lowered by 45 degrees as in the A-pose of MMD, and a target arm in the T-pose
+// with an unmapped joint between the shoulder and the elbow.
+func arms() (*Rig, *Rig) {
+	source := &Rig{
+		Names:   []string{"肩", "ひじ"},
+		Parents: []int{-1, 0},
+		Rest:    []math3d.Vector3{math3d.NewVector3(0, 2, 0), math3d.NewVector3(1, 1, 0)},
+		Order:   []int{0, 1},
+	}
+	target := &Rig{
+		Names:   []string{"shoulder", "", "elbow"},
+		Parents: []int{-1, 0, 1},
+		Rest:    []math3d.Vector3{math3d.NewVector3(0, 3, 0), math3d.NewVector3(0.5, 3, 0), math3d.NewVector3(2, 3, 0)},
+		Order:   []int{0, 1, 2},
+	}
+	return source, target
+}
+
+func TestNew(t *testing.T) {
+
+	lowered := math3d.NewQuaternionFromAxisAngle(math3d.NewVector3(0, 0, 1), -math.Pi/4)
+
+	tests := []struct {
+		name       string
+		follows    func(parent int, child int) bool
+		correction math3d.Quaternion
+	}{
+		{"first child", nil, lowered},
+		{"child accepted", func(parent int, child int) bool { return child == 2 }, lowered},
+		{"child rejected", func(parent int, child int) bool { return false }, math3d.IdentityQuaternion()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, target := arms()
+			r := New(source, target, map[string]string{"shoulder": "肩", "elbow": "ひじ", "": "肩"}, tt.follows)
+
+			if len(r.Pairs) != 2 {
+				t.Fatalf("Pairs = %v, want shoulder and elbow", r.Pairs)
+			}
+			shoulder, elbow := r.Pairs[0], r.Pairs[1]
+			if shoulder.Source != 0 || shoulder.Target != 0 || shoulder.Parent != -1 {
+				t.Errorf("shoulder = %+v", shoulder)
+			}
+			if elbow.Source != 1 || elbow.Target != 2 || elbow.Parent != 0 {
+				t.Errorf("elbow = %+v", elbow)
+			}
+			if a := shoulder.Correction.Angle(tt.correction); a > 1e-9 {
+				t.Errorf("shoulder correction = %v, want %v", shoulder.Correction, tt.correction)
+			}
+			// 子は親の補正を引き継ぐ
+			if a := elbow.Correction.Angle(shoulder.Correction); a > 1e-9 {
+				t.Errorf("elbow correction = %v, want %v", elbow.Correction, shoulder.Correction)
+			}
+		})
+	}
+}
+
+func TestRetarget(t *testing.T) {
+
+	source, target := arms()
+	r := New(source, target, map[string]string{"shoulder": "肩", "elbow": "ひじ"}, nil)
+
+	// ソースのひじを曲げると、ターゲットのひじは肩からの相対回転で曲がる
+	bend := math3d.NewQuaternionFromAxisAngle(math3d.NewVector3(0, 1, 0), math.Pi/2)
+	locals := r.Retarget(func(i int) math3d.Quaternion {
+		if i == 1 {
+			return bend
+		}
+		return math3d.IdentityQuaternion()
+	})
+
+	correction := r.Pairs[0].Correction
+	want := []math3d.Quaternion{
+		correction,
+		correction.Conjugate().Mul(bend).Mul(correction),
+	}
+	for i, w := range want {
+		if a := locals[i].Angle(w); a > 1e-9 {
+			t.Errorf("local %d = %v, want %v", i, locals[i], w)
+		}
+	}
+
+	// 回転を戻すと、ターゲットのひじはソースのひじと同じ方向を向く
+	elbow := correction.Mul(locals[1]).Rotate(target.Rest[2].Sub(target.Rest[0]).Normalize())
+	if d := elbow.Distance(bend.Rotate(math3d.NewVector3(1, -1, 0).Normalize())); d > 1e-9 {
+		t.Errorf("elbow points %v", elbow)
+	}
+
+	s, h := r.Heights()
+	if s != 1 || h != 1 {
+		t.Errorf("Heights() = %v, %v, want 1 and 1 for a flat target", s, h)
+	}
+}
@@ -0,0 +1,30 @@
+package skeleton
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/pmx"
+)
+
+// NewFromPMX creates Skeleton of the bones of the PMX model, converting them to the right-handed coordinate system.
+func NewFromPMX(m *pmx.Model) (*Skeleton, error) {
+
+	bones := make([]Bone, len(m.Bones))
+	for i, b := range m.Bones {
+		// PMXのボーンの位置はモデル座標のため、親からの相対位置にする
+		p := vector(b.Position)
+		if b.Parent >= 0 && b.Parent < len(m.Bones) {
+			p = p.Sub(vector(m.Bones[b.Parent].Position))
+		}
+		bones[i] = Bone{
+			Name:     b.Name,
+			Parent:   b.Parent,
+			Position: p.FlipZ(),
+		}
+	}
+
+	return New(bones)
+}
+
+func vector(v [3]float32) math3d.Vector3 {
+	return math3d.NewVector3(float64(v[0]), float64(v[1]), float64(v[2]))
+}
@@ -0,0 +1,162 @@
+// Package skeleton is bone hierarchy of MMD models handled in Go, without three.js.
+//
+// Poses are local transforms of the bones as three.js bones have them:
+// positions relative to the parent bone and rotations from the rest pose,
+// in the right-handed coordinate system of three.js.
+package skeleton
+
+import (
+	"app/lib/mmd/math3d"
+	"fmt"
+)
+
+// Bone is a bone in the rest pose.
+type Bone struct {
+	Name string
+	// Parent is the index of the parent bone. It is -1 for root bones.
+	Parent int
+	// Position is the rest position relative to the parent bone, or to the model for root bones.
+	Position math3d.Vector3
+}
+
+// Transform is position and rotation of a bone.
+type Transform struct {
+	Position math3d.Vector3
+	Rotation math3d.Quaternion
+}
+
+// Apply returns the transform of a child whose local transform is local.
+func (t Transform) Apply(local Transform) Transform {
+	return Transform{
+		Position: t.Position.Add(t.Rotation.Rotate(local.Position)),
+		Rotation: t.Rotation.Mul(local.Rotation),
+	}
+}
+
+// Matrix gets the transform as a 4x4 matrix in column-major order, as elements of Matrix4 of three.js.
+func (t Transform) Matrix() [16]float64 {
+	x := t.Rotation.Rotate(math3d.NewVector3(1, 0, 0))
+	y := t.Rotation.Rotate(math3d.NewVector3(0, 1, 0))
+	z := t.Rotation.Rotate(math3d.NewVector3(0, 0, 1))
+	p := t.Position
+	return [16]float64{
+		x.X, x.Y, x.Z, 0,
+		y.X, y.Y, y.Z, 0,
+		z.X, z.Y, z.Z, 0,
+		p.X, p.Y, p.Z, 1,
+	}
+}
+
+// Skeleton is bones of a model.
+type Skeleton struct {
+	Bones []Bone
+
+	// order is indices of the bones with parents before their children.
+	order    []int
+	children [][]int
+	names    map[string]int
+}
+
+// New creates Skeleton. Bones may come before their parents, as in some PMX files.
+func New(bones []Bone) (*Skeleton, error) {
+
+	s := &Skeleton{
+		Bones:    bones,
+		children: make([][]int, len(bones)),
+		names:    make(map[string]int, len(bones)),
+	}
+
+	for i, b := range bones {
+		if b.Parent >= len(bones) || b.Parent < -1 || b.Parent == i {
+			return nil, fmt.Errorf("skeleton: bone %q has invalid parent %d", b.Name, b.Parent)
+		}
+		if b.Parent >= 0 {
+			s.children[b.Parent] = append(s.children[b.Parent], i)
+		}
+		// 同名のボーンは先頭を優先する
+		if _, ok := s.names[b.Name]; !ok {
+			s.names[b.Name] = i
+		}
+	}
+
+	// 親から順に辿り、辿れなかったボーンは循環している
+	for i, b := range bones {
+		if b.Parent < 0 {
+			s.visit(i)
+		}
+	}
+	if len(s.order) != len(bones) {
+		return nil, fmt.Errorf("skeleton: bones have a cyclic hierarchy")
+	}
+
+	return s, nil
+}
+
+func (s *Skeleton) visit(i int) {
+	s.order = append(s.order, i)
+	for _, c := range s.children[i] {
+		s.visit(c)
+	}
+}
+
+// Order gets indices of the bones with parents before their children.
+func (s *Skeleton) Order() []int {
+	return s.order
+}
+
+// Children gets indices of the child bones.
+func (s *Skeleton) Children(i int) []int {
+	return s.children[i]
+}
+
+// Index gets the index of the bone by name.
+func (s *Skeleton) Index(name string) (int, bool) {
+	i, ok := s.names[name]
+	return i, ok
+}
+
+// RestPose gets the local transforms of the rest pose.
+func (s *Skeleton) RestPose() []Transform {
+	pose := make([]Transform, len(s.Bones))
+	for i, b := range s.Bones {
+		pose[i] = Transform{Position: b.Position, Rotation: math3d.IdentityQuaternion()}
+	}
+	return pose
+}
+
+// World gets the transforms of the bones in the model from the local transforms.
+func (s *Skeleton) World(local []Transform) []Transform {
+	world := make([]Transform, len(s.Bones))
+	for _, i := range s.order {
+		world[i] = s.WorldOf(i, local[i], world)
+	}
+	return world
+}
+
+// WorldOf gets the transform of the bone i in the model from its local transform,
+// with transforms of its parent in world.
+func (s *Skeleton) WorldOf(i int, local Transform, world []Transform) Transform {
+	if p := s.Bones[i].Parent; p >= 0 {
+		return world[p].Apply(local)
+	}
+	return local
+}
+
+// RestWorldPositions gets the positions of the bones in the model in the rest pose.
+func (s *Skeleton) RestWorldPositions() []math3d.Vector3 {
+	world := s.World(s.RestPose())
+	positions := make([]math3d.Vector3, len(world))
+	for i, t := range world {
+		positions[i] = t.Position
+	}
+	return positions
+}
+
+// UpdateWorld updates world, the transforms of the bones in the model, for the bone i and its descendants
+// after their local transforms are changed.
+func (s *Skeleton) UpdateWorld(i int, local []Transform, world []Transform) {
+	world[i] = s.WorldOf(i, local[i], world)
+	for _, c := range s.children[i] {
+		s.UpdateWorld(c, local, world)
+	}
+}
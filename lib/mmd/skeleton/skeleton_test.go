@@ -0,0 +1,131 @@
+package skeleton
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/pmx"
+	"math"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		bones []Bone
+		order []int
+		valid bool
+	}{
+		{
+			name:  "parents first",
+			bones: []Bone{{Name: "a", Parent: -1}, {Name: "b", Parent: 0}, {Name: "c", Parent: 1}},
+			order: []int{0, 1, 2},
+			valid: true,
+		},
+		{
+			name:  "children first",
+			bones: []Bone{{Name: "c", Parent: 1}, {Name: "b", Parent: 2}, {Name: "a", Parent: -1}},
+			order: []int{2, 1, 0},
+			valid: true,
+		},
+		{
+			name:  "parent out of range",
+			bones: []Bone{{Name: "a", Parent: -1}, {Name: "b", Parent: 2}},
+		},
+		{
+			name:  "parent below -1",
+			bones: []Bone{{Name: "a", Parent: -2}},
+		},
+		{
+			name:  "own parent",
+			bones: []Bone{{Name: "a", Parent: 0}},
+		},
+		{
+			name:  "cyclic",
+			bones: []Bone{{Name: "root", Parent: -1}, {Name: "a", Parent: 2}, {Name: "b", Parent: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.bones)
+			if !tt.valid {
+				if err == nil {
+					t.Fatalf("New() = %v, want an error", s)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range s.Order() {
+				if v != tt.order[i] {
+					t.Fatalf("Order() = %v, want %v", s.Order(), tt.order)
+				}
+			}
+		})
+	}
+}
+
+func TestWorld(t *testing.T) {
+
+	s, err := New([]Bone{
+		{Name: "root", Parent: -1, Position: math3d.NewVector3(0, 1, 0)},
+		{Name: "arm", Parent: 0, Position: math3d.NewVector3(2, 0, 0)},
+		{Name: "hand", Parent: 1, Position: math3d.NewVector3(1, 0, 0)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 腕を上に向けると、手は腕の先から上に伸びる
+	local := s.RestPose()
+	local[1].Rotation = math3d.NewQuaternionFromAxisAngle(math3d.NewVector3(0, 0, 1), math.Pi/2)
+	world := s.World(local)
+
+	want := []math3d.Vector3{
+		math3d.NewVector3(0, 1, 0),
+		math3d.NewVector3(2, 1, 0),
+		math3d.NewVector3(2, 2, 0),
+	}
+	for i, w := range want {
+		if d := world[i].Position.Distance(w); d > 1e-9 {
+			t.Errorf("bone %d is at %v, want %v", i, world[i].Position, w)
+		}
+	}
+
+	// 一部だけ更新しても、全体を計算した場合と一致する
+	rest := s.World(s.RestPose())
+	s.UpdateWorld(1, local, rest)
+	for i := range world {
+		if d := rest[i].Position.Distance(world[i].Position); d > 1e-9 {
+			t.Errorf("UpdateWorld moved bone %d to %v, want %v", i, rest[i].Position, world[i].Position)
+		}
+	}
+}
+
+func TestNewFromPMX(t *testing.T) {
+
+	m := &pmx.Model{Bones: []pmx.Bone{
+		{Name: "センター", Parent: -1, Position: [3]float32{0, 8, 1}},
+		{Name: "上半身", Parent: 0, Position: [3]float32{0, 12, 2}},
+	}}
+
+	s, err := NewFromPMX(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 親からの相対位置で、Zは反転する
+	want := []math3d.Vector3{
+		math3d.NewVector3(0, 8, -1),
+		math3d.NewVector3(0, 4, -1),
+	}
+	for i, w := range want {
+		if s.Bones[i].Position != w {
+			t.Errorf("bone %d is at %v, want %v", i, s.Bones[i].Position, w)
+		}
+	}
+	if i, ok := s.Index("上半身"); !ok || i != 1 {
+		t.Errorf("Index() = %v, %v, want 1, true", i, ok)
+	}
+}
@@ -0,0 +1,162 @@
+// Package springbone is a lightweight solver of secondary motion for hair, skirts and accessories.
+//
+// Each joint bone swings its tail toward the animated direction with a spring,
+// in the same way as spring bones of VRM, and avoids spheres attached to other bones.
+// It runs in Go without Ammo.js, so it is cheap enough for phones.
+package springbone
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/skeleton"
+	"math"
+)
+
+// Joint is a bone swung by a spring.
+type Joint struct {
+	Bone int
+
+	// Stiffness is the strength pulling the tail back to the animated direction, in bone lengths per second.
+	Stiffness float64
+	// Drag is the ratio of velocity lost in each step, in [0, 1].
+	Drag float64
+	// Gravity is the strength of gravity, in bone lengths per second.
+	Gravity float64
+	// HitRadius is the radius of the tail colliding with colliders.
+	HitRadius float64
+}
+
+// Collider is a sphere attached to a bone, which joints do not enter.
+type Collider struct {
+	Bone int
+	// Offset is the center relative to the bone, in the rest orientation of the bone.
+	Offset math3d.Vector3
+	Radius float64
+}
+
+// Config is joints and colliders of a model.
+type Config struct {
+	Joints    []Joint
+	Colliders []Collider
+
+	// GravityDirection is the direction of gravity in the model. Default is (0, -1, 0).
+	GravityDirection math3d.Vector3
+}
+
+// Default values of joints made from rigid bodies.
+var (
+	DefaultStiffness = 4.0
+	DefaultDrag      = 0.4
+	DefaultGravity   = 0.5
+
+	// SpringStiffnessScale converts rotational springs of PMX joints to Stiffness added to DefaultStiffness.
+	SpringStiffnessScale = 0.05
+)
+
+// RigidBodyType is how a rigid body of PMX moves.
+type RigidBodyType int
+
+const (
+	// FollowBone rigid bodies move with their bones.
+	FollowBone RigidBodyType = iota
+	// Physics rigid bodies move their bones by physics.
+	Physics
+	// PhysicsWithBone rigid bodies move their bones by physics, keeping positions of the bones.
+	PhysicsWithBone
+)
+
+// RigidBodyShape is the shape of a rigid body of PMX.
+type RigidBodyShape int
+
+const (
+	// Sphere has the radius in Size[0].
+	Sphere RigidBodyShape = iota
+	// Box has the half extents in Size.
+	Box
+	// Capsule has the radius in Size[0] and the height in Size[1].
+	Capsule
+)
+
+// RigidBody is a rigid body of PMX.
+type RigidBody struct {
+	// Bone is the index of the bone, or -1 if the body has no bone.
+	Bone  int
+	Type  RigidBodyType
+	Shape RigidBodyShape
+	Size  [3]float64
+	// Position is the center relative to the rest position of the bone.
+	Position math3d.Vector3
+	// AngularDamping is damping of rotation in [0, 1].
+	AngularDamping float64
+}
+
+// Constraint is a joint of PMX, which connects two rigid bodies.
+type Constraint struct {
+	BodyA int
+	BodyB int
+	// SpringRotation is the rotational spring constants around the axes.
+	SpringRotation [3]float64
+}
+
+// NewConfigFromRigidBodies makes joints of bones moved by physics bodies,
+// and colliders of bodies following bones.
+// Damping of bodies is used as drag, and rotational springs of constraints stiffen the joints.
+func NewConfigFromRigidBodies(s *skeleton.Skeleton, bodies []RigidBody, constraints []Constraint) Config {
+
+	// 剛体ごとに、それを動かすジョイントのバネの強さを集める
+	springs := make([]float64, len(bodies))
+	for _, c := range constraints {
+		if c.BodyB < 0 || c.BodyB >= len(bodies) {
+			continue
+		}
+		r := c.SpringRotation
+		springs[c.BodyB] = math.Max(springs[c.BodyB], (math.Abs(r[0])+math.Abs(r[1])+math.Abs(r[2]))/3)
+	}
+
+	var config Config
+	jointed := make(map[int]bool)
+
+	for i, b := range bodies {
+		if b.Bone < 0 || b.Bone >= len(s.Bones) {
+			continue
+		}
+
+		if b.Type == FollowBone {
+			config.Colliders = append(config.Colliders, Collider{
+				Bone:   b.Bone,
+				Offset: b.Position,
+				Radius: b.radius(),
+			})
+			continue
+		}
+
+		// 同じボーンに複数の剛体がある場合は最初のものを使う
+		if jointed[b.Bone] {
+			continue
+		}
+		jointed[b.Bone] = true
+
+		drag := DefaultDrag
+		if b.AngularDamping > 0 {
+			drag = math.Min(1, b.AngularDamping)
+		}
+		config.Joints = append(config.Joints, Joint{
+			Bone:      b.Bone,
+			Stiffness: DefaultStiffness + springs[i]*SpringStiffnessScale,
+			Drag:      drag,
+			Gravity:   DefaultGravity,
+			HitRadius: b.radius(),
+		})
+	}
+
+	return config
+}
+
+// radius gets the radius of the sphere inscribed in the body.
+func (b RigidBody) radius() float64 {
+	switch b.Shape {
+	case Box:
+		return math.Min(b.Size[0], math.Min(b.Size[1], b.Size[2]))
+	default:
+		return b.Size[0]
+	}
+}
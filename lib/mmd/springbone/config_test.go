@@ -0,0 +1,49 @@
+package springbone
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/skeleton"
+	"testing"
+)
+
+func TestNewConfigFromRigidBodies(t *testing.T) {
+
+	s, err := skeleton.New([]skeleton.Bone{
+		{Name: "髪", Parent: -1},
+		{Name: "髪先", Parent: 0, Position: math3d.NewVector3(1, 0, 0)},
+		{Name: "体", Parent: -1, Position: math3d.NewVector3(0.2, -1, 0)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bodies := []RigidBody{
+		{Bone: 0, Type: Physics, Shape: Sphere, Size: [3]float64{0.1}, AngularDamping: 0.4},
+		// 剛体がボーンの位置にある
+		{Bone: 2, Type: FollowBone, Shape: Sphere, Size: [3]float64{0.5}},
+		{Bone: -1, Type: Physics, Shape: Sphere, Size: [3]float64{1}},
+	}
+	config := NewConfigFromRigidBodies(s, bodies, nil)
+
+	if len(config.Joints) != 1 || config.Joints[0].Bone != 0 {
+		t.Fatalf("joints = %+v, want one of bone 0", config.Joints)
+	}
+	if len(config.Colliders) != 1 {
+		t.Fatalf("colliders = %+v, want one", config.Colliders)
+	}
+	c := config.Colliders[0]
+	if c.Bone != 2 || c.Offset.Length() != 0 || c.Radius != 0.5 {
+		t.Errorf("collider = %+v, want on bone 2 at offset 0 with radius 0.5", c)
+	}
+
+	// 衝突球はボーンの上にあり、垂れた髪を押し出す
+	solver, err := NewSolver(s, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tail := simulate(s, solver, math3d.IdentityQuaternion(), 10)
+	center := s.RestWorldPositions()[2]
+	if d := tail.Distance(center); d < c.Radius+config.Joints[0].HitRadius-1e-2 {
+		t.Errorf("tail %v is in the collider on the bone at %v, %v from the center", tail, center, d)
+	}
+}
@@ -0,0 +1,166 @@
+package springbone
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/skeleton"
+	"fmt"
+	"math"
+)
+
+// Step is the time of a step of the simulation in seconds.
+// Frames longer than MaxDelta are shortened, so that the simulation does not explode after the page is hidden.
+var (
+	Step     = 1.0 / 60
+	MaxDelta = 0.1
+)
+
+// Solver moves joint bones of a skeleton by springs.
+type Solver struct {
+	skeleton  *skeleton.Skeleton
+	colliders []Collider
+	gravity   math3d.Vector3
+
+	// joints are indexed by bone, and nil for bones which are not joints.
+	joints []*joint
+
+	remainder   float64
+	initialized bool
+}
+
+// joint is a state of a joint bone.
+type joint struct {
+	Joint
+	// axis is the direction to the tail in the rest orientation of the bone.
+	axis   math3d.Vector3
+	length float64
+
+	// prev and current are positions of the tail in the model.
+	prev    math3d.Vector3
+	current math3d.Vector3
+}
+
+// NewSolver creates Solver. Joints whose bones have no length are ignored.
+func NewSolver(s *skeleton.Skeleton, config Config) (*Solver, error) {
+
+	solver := &Solver{
+		skeleton:  s,
+		colliders: config.Colliders,
+		gravity:   config.GravityDirection.Normalize(),
+		joints:    make([]*joint, len(s.Bones)),
+	}
+	if config.GravityDirection.Length() == 0 {
+		solver.gravity = math3d.NewVector3(0, -1, 0)
+	}
+
+	for _, c := range config.Colliders {
+		if c.Bone < 0 || c.Bone >= len(s.Bones) {
+			return nil, fmt.Errorf("springbone: collider has invalid bone %d", c.Bone)
+		}
+	}
+
+	for _, j := range config.Joints {
+		if j.Bone < 0 || j.Bone >= len(s.Bones) {
+			return nil, fmt.Errorf("springbone: joint has invalid bone %d", j.Bone)
+		}
+
+		// 先端は最初の子ボーンの方向で、子がなければ親からの方向に伸ばす
+		tail := s.Bones[j.Bone].Position
+		if children := s.Children(j.Bone); len(children) > 0 {
+			tail = s.Bones[children[0]].Position
+		}
+		if tail.Length() == 0 {
+			continue
+		}
+
+		solver.joints[j.Bone] = &joint{
+			Joint:  j,
+			axis:   tail.Normalize(),
+			length: tail.Length(),
+		}
+	}
+
+	return solver, nil
+}
+
+// Reset places the tails in the next pose, as if the joints have been at rest.
+// Call it when the pose jumps, such as when a motion is seeked.
+func (s *Solver) Reset() {
+	s.initialized = false
+	s.remainder = 0
+}
+
+// Update advances the simulation by delta seconds and rotates the joint bones in local, the local transforms of the bones.
+// Rotations of the joints in local are taken as the animated pose which the springs pull the bones back to.
+func (s *Solver) Update(local []skeleton.Transform, delta float64) {
+
+	// 衝突判定はアニメーションされた姿勢で行う
+	animated := s.skeleton.World(local)
+
+	if !s.initialized {
+		for i, j := range s.joints {
+			if j != nil {
+				j.current = animated[i].Position.Add(animated[i].Rotation.Rotate(j.axis.Scale(j.length)))
+				j.prev = j.current
+			}
+		}
+		s.initialized = true
+	}
+
+	s.remainder += math.Min(delta, MaxDelta)
+	steps := int(s.remainder / Step)
+	s.remainder -= float64(steps) * Step
+
+	world := make([]skeleton.Transform, len(local))
+	for _, i := range s.skeleton.Order() {
+		j := s.joints[i]
+		if j == nil {
+			world[i] = s.skeleton.WorldOf(i, local[i], world)
+			continue
+		}
+
+		parent := skeleton.Transform{Rotation: math3d.IdentityQuaternion()}
+		if p := s.skeleton.Bones[i].Parent; p >= 0 {
+			parent = world[p]
+		}
+		head := parent.Apply(local[i])
+
+		for n := 0; n < steps; n++ {
+			s.step(j, head, animated)
+		}
+
+		// 先端を向くように回転させる
+		from := head.Rotation.Rotate(j.axis)
+		to := j.current.Sub(head.Position).Normalize()
+		rotation := math3d.NewQuaternionFromUnitVectors(from, to).Mul(head.Rotation)
+		local[i].Rotation = parent.Rotation.Conjugate().Mul(rotation).Normalize()
+
+		world[i] = parent.Apply(local[i])
+	}
+}
+
+// step moves the tail of the joint whose head has the transform.
+func (s *Solver) step(j *joint, head skeleton.Transform, animated []skeleton.Transform) {
+
+	inertia := j.current.Sub(j.prev).Scale(1 - j.Drag)
+	stiffness := head.Rotation.Rotate(j.axis).Scale(j.Stiffness * j.length * Step)
+	gravity := s.gravity.Scale(j.Gravity * j.length * Step)
+
+	next := j.current.Add(inertia).Add(stiffness).Add(gravity)
+	next = head.Position.Add(next.Sub(head.Position).Normalize().Scale(j.length))
+
+	for _, c := range s.colliders {
+		if c.Bone == j.Bone {
+			continue
+		}
+		t := animated[c.Bone]
+		center := t.Position.Add(t.Rotation.Rotate(c.Offset))
+		r := c.Radius + j.HitRadius
+		if d := next.Sub(center); d.Length() < r {
+			next = center.Add(d.Normalize().Scale(r))
+			next = head.Position.Add(next.Sub(head.Position).Normalize().Scale(j.length))
+		}
+	}
+
+	j.prev = j.current
+	j.current = next
+}
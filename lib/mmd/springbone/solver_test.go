@@ -0,0 +1,148 @@
+package springbone
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/skeleton"
+	"math"
+	"testing"
+)
+
+// hair makes a bone at the origin whose tail is 1 along +X, and a body bone carrying colliders.
+func hair(t *testing.T) *skeleton.Skeleton {
+	s, err := skeleton.New([]skeleton.Bone{
+		{Name: "髪", Parent: -1},
+		{Name: "髪先", Parent: 0, Position: math3d.NewVector3(1, 0, 0)},
+		{Name: "体", Parent: -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// simulate updates the solver for seconds, with the hair rotated by animated in every frame,
+// and gets the position of the tail.
+func simulate(s *skeleton.Skeleton, solver *Solver, animated math3d.Quaternion, seconds float64) math3d.Vector3 {
+	var local []skeleton.Transform
+	for t := 0.0; t < seconds; t += Step {
+		local = s.RestPose()
+		local[0].Rotation = animated
+		solver.Update(local, Step)
+	}
+	return s.World(local)[1].Position
+}
+
+func TestSolverSettles(t *testing.T) {
+
+	up := math3d.NewQuaternionFromAxisAngle(math3d.NewVector3(0, 0, 1), math.Pi/2)
+
+	tests := []struct {
+		name     string
+		joint    Joint
+		animated math3d.Quaternion
+		want     math3d.Vector3
+	}{
+		{
+			name:     "gravity only hangs down",
+			joint:    Joint{Bone: 0, Drag: 0.4, Gravity: 1},
+			animated: math3d.IdentityQuaternion(),
+			want:     math3d.NewVector3(0, -1, 0),
+		},
+		{
+			name:     "stiffness only keeps the rest direction",
+			joint:    Joint{Bone: 0, Stiffness: 4, Drag: 0.4},
+			animated: math3d.IdentityQuaternion(),
+			want:     math3d.NewVector3(1, 0, 0),
+		},
+		{
+			name:     "stiffness follows the animated direction",
+			joint:    Joint{Bone: 0, Stiffness: 4, Drag: 0.4},
+			animated: up,
+			want:     math3d.NewVector3(0, 1, 0),
+		},
+		{
+			// 先端を戻す力と重力の和の方向で釣り合う
+			name:     "stiffness against gravity",
+			joint:    Joint{Bone: 0, Stiffness: 4, Drag: 0.4, Gravity: 0.5},
+			animated: math3d.IdentityQuaternion(),
+			want:     math3d.NewVector3(4, -0.5, 0).Normalize(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := hair(t)
+			solver, err := NewSolver(s, Config{Joints: []Joint{tt.joint}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 静止状態から始め、アニメーションされた姿勢に追従させる
+			simulate(s, solver, math3d.IdentityQuaternion(), Step)
+			tail := simulate(s, solver, tt.animated, 20)
+
+			if d := tail.Distance(tt.want); d > 1e-2 {
+				t.Errorf("tail is at %v, want %v", tail, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolverColliders(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		collider Collider
+	}{
+		{
+			name:     "sphere under the tail",
+			collider: Collider{Bone: 2, Offset: math3d.NewVector3(0.2, -1, 0), Radius: 0.5},
+		},
+		{
+			name:     "sphere across the tail",
+			collider: Collider{Bone: 2, Offset: math3d.NewVector3(-0.1, -0.9, 0.1), Radius: 0.3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := hair(t)
+			joint := Joint{Bone: 0, Drag: 0.4, Gravity: 1, HitRadius: 0.1}
+			solver, err := NewSolver(s, Config{Joints: []Joint{joint}, Colliders: []Collider{tt.collider}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tail := simulate(s, solver, math3d.IdentityQuaternion(), 10)
+
+			// 押し出した後に骨の長さへ戻すため、わずかにめり込むことは許す
+			r := tt.collider.Radius + joint.HitRadius
+			if d := tail.Distance(tt.collider.Offset); d < r-1e-2 {
+				t.Errorf("tail %v is in the collider, %v from the center, want at least %v", tail, d, r)
+			}
+			if l := tail.Length(); math.Abs(l-1) > 1e-6 {
+				t.Errorf("hair length = %v, want 1", l)
+			}
+		})
+	}
+}
+
+func TestNewSolverInvalidBones(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"joint out of range", Config{Joints: []Joint{{Bone: 3}}}},
+		{"negative joint", Config{Joints: []Joint{{Bone: -1}}}},
+		{"collider out of range", Config{Colliders: []Collider{{Bone: 3}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSolver(hair(t), tt.config); err == nil {
+				t.Error("NewSolver() succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package vmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// headerSignature is the signature at the beginning of VMD files of MMD 2 or later.
+const headerSignature = "Vocaloid Motion Data 0002"
+
+const (
+	headerLength          = 30
+	modelNameLength       = 20
+	boneNameLength        = 15
+	morphNameLength       = 15
+	ikNameLength          = 20
+	boneFrameLength       = boneNameLength + 4 + 4*3 + 4*4 + 64
+	morphFrameLength      = morphNameLength + 4 + 4
+	cameraFrameLength     = 4 + 4 + 4*3 + 4*3 + 24 + 4 + 1
+	lightFrameLength      = 4 + 4*3 + 4*3
+	selfShadowFrameLength = 4 + 1 + 4
+)
+
+// ErrInvalidHeader is returned when the data is not a VMD file.
+var ErrInvalidHeader = errors.New("vmd: invalid header")
+
+// Decode reads a VMD file.
+// Sections after the morph keyframes may be missing in files of old MMD, and they are left empty then.
+func Decode(r io.Reader) (*Motion, error) {
+
+	d := &decoder{r: bufio.NewReader(r)}
+	m := &Motion{}
+
+	header, err := d.bytes(headerLength)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(header, []byte(headerSignature)) {
+		return nil, ErrInvalidHeader
+	}
+
+	if m.ModelName, err = d.text(modelNameLength); err != nil {
+		return nil, err
+	}
+
+	// ボーンとモーフは必須
+	if m.Bones, err = d.bones(); err != nil {
+		return nil, err
+	}
+	if m.Morphs, err = d.morphs(); err != nil {
+		return nil, err
+	}
+
+	// 以降のセクションは古いファイルでは省略される
+	if m.Cameras, err = d.cameras(); err != nil {
+		return optional(m, err)
+	}
+	if m.Lights, err = d.lights(); err != nil {
+		return optional(m, err)
+	}
+	if m.SelfShadows, err = d.selfShadows(); err != nil {
+		return optional(m, err)
+	}
+	if m.IKs, err = d.iks(); err != nil {
+		return optional(m, err)
+	}
+
+	return m, nil
+}
+
+// optional returns the motion read so far if the file ends at the beginning of a section.
+func optional(m *Motion, err error) (*Motion, error) {
+	if err == errSectionMissing {
+		return m, nil
+	}
+	return nil, err
+}
+
+// errSectionMissing is the file ends before the count of a section.
+var errSectionMissing = errors.New("vmd: section is missing")
+
+type decoder struct {
+	r *bufio.Reader
+}
+
+func (d *decoder) bytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return nil, unexpected(err)
+	}
+	return b, nil
+}
+
+func (d *decoder) text(n int) (string, error) {
+	b, err := d.bytes(n)
+	if err != nil {
+		return "", err
+	}
+	return decodeText(b)
+}
+
+// count reads the number of records of a section.
+func (d *decoder) count(recordLength int) (int, error) {
+
+	b := make([]byte, 4)
+	n, err := io.ReadFull(d.r, b)
+	if n == 0 && err == io.EOF {
+		return 0, errSectionMissing
+	}
+	if err != nil {
+		return 0, unexpected(err)
+	}
+
+	c := binary.LittleEndian.Uint32(b)
+	// 壊れたファイルで件数がオーバーフローしないよう上限を確かめる
+	if recordLength > 0 && uint64(c) > math.MaxInt32/uint64(recordLength) {
+		return 0, fmt.Errorf("vmd: too many records: %d", c)
+	}
+	return int(c), nil
+}
+
+func (d *decoder) bones() ([]BoneFrame, error) {
+
+	n, err := d.count(boneFrameLength)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([]BoneFrame, 0, capacity(n))
+	for i := 0; i < n; i++ {
+		b, err := d.bytes(boneFrameLength)
+		if err != nil {
+			return nil, err
+		}
+
+		var f BoneFrame
+		if f.Name, err = decodeText(b[:boneNameLength]); err != nil {
+			return nil, err
+		}
+		p := b[boneNameLength:]
+		f.Frame = binary.LittleEndian.Uint32(p)
+		floats(p[4:], f.Position[:])
+		floats(p[16:], f.Rotation[:])
+		copy(f.RawInterpolation[:], p[32:])
+
+		frames = append(frames, f)
+	}
+
+	return frames, nil
+}
+
+func (d *decoder) morphs() ([]MorphFrame, error) {
+
+	n, err := d.count(morphFrameLength)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([]MorphFrame, 0, capacity(n))
+	for i := 0; i < n; i++ {
+		b, err := d.bytes(morphFrameLength)
+		if err != nil {
+			return nil, err
+		}
+
+		var f MorphFrame
+		if f.Name, err = decodeText(b[:morphNameLength]); err != nil {
+			return nil, err
+		}
+		p := b[morphNameLength:]
+		f.Frame = binary.LittleEndian.Uint32(p)
+		f.Weight = math.Float32frombits(binary.LittleEndian.Uint32(p[4:]))
+
+		frames = append(frames, f)
+	}
+
+	return frames, nil
+}
+
+func (d *decoder) cameras() ([]CameraFrame, error) {
+
+	n, err := d.count(cameraFrameLength)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([]CameraFrame, 0, capacity(n))
+	for i := 0; i < n; i++ {
+		b, err := d.bytes(cameraFrameLength)
+		if err != nil {
+			return nil, err
+		}
+
+		var f CameraFrame
+		f.Frame = binary.LittleEndian.Uint32(b)
+		f.Distance = math.Float32frombits(binary.LittleEndian.Uint32(b[4:]))
+		floats(b[8:], f.Position[:])
+		floats(b[20:], f.Rotation[:])
+		copy(f.RawInterpolation[:], b[32:56])
+		f.Fov = binary.LittleEndian.Uint32(b[56:])
+		// 0でパースペクティブ有効
+		f.Orthographic = b[60] != 0
+
+		frames = append(frames, f)
+	}
+
+	return frames, nil
+}
+
+func (d *decoder) lights() ([]LightFrame, error) {
+
+	n, err := d.count(lightFrameLength)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([]LightFrame, 0, capacity(n))
+	for i := 0; i < n; i++ {
+		b, err := d.bytes(lightFrameLength)
+		if err != nil {
+			return nil, err
+		}
+
+		var f LightFrame
+		f.Frame = binary.LittleEndian.Uint32(b)
+		floats(b[4:], f.Color[:])
+		floats(b[16:], f.Direction[:])
+
+		frames = append(frames, f)
+	}
+
+	return frames, nil
+}
+
+func (d *decoder) selfShadows() ([]SelfShadowFrame, error) {
+
+	n, err := d.count(selfShadowFrameLength)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([]SelfShadowFrame, 0, capacity(n))
+	for i := 0; i < n; i++ {
+		b, err := d.bytes(selfShadowFrameLength)
+		if err != nil {
+			return nil, err
+		}
+
+		frames = append(frames, SelfShadowFrame{
+			Frame:    binary.LittleEndian.Uint32(b),
+			Mode:     SelfShadowMode(b[4]),
+			Distance: math.Float32frombits(binary.LittleEndian.Uint32(b[5:])),
+		})
+	}
+
+	return frames, nil
+}
+
+func (d *decoder) iks() ([]IKFrame, error) {
+
+	// IKのレコードは可変長のため、最小の長さで上限を確かめる
+	n, err := d.count(4 + 1 + 4)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([]IKFrame, 0, capacity(n))
+	for i := 0; i < n; i++ {
+		b, err := d.bytes(4 + 1 + 4)
+		if err != nil {
+			return nil, err
+		}
+
+		f := IKFrame{
+			Frame: binary.LittleEndian.Uint32(b),
+			Show:  b[4] != 0,
+		}
+		count := binary.LittleEndian.Uint32(b[5:])
+		if count > math.MaxInt32/(ikNameLength+1) {
+			return nil, fmt.Errorf("vmd: too many IK states: %d", count)
+		}
+
+		f.IKs = make([]IKState, 0, capacity(int(count)))
+		for j := 0; j < int(count); j++ {
+			s, err := d.bytes(ikNameLength + 1)
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := decodeText(s[:ikNameLength])
+			if err != nil {
+				return nil, err
+			}
+			f.IKs = append(f.IKs, IKState{Name: name, Enabled: s[ikNameLength] != 0})
+		}
+
+		frames = append(frames, f)
+	}
+
+	return frames, nil
+}
+
+// capacity limits initial capacity of slices, since the count may be broken.
+func capacity(n int) int {
+	const max = 1 << 16
+	if n > max {
+		return max
+	}
+	return n
+}
+
+func floats(b []byte, dst []float32) {
+	for i := range dst {
+		dst[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+}
+
+// decodeText decodes a null-terminated Shift_JIS string.
+// MMD leaves garbage after the terminator, so it is ignored.
+func decodeText(b []byte) (string, error) {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+
+	s, err := japanese.ShiftJIS.NewDecoder().Bytes(b)
+	if err != nil {
+		return "", fmt.Errorf("vmd: invalid text: %w", err)
+	}
+	return string(s), nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
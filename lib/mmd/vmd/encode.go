@@ -0,0 +1,136 @@
+package vmd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Encode writes the motion as a VMD file of MMD 2 or later.
+// Names longer than their fields are truncated, and characters which Shift_JIS does not have are replaced with "?".
+func Encode(w io.Writer, m *Motion) error {
+
+	e := &encoder{w: bufio.NewWriter(w)}
+
+	e.text(headerSignature, headerLength)
+	e.text(m.ModelName, modelNameLength)
+
+	e.uint32(uint32(len(m.Bones)))
+	for _, f := range m.Bones {
+		e.text(f.Name, boneNameLength)
+		e.uint32(f.Frame)
+		e.floats(f.Position[:])
+		e.floats(f.Rotation[:])
+		e.write(f.RawInterpolation[:])
+	}
+
+	e.uint32(uint32(len(m.Morphs)))
+	for _, f := range m.Morphs {
+		e.text(f.Name, morphNameLength)
+		e.uint32(f.Frame)
+		e.floats([]float32{f.Weight})
+	}
+
+	e.uint32(uint32(len(m.Cameras)))
+	for _, f := range m.Cameras {
+		e.uint32(f.Frame)
+		e.floats([]float32{f.Distance})
+		e.floats(f.Position[:])
+		e.floats(f.Rotation[:])
+		e.write(f.RawInterpolation[:])
+		e.uint32(f.Fov)
+		e.bool(f.Orthographic)
+	}
+
+	e.uint32(uint32(len(m.Lights)))
+	for _, f := range m.Lights {
+		e.uint32(f.Frame)
+		e.floats(f.Color[:])
+		e.floats(f.Direction[:])
+	}
+
+	e.uint32(uint32(len(m.SelfShadows)))
+	for _, f := range m.SelfShadows {
+		e.uint32(f.Frame)
+		e.write([]byte{byte(f.Mode)})
+		e.floats([]float32{f.Distance})
+	}
+
+	e.uint32(uint32(len(m.IKs)))
+	for _, f := range m.IKs {
+		e.uint32(f.Frame)
+		e.bool(f.Show)
+		e.uint32(uint32(len(f.IKs)))
+		for _, s := range f.IKs {
+			e.text(s.Name, ikNameLength)
+			e.bool(s.Enabled)
+		}
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// encoder keeps the first error, so that writes can be chained.
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) write(b []byte) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.Write(b)
+}
+
+func (e *encoder) uint32(v uint32) {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	e.write(b)
+}
+
+func (e *encoder) floats(v []float32) {
+	b := make([]byte, len(v)*4)
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[i*4:], math.Float32bits(f))
+	}
+	e.write(b)
+}
+
+func (e *encoder) bool(v bool) {
+	if v {
+		e.write([]byte{1})
+	} else {
+		e.write([]byte{0})
+	}
+}
+
+// text writes a null-padded Shift_JIS string of n bytes.
+func (e *encoder) text(s string, n int) {
+	e.write(encodeText(s, n))
+}
+
+// encodeText encodes the string in Shift_JIS to n bytes without splitting multibyte characters.
+func encodeText(s string, n int) []byte {
+
+	b := make([]byte, 0, n)
+	encoder := japanese.ShiftJIS.NewEncoder()
+	for _, r := range s {
+		c, err := encoder.Bytes([]byte(string(r)))
+		if err != nil {
+			c = []byte("?")
+		}
+		if len(b)+len(c) > n {
+			break
+		}
+		b = append(b, c...)
+	}
+
+	return append(b, make([]byte, n-len(b))...)
+}
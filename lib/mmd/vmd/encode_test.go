@@ -0,0 +1,91 @@
+package vmd
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// sample makes a motion with keyframes in every section.
+func sample() *Motion {
+
+	bone := BoneFrame{Name: "左足ＩＫ", Frame: 10, Position: [3]float32{1.5, 0.25, -2}, Rotation: [4]float32{0, 0.38268343, 0, 0.9238795}}
+	bone.SetInterpolation(ChannelX, LinearBezier)
+	bone.SetInterpolation(ChannelY, Bezier{X1: 64, Y1: 0, X2: 64, Y2: 127})
+	bone.SetInterpolation(ChannelZ, LinearBezier)
+	bone.SetInterpolation(ChannelRotation, Bezier{X1: 10, Y1: 90, X2: 100, Y2: 30})
+
+	return &Motion{
+		ModelName: "初音ミク",
+		Bones: []BoneFrame{
+			{Name: "センター", Frame: 0, Rotation: [4]float32{0, 0, 0, 1}},
+			bone,
+		},
+		Morphs: []MorphFrame{
+			{Name: "まばたき", Frame: 0, Weight: 0},
+			{Name: "ウィンク", Frame: 5, Weight: 1},
+		},
+		Cameras: []CameraFrame{
+			{Frame: 0, Distance: -45, Position: [3]float32{0, 10, 0}, RawInterpolation: LinearCameraInterpolation, Fov: 30},
+			{Frame: 30, Distance: -20, Position: [3]float32{2, 12, 1}, Rotation: [3]float32{0.1, -0.5, 0.2}, RawInterpolation: LinearCameraInterpolation, Fov: 45, Orthographic: true},
+		},
+		Lights: []LightFrame{
+			{Frame: 0, Color: [3]float32{0.6, 0.6, 0.6}, Direction: [3]float32{-0.5, -1, 0.5}},
+		},
+		SelfShadows: []SelfShadowFrame{
+			{Frame: 0, Mode: SelfShadowMode1, Distance: 0.088},
+		},
+		IKs: []IKFrame{
+			{Frame: 0, Show: true, IKs: []IKState{{Name: "左足ＩＫ", Enabled: true}, {Name: "右足ＩＫ", Enabled: false}}},
+		},
+	}
+}
+
+func TestEncode(t *testing.T) {
+
+	m := sample()
+
+	var b bytes.Buffer
+	if err := Encode(&b, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("Decode(Encode(m)) = %+v, want %+v", got, m)
+	}
+}
+
+func TestEncodeText(t *testing.T) {
+
+	tests := []struct {
+		name string
+		in   string
+		n    int
+		want string
+	}{
+		{"fits", "センター", 15, "センター"},
+		// 2バイト文字を途中で切らない
+		{"truncated", "左ひじ捩れ補助ボーン", 15, "左ひじ捩れ補助"},
+		{"not in Shift_JIS", "右👍", 15, "右?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := encodeText(tt.in, tt.n)
+			if len(b) != tt.n {
+				t.Fatalf("%d bytes, want %d", len(b), tt.n)
+			}
+			got, err := decodeText(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("encodeText(%q) is %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
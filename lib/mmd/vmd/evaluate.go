@@ -0,0 +1,101 @@
+package vmd
+
+import (
+	"math"
+	"sort"
+)
+
+// BoneTracks splits bone keyframes by bone name. The keyframes must be sorted by frame.
+func (m *Motion) BoneTracks() map[string][]BoneFrame {
+	tracks := make(map[string][]BoneFrame)
+	for _, f := range m.Bones {
+		tracks[f.Name] = append(tracks[f.Name], f)
+	}
+	return tracks
+}
+
+// MorphTracks splits morph keyframes by morph name. The keyframes must be sorted by frame.
+func (m *Motion) MorphTracks() map[string][]MorphFrame {
+	tracks := make(map[string][]MorphFrame)
+	for _, f := range m.Morphs {
+		tracks[f.Name] = append(tracks[f.Name], f)
+	}
+	return tracks
+}
+
+// BoneAt gets the position and the rotation at the frame from keyframes of a bone sorted by frame.
+// They are interpolated by the curves of the next keyframe, as MMD does.
+// It returns false if there are no keyframes.
+func BoneAt(frames []BoneFrame, frame float64) (BoneFrame, bool) {
+
+	if len(frames) == 0 {
+		return BoneFrame{}, false
+	}
+
+	// frameより後ろにある最初のキーフレーム
+	i := sort.Search(len(frames), func(i int) bool {
+		return float64(frames[i].Frame) > frame
+	})
+	if i == 0 {
+		return frames[0], true
+	}
+	if i == len(frames) {
+		return frames[i-1], true
+	}
+
+	prev, next := frames[i-1], frames[i]
+	t := (frame - float64(prev.Frame)) / float64(next.Frame-prev.Frame)
+
+	for j := 0; j < 3; j++ {
+		s := next.Interpolation(ChannelX + Channel(j)).Evaluate(t)
+		prev.Position[j] += float32(s) * (next.Position[j] - prev.Position[j])
+	}
+
+	s := next.Interpolation(ChannelRotation).Evaluate(t)
+	q := quaternion(prev.Rotation).Slerp(quaternion(next.Rotation), s)
+	prev.Rotation = [4]float32{float32(q.X), float32(q.Y), float32(q.Z), float32(q.W)}
+
+	prev.Frame = uint32(math.Floor(frame))
+	return prev, true
+}
+
+// MorphAt gets the weight at the frame from keyframes of a morph sorted by frame, interpolated linearly.
+// It returns false if there are no keyframes.
+func MorphAt(frames []MorphFrame, frame float64) (float32, bool) {
+
+	if len(frames) == 0 {
+		return 0, false
+	}
+
+	i := sort.Search(len(frames), func(i int) bool {
+		return float64(frames[i].Frame) > frame
+	})
+	if i == 0 {
+		return frames[0].Weight, true
+	}
+	if i == len(frames) {
+		return frames[i-1].Weight, true
+	}
+
+	prev, next := frames[i-1], frames[i]
+	t := float32((frame - float64(prev.Frame)) / float64(next.Frame-prev.Frame))
+	return prev.Weight + t*(next.Weight-prev.Weight), true
+}
+
+// IKEnabledAt gets whether the IK bone is enabled at the frame. The keyframes must be sorted by frame.
+// IK bones are enabled unless a keyframe turns them off.
+func IKEnabledAt(frames []IKFrame, name string, frame float64) bool {
+
+	enabled := true
+	for _, f := range frames {
+		if float64(f.Frame) > frame {
+			break
+		}
+		for _, s := range f.IKs {
+			if s.Name == name {
+				enabled = s.Enabled
+			}
+		}
+	}
+	return enabled
+}
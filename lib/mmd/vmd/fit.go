@@ -0,0 +1,264 @@
+package vmd
+
+import (
+	"math"
+	"sort"
+)
+
+// FitReport is the result of Fit.
+type FitReport struct {
+	// Before and After are the numbers of bone and morph keyframes.
+	Before int
+	After  int
+
+	// MaxPosition, MaxRotation and MaxWeight are the largest errors of the fitted motion at integer frames.
+	MaxPosition float64
+	MaxRotation float64
+	MaxWeight   float64
+}
+
+// Ratio gets the compression ratio, the number of keyframes before fitting divided by the number after.
+func (r FitReport) Ratio() float64 {
+	if r.After == 0 {
+		return 1
+	}
+	return float64(r.Before) / float64(r.After)
+}
+
+// Fit replaces bone keyframes with fewer ones whose Bezier curves reproduce the motion within the tolerance,
+// and reduces morph keyframes as Reduce does, since morphs are interpolated linearly.
+// It is meant for dense motions such as motion capture. The motion is compared with the original at every integer frame.
+// The keyframes are sorted by frame afterwards.
+func (m *Motion) Fit(t Tolerance) FitReport {
+
+	m.Sort()
+	r := FitReport{Before: len(m.Bones) + len(m.Morphs)}
+
+	var bones []BoneFrame
+	for _, frames := range groupBones(m.Bones) {
+		bones = append(bones, fitBones(frames, t, &r)...)
+	}
+	m.Bones = bones
+
+	var morphs []MorphFrame
+	for _, frames := range groupMorphs(m.Morphs) {
+		morphs = append(morphs, fitMorphs(frames, t, &r)...)
+	}
+	m.Morphs = morphs
+
+	m.Sort()
+	r.After = len(m.Bones) + len(m.Morphs)
+	return r
+}
+
+// fitBones fits sorted keyframes of a bone.
+func fitBones(frames []BoneFrame, t Tolerance, r *FitReport) []BoneFrame {
+
+	if len(frames) <= 2 {
+		return frames
+	}
+
+	// 元のモーションを毎フレーム評価したものを再現する
+	first, last := frames[0].Frame, frames[len(frames)-1].Frame
+	samples := make([]BoneFrame, last-first+1)
+	for i := range samples {
+		samples[i], _ = BoneAt(frames, float64(first)+float64(i))
+		samples[i].Frame = first + uint32(i)
+	}
+
+	fitted := []BoneFrame{samples[0]}
+	for start := 0; start < len(samples)-1; {
+		// 倍々に伸ばしてから二分探索で、収まる最も長い区間を探す
+		end := start + 1
+		f, _ := fitBone(samples[start], samples[end], nil, t)
+		for step := 1; end < len(samples)-1; step *= 2 {
+			next := end + step
+			if next >= len(samples) {
+				next = len(samples) - 1
+			}
+			if g, ok := fitBone(samples[start], samples[next], samples[start+1:next], t); ok {
+				end, f = next, g
+				continue
+			}
+
+			// endで収まり、nextで収まらない
+			for lo, hi := end, next; hi-lo > 1; {
+				mid := (lo + hi) / 2
+				if g, ok := fitBone(samples[start], samples[mid], samples[start+1:mid], t); ok {
+					lo, end, f = mid, mid, g
+				} else {
+					hi = mid
+				}
+			}
+			break
+		}
+
+		p, q := boneErrors(samples[start], f, samples[start+1:end])
+		r.MaxPosition = math.Max(r.MaxPosition, p)
+		r.MaxRotation = math.Max(r.MaxRotation, q)
+
+		fitted = append(fitted, f)
+		start = end
+	}
+
+	return fitted
+}
+
+// fitBone finds curves from a to b which reproduce the keyframes between them.
+// It returns b with the curves, and false if no curves are within the tolerance.
+func fitBone(a BoneFrame, b BoneFrame, between []BoneFrame, t Tolerance) (BoneFrame, bool) {
+
+	s := make([]float64, len(between))
+	for i, f := range between {
+		s[i] = float64(f.Frame-a.Frame) / float64(b.Frame-a.Frame)
+	}
+
+	// 各軸の誤差を抑えて、距離の誤差を許容値に収める
+	tolerance := t.Position / math.Sqrt(3)
+	for j := 0; j < 3; j++ {
+		va, vb := float64(a.Position[j]), float64(b.Position[j])
+		curve, ok := fitCurve(s, tolerance, func(i int, y float64) float64 {
+			return math.Abs(va + (vb-va)*y - float64(between[i].Position[j]))
+		})
+		if !ok {
+			return b, false
+		}
+		b.SetInterpolation(ChannelX+Channel(j), curve)
+	}
+
+	qa, qb := quaternion(a.Rotation), quaternion(b.Rotation)
+	curve, ok := fitCurve(s, t.Rotation, func(i int, y float64) float64 {
+		return qa.Slerp(qb, y).Angle(quaternion(between[i].Rotation))
+	})
+	if !ok {
+		return b, false
+	}
+	b.SetInterpolation(ChannelRotation, curve)
+
+	return b, true
+}
+
+// boneErrors gets the largest errors of the position and the rotation of keyframes between a and b.
+func boneErrors(a BoneFrame, b BoneFrame, between []BoneFrame) (position float64, rotation float64) {
+
+	track := []BoneFrame{a, b}
+	for _, f := range between {
+		v, _ := BoneAt(track, float64(f.Frame))
+		position = math.Max(position, vector(v.Position).Distance(vector(f.Position)))
+		rotation = math.Max(rotation, quaternion(v.Rotation).Angle(quaternion(f.Rotation)))
+	}
+	return position, rotation
+}
+
+// fitCurve finds a Bezier curve whose progress at the times s gives errors within the tolerance.
+// err gets the error of the i-th sample when the progress is y.
+func fitCurve(s []float64, tolerance float64, err func(i int, y float64) float64) (Bezier, bool) {
+
+	cost := func(b Bezier) float64 {
+		var e float64
+		for i, x := range s {
+			e = math.Max(e, err(i, b.Evaluate(x)))
+		}
+		return e
+	}
+
+	best := LinearBezier
+	e := cost(best)
+	if e <= tolerance || len(s) == 0 {
+		return best, true
+	}
+
+	// 両端の傾きから初期値を決め、制御点を一つずつ動かして誤差を減らす
+	initial := tangents(s, err)
+	if c := cost(initial); c < e {
+		best, e = initial, c
+	}
+
+	for step := 32; step >= 1 && e > tolerance; step /= 2 {
+		for improved := true; improved && e > tolerance; {
+			improved = false
+			for k := 0; k < 4; k++ {
+				for _, d := range []int{step, -step} {
+					c := best
+					p := c.point(k)
+					v := int(*p) + d
+					if v < 0 || v > 127 {
+						continue
+					}
+					*p = byte(v)
+					if ce := cost(c); ce < e {
+						best, e, improved = c, ce, true
+					}
+				}
+			}
+		}
+	}
+
+	return best, e <= tolerance
+}
+
+// tangents makes a curve with the slopes at both ends of the samples.
+// The progress of each sample is found by searching y where the error is smallest.
+func tangents(s []float64, err func(i int, y float64) float64) Bezier {
+
+	progress := func(i int) float64 {
+		// 誤差が最小になる進み具合を0から1の範囲で探す
+		k := sort.Search(128, func(k int) bool {
+			return k == 127 || err(i, float64(k+1)/127) > err(i, float64(k)/127)
+		})
+		return float64(k) / 127
+	}
+
+	n := len(s)
+	m0 := progress(0) / s[0]
+	m1 := (1 - progress(n-1)) / (1 - s[n-1])
+
+	clamp := func(v float64) byte {
+		return byte(math.Round(math.Max(0, math.Min(1, v)) * 127))
+	}
+	return Bezier{
+		X1: clamp(1.0 / 3),
+		Y1: clamp(m0 / 3),
+		X2: clamp(2.0 / 3),
+		Y2: clamp(1 - m1/3),
+	}
+}
+
+// point gets the control value k in the order X1, Y1, X2, Y2.
+func (b *Bezier) point(k int) *byte {
+	switch k {
+	case 0:
+		return &b.X1
+	case 1:
+		return &b.Y1
+	case 2:
+		return &b.X2
+	}
+	return &b.Y2
+}
+
+// fitMorphs reduces sorted keyframes of a morph evaluated at every frame.
+func fitMorphs(frames []MorphFrame, t Tolerance, r *FitReport) []MorphFrame {
+
+	if len(frames) <= 2 {
+		return frames
+	}
+
+	first, last := frames[0].Frame, frames[len(frames)-1].Frame
+	samples := make([]MorphFrame, last-first+1)
+	for i := range samples {
+		w, _ := MorphAt(frames, float64(first)+float64(i))
+		samples[i] = MorphFrame{Name: frames[0].Name, Frame: first + uint32(i), Weight: w}
+	}
+
+	reduced := reduceMorphs(samples, t)
+	for k := 1; k < len(reduced); k++ {
+		a, b := reduced[k-1], reduced[k]
+		for _, f := range samples[a.Frame-first+1 : b.Frame-first] {
+			s := float64(f.Frame-a.Frame) / float64(b.Frame-a.Frame)
+			w := float64(a.Weight) + (float64(b.Weight)-float64(a.Weight))*s
+			r.MaxWeight = math.Max(r.MaxWeight, math.Abs(w-float64(f.Weight)))
+		}
+	}
+	return reduced
+}
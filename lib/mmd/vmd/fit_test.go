@@ -0,0 +1,122 @@
+package vmd
+
+import (
+	"math"
+	"testing"
+)
+
+// dense makes a motion with keyframes on every frame for seconds, as motion capture makes.
+// The bone eases in and out while it moves and turns, and the morph rises and falls linearly.
+func dense(frames int) *Motion {
+
+	m := &Motion{}
+	for i := 0; i <= frames; i++ {
+		x := float64(i) / float64(frames)
+		ease := x * x * (3 - 2*x)
+		a := ease * math.Pi / 2
+
+		f := BoneFrame{
+			Name:     "センター",
+			Frame:    uint32(i),
+			Position: [3]float32{float32(10 * ease), float32(math.Sin(x * 2 * math.Pi)), 0},
+			Rotation: [4]float32{0, float32(math.Sin(a / 2)), 0, float32(math.Cos(a / 2))},
+		}
+		for ch := ChannelX; ch <= ChannelRotation; ch++ {
+			f.SetInterpolation(ch, LinearBezier)
+		}
+		m.Bones = append(m.Bones, f)
+
+		m.Morphs = append(m.Morphs, MorphFrame{Name: "あ", Frame: uint32(i), Weight: float32(1 - math.Abs(2*x-1))})
+	}
+	return m
+}
+
+func TestFit(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		tolerance Tolerance
+	}{
+		{"default", DefaultTolerance},
+		{"loose", Tolerance{Position: 0.1, Rotation: 2 * math.Pi / 180, Weight: 0.05}},
+		{"tight", Tolerance{Position: 0.001, Rotation: 0.02 * math.Pi / 180, Weight: 0.0005}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := dense(90)
+			m := dense(90)
+			r := m.Fit(tt.tolerance)
+
+			if r.Before != len(original.Bones)+len(original.Morphs) {
+				t.Errorf("Before = %d, want %d", r.Before, len(original.Bones)+len(original.Morphs))
+			}
+			if r.After != len(m.Bones)+len(m.Morphs) {
+				t.Errorf("After = %d, want %d", r.After, len(m.Bones)+len(m.Morphs))
+			}
+			if r.After >= r.Before {
+				t.Errorf("After = %d, want fewer than %d", r.After, r.Before)
+			}
+			if want := float64(r.Before) / float64(r.After); r.Ratio() != want {
+				t.Errorf("Ratio() = %v, want %v", r.Ratio(), want)
+			}
+
+			// 毎フレーム元のモーションと比べ、最大誤差が許容値と報告に一致することを確かめる
+			var position, rotation, weight float64
+			for i := range original.Bones {
+				want := original.Bones[i]
+				got, _ := BoneAt(m.Bones, float64(want.Frame))
+				position = math.Max(position, vector(got.Position).Distance(vector(want.Position)))
+				rotation = math.Max(rotation, quaternion(got.Rotation).Angle(quaternion(want.Rotation)))
+
+				w, _ := MorphAt(m.Morphs, float64(want.Frame))
+				weight = math.Max(weight, math.Abs(float64(w-original.Morphs[i].Weight)))
+			}
+
+			for _, e := range []struct {
+				name      string
+				measured  float64
+				reported  float64
+				tolerance float64
+			}{
+				{"position", position, r.MaxPosition, tt.tolerance.Position},
+				{"rotation", rotation, r.MaxRotation, tt.tolerance.Rotation},
+				{"weight", weight, r.MaxWeight, tt.tolerance.Weight},
+			} {
+				if e.measured > e.tolerance {
+					t.Errorf("%s error %v is over the tolerance %v", e.name, e.measured, e.tolerance)
+				}
+				if math.Abs(e.measured-e.reported) > 1e-6 {
+					t.Errorf("%s error %v is reported as %v", e.name, e.measured, e.reported)
+				}
+			}
+		})
+	}
+}
+
+func TestFitLinear(t *testing.T) {
+
+	// 直線的な動きは両端のキーフレームだけになる
+	m := &Motion{}
+	for i := 0; i <= 30; i++ {
+		f := BoneFrame{Name: "全ての親", Frame: uint32(i), Position: [3]float32{float32(i), 0, 0}, Rotation: [4]float32{0, 0, 0, 1}}
+		for ch := ChannelX; ch <= ChannelRotation; ch++ {
+			f.SetInterpolation(ch, LinearBezier)
+		}
+		m.Bones = append(m.Bones, f)
+	}
+
+	r := m.Fit(DefaultTolerance)
+	if len(m.Bones) != 2 || m.Bones[0].Frame != 0 || m.Bones[1].Frame != 30 {
+		t.Errorf("fitted to %d keyframes, want frames 0 and 30", len(m.Bones))
+	}
+	if r.Before != 31 || r.After != 2 || r.MaxPosition > 1e-6 {
+		t.Errorf("report = %+v, want 31 to 2 keyframes without error", r)
+	}
+}
+
+func TestFitReportRatio(t *testing.T) {
+	if got := (FitReport{}).Ratio(); got != 1 {
+		t.Errorf("Ratio() of an empty motion = %v, want 1", got)
+	}
+}
@@ -0,0 +1,131 @@
+package vmd
+
+import "math"
+
+// Bezier is a cubic Bezier curve from (0, 0) to (127, 127) with two control points.
+type Bezier struct {
+	X1, Y1, X2, Y2 byte
+}
+
+// LinearBezier is the curve of a straight line.
+var LinearBezier = Bezier{X1: 20, Y1: 20, X2: 107, Y2: 107}
+
+// Channel is an interpolated channel of a keyframe.
+type Channel int
+
+const (
+	// ChannelX is X of bone position or camera target.
+	ChannelX Channel = iota
+	// ChannelY is Y of bone position or camera target.
+	ChannelY
+	// ChannelZ is Z of bone position or camera target.
+	ChannelZ
+	// ChannelRotation is bone or camera rotation.
+	ChannelRotation
+	// ChannelDistance is camera distance.
+	ChannelDistance
+	// ChannelFov is camera FOV.
+	ChannelFov
+)
+
+// Interpolation gets the curve of the channel. Channels other than X, Y, Z and rotation are linear.
+func (f *BoneFrame) Interpolation(ch Channel) Bezier {
+	if ch > ChannelRotation {
+		return LinearBezier
+	}
+
+	i := int(ch)
+	return Bezier{
+		X1: f.RawInterpolation[i],
+		Y1: f.RawInterpolation[i+4],
+		X2: f.RawInterpolation[i+8],
+		Y2: f.RawInterpolation[i+12],
+	}
+}
+
+// SetInterpolation sets the curve of the channel.
+// The bytes which MMD fills with copies of the curves are filled in the same way.
+func (f *BoneFrame) SetInterpolation(ch Channel, b Bezier) {
+	if ch > ChannelRotation {
+		return
+	}
+
+	i := int(ch)
+	f.RawInterpolation[i] = b.X1
+	f.RawInterpolation[i+4] = b.Y1
+	f.RawInterpolation[i+8] = b.X2
+	f.RawInterpolation[i+12] = b.Y2
+
+	// 残りの48バイトは先頭16バイトを1バイトずつずらした複製
+	for row := 1; row < 4; row++ {
+		for j := 0; j < 16; j++ {
+			k := j + row
+			if k < 16 {
+				f.RawInterpolation[row*16+j] = f.RawInterpolation[k]
+			} else {
+				f.RawInterpolation[row*16+j] = 0
+			}
+		}
+	}
+}
+
+// Interpolation gets the curve of the channel.
+func (f *CameraFrame) Interpolation(ch Channel) Bezier {
+	i := int(ch) * 4
+	return Bezier{
+		X1: f.RawInterpolation[i],
+		X2: f.RawInterpolation[i+1],
+		Y1: f.RawInterpolation[i+2],
+		Y2: f.RawInterpolation[i+3],
+	}
+}
+
+// SetInterpolation sets the curve of the channel.
+func (f *CameraFrame) SetInterpolation(ch Channel, b Bezier) {
+	i := int(ch) * 4
+	f.RawInterpolation[i] = b.X1
+	f.RawInterpolation[i+1] = b.X2
+	f.RawInterpolation[i+2] = b.Y1
+	f.RawInterpolation[i+3] = b.Y2
+}
+
+// Evaluate gets the progress of the value y in [0, 1] at the progress of time x in [0, 1].
+func (b Bezier) Evaluate(x float64) float64 {
+
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	if b.X1 == b.Y1 && b.X2 == b.Y2 {
+		return x
+	}
+
+	x1, y1 := float64(b.X1)/127, float64(b.Y1)/127
+	x2, y2 := float64(b.X2)/127, float64(b.Y2)/127
+
+	// xについてtを二分法で解く
+	lo, hi := 0.0, 1.0
+	t := x
+	for i := 0; i < 32; i++ {
+		bx := cubic(t, x1, x2)
+		if math.Abs(bx-x) < 1e-7 {
+			break
+		}
+		if bx < x {
+			lo = t
+		} else {
+			hi = t
+		}
+		t = (lo + hi) / 2
+	}
+
+	return cubic(t, y1, y2)
+}
+
+// cubic gets the value of a cubic Bezier curve from 0 to 1 with control values p1 and p2.
+func cubic(t float64, p1 float64, p2 float64) float64 {
+	s := 1 - t
+	return 3*s*s*t*p1 + 3*s*t*t*p2 + t*t*t
+}
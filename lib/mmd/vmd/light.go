@@ -0,0 +1,40 @@
+package vmd
+
+import (
+	"sort"
+)
+
+// DefaultLight is the light in the initial state of MMD.
+var DefaultLight = LightFrame{
+	Color:     [3]float32{154.0 / 255, 154.0 / 255, 154.0 / 255},
+	Direction: [3]float32{-0.5, -1.0, 0.5},
+}
+
+// LightAt gets the light at the frame. The keyframes must be sorted by frame.
+// The color and the direction are interpolated linearly between keyframes.
+// It returns false if there are no keyframes.
+func LightAt(frames []LightFrame, frame float64) (LightFrame, bool) {
+
+	if len(frames) == 0 {
+		return LightFrame{}, false
+	}
+
+	// frameより後ろにある最初のキーフレーム
+	i := sort.Search(len(frames), func(i int) bool {
+		return float64(frames[i].Frame) > frame
+	})
+	if i == 0 {
+		return frames[0], true
+	}
+	if i == len(frames) {
+		return frames[i-1], true
+	}
+
+	prev, next := frames[i-1], frames[i]
+	t := float32((frame - float64(prev.Frame)) / float64(next.Frame-prev.Frame))
+	for j := 0; j < 3; j++ {
+		prev.Color[j] += t * (next.Color[j] - prev.Color[j])
+		prev.Direction[j] += t * (next.Direction[j] - prev.Direction[j])
+	}
+	return prev, true
+}
@@ -0,0 +1,74 @@
+package vmd
+
+import "strings"
+
+// MirroredNames are pairs of names which swap in mirror images, besides names with 左 and 右.
+// Morphs of the standard models close the left eye without 左 in their names.
+var MirroredNames = map[string]string{
+	"ウィンク":   "ウィンク右",
+	"ウィンク右":  "ウィンク",
+	"ウィンク２":  "ｳｨﾝｸ２右",
+	"ｳｨﾝｸ２右": "ウィンク２",
+}
+
+// MirrorName gets the name of the bone or morph of the other side.
+func MirrorName(name string) string {
+
+	if v, ok := MirroredNames[name]; ok {
+		return v
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '左':
+			return '右'
+		case '右':
+			return '左'
+		}
+		return r
+	}, name)
+}
+
+// MirrorPosition reflects the position across the plane x = 0, which is the sagittal plane of models.
+func MirrorPosition(p [3]float32) [3]float32 {
+	return [3]float32{-p[0], p[1], p[2]}
+}
+
+// MirrorRotation reflects the rotation (x, y, z, w) across the plane x = 0.
+func MirrorRotation(q [4]float32) [4]float32 {
+	return [4]float32{q[0], -q[1], -q[2], q[3]}
+}
+
+// Mirror makes the motion the mirror image across the sagittal plane of the model.
+// Bones, morphs and IK switches of 左 and 右 swap, and positions and rotations are reflected.
+// Camera and light keyframes are reflected across the same plane of the stage.
+func (m *Motion) Mirror() {
+
+	for i := range m.Bones {
+		f := &m.Bones[i]
+		f.Name = MirrorName(f.Name)
+		f.Position = MirrorPosition(f.Position)
+		f.Rotation = MirrorRotation(f.Rotation)
+	}
+
+	for i := range m.Morphs {
+		m.Morphs[i].Name = MirrorName(m.Morphs[i].Name)
+	}
+
+	for i := range m.IKs {
+		for k := range m.IKs[i].IKs {
+			m.IKs[i].IKs[k].Name = MirrorName(m.IKs[i].IKs[k].Name)
+		}
+	}
+
+	for i := range m.Cameras {
+		f := &m.Cameras[i]
+		f.Position = MirrorPosition(f.Position)
+		// X軸で反転すると、Y軸とZ軸の周りの回転が逆になる
+		f.Rotation = [3]float32{f.Rotation[0], -f.Rotation[1], -f.Rotation[2]}
+	}
+
+	for i := range m.Lights {
+		m.Lights[i].Direction = MirrorPosition(m.Lights[i].Direction)
+	}
+}
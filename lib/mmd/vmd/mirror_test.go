@@ -0,0 +1,87 @@
+package vmd
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMirrorName(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"左足ＩＫ", "右足ＩＫ"},
+		{"右腕捩1", "左腕捩1"},
+		{"センター", "センター"},
+		{"ウィンク", "ウィンク右"},
+		{"ウィンク右", "ウィンク"},
+		{"ウィンク２", "ｳｨﾝｸ２右"},
+		{"ｳｨﾝｸ２右", "ウィンク２"},
+	}
+
+	for _, tt := range tests {
+		if got := MirrorName(tt.in); got != tt.want {
+			t.Errorf("MirrorName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMirror(t *testing.T) {
+
+	m := sample()
+	m.Mirror()
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"bone name", m.Bones[1].Name, "右足ＩＫ"},
+		{"bone position", m.Bones[1].Position, [3]float32{-1.5, 0.25, -2}},
+		// Y軸周りの回転は逆になる
+		{"bone rotation", m.Bones[1].Rotation, [4]float32{0, -0.38268343, 0, 0.9238795}},
+		{"bone interpolation", m.Bones[1].RawInterpolation, sample().Bones[1].RawInterpolation},
+		{"center", m.Bones[0], sample().Bones[0]},
+		{"morph", m.Morphs[1].Name, "ウィンク右"},
+		{"IK", m.IKs[0].IKs, []IKState{{Name: "右足ＩＫ", Enabled: true}, {Name: "左足ＩＫ", Enabled: false}}},
+		{"camera target", m.Cameras[1].Position, [3]float32{-2, 12, 1}},
+		{"camera rotation", m.Cameras[1].Rotation, [3]float32{0.1, 0.5, -0.2}},
+		{"camera distance", m.Cameras[1].Distance, float32(-20)},
+		{"light", m.Lights[0].Direction, [3]float32{0.5, -1, 0.5}},
+		{"self shadow", m.SelfShadows, sample().SelfShadows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMirrorTwice(t *testing.T) {
+
+	m := sample()
+	m.Mirror()
+
+	// 反転したモーションもVMDとして書き出せる
+	var b bytes.Buffer
+	if err := Encode(&b, m); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, m) {
+		t.Errorf("Decode(Encode(mirrored)) = %+v, want %+v", decoded, m)
+	}
+
+	decoded.Mirror()
+	if want := sample(); !reflect.DeepEqual(decoded, want) {
+		t.Errorf("mirrored twice = %+v, want %+v", decoded, want)
+	}
+}
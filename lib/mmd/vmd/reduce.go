@@ -0,0 +1,155 @@
+package vmd
+
+import (
+	"app/lib/mmd/math3d"
+	"math"
+)
+
+// Tolerance is the largest error allowed when keyframes are removed.
+type Tolerance struct {
+	// Position is distance in MMD units.
+	Position float64
+	// Rotation is angle in radians.
+	Rotation float64
+	// Weight is difference of morph weights.
+	Weight float64
+}
+
+// DefaultTolerance is small enough that the difference is not visible on a model of usual size.
+var DefaultTolerance = Tolerance{
+	Position: 0.01,
+	Rotation: 0.2 * math.Pi / 180,
+	Weight:   0.005,
+}
+
+// Reduce removes bone and morph keyframes which linear interpolation of their neighbors reproduces within the tolerance.
+// It is meant for motions sampled every frame, such as recorded ones, whose keyframes are interpolated linearly.
+// The keyframes are sorted by frame afterwards.
+func (m *Motion) Reduce(t Tolerance) {
+
+	m.Sort()
+
+	var bones []BoneFrame
+	for _, frames := range groupBones(m.Bones) {
+		bones = append(bones, reduceBones(frames, t)...)
+	}
+	m.Bones = bones
+
+	var morphs []MorphFrame
+	for _, frames := range groupMorphs(m.Morphs) {
+		morphs = append(morphs, reduceMorphs(frames, t)...)
+	}
+	m.Morphs = morphs
+
+	m.Sort()
+}
+
+// groupBones splits keyframes by bone name in the order the names appear.
+func groupBones(frames []BoneFrame) [][]BoneFrame {
+	index := make(map[string]int)
+	var groups [][]BoneFrame
+	for _, f := range frames {
+		i, ok := index[f.Name]
+		if !ok {
+			i = len(groups)
+			index[f.Name] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], f)
+	}
+	return groups
+}
+
+// groupMorphs splits keyframes by morph name in the order the names appear.
+func groupMorphs(frames []MorphFrame) [][]MorphFrame {
+	index := make(map[string]int)
+	var groups [][]MorphFrame
+	for _, f := range frames {
+		i, ok := index[f.Name]
+		if !ok {
+			i = len(groups)
+			index[f.Name] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], f)
+	}
+	return groups
+}
+
+// reduceBones reduces sorted keyframes of a bone.
+func reduceBones(frames []BoneFrame, t Tolerance) []BoneFrame {
+
+	if len(frames) <= 2 {
+		return frames
+	}
+
+	// 始点から伸ばせるだけ伸ばし、誤差が許容値を超えたら直前のキーフレームを残す
+	reduced := []BoneFrame{frames[0]}
+	start := 0
+	for end := 2; end < len(frames); end++ {
+		if !linearBones(frames[start], frames[end], frames[start+1:end], t) {
+			reduced = append(reduced, frames[end-1])
+			start = end - 1
+		}
+	}
+
+	return append(reduced, frames[len(frames)-1])
+}
+
+// linearBones gets whether interpolation from a to b reproduces the keyframes between them.
+func linearBones(a BoneFrame, b BoneFrame, between []BoneFrame, t Tolerance) bool {
+
+	pa, pb := vector(a.Position), vector(b.Position)
+	qa, qb := quaternion(a.Rotation), quaternion(b.Rotation)
+
+	for _, f := range between {
+		s := float64(f.Frame-a.Frame) / float64(b.Frame-a.Frame)
+		if pa.Lerp(pb, s).Distance(vector(f.Position)) > t.Position {
+			return false
+		}
+		if qa.Slerp(qb, s).Angle(quaternion(f.Rotation)) > t.Rotation {
+			return false
+		}
+	}
+
+	return true
+}
+
+// reduceMorphs reduces sorted keyframes of a morph.
+func reduceMorphs(frames []MorphFrame, t Tolerance) []MorphFrame {
+
+	if len(frames) <= 2 {
+		return frames
+	}
+
+	reduced := []MorphFrame{frames[0]}
+	start := 0
+	for end := 2; end < len(frames); end++ {
+		if !linearMorphs(frames[start], frames[end], frames[start+1:end], t) {
+			reduced = append(reduced, frames[end-1])
+			start = end - 1
+		}
+	}
+
+	return append(reduced, frames[len(frames)-1])
+}
+
+// linearMorphs gets whether interpolation from a to b reproduces the keyframes between them.
+func linearMorphs(a MorphFrame, b MorphFrame, between []MorphFrame, t Tolerance) bool {
+	for _, f := range between {
+		s := float64(f.Frame-a.Frame) / float64(b.Frame-a.Frame)
+		w := float64(a.Weight) + (float64(b.Weight)-float64(a.Weight))*s
+		if math.Abs(w-float64(f.Weight)) > t.Weight {
+			return false
+		}
+	}
+	return true
+}
+
+func vector(v [3]float32) math3d.Vector3 {
+	return math3d.NewVector3(float64(v[0]), float64(v[1]), float64(v[2]))
+}
+
+func quaternion(q [4]float32) math3d.Quaternion {
+	return math3d.Quaternion{X: float64(q[0]), Y: float64(q[1]), Z: float64(q[2]), W: float64(q[3])}
+}
@@ -0,0 +1,37 @@
+package vmd
+
+import (
+	"math"
+	"testing"
+)
+
+func TestReduce(t *testing.T) {
+
+	original := dense(90)
+	m := dense(90)
+	m.Reduce(DefaultTolerance)
+
+	if len(m.Bones) >= len(original.Bones) || len(m.Morphs) >= len(original.Morphs) {
+		t.Fatalf("%d bone and %d morph keyframes are left of %d", len(m.Bones), len(m.Morphs), len(original.Bones))
+	}
+
+	// 三角形の重みは両端と頂点だけが残る
+	if len(m.Morphs) != 3 || m.Morphs[1].Frame != 45 {
+		t.Errorf("morph keyframes = %+v, want frames 0, 45 and 90", m.Morphs)
+	}
+
+	// 残ったキーフレームの線形補間が毎フレーム許容値に収まる
+	for i, want := range original.Bones {
+		got, _ := BoneAt(m.Bones, float64(want.Frame))
+		if d := vector(got.Position).Distance(vector(want.Position)); d > DefaultTolerance.Position {
+			t.Errorf("frame %d: position error %v is over the tolerance", i, d)
+		}
+		if d := quaternion(got.Rotation).Angle(quaternion(want.Rotation)); d > DefaultTolerance.Rotation {
+			t.Errorf("frame %d: rotation error %v is over the tolerance", i, d)
+		}
+		w, _ := MorphAt(m.Morphs, float64(want.Frame))
+		if d := math.Abs(float64(w - original.Morphs[i].Weight)); d > DefaultTolerance.Weight {
+			t.Errorf("frame %d: weight error %v is over the tolerance", i, d)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package vmd
+
+import (
+	"sort"
+)
+
+// DefaultSelfShadowDistance is Distance of the self shadow in the initial state of MMD, shown as 8875.
+const DefaultSelfShadowDistance = 0.01125
+
+// DisplayDistance gets the shadow range shown in MMD, from 0 to 10000.
+func (f SelfShadowFrame) DisplayDistance() float64 {
+	return 10000 - float64(f.Distance)*100000
+}
+
+// SelfShadowAt gets the self shadow state at the frame. The keyframes must be sorted by frame.
+// The mode changes at keyframes, and the distance is interpolated linearly between keyframes of the same mode.
+// It returns false if there are no keyframes.
+func SelfShadowAt(frames []SelfShadowFrame, frame float64) (SelfShadowFrame, bool) {
+
+	if len(frames) == 0 {
+		return SelfShadowFrame{}, false
+	}
+
+	// frameより後ろにある最初のキーフレーム
+	i := sort.Search(len(frames), func(i int) bool {
+		return float64(frames[i].Frame) > frame
+	})
+	if i == 0 {
+		return frames[0], true
+	}
+	if i == len(frames) {
+		return frames[i-1], true
+	}
+
+	prev, next := frames[i-1], frames[i]
+	if prev.Mode != next.Mode {
+		return prev, true
+	}
+
+	t := (frame - float64(prev.Frame)) / float64(next.Frame-prev.Frame)
+	prev.Distance += float32(t) * (next.Distance - prev.Distance)
+	return prev, true
+}
+
+// Sort sorts all keyframes by frame. Keyframes of VMD files are not always sorted.
+func (m *Motion) Sort() {
+	sort.SliceStable(m.Bones, func(i, j int) bool { return m.Bones[i].Frame < m.Bones[j].Frame })
+	sort.SliceStable(m.Morphs, func(i, j int) bool { return m.Morphs[i].Frame < m.Morphs[j].Frame })
+	sort.SliceStable(m.Cameras, func(i, j int) bool { return m.Cameras[i].Frame < m.Cameras[j].Frame })
+	sort.SliceStable(m.Lights, func(i, j int) bool { return m.Lights[i].Frame < m.Lights[j].Frame })
+	sort.SliceStable(m.SelfShadows, func(i, j int) bool { return m.SelfShadows[i].Frame < m.SelfShadows[j].Frame })
+	sort.SliceStable(m.IKs, func(i, j int) bool { return m.IKs[i].Frame < m.IKs[j].Frame })
+}
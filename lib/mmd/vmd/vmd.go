@@ -0,0 +1,119 @@
+// Package vmd is data model of VMD (Vocaloid Motion Data) files.
+//
+// Values are stored as they are in the file, in the left-handed MMD coordinate system.
+package vmd
+
+// FramesPerSecond is frame rate of VMD keyframes.
+const FramesPerSecond = 30
+
+// Motion is content of a VMD file.
+type Motion struct {
+	// ModelName is the name of the model the motion is made for. Camera motions have "カメラ・照明".
+	ModelName string
+
+	Bones       []BoneFrame
+	Morphs      []MorphFrame
+	Cameras     []CameraFrame
+	Lights      []LightFrame
+	SelfShadows []SelfShadowFrame
+	IKs         []IKFrame
+}
+
+// CameraModelName is ModelName of camera and light motions.
+const CameraModelName = "カメラ・照明"
+
+// BoneFrame is a keyframe of a bone.
+type BoneFrame struct {
+	Name  string
+	Frame uint32
+
+	// Position is translation from the rest position.
+	Position [3]float32
+	// Rotation is a quaternion (x, y, z, w).
+	Rotation [4]float32
+
+	// RawInterpolation is raw 64 bytes of Bezier control points.
+	// Use Interpolation and SetInterpolation to access them by channel.
+	RawInterpolation [64]byte
+}
+
+// MorphFrame is a keyframe of a morph.
+type MorphFrame struct {
+	Name   string
+	Frame  uint32
+	Weight float32
+}
+
+// CameraFrame is a keyframe of the camera.
+type CameraFrame struct {
+	Frame uint32
+
+	// Distance from the target. It is negative when the camera is in front of the target.
+	Distance float32
+	// Position is the target point.
+	Position [3]float32
+	// Rotation is Euler angles in radians.
+	Rotation [3]float32
+
+	// RawInterpolation is raw 24 bytes of Bezier control points
+	// as (x1, x2, y1, y2) for X, Y, Z, rotation, distance and FOV.
+	RawInterpolation [24]byte
+
+	// Fov is vertical field of view in degrees.
+	Fov uint32
+	// Orthographic is true when perspective is turned off.
+	Orthographic bool
+}
+
+// LightFrame is a keyframe of the light.
+type LightFrame struct {
+	Frame uint32
+
+	// Color is RGB in [0, 1].
+	Color [3]float32
+	// Direction is the direction the light travels. The initial light of MMD is (-0.5, -1.0, 0.5).
+	Direction [3]float32
+}
+
+// SelfShadowMode is the mode of the self shadow.
+type SelfShadowMode uint8
+
+const (
+	// SelfShadowOff turns the self shadow off.
+	SelfShadowOff SelfShadowMode = iota
+	// SelfShadowMode1 fits the shadow to the camera.
+	SelfShadowMode1
+	// SelfShadowMode2 fits the shadow to the model.
+	SelfShadowMode2
+)
+
+// SelfShadowFrame is a keyframe of the self shadow.
+type SelfShadowFrame struct {
+	Frame uint32
+	Mode  SelfShadowMode
+	// Distance is the shadow range as stored in the file. The value shown in MMD is 10000 - Distance * 100000.
+	Distance float32
+}
+
+// IKFrame is a keyframe of the model visibility and IK switches.
+type IKFrame struct {
+	Frame uint32
+	Show  bool
+	IKs   []IKState
+}
+
+// IKState is enabled state of an IK bone.
+type IKState struct {
+	Name    string
+	Enabled bool
+}
+
+// LinearCameraInterpolation is camera interpolation of straight lines.
+var LinearCameraInterpolation = [24]byte{
+	20, 107, 20, 107,
+	20, 107, 20, 107,
+	20, 107, 20, 107,
+	20, 107, 20, 107,
+	20, 107, 20, 107,
+	20, 107, 20, 107,
+}
@@ -0,0 +1,141 @@
+package vpd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// headerSignature is the first line of VPD files.
+const headerSignature = "Vocaloid Pose Data file"
+
+// ErrInvalidHeader is returned when the data is not a VPD file.
+var ErrInvalidHeader = errors.New("vpd: invalid header")
+
+// Decode reads a VPD file. The text is read as Shift_JIS as MMD writes it, or as UTF-8 if it is valid UTF-8.
+func Decode(r io.Reader) (*Pose, error) {
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+
+	// Shift_JISの日本語がUTF-8として正しいことはまずない
+	if !utf8.Valid(b) {
+		if b, err = japanese.ShiftJIS.NewDecoder().Bytes(b); err != nil {
+			return nil, fmt.Errorf("vpd: invalid text: %w", err)
+		}
+	}
+
+	lines := statements(string(b))
+	if len(lines) == 0 || lines[0] != headerSignature {
+		return nil, ErrInvalidHeader
+	}
+	lines = lines[1:]
+
+	d := &decoder{lines: lines}
+	p := &Pose{}
+	p.ModelFile = strings.TrimSuffix(d.next(), ";")
+	if _, err := strconv.Atoi(strings.TrimSuffix(d.next(), ";")); err != nil {
+		return nil, fmt.Errorf("vpd: invalid bone count: %w", err)
+	}
+
+	for d.more() {
+		line := d.next()
+		i := strings.Index(line, "{")
+		if i < 0 {
+			return nil, fmt.Errorf("vpd: unexpected %q", line)
+		}
+		kind, name := line[:i], line[i+1:]
+
+		switch {
+		case strings.HasPrefix(kind, "Bone"):
+			position, err := d.floats(3)
+			if err != nil {
+				return nil, err
+			}
+			rotation, err := d.floats(4)
+			if err != nil {
+				return nil, err
+			}
+			bone := Bone{Name: name}
+			copy(bone.Position[:], position)
+			copy(bone.Rotation[:], rotation)
+			p.Bones = append(p.Bones, bone)
+		case strings.HasPrefix(kind, "Morph"):
+			weight, err := d.floats(1)
+			if err != nil {
+				return nil, err
+			}
+			p.Morphs = append(p.Morphs, Morph{Name: name, Weight: weight[0]})
+		default:
+			return nil, fmt.Errorf("vpd: unknown block %q", kind)
+		}
+
+		if d.next() != "}" {
+			return nil, fmt.Errorf("vpd: block %v is not closed", name)
+		}
+	}
+
+	return p, nil
+}
+
+// statements gets the lines without comments and blanks.
+func statements(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+type decoder struct {
+	lines []string
+}
+
+func (d *decoder) more() bool {
+	return len(d.lines) > 0
+}
+
+// next gets the next line, or empty string at the end.
+func (d *decoder) next() string {
+	if len(d.lines) == 0 {
+		return ""
+	}
+	line := d.lines[0]
+	d.lines = d.lines[1:]
+	return line
+}
+
+// floats reads a line of n comma separated numbers ending with a semicolon.
+func (d *decoder) floats(n int) ([]float32, error) {
+
+	line := d.next()
+	fields := strings.Split(strings.TrimSuffix(line, ";"), ",")
+	if len(fields) != n {
+		return nil, fmt.Errorf("vpd: %d numbers are expected: %q", n, line)
+	}
+
+	v := make([]float32, n)
+	for i, f := range fields {
+		x, err := strconv.ParseFloat(strings.TrimSpace(f), 32)
+		if err != nil {
+			return nil, fmt.Errorf("vpd: invalid number: %w", err)
+		}
+		v[i] = float32(x)
+	}
+	return v, nil
+}
@@ -0,0 +1,38 @@
+package vpd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Encode writes the pose as a VPD file in Shift_JIS with CRLF line endings, as MMD writes it.
+func Encode(w io.Writer, p *Pose) error {
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%v\r\n\r\n", headerSignature)
+	fmt.Fprintf(&b, "%v;\t\t// 親ファイル名\r\n", p.ModelFile)
+	fmt.Fprintf(&b, "%d;\t\t\t\t// 総ポーズボーン数\r\n\r\n", len(p.Bones))
+
+	for i, bone := range p.Bones {
+		fmt.Fprintf(&b, "Bone%d{%v\r\n", i, bone.Name)
+		fmt.Fprintf(&b, "  %f,%f,%f;\t\t\t\t// trans x,y,z\r\n", bone.Position[0], bone.Position[1], bone.Position[2])
+		fmt.Fprintf(&b, "  %f,%f,%f,%f;\t\t// Quaternion x,y,z,w\r\n", bone.Rotation[0], bone.Rotation[1], bone.Rotation[2], bone.Rotation[3])
+		fmt.Fprintf(&b, "}\r\n\r\n")
+	}
+
+	for i, morph := range p.Morphs {
+		fmt.Fprintf(&b, "Morph%d{%v\r\n", i, morph.Name)
+		fmt.Fprintf(&b, "  %f;\t\t\t\t// weight\r\n", morph.Weight)
+		fmt.Fprintf(&b, "}\r\n\r\n")
+	}
+
+	s, err := japanese.ShiftJIS.NewEncoder().Bytes(b.Bytes())
+	if err != nil {
+		return fmt.Errorf("vpd: name cannot be written in Shift_JIS: %w", err)
+	}
+	_, err = w.Write(s)
+	return err
+}
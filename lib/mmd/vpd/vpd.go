@@ -0,0 +1,45 @@
+// Package vpd is data model of VPD (Vocaloid Pose Data) files, the text format of poses of MMD.
+//
+// Values are stored as they are in the file, in the left-handed MMD coordinate system.
+package vpd
+
+import "app/lib/mmd/vmd"
+
+// Pose is content of a VPD file.
+type Pose struct {
+	// ModelFile is the file name of the model the pose is made for, such as "miku.osm".
+	ModelFile string
+
+	Bones  []Bone
+	Morphs []Morph
+}
+
+// Bone is the pose of a bone.
+type Bone struct {
+	Name string
+	// Position is translation from the rest position.
+	Position [3]float32
+	// Rotation is a quaternion (x, y, z, w).
+	Rotation [4]float32
+}
+
+// Morph is the weight of a morph.
+type Morph struct {
+	Name   string
+	Weight float32
+}
+
+// Mirror makes the pose the mirror image across the sagittal plane of the model, in the same way as vmd.Motion.Mirror.
+func (p *Pose) Mirror() {
+
+	for i := range p.Bones {
+		b := &p.Bones[i]
+		b.Name = vmd.MirrorName(b.Name)
+		b.Position = vmd.MirrorPosition(b.Position)
+		b.Rotation = vmd.MirrorRotation(b.Rotation)
+	}
+
+	for i := range p.Morphs {
+		p.Morphs[i].Name = vmd.MirrorName(p.Morphs[i].Name)
+	}
+}
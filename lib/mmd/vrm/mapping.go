@@ -0,0 +1,101 @@
+package vrm
+
+// Mapping is names of MMD bones by humanoid bone name of VRM.
+// Humanoid bones missing in the mapping keep their rest poses.
+type Mapping map[string]string
+
+// MMDBones maps the humanoid bones to the standard bones of MMD models.
+// hips is mapped to 下半身, which moves with センター and グルーブ, so that the avatar takes all of them.
+var MMDBones = Mapping{
+	"hips":                    "下半身",
+	"spine":                   "上半身",
+	"chest":                   "上半身2",
+	"neck":                    "首",
+	"head":                    "頭",
+	"leftEye":                 "左目",
+	"rightEye":                "右目",
+	"leftShoulder":            "左肩",
+	"leftUpperArm":            "左腕",
+	"leftLowerArm":            "左ひじ",
+	"leftHand":                "左手首",
+	"rightShoulder":           "右肩",
+	"rightUpperArm":           "右腕",
+	"rightLowerArm":           "右ひじ",
+	"rightHand":               "右手首",
+	"leftUpperLeg":            "左足",
+	"leftLowerLeg":            "左ひざ",
+	"leftFoot":                "左足首",
+	"leftToes":                "左つま先",
+	"rightUpperLeg":           "右足",
+	"rightLowerLeg":           "右ひざ",
+	"rightFoot":               "右足首",
+	"rightToes":               "右つま先",
+	"leftThumbMetacarpal":     "左親指０",
+	"leftThumbProximal":       "左親指１",
+	"leftThumbDistal":         "左親指２",
+	"leftIndexProximal":       "左人指１",
+	"leftIndexIntermediate":   "左人指２",
+	"leftIndexDistal":         "左人指３",
+	"leftMiddleProximal":      "左中指１",
+	"leftMiddleIntermediate":  "左中指２",
+	"leftMiddleDistal":        "左中指３",
+	"leftRingProximal":        "左薬指１",
+	"leftRingIntermediate":    "左薬指２",
+	"leftRingDistal":          "左薬指３",
+	"leftLittleProximal":      "左小指１",
+	"leftLittleIntermediate":  "左小指２",
+	"leftLittleDistal":        "左小指３",
+	"rightThumbMetacarpal":    "右親指０",
+	"rightThumbProximal":      "右親指１",
+	"rightThumbDistal":        "右親指２",
+	"rightIndexProximal":      "右人指１",
+	"rightIndexIntermediate":  "右人指２",
+	"rightIndexDistal":        "右人指３",
+	"rightMiddleProximal":     "右中指１",
+	"rightMiddleIntermediate": "右中指２",
+	"rightMiddleDistal":       "右中指３",
+	"rightRingProximal":       "右薬指１",
+	"rightRingIntermediate":   "右薬指２",
+	"rightRingDistal":         "右薬指３",
+	"rightLittleProximal":     "右小指１",
+	"rightLittleIntermediate": "右小指２",
+	"rightLittleDistal":       "右小指３",
+}
+
+// humanBones0 are humanoid bone names of VRM 1.0 by those of VRM 0.x which differ.
+var humanBones0 = map[string]string{
+	"leftThumbProximal":      "leftThumbMetacarpal",
+	"leftThumbIntermediate":  "leftThumbProximal",
+	"rightThumbProximal":     "rightThumbMetacarpal",
+	"rightThumbIntermediate": "rightThumbProximal",
+}
+
+// MMDMorphs are names of MMD morphs by preset expression name.
+// The vowels drive lip sync, and the others are the usual morphs of the standard models.
+var MMDMorphs = map[string]string{
+	"aa":         "あ",
+	"ih":         "い",
+	"ou":         "う",
+	"ee":         "え",
+	"oh":         "お",
+	"blink":      "まばたき",
+	"blinkLeft":  "ウィンク",
+	"blinkRight": "ウィンク右",
+	"happy":      "笑い",
+	"angry":      "怒り",
+	"sad":        "困る",
+	"relaxed":    "なごみ",
+	"surprised":  "びっくり",
+}
+
+// ExpressionsFor gets the expressions which the MMD morph of the name drives.
+// An expression of the same name is also driven, for motions made for VRM avatars.
+func (m *Model) ExpressionsFor(morph string) []*Expression {
+	var res []*Expression
+	for _, e := range m.Expressions {
+		if e.Name == morph || (e.Preset && MMDMorphs[e.Name] == morph) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
@@ -0,0 +1,281 @@
+package vrm
+
+import (
+	"app/lib/mmd/bvh"
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/pmx"
+	"app/lib/mmd/pose"
+	"app/lib/mmd/retarget"
+	"app/lib/mmd/vmd"
+	"errors"
+	"math"
+	"strings"
+)
+
+// Options is parameters of retargeting VMD motions to avatars.
+type Options struct {
+	// Mapping is the MMD bones of the humanoid bones. If nil, MMDBones is used.
+	Mapping Mapping
+	// Model is the MMD model the motion is for. If nil, bvh.StandardModel is used.
+	Model *pmx.Model
+	// Scale is meters per MMD unit for translations. If 0, it is estimated from the heights of the mapped bones.
+	Scale float64
+}
+
+// Animation is a motion of the avatar sampled at 30 fps, in the right-handed coordinate system of glTF.
+type Animation struct {
+	// Duration is the length in seconds.
+	Duration float64
+	Nodes    []NodeTrack
+	Morphs   []MorphTrack
+}
+
+// NodeTrack is local transforms of a node.
+type NodeTrack struct {
+	Node      int
+	Times     []float64
+	Rotations []math3d.Quaternion
+	// Translations are nil except for the root of the humanoid bones.
+	Translations []math3d.Vector3
+}
+
+// MorphTrack is the weight of a morph target of a glTF mesh, driven by the expressions binding it.
+type MorphTrack struct {
+	Mesh    int
+	Index   int
+	Times   []float64
+	Weights []float64
+}
+
+// ErrNoMapping is returned when no humanoid bones are mapped to bones of the model.
+var ErrNoMapping = errors.New("vrm: no humanoid bones are mapped to bones")
+
+// Retarget converts the VMD motion to an animation of the avatar.
+//
+// The motion is evaluated with IK and 付与 of the model. The humanoid bones get rotations which turn them
+// in the same directions in the world as the bones, so the T-pose of the avatar is corrected to the A-pose of MMD.
+// Motions of VRM 0.x avatars are turned around, since they face -Z. Morphs of the motion drive
+// the expressions of the same name and the presets mapped to them by MMDMorphs.
+func Retarget(m *Model, v *vmd.Motion, o Options) (*Animation, error) {
+
+	mapping := o.Mapping
+	if mapping == nil {
+		mapping = MMDBones
+	}
+	model := o.Model
+	if model == nil {
+		model = bvh.StandardModel()
+	}
+	e, err := pose.New(model)
+	if err != nil {
+		return nil, err
+	}
+	e.SetMotion(v)
+	s := e.Skeleton()
+	sourceRest := s.RestWorldPositions()
+
+	// VRM 0.xは-Zを向くため、ソースをY軸回りに半回転して向きを合わせる
+	facing := math3d.IdentityQuaternion()
+	if m.Version == 0 {
+		facing = math3d.NewQuaternionFromAxisAngle(math3d.NewVector3(0, 1, 0), math.Pi)
+	}
+
+	restPositions, restRotations := m.rest()
+	order := m.order()
+
+	humans := make(map[int]string, len(m.HumanBones))
+	for name, node := range m.HumanBones {
+		humans[node] = name
+	}
+
+	// VRM 0.xの向きに合わせて補正を求めるため、ソースの初期姿勢も回す
+	source := retarget.NewRigFromSkeleton(s)
+	for i, p := range source.Rest {
+		source.Rest[i] = facing.Rotate(p)
+	}
+	target := &retarget.Rig{
+		Names:   make([]string, len(m.Nodes)),
+		Parents: make([]int, len(m.Nodes)),
+		Rest:    restPositions,
+		Order:   order,
+	}
+	for i, n := range m.Nodes {
+		target.Names[i] = humans[i]
+		target.Parents[i] = n.Parent
+	}
+
+	r := retarget.New(source, target, mapping, func(parent int, child int) bool {
+		return direction(humans[parent], humans[child])
+	})
+	pairs := r.Pairs
+	if len(pairs) == 0 {
+		return nil, ErrNoMapping
+	}
+	pairIndex := make(map[int]int, len(pairs))
+	for i, p := range pairs {
+		pairIndex[p.Target] = i
+	}
+
+	scale := o.Scale
+	if scale == 0 {
+		sourceHeight, targetHeight := r.Heights()
+		scale = targetHeight / sourceHeight
+	}
+
+	var last uint32
+	for _, f := range v.Bones {
+		if f.Frame > last {
+			last = f.Frame
+		}
+	}
+	for _, f := range v.Morphs {
+		if f.Frame > last {
+			last = f.Frame
+		}
+	}
+
+	a := &Animation{Duration: float64(last) / vmd.FramesPerSecond}
+	a.Nodes = make([]NodeTrack, len(pairs))
+	for i, p := range pairs {
+		a.Nodes[i].Node = p.Target
+	}
+
+	global := make([]math3d.Quaternion, len(pairs))
+	rotations := make([]math3d.Quaternion, len(m.Nodes))
+	positions := make([]math3d.Vector3, len(m.Nodes))
+	for f := 0; f <= int(last); f++ {
+		t := float64(f) / vmd.FramesPerSecond
+		_, world := e.Evaluate(float64(f))
+		for i, p := range pairs {
+			w := facing.Mul(world[p.Source].Rotation).Mul(facing.Conjugate())
+			global[i] = w.Mul(p.Correction).Mul(restRotations[p.Target]).Normalize()
+		}
+
+		// 対応しないノードは初期姿勢のまま、親から順にワールドの姿勢を求める
+		for _, n := range order {
+			node := m.Nodes[n]
+			parentRotation, parentPosition := math3d.IdentityQuaternion(), math3d.Vector3{}
+			if p := node.Parent; p >= 0 {
+				parentRotation, parentPosition = rotations[p], positions[p]
+			}
+
+			local, translation := node.Rotation, node.Translation
+			i, ok := pairIndex[n]
+			if ok {
+				local = parentRotation.Conjugate().Mul(global[i]).Normalize()
+				if pairs[i].Parent < 0 {
+					src := pairs[i].Source
+					d := facing.Rotate(world[src].Position.Sub(sourceRest[src])).Scale(scale)
+					translation = parentRotation.Conjugate().Rotate(restPositions[n].Add(d).Sub(parentPosition))
+				}
+			}
+			rotations[n] = parentRotation.Mul(local)
+			positions[n] = parentPosition.Add(parentRotation.Rotate(translation))
+			if !ok {
+				continue
+			}
+
+			track := &a.Nodes[i]
+			// 補間が遠回りしないよう、前のキーと同じ半球に揃える
+			if k := len(track.Rotations); k > 0 && track.Rotations[k-1].Dot(local) < 0 {
+				local = math3d.Quaternion{X: -local.X, Y: -local.Y, Z: -local.Z, W: -local.W}
+			}
+			track.Times = append(track.Times, t)
+			track.Rotations = append(track.Rotations, local)
+			if pairs[i].Parent < 0 {
+				track.Translations = append(track.Translations, translation)
+			}
+		}
+	}
+
+	a.Morphs = m.morphs(v, last)
+	return a, nil
+}
+
+// morphs gets weights of the morph targets driven by the morphs of the motion.
+func (m *Model) morphs(v *vmd.Motion, last uint32) []MorphTrack {
+
+	sorted := &vmd.Motion{Morphs: append([]vmd.MorphFrame(nil), v.Morphs...)}
+	sorted.Sort()
+	tracks := sorted.MorphTracks()
+
+	type target struct {
+		mesh  int
+		index int
+	}
+	var res []MorphTrack
+	index := make(map[target]int)
+	weights := make(map[*Expression][]float64)
+
+	for name, frames := range tracks {
+		for _, e := range m.ExpressionsFor(name) {
+			w := weights[e]
+			if w == nil {
+				w = make([]float64, last+1)
+				weights[e] = w
+			}
+			for f := range w {
+				if weight, ok := vmd.MorphAt(frames, float64(f)); ok {
+					w[f] += float64(weight)
+				}
+			}
+		}
+	}
+
+	// 表情の順に処理して、出力の順序を一定にする
+	for _, e := range m.Expressions {
+		w, ok := weights[e]
+		if !ok {
+			continue
+		}
+		for _, b := range e.Binds {
+			k := target{mesh: b.Mesh, index: b.Index}
+			i, ok := index[k]
+			if !ok {
+				i = len(res)
+				index[k] = i
+				res = append(res, MorphTrack{
+					Mesh:    b.Mesh,
+					Index:   b.Index,
+					Times:   make([]float64, last+1),
+					Weights: make([]float64, last+1),
+				})
+				for f := range res[i].Times {
+					res[i].Times[f] = float64(f) / vmd.FramesPerSecond
+				}
+			}
+			for f, weight := range w {
+				res[i].Weights[f] += b.Weight * e.Weight(weight)
+			}
+		}
+	}
+
+	for _, t := range res {
+		for f, weight := range t.Weights {
+			t.Weights[f] = math.Min(weight, 1)
+		}
+	}
+	return res
+}
+
+// Weight gets the weight of the expression from the weight of the morphs driving it.
+func (e *Expression) Weight(w float64) float64 {
+	w = math.Max(0, math.Min(w, 1))
+	if e.IsBinary {
+		return math.Round(w)
+	}
+	return w
+}
+
+// direction gets whether the child shows the direction of the humanoid bone.
+// The trunk is turned only by the children on the center line, since the legs, the shoulders and the eyes
+// spread sideways in different angles between models, and hands are turned by the middle fingers.
+func direction(name string, child string) bool {
+	switch {
+	case !strings.HasPrefix(name, "left") && !strings.HasPrefix(name, "right"):
+		return !strings.HasPrefix(child, "left") && !strings.HasPrefix(child, "right")
+	case strings.HasSuffix(name, "Hand"):
+		return strings.HasSuffix(child, "MiddleProximal")
+	}
+	return true
+}
@@ -0,0 +1,418 @@
+// Package vrm reads humanoid avatars of VRM, glTF files with the VRM extensions, and maps them to MMD.
+//
+// Both VRM 0.x (the "VRM" extension) and VRM 1.0 ("VRMC_vrm") are read into the same Model.
+// Humanoid bones are mapped to the standard bones of MMD models so that VMD motions are retargeted,
+// and expressions are mapped to the names of MMD morphs so that facial motions drive them.
+package vrm
+
+import (
+	"app/lib/mmd/math3d"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Model is the avatar information of a VRM file.
+type Model struct {
+	// Version is 0 for VRM 0.x and 1 for VRM 1.0.
+	Version int
+	Meta    Meta
+	// Nodes are the nodes of the glTF file, in the same order.
+	Nodes []Node
+	// HumanBones are node indices by humanoid bone name of VRM 1.0, such as "hips" and "leftUpperArm".
+	HumanBones map[string]int
+	// Expressions are the facial expressions, called blend shape groups in VRM 0.x.
+	Expressions []*Expression
+}
+
+// Meta is the information of the avatar and its license.
+type Meta struct {
+	Name      string
+	Version   string
+	Authors   []string
+	Copyright string
+	Contact   string
+	// References are the original works of the avatar.
+	References []string
+	// License is the name or the URL of the license.
+	License string
+	// CommercialUsage is "personalNonProfit", "personalProfit" or "corporation" in VRM 1.0,
+	// and "Allow" or "Disallow" in VRM 0.x.
+	CommercialUsage string
+}
+
+// Node is a node of the glTF file in the rest pose.
+type Node struct {
+	Name     string
+	Parent   int
+	Children []int
+	// Mesh is the index of the mesh of the node, or -1.
+	Mesh        int
+	Translation math3d.Vector3
+	Rotation    math3d.Quaternion
+}
+
+// Expression is a facial expression made of morph targets.
+type Expression struct {
+	// Name is the preset name of VRM 1.0 for presets, such as "aa" and "happy", and the name for custom expressions.
+	Name string
+	// Preset is true for the presets.
+	Preset bool
+	// IsBinary makes weights 0 or 1.
+	IsBinary bool
+	Binds    []Bind
+}
+
+// Bind is a morph target of an expression.
+type Bind struct {
+	// Mesh is the index of the glTF mesh.
+	Mesh int
+	// Index is the index of the morph target in the mesh.
+	Index int
+	// Weight is the weight of the morph target at the full expression, from 0 to 1.
+	Weight float64
+}
+
+// ErrNotVRM is returned when the glTF file has no VRM extension.
+var ErrNotVRM = errors.New("vrm: no VRM extension")
+
+// presets0 are preset names of VRM 1.0 by those of VRM 0.x.
+var presets0 = map[string]string{
+	"neutral":   "neutral",
+	"a":         "aa",
+	"i":         "ih",
+	"u":         "ou",
+	"e":         "ee",
+	"o":         "oh",
+	"blink":     "blink",
+	"blink_l":   "blinkLeft",
+	"blink_r":   "blinkRight",
+	"joy":       "happy",
+	"angry":     "angry",
+	"sorrow":    "sad",
+	"fun":       "relaxed",
+	"lookup":    "lookUp",
+	"lookdown":  "lookDown",
+	"lookleft":  "lookLeft",
+	"lookright": "lookRight",
+}
+
+type document struct {
+	Nodes []struct {
+		Name        string       `json:"name"`
+		Children    []int        `json:"children"`
+		Mesh        *int         `json:"mesh"`
+		Translation *[3]float64  `json:"translation"`
+		Rotation    *[4]float64  `json:"rotation"`
+		Matrix      *[16]float64 `json:"matrix"`
+	} `json:"nodes"`
+	Extensions struct {
+		VRM0 *vrm0 `json:"VRM"`
+		VRM1 *vrm1 `json:"VRMC_vrm"`
+	} `json:"extensions"`
+}
+
+type vrm0 struct {
+	Meta struct {
+		Title                string `json:"title"`
+		Version              string `json:"version"`
+		Author               string `json:"author"`
+		ContactInformation   string `json:"contactInformation"`
+		Reference            string `json:"reference"`
+		LicenseName          string `json:"licenseName"`
+		OtherLicenseURL      string `json:"otherLicenseUrl"`
+		CommercialUssageName string `json:"commercialUssageName"`
+	} `json:"meta"`
+	Humanoid struct {
+		HumanBones []struct {
+			Bone string `json:"bone"`
+			Node int    `json:"node"`
+		} `json:"humanBones"`
+	} `json:"humanoid"`
+	BlendShapeMaster struct {
+		BlendShapeGroups []struct {
+			Name       string `json:"name"`
+			PresetName string `json:"presetName"`
+			IsBinary   bool   `json:"isBinary"`
+			Binds      []struct {
+				Mesh   int     `json:"mesh"`
+				Index  int     `json:"index"`
+				Weight float64 `json:"weight"`
+			} `json:"binds"`
+		} `json:"blendShapeGroups"`
+	} `json:"blendShapeMaster"`
+}
+
+type vrm1 struct {
+	Meta struct {
+		Name                 string   `json:"name"`
+		Version              string   `json:"version"`
+		Authors              []string `json:"authors"`
+		CopyrightInformation string   `json:"copyrightInformation"`
+		ContactInformation   string   `json:"contactInformation"`
+		References           []string `json:"references"`
+		LicenseURL           string   `json:"licenseUrl"`
+		CommercialUsage      string   `json:"commercialUsage"`
+	} `json:"meta"`
+	Humanoid struct {
+		HumanBones map[string]struct {
+			Node int `json:"node"`
+		} `json:"humanBones"`
+	} `json:"humanoid"`
+	Expressions struct {
+		Preset map[string]expression1 `json:"preset"`
+		Custom map[string]expression1 `json:"custom"`
+	} `json:"expressions"`
+}
+
+type expression1 struct {
+	IsBinary         bool `json:"isBinary"`
+	MorphTargetBinds []struct {
+		Node   int     `json:"node"`
+		Index  int     `json:"index"`
+		Weight float64 `json:"weight"`
+	} `json:"morphTargetBinds"`
+}
+
+// Parse reads the VRM extensions from the JSON of a glTF file, the JSON chunk of a .vrm file.
+func Parse(data []byte) (*Model, error) {
+
+	var d document
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+
+	m := &Model{
+		Nodes:      make([]Node, len(d.Nodes)),
+		HumanBones: make(map[string]int),
+	}
+	for i := range m.Nodes {
+		m.Nodes[i] = Node{Parent: -1, Mesh: -1, Rotation: math3d.IdentityQuaternion()}
+	}
+	for i, n := range d.Nodes {
+		node := &m.Nodes[i]
+		node.Name = n.Name
+		node.Children = n.Children
+		if n.Mesh != nil {
+			node.Mesh = *n.Mesh
+		}
+		if t := n.Translation; t != nil {
+			node.Translation = math3d.NewVector3(t[0], t[1], t[2])
+		}
+		if r := n.Rotation; r != nil {
+			node.Rotation = math3d.Quaternion{X: r[0], Y: r[1], Z: r[2], W: r[3]}.Normalize()
+		}
+		if a := n.Matrix; a != nil {
+			// 列優先の行列から移動と回転を取り出す。拡大縮小は無視する
+			node.Translation = math3d.NewVector3(a[12], a[13], a[14])
+			x := math3d.NewVector3(a[0], a[1], a[2]).Normalize()
+			y := math3d.NewVector3(a[4], a[5], a[6]).Normalize()
+			z := math3d.NewVector3(a[8], a[9], a[10]).Normalize()
+			node.Rotation = math3d.NewQuaternionFromBasis(x, y, z)
+		}
+		for _, c := range n.Children {
+			if c < 0 || c >= len(m.Nodes) {
+				return nil, errors.New("vrm: invalid child node")
+			}
+			m.Nodes[c].Parent = i
+		}
+	}
+
+	switch {
+	case d.Extensions.VRM1 != nil:
+		m.Version = 1
+		if err := m.parse1(d.Extensions.VRM1); err != nil {
+			return nil, err
+		}
+	case d.Extensions.VRM0 != nil:
+		m.Version = 0
+		if err := m.parse0(d.Extensions.VRM0); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrNotVRM
+	}
+
+	if _, ok := m.HumanBones["hips"]; !ok {
+		return nil, errors.New("vrm: no hips bone")
+	}
+	return m, nil
+}
+
+func (m *Model) parse0(v *vrm0) error {
+
+	meta := v.Meta
+	m.Meta = Meta{
+		Name:            meta.Title,
+		Version:         meta.Version,
+		Contact:         meta.ContactInformation,
+		License:         meta.LicenseName,
+		CommercialUsage: meta.CommercialUssageName,
+	}
+	if meta.Author != "" {
+		m.Meta.Authors = []string{meta.Author}
+	}
+	if meta.Reference != "" {
+		m.Meta.References = []string{meta.Reference}
+	}
+	if meta.LicenseName == "Other" && meta.OtherLicenseURL != "" {
+		m.Meta.License = meta.OtherLicenseURL
+	}
+
+	for _, b := range v.Humanoid.HumanBones {
+		name := b.Bone
+		if renamed, ok := humanBones0[name]; ok {
+			name = renamed
+		}
+		if err := m.setHumanBone(name, b.Node); err != nil {
+			return err
+		}
+	}
+
+	for _, g := range v.BlendShapeMaster.BlendShapeGroups {
+		e := &Expression{Name: g.Name, IsBinary: g.IsBinary}
+		if name, ok := presets0[strings.ToLower(g.PresetName)]; ok {
+			e.Name = name
+			e.Preset = true
+		}
+		for _, b := range g.Binds {
+			// 0.xの重みは0から100
+			e.Binds = append(e.Binds, Bind{Mesh: b.Mesh, Index: b.Index, Weight: b.Weight / 100})
+		}
+		m.Expressions = append(m.Expressions, e)
+	}
+	return nil
+}
+
+func (m *Model) parse1(v *vrm1) error {
+
+	meta := v.Meta
+	m.Meta = Meta{
+		Name:            meta.Name,
+		Version:         meta.Version,
+		Authors:         meta.Authors,
+		Copyright:       meta.CopyrightInformation,
+		Contact:         meta.ContactInformation,
+		References:      meta.References,
+		License:         meta.LicenseURL,
+		CommercialUsage: meta.CommercialUsage,
+	}
+
+	for name, b := range v.Humanoid.HumanBones {
+		if err := m.setHumanBone(name, b.Node); err != nil {
+			return err
+		}
+	}
+
+	add := func(name string, preset bool, x expression1) error {
+		e := &Expression{Name: name, Preset: preset, IsBinary: x.IsBinary}
+		for _, b := range x.MorphTargetBinds {
+			if b.Node < 0 || b.Node >= len(m.Nodes) || m.Nodes[b.Node].Mesh < 0 {
+				return errors.New("vrm: expression " + name + " binds a node without mesh")
+			}
+			e.Binds = append(e.Binds, Bind{Mesh: m.Nodes[b.Node].Mesh, Index: b.Index, Weight: b.Weight})
+		}
+		m.Expressions = append(m.Expressions, e)
+		return nil
+	}
+	// mapの順序は不定のため、名前順に並べる
+	for _, name := range sortedKeys(v.Expressions.Preset) {
+		if err := add(name, true, v.Expressions.Preset[name]); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(v.Expressions.Custom) {
+		if err := add(name, false, v.Expressions.Custom[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Model) setHumanBone(name string, node int) error {
+	if node < 0 || node >= len(m.Nodes) {
+		return errors.New("vrm: humanoid bone " + name + " refers to an invalid node")
+	}
+	m.HumanBones[name] = node
+	return nil
+}
+
+// Expression gets the expression of the name, or nil.
+func (m *Model) Expression(name string) *Expression {
+	for _, e := range m.Expressions {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// MeshNodes gets the indices of the nodes which have the mesh.
+func (m *Model) MeshNodes(mesh int) []int {
+	var res []int
+	for i, n := range m.Nodes {
+		if n.Mesh == mesh {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// Faces gets the direction the avatar faces in the rest pose. VRM 0.x avatars face -Z and VRM 1.0 ones face +Z.
+func (m *Model) Faces() math3d.Vector3 {
+	if m.Version == 0 {
+		return math3d.NewVector3(0, 0, -1)
+	}
+	return math3d.NewVector3(0, 0, 1)
+}
+
+// order gets indices of the nodes with parents before their children.
+func (m *Model) order() []int {
+
+	res := make([]int, 0, len(m.Nodes))
+	visited := make([]bool, len(m.Nodes))
+	var visit func(i int)
+	visit = func(i int) {
+		// 不正なファイルの循環で止まらないようにする
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		res = append(res, i)
+		for _, c := range m.Nodes[i].Children {
+			visit(c)
+		}
+	}
+	for i, n := range m.Nodes {
+		if n.Parent < 0 {
+			visit(i)
+		}
+	}
+	return res
+}
+
+// rest gets world positions and rotations of the nodes in the rest pose.
+func (m *Model) rest() ([]math3d.Vector3, []math3d.Quaternion) {
+
+	positions := make([]math3d.Vector3, len(m.Nodes))
+	rotations := make([]math3d.Quaternion, len(m.Nodes))
+	for _, i := range m.order() {
+		n := m.Nodes[i]
+		positions[i], rotations[i] = n.Translation, n.Rotation
+		if p := n.Parent; p >= 0 {
+			positions[i] = positions[p].Add(rotations[p].Rotate(n.Translation))
+			rotations[i] = rotations[p].Mul(n.Rotation)
+		}
+	}
+	return positions, rotations
+}
+
+func sortedKeys(m map[string]expression1) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,640 @@
+package xfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+const headerLength = 16
+
+var (
+	// ErrInvalidHeader is returned when the data is not a .x file.
+	ErrInvalidHeader = errors.New("xfile: invalid header")
+	// ErrUnsupportedFormat is returned for binary and compressed .x files.
+	ErrUnsupportedFormat = errors.New("xfile: only the text format is supported")
+)
+
+// Decode reads a .x file of the text format.
+// Objects other than frames, meshes and their materials are skipped.
+func Decode(r io.Reader) (*Model, error) {
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// "xof 0302txt 0032" のようにバージョン、形式、浮動小数点のビット数が並ぶ
+	if len(b) < headerLength || string(b[:4]) != "xof " {
+		return nil, ErrInvalidHeader
+	}
+	if string(b[8:12]) != "txt " {
+		return nil, ErrUnsupportedFormat
+	}
+
+	p := &parser{s: &scanner{b: b, pos: headerLength}, named: make(map[string]*object)}
+	objects, err := p.objects()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Model{}
+	for _, o := range objects {
+		if err := p.collect(m, o, identity, make(map[*object]bool)); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// collect reads meshes in the object and its frames.
+// ancestors are the frames containing the object, used to reject frames referring to themselves.
+func (p *parser) collect(m *Model, o *object, transform [16]float32, ancestors map[*object]bool) error {
+
+	o, err := p.resolve(o)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case o.is("Mesh"):
+		mesh, err := p.mesh(o)
+		if err != nil {
+			return err
+		}
+		mesh.transform(transform)
+		m.Meshes = append(m.Meshes, mesh)
+
+	case o.is("Frame"):
+		// 参照で循環するフレームは無限に再帰するため拒否する
+		if ancestors[o] {
+			return fmt.Errorf("xfile: frame %q contains itself", o.name)
+		}
+		ancestors[o] = true
+		defer delete(ancestors, o)
+
+		// 行ベクトルの規約のため、子の行列に親の行列を右から掛ける
+		local := identity
+		for _, c := range o.children {
+			if c.is("FrameTransformMatrix") {
+				d := c.data()
+				for i := range local {
+					v, err := d.float()
+					if err != nil {
+						return err
+					}
+					local[i] = v
+				}
+			}
+		}
+		t := multiply(local, transform)
+
+		for _, c := range o.children {
+			if err := p.collect(m, c, t, ancestors); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) mesh(o *object) (*Mesh, error) {
+
+	m := &Mesh{Name: o.name}
+	d := o.data()
+
+	var err error
+	if m.Vertices, err = d.vectors(); err != nil {
+		return nil, err
+	}
+	if m.Faces, err = d.faces(len(m.Vertices)); err != nil {
+		return nil, err
+	}
+
+	for _, c := range o.children {
+		switch {
+		case c.is("MeshNormals"):
+			d := c.data()
+			if m.Normals, err = d.vectors(); err != nil {
+				return nil, err
+			}
+			if m.FaceNormals, err = d.faces(len(m.Normals)); err != nil {
+				return nil, err
+			}
+
+		case c.is("MeshTextureCoords"):
+			d := c.data()
+			n, err := d.count()
+			if err != nil {
+				return nil, err
+			}
+			m.TexCoords = make([][2]float32, n)
+			for i := range m.TexCoords {
+				if m.TexCoords[i][0], err = d.float(); err != nil {
+					return nil, err
+				}
+				if m.TexCoords[i][1], err = d.float(); err != nil {
+					return nil, err
+				}
+			}
+
+		case c.is("MeshMaterialList"):
+			if err := p.materialList(m, c); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (p *parser) materialList(m *Mesh, o *object) error {
+
+	d := o.data()
+	n, err := d.count()
+	if err != nil {
+		return err
+	}
+
+	nFaces, err := d.count()
+	if err != nil {
+		return err
+	}
+	m.FaceMaterials = make([]int, nFaces)
+	for i := range m.FaceMaterials {
+		if m.FaceMaterials[i], err = d.index(n); err != nil {
+			return err
+		}
+	}
+
+	// マテリアルは子オブジェクトか、名前による参照で与えられる
+	for _, c := range o.children {
+		c, err := p.resolve(c)
+		if err != nil {
+			return err
+		}
+		if !c.is("Material") {
+			continue
+		}
+
+		mat, err := material(c)
+		if err != nil {
+			return err
+		}
+		m.Materials = append(m.Materials, mat)
+	}
+
+	if len(m.Materials) < n {
+		return fmt.Errorf("xfile: %d materials are expected but %d are found", n, len(m.Materials))
+	}
+	return nil
+}
+
+func material(o *object) (Material, error) {
+
+	m := Material{Name: o.name}
+	d := o.data()
+
+	values := make([]float32, 0, 11)
+	for i := 0; i < 11; i++ {
+		v, err := d.float()
+		if err != nil {
+			return m, err
+		}
+		values = append(values, v)
+	}
+	copy(m.Diffuse[:], values[0:4])
+	m.Power = values[4]
+	copy(m.Specular[:], values[5:8])
+	copy(m.Emissive[:], values[8:11])
+
+	for _, c := range o.children {
+		if !c.is("TextureFilename") {
+			continue
+		}
+		name, err := c.data().string()
+		if err != nil {
+			return m, err
+		}
+
+		// MMDは "texture.bmp*sphere.sph" の形式でスフィアマップを指定する
+		for _, v := range strings.Split(name, "*") {
+			v = strings.ReplaceAll(v, "\\", "/")
+			switch strings.ToLower(v[strings.LastIndex(v, ".")+1:]) {
+			case "sph", "spa":
+				m.SphereMap = v
+			default:
+				m.Texture = v
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// transform applies the frame transform to vertices and normals.
+func (m *Mesh) transform(t [16]float32) {
+
+	if t == identity {
+		return
+	}
+
+	for i, v := range m.Vertices {
+		m.Vertices[i] = [3]float32{
+			v[0]*t[0] + v[1]*t[4] + v[2]*t[8] + t[12],
+			v[0]*t[1] + v[1]*t[5] + v[2]*t[9] + t[13],
+			v[0]*t[2] + v[1]*t[6] + v[2]*t[10] + t[14],
+		}
+	}
+	for i, v := range m.Normals {
+		n := [3]float32{
+			v[0]*t[0] + v[1]*t[4] + v[2]*t[8],
+			v[0]*t[1] + v[1]*t[5] + v[2]*t[9],
+			v[0]*t[2] + v[1]*t[6] + v[2]*t[10],
+		}
+		l := float32(math.Sqrt(float64(n[0]*n[0] + n[1]*n[1] + n[2]*n[2])))
+		if l > 0 {
+			n[0], n[1], n[2] = n[0]/l, n[1]/l, n[2]/l
+		}
+		m.Normals[i] = n
+	}
+}
+
+var identity = [16]float32{
+	1, 0, 0, 0,
+	0, 1, 0, 0,
+	0, 0, 1, 0,
+	0, 0, 0, 1,
+}
+
+func multiply(a [16]float32, b [16]float32) [16]float32 {
+	var r [16]float32
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			var v float32
+			for k := 0; k < 4; k++ {
+				v += a[i*4+k] * b[k*4+j]
+			}
+			r[i*4+j] = v
+		}
+	}
+	return r
+}
+
+// object is a data object of a .x file.
+type object struct {
+	typ  string
+	name string
+	// values are numbers and strings of the object in order.
+	values   []token
+	children []*object
+	// reference is the name of the referenced object, for references like "{ name }".
+	reference string
+}
+
+func (o *object) is(typ string) bool {
+	return strings.EqualFold(o.typ, typ)
+}
+
+func (o *object) data() *values {
+	return &values{o: o}
+}
+
+// values reads values of an object in order.
+type values struct {
+	o   *object
+	pos int
+}
+
+func (v *values) next() (token, error) {
+	if v.pos >= len(v.o.values) {
+		return token{}, fmt.Errorf("xfile: %v has too few values", v.o.typ)
+	}
+	t := v.o.values[v.pos]
+	v.pos++
+	return t, nil
+}
+
+func (v *values) float() (float32, error) {
+	t, err := v.next()
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(t.text, 32)
+	if err != nil {
+		return 0, fmt.Errorf("xfile: invalid number %q in %v", t.text, v.o.typ)
+	}
+	return float32(f), nil
+}
+
+func (v *values) count() (int, error) {
+	t, err := v.next()
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(t.text)
+	// 壊れたファイルで巨大な配列を確保しないよう、残りの値の数で制限する
+	if err != nil || n < 0 || n > len(v.o.values) {
+		return 0, fmt.Errorf("xfile: invalid count %q in %v", t.text, v.o.typ)
+	}
+	return n, nil
+}
+
+// index reads an index less than n.
+func (v *values) index(n int) (int, error) {
+	t, err := v.next()
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(t.text)
+	if err != nil || i < 0 || i >= n {
+		return 0, fmt.Errorf("xfile: invalid index %q in %v", t.text, v.o.typ)
+	}
+	return i, nil
+}
+
+func (v *values) string() (string, error) {
+	t, err := v.next()
+	if err != nil {
+		return "", err
+	}
+	if !t.quoted {
+		return "", fmt.Errorf("xfile: string is expected in %v", v.o.typ)
+	}
+	return t.text, nil
+}
+
+func (v *values) vectors() ([][3]float32, error) {
+	n, err := v.count()
+	if err != nil {
+		return nil, err
+	}
+	vectors := make([][3]float32, n)
+	for i := range vectors {
+		for j := 0; j < 3; j++ {
+			if vectors[i][j], err = v.float(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return vectors, nil
+}
+
+// faces reads faces of indices less than n.
+func (v *values) faces(n int) ([][]int, error) {
+	count, err := v.count()
+	if err != nil {
+		return nil, err
+	}
+	faces := make([][]int, count)
+	for i := range faces {
+		c, err := v.count()
+		if err != nil {
+			return nil, err
+		}
+		faces[i] = make([]int, c)
+		for j := range faces[i] {
+			if faces[i][j], err = v.index(n); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return faces, nil
+}
+
+type parser struct {
+	s *scanner
+	// named is objects by name for references.
+	named map[string]*object
+}
+
+// objects reads objects at the top level.
+func (p *parser) objects() ([]*object, error) {
+
+	var objects []*object
+	for {
+		t, err := p.s.next()
+		if err == io.EOF {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if t.quoted || t.text == "{" || t.text == "}" {
+			return nil, fmt.Errorf("xfile: unexpected %q at the top level", t.text)
+		}
+
+		o, err := p.object(t.text)
+		if err != nil {
+			return nil, err
+		}
+		if o != nil {
+			objects = append(objects, o)
+		}
+	}
+}
+
+// object reads an object after its type. Templates are skipped and nil is returned.
+func (p *parser) object(typ string) (*object, error) {
+
+	o := &object{typ: typ}
+
+	t, err := p.s.expect()
+	if err != nil {
+		return nil, err
+	}
+	if t.text != "{" || t.quoted {
+		o.name = t.text
+		if t, err = p.s.expect(); err != nil {
+			return nil, err
+		}
+	}
+	if t.text != "{" || t.quoted {
+		return nil, fmt.Errorf("xfile: \"{\" is expected after %v", typ)
+	}
+
+	// テンプレートは型の定義なので読み飛ばす
+	if typ == "template" {
+		return nil, p.s.skipBlock()
+	}
+
+	for {
+		t, err := p.s.expect()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case t.quoted || t.number():
+			o.values = append(o.values, t)
+
+		case t.text == "}":
+			if o.name != "" {
+				p.named[o.name] = o
+			}
+			return o, nil
+
+		case t.text == "{":
+			// "{ name }" は他のオブジェクトへの参照
+			r, err := p.s.expect()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.s.close(); err != nil {
+				return nil, err
+			}
+			o.children = append(o.children, &object{reference: r.text})
+
+		default:
+			c, err := p.object(t.text)
+			if err != nil {
+				return nil, err
+			}
+			if c != nil {
+				o.children = append(o.children, c)
+			}
+		}
+	}
+}
+
+// resolve gets the referenced object if o is a reference.
+// Objects may be referenced before they are defined, so references are resolved after parsing.
+func (p *parser) resolve(o *object) (*object, error) {
+	if o.reference == "" {
+		return o, nil
+	}
+	r, ok := p.named[o.reference]
+	if !ok {
+		return nil, fmt.Errorf("xfile: object %q is not found", o.reference)
+	}
+	return r, nil
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+func (t token) number() bool {
+	if t.quoted || t.text == "" {
+		return false
+	}
+	c := t.text[0]
+	return c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9')
+}
+
+// scanner splits a .x file into tokens.
+// Separators "," and ";" are skipped, since counts in the data tell where arrays end.
+type scanner struct {
+	b   []byte
+	pos int
+}
+
+func (s *scanner) next() (token, error) {
+
+	for s.pos < len(s.b) {
+		c := s.b[s.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == ',' || c == ';':
+			s.pos++
+
+		case c == '#' || (c == '/' && s.pos+1 < len(s.b) && s.b[s.pos+1] == '/'):
+			for s.pos < len(s.b) && s.b[s.pos] != '\n' {
+				s.pos++
+			}
+
+		case c == '<':
+			// テンプレートのGUIDは使わない
+			end := bytes.IndexByte(s.b[s.pos:], '>')
+			if end < 0 {
+				return token{}, io.ErrUnexpectedEOF
+			}
+			s.pos += end + 1
+
+		case c == '{' || c == '}':
+			s.pos++
+			return token{text: string(c)}, nil
+
+		case c == '"':
+			end := bytes.IndexByte(s.b[s.pos+1:], '"')
+			if end < 0 {
+				return token{}, io.ErrUnexpectedEOF
+			}
+			text, err := decodeText(s.b[s.pos+1 : s.pos+1+end])
+			if err != nil {
+				return token{}, err
+			}
+			s.pos += end + 2
+			return token{text: text, quoted: true}, nil
+
+		default:
+			start := s.pos
+			for s.pos < len(s.b) && !strings.ContainsRune(" \t\r\n,;{}\"<#", rune(s.b[s.pos])) {
+				s.pos++
+			}
+			return token{text: string(s.b[start:s.pos])}, nil
+		}
+	}
+
+	return token{}, io.EOF
+}
+
+// expect reads a token which must exist.
+func (s *scanner) expect() (token, error) {
+	t, err := s.next()
+	if err == io.EOF {
+		return t, io.ErrUnexpectedEOF
+	}
+	return t, err
+}
+
+// close reads "}" of a reference.
+func (s *scanner) close() error {
+	t, err := s.expect()
+	if err != nil {
+		return err
+	}
+	if t.text != "}" || t.quoted {
+		return fmt.Errorf("xfile: \"}\" is expected but %q is found", t.text)
+	}
+	return nil
+}
+
+// skipBlock skips tokens until "}" of the current block.
+func (s *scanner) skipBlock() error {
+	depth := 1
+	for depth > 0 {
+		t, err := s.expect()
+		if err != nil {
+			return err
+		}
+		if t.quoted {
+			continue
+		}
+		switch t.text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		}
+	}
+	return nil
+}
+
+// decodeText decodes a Shift_JIS string, which MMD uses for file names.
+func decodeText(b []byte) (string, error) {
+	s, err := japanese.ShiftJIS.NewDecoder().Bytes(b)
+	if err != nil {
+		return "", fmt.Errorf("xfile: invalid text: %w", err)
+	}
+	return string(s), nil
+}
@@ -0,0 +1,81 @@
+package xfile
+
+import (
+	"strings"
+	"testing"
+)
+
+const header = "xof 0302txt 0032\n"
+
+const triangle = `
+Mesh tri {
+ 3;
+ 0.0;0.0;0.0;,
+ 1.0;0.0;0.0;,
+ 0.0;1.0;0.0;;
+ 1;
+ 3;0,1,2;;
+}
+`
+
+func TestDecodeFrames(t *testing.T) {
+
+	// Aは自身を動かしてから三角形を置き、BはAを参照して2つ目の三角形を置く
+	text := header + `
+Frame A {
+ FrameTransformMatrix {
+  1.0,0.0,0.0,0.0,
+  0.0,1.0,0.0,0.0,
+  0.0,0.0,1.0,0.0,
+  5.0,0.0,0.0,1.0;;
+ }
+` + triangle + `
+}
+Frame B {
+ { A }
+}
+`
+
+	m, err := Decode(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Meshes) != 2 {
+		t.Fatalf("got %d meshes, want 2", len(m.Meshes))
+	}
+	for i, mesh := range m.Meshes {
+		if mesh.Vertices[1] != [3]float32{6, 0, 0} {
+			t.Errorf("mesh %d has vertex %v, want [6 0 0]", i, mesh.Vertices[1])
+		}
+	}
+}
+
+func TestDecodeCyclicFrames(t *testing.T) {
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{
+			name: "frame refers to itself",
+			text: `Frame A { { A } }`,
+		},
+		{
+			name: "frames refer to each other",
+			text: `Frame A { { B } } Frame B { { A } }`,
+		},
+		{
+			name: "nested frame refers to its ancestor",
+			text: `Frame A { Frame B { Frame C { { A } } } }`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Decode(strings.NewReader(header + tt.text))
+			if err == nil {
+				t.Fatalf("Decode() = %v, want an error", m)
+			}
+		})
+	}
+}
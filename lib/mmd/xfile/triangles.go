@@ -0,0 +1,110 @@
+package xfile
+
+// Triangles is the meshes of a model flattened into triangle lists for BufferGeometry.
+// Coordinates are converted to right-handed ones of three.js in the same way as MMDLoader does for PMX.
+type Triangles struct {
+	// Positions, Normals and UVs have 3, 3 and 2 values for each vertex of the triangles.
+	// Normals is empty if some meshes have no normals, and UVs is empty if no meshes have UVs.
+	Positions []float32
+	Normals   []float32
+	UVs       []float32
+
+	// Groups are ranges of vertices drawn with the same material, in the order of Materials.
+	Groups    []Group
+	Materials []Material
+}
+
+// Group is a range of vertices drawn with a material.
+type Group struct {
+	Start    int
+	Count    int
+	Material int
+}
+
+// Triangles flattens the meshes of the model. Faces with more than three vertices are split into fans.
+// A mesh without materials is drawn with a white material.
+func (m *Model) Triangles() Triangles {
+
+	var t Triangles
+
+	hasNormals := true
+	hasUVs := false
+	for _, mesh := range m.Meshes {
+		hasNormals = hasNormals && len(mesh.FaceNormals) == len(mesh.Faces)
+		hasUVs = hasUVs || len(mesh.TexCoords) == len(mesh.Vertices)
+	}
+
+	for _, mesh := range m.Meshes {
+		materials := mesh.Materials
+		if len(materials) == 0 {
+			materials = []Material{DefaultMaterial}
+		}
+
+		// マテリアルごとにまとめて描画範囲を作る
+		for i, mat := range materials {
+			start := len(t.Positions) / 3
+
+			for f, face := range mesh.Faces {
+				if mesh.faceMaterial(f) != i {
+					continue
+				}
+
+				// 左手系から右手系への変換で面が裏返るため、頂点の順序を逆にする
+				for k := 1; k+1 < len(face); k++ {
+					for _, c := range [3]int{0, k + 1, k} {
+						v := mesh.Vertices[face[c]]
+						t.Positions = append(t.Positions, v[0], v[1], -v[2])
+
+						if hasNormals {
+							n := mesh.normal(f, c)
+							t.Normals = append(t.Normals, n[0], n[1], -n[2])
+						}
+						if hasUVs {
+							var uv [2]float32
+							if len(mesh.TexCoords) == len(mesh.Vertices) {
+								uv = mesh.TexCoords[face[c]]
+							}
+							// DirectXのVは下向きのため反転する
+							t.UVs = append(t.UVs, uv[0], 1-uv[1])
+						}
+					}
+				}
+			}
+
+			count := len(t.Positions)/3 - start
+			if count > 0 {
+				t.Groups = append(t.Groups, Group{Start: start, Count: count, Material: len(t.Materials)})
+				t.Materials = append(t.Materials, mat)
+			}
+		}
+	}
+
+	return t
+}
+
+// DefaultMaterial is the material for meshes without MeshMaterialList.
+var DefaultMaterial = Material{
+	Diffuse: [4]float32{1, 1, 1, 1},
+	Power:   5,
+}
+
+// faceMaterial gets the material index of the face.
+// Faces after FaceMaterials use the last index, as DirectX does when the list is shorter than the faces.
+func (m *Mesh) faceMaterial(face int) int {
+	if len(m.FaceMaterials) == 0 {
+		return 0
+	}
+	if face >= len(m.FaceMaterials) {
+		return m.FaceMaterials[len(m.FaceMaterials)-1]
+	}
+	return m.FaceMaterials[face]
+}
+
+// normal gets the normal of the corner of the face.
+func (m *Mesh) normal(face int, corner int) [3]float32 {
+	indices := m.FaceNormals[face]
+	if corner >= len(indices) {
+		return [3]float32{}
+	}
+	return m.Normals[indices[corner]]
+}
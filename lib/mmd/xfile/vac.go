@@ -0,0 +1,89 @@
+package xfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Placement is a .vac file, which MMD reads to place an accessory.
+//
+// A .vac file is Shift_JIS text of the lines:
+// accessory name, .x file name, scale, position "x,y,z", rotation "x,y,z" in degrees and bone name.
+type Placement struct {
+	Name string
+	// File is the .x file name relative to the .vac file.
+	File  string
+	Scale float32
+	// Position and Rotation are in the left-handed coordinates of MMD, relative to the bone.
+	Position [3]float32
+	Rotation [3]float32
+	// Bone is the name of the bone the accessory follows. Empty if it is placed in the world.
+	Bone string
+}
+
+// DecodePlacement reads a .vac file.
+func DecodePlacement(r io.Reader) (*Placement, error) {
+
+	var lines []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line, err := decodeText(s.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	// ボーン名の行は省略されることがある
+	if len(lines) < 5 {
+		return nil, fmt.Errorf("xfile: vac file has %d lines, 5 or more are expected", len(lines))
+	}
+
+	p := &Placement{
+		Name: lines[0],
+		File: strings.ReplaceAll(lines[1], "\\", "/"),
+	}
+
+	scale, err := strconv.ParseFloat(lines[2], 32)
+	if err != nil {
+		return nil, fmt.Errorf("xfile: invalid scale %q", lines[2])
+	}
+	p.Scale = float32(scale)
+
+	if p.Position, err = vector(lines[3]); err != nil {
+		return nil, err
+	}
+	if p.Rotation, err = vector(lines[4]); err != nil {
+		return nil, err
+	}
+
+	if len(lines) > 5 {
+		p.Bone = lines[5]
+	}
+
+	return p, nil
+}
+
+// vector parses "x,y,z".
+func vector(s string) ([3]float32, error) {
+	var v [3]float32
+
+	values := strings.Split(s, ",")
+	if len(values) != 3 {
+		return v, fmt.Errorf("xfile: invalid vector %q", s)
+	}
+	for i, value := range values {
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 32)
+		if err != nil {
+			return v, fmt.Errorf("xfile: invalid vector %q", s)
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
@@ -0,0 +1,54 @@
+// Package xfile reads DirectX .x files used as MMD accessories, and .vac files which place them.
+//
+// Only the text format is supported, which is what MMD accessories are distributed in.
+package xfile
+
+import "strings"
+
+// Model is the meshes in a .x file.
+// Transforms of frames are applied to the vertices, so the meshes are in the coordinates of the file.
+type Model struct {
+	Meshes []*Mesh
+}
+
+// Mesh is a Mesh object of a .x file. Coordinates are left-handed as in the file.
+type Mesh struct {
+	Name string
+
+	Vertices [][3]float32
+	// Faces are indices of Vertices. A face may have more than three vertices.
+	Faces [][]int
+
+	// Normals and FaceNormals are empty if the mesh has no MeshNormals.
+	Normals [][3]float32
+	// FaceNormals are indices of Normals for each vertex of Faces.
+	FaceNormals [][]int
+
+	// TexCoords are UVs for each vertex of Vertices. Empty if the mesh has no MeshTextureCoords.
+	TexCoords [][2]float32
+
+	Materials []Material
+	// FaceMaterials are indices of Materials for each face.
+	FaceMaterials []int
+}
+
+// Material is a Material object of a .x file.
+type Material struct {
+	Name string
+
+	// Diffuse is RGBA of the face color.
+	Diffuse  [4]float32
+	Power    float32
+	Specular [3]float32
+	Emissive [3]float32
+
+	// Texture is the file name of the texture relative to the .x file. Empty if none.
+	Texture string
+	// SphereMap is the file name of the sphere map which MMD reads after "*" in the texture name.
+	SphereMap string
+}
+
+// SphereAdd gets whether the sphere map is added (.spa) rather than multiplied (.sph).
+func (m Material) SphereAdd() bool {
+	return strings.HasSuffix(strings.ToLower(m.SphereMap), ".spa")
+}
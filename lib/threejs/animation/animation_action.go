@@ -14,6 +14,9 @@ type Action interface {
 	// Default is false.
 	// Note: clampWhenFinished has no impact if the action is interrupted (it has only an effect if its last loop has really finished).
 	ClampWhenFinished() bool
+	// SetClampWhenFinished sets if clampWhenFinished is set to true the animation will automatically be paused on its last frame.
+	// If clampWhenFinished is set to false, enabled will automatically be switched to false when the last loop of the action has finished, so that this action has no further impact.
+	SetClampWhenFinished(b bool)
 
 	// Enabled gets Setting enabled to false disables this action, so that it has no impact. Default is true.
 	Enabled() bool
@@ -172,6 +175,12 @@ func (c *actionImp) ClampWhenFinished() bool {
 	return c.Get("clampWhenFinished").Bool()
 }
 
+// SetClampWhenFinished sets if clampWhenFinished is set to true the animation will automatically be paused on its last frame.
+// If clampWhenFinished is set to false, enabled will automatically be switched to false when the last loop of the action has finished, so that this action has no further impact.
+func (c *actionImp) SetClampWhenFinished(b bool) {
+	c.Set("clampWhenFinished", b)
+}
+
 // Enabled gets Setting enabled to false disables this action, so that it has no impact. Default is true.
 func (c *actionImp) Enabled() bool {
 	return c.Get("enabled").Bool()
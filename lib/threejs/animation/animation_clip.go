@@ -1,6 +1,7 @@
 package animation
 
 import (
+	"app/lib/threejs"
 	"errors"
 	"syscall/js"
 )
@@ -37,6 +38,17 @@ type clipImp struct {
 	js.Value
 }
 
+// NewClip creates a clip of the tracks. If duration is negative, it is calculated from the tracks.
+func NewClip(name string, duration float64, tracks []Track) Clip {
+	values := make([]interface{}, len(tracks))
+	for i, t := range tracks {
+		values[i] = t.JSValue()
+	}
+	return &clipImp{
+		Value: threejs.Threejs("AnimationClip").New(name, duration, values),
+	}
+}
+
 func NewClipFromJSValue(v js.Value) (Clip, error) {
 	if v.IsNull() || v.IsUndefined() {
 		return nil, errors.New("clip is not valid")
@@ -0,0 +1,30 @@
+package animation
+
+// CrossFade switches playback from one action to another, blending their weights over the passed time interval.
+//
+// from — The action currently playing. If nil, next fades in from the rest pose.
+// next — The action to be played.
+// durationInSeconds — Blend time. If 0 or less, next is played immediately and from is stopped.
+// warp — If true, additional warping (gradually changes of the time scales) will be applied.
+func CrossFade(from Action, next Action, durationInSeconds float64, warp bool) {
+
+	next.Reset()
+	next.SetEffectiveTimeScale(1)
+	next.SetEffectiveWeight(1)
+	next.Play()
+
+	if durationInSeconds <= 0 {
+		if from != nil && !from.JSValue().Equal(next.JSValue()) {
+			from.Stop()
+		}
+		return
+	}
+
+	// 同じアクションへの切り替え、または再生中のアクションがない場合はフェードインのみ
+	if from == nil || from.JSValue().Equal(next.JSValue()) || !from.Scheduled() {
+		next.FadeIn(durationInSeconds)
+		return
+	}
+
+	from.CrossFadeTo(next, durationInSeconds, warp)
+}
@@ -57,6 +57,13 @@ type mixerImp struct {
 	js.Value
 }
 
+// NewMixer creates Mixer which animates the root object and its descendants.
+func NewMixer(root threejs.Object3D) Mixer {
+	return &mixerImp{
+		Value: threejs.Threejs("AnimationMixer").New(root.JSValue()),
+	}
+}
+
 // NewMixerFromJSValue creates Mixer from js.Value.
 func NewMixerFromJSValue(v js.Value) (Mixer, error) {
 	if v.IsNull() || v.IsUndefined() {
@@ -0,0 +1,65 @@
+package animation
+
+import (
+	"app/lib/threejs"
+	"encoding/binary"
+	"math"
+	"syscall/js"
+)
+
+// Track is a timed sequence of keyframes of a property of an object, such as "uuid.quaternion".
+// The name is resolved by PropertyBinding from the root of the mixer, with the name or the UUID of the object.
+type Track interface {
+	JSValue() js.Value
+
+	// Name gets the name of the track, which refers to the animated property.
+	Name() string
+}
+
+type trackImp struct {
+	js.Value
+}
+
+// NewQuaternionTrack creates a track of quaternions. values are x, y, z and w of each keyframe.
+func NewQuaternionTrack(name string, times []float64, values []float64) Track {
+	return newTrack("QuaternionKeyframeTrack", name, times, values)
+}
+
+// NewVectorTrack creates a track of vectors, such as positions. values are the components of each keyframe.
+func NewVectorTrack(name string, times []float64, values []float64) Track {
+	return newTrack("VectorKeyframeTrack", name, times, values)
+}
+
+// NewNumberTrack creates a track of numbers, such as weights of morph targets.
+func NewNumberTrack(name string, times []float64, values []float64) Track {
+	return newTrack("NumberKeyframeTrack", name, times, values)
+}
+
+func newTrack(class string, name string, times []float64, values []float64) Track {
+	return &trackImp{
+		Value: threejs.Threejs(class).New(name, float32Array(times), float32Array(values)),
+	}
+}
+
+// JSValue is ...
+func (c *trackImp) JSValue() js.Value {
+	return c.Value
+}
+
+// Name gets the name of the track, which refers to the animated property.
+func (c *trackImp) Name() string {
+	return c.Get("name").String()
+}
+
+// float32Array copies values to Float32Array.
+func float32Array(values []float64) js.Value {
+
+	// 要素ごとにJSを呼ぶと遅いため、バイト列としてまとめてコピーする
+	b := make([]byte, len(values)*4)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(b[i*4:], math.Float32bits(float32(v)))
+	}
+	u8 := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(u8, b)
+	return js.Global().Get("Float32Array").New(u8.Get("buffer"))
+}
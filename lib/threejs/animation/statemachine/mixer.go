@@ -0,0 +1,13 @@
+package statemachine
+
+// Mixer is the player which a state machine drives.
+// NewMixer adapts animation.Mixer to this interface in the browser,
+// and tests can supply a fake one.
+type Mixer interface {
+	// Duration gets the duration of the clip in seconds.
+	Duration(clip string) (float64, error)
+
+	// CrossFade starts playing the clip of the state "to", fading out the clip of the state "from" within the passed time interval.
+	// from is nil when nothing is playing.
+	CrossFade(from *State, to *State, durationInSeconds float64) error
+}
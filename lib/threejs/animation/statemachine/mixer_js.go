@@ -0,0 +1,77 @@
+//go:build js && wasm
+// +build js,wasm
+
+package statemachine
+
+import (
+	"app/lib/threejs/animation"
+	"fmt"
+)
+
+// mixerImp drives animation.Mixer with clips keyed by their names.
+type mixerImp struct {
+	mixer animation.Mixer
+	clips map[string]animation.Clip
+}
+
+// NewMixer creates Mixer from animation.Mixer.
+// States refer the clips by animation.Clip.Name.
+func NewMixer(mixer animation.Mixer, clips []animation.Clip) Mixer {
+
+	m := &mixerImp{
+		mixer: mixer,
+		clips: make(map[string]animation.Clip, len(clips)),
+	}
+	for _, clip := range clips {
+		m.clips[clip.Name()] = clip
+	}
+
+	return m
+}
+
+func (c *mixerImp) Duration(clip string) (float64, error) {
+	v, ok := c.clips[clip]
+	if !ok {
+		return 0, fmt.Errorf("clip %q is not found", clip)
+	}
+
+	return v.Duration(), nil
+}
+
+func (c *mixerImp) CrossFade(from *State, to *State, durationInSeconds float64) error {
+
+	next, err := c.action(to.Clip())
+	if err != nil {
+		return err
+	}
+
+	var prev animation.Action
+	if from != nil {
+		prev, err = c.action(from.Clip())
+		if err != nil {
+			return err
+		}
+	}
+
+	if to.Looping() {
+		next.SetLoop(animation.LoopRepeat, -1)
+		next.SetClampWhenFinished(false)
+	} else {
+		next.SetLoop(animation.LoopOnce, 0)
+		next.SetClampWhenFinished(true)
+	}
+
+	animation.CrossFade(prev, next, durationInSeconds, false)
+	next.SetEffectiveTimeScale(to.PlaybackSpeed())
+
+	return nil
+}
+
+func (c *mixerImp) action(clip string) (animation.Action, error) {
+	v, ok := c.clips[clip]
+	if !ok {
+		return nil, fmt.Errorf("clip %q is not found", clip)
+	}
+
+	return c.mixer.ClipAction(v)
+}
@@ -0,0 +1,30 @@
+package statemachine
+
+// ParameterType is a type of a parameter which is referred by transition conditions.
+type ParameterType int
+
+const (
+	// Bool is a parameter which holds true or false.
+	Bool ParameterType = iota + 1
+	// Float is a parameter which holds a number.
+	Float
+	// Trigger is a boolean parameter which is reset when a transition consumes it.
+	Trigger
+)
+
+// parameter is a value set from Go code.
+type parameter struct {
+	typ   ParameterType
+	value float64
+}
+
+func (p *parameter) bool() bool {
+	return p.value != 0
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,49 @@
+package statemachine
+
+// State is a state of the machine bound to an animation clip.
+type State struct {
+	name string
+	clip string
+
+	loop  bool
+	speed float64
+
+	transitions []*Transition
+}
+
+// StateOption is option for AddState method.
+type StateOption func(*State)
+
+// Loop sets a flag whether the clip of the state is repeated. Default is true.
+func Loop(b bool) StateOption {
+	return func(s *State) {
+		s.loop = b
+	}
+}
+
+// Speed sets a playback speed of the state. Default is 1.
+func Speed(v float64) StateOption {
+	return func(s *State) {
+		s.speed = v
+	}
+}
+
+// Name gets the name of the state.
+func (s *State) Name() string {
+	return s.name
+}
+
+// Clip gets the name of the clip played in the state.
+func (s *State) Clip() string {
+	return s.clip
+}
+
+// Looping gets a flag whether the clip of the state is repeated.
+func (s *State) Looping() bool {
+	return s.loop
+}
+
+// PlaybackSpeed gets the playback speed of the state.
+func (s *State) PlaybackSpeed() float64 {
+	return s.speed
+}
@@ -0,0 +1,284 @@
+// Package statemachine switches animation clips by states, transitions and parameters,
+// like idle -> dance -> bow -> idle.
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AnyState is the source state name of transitions which can be made from any state.
+const AnyState = "*"
+
+const defaultBlendDuration = 0.25
+
+// Machine is an animation state machine driving a Mixer.
+type Machine struct {
+	mixer Mixer
+
+	states     map[string]*State
+	anyState   []*Transition
+	parameters map[string]*parameter
+
+	current *State
+	// elapsed is time in seconds since the current state was entered.
+	elapsed float64
+}
+
+// New creates a new state machine.
+func New(mixer Mixer) *Machine {
+	return &Machine{
+		mixer:      mixer,
+		states:     make(map[string]*State),
+		parameters: make(map[string]*parameter),
+	}
+}
+
+// AddState adds a state bound to a clip.
+func (m *Machine) AddState(name string, clip string, options ...StateOption) (*State, error) {
+
+	if name == "" || name == AnyState {
+		return nil, fmt.Errorf("state name %q is not valid", name)
+	}
+	if _, ok := m.states[name]; ok {
+		return nil, fmt.Errorf("state %q already exists", name)
+	}
+
+	s := &State{
+		name:  name,
+		clip:  clip,
+		loop:  true,
+		speed: 1,
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	m.states[name] = s
+	return s, nil
+}
+
+// AddTransition adds a transition. Set AnyState to from for transitions which can be made from any state.
+// A transition without conditions and exit time is never made.
+func (m *Machine) AddTransition(from string, to string, options ...TransitionOption) (*Transition, error) {
+
+	if _, ok := m.states[to]; !ok {
+		return nil, fmt.Errorf("state %q is not found", to)
+	}
+
+	t := &Transition{
+		from:     from,
+		to:       to,
+		duration: defaultBlendDuration,
+	}
+	for _, opt := range options {
+		opt(t)
+	}
+
+	for _, c := range t.conditions {
+		if _, ok := m.parameters[c.Parameter]; !ok {
+			return nil, fmt.Errorf("parameter %q is not found", c.Parameter)
+		}
+	}
+
+	if from == AnyState {
+		m.anyState = append(m.anyState, t)
+		return t, nil
+	}
+
+	s, ok := m.states[from]
+	if !ok {
+		return nil, fmt.Errorf("state %q is not found", from)
+	}
+	s.transitions = append(s.transitions, t)
+
+	return t, nil
+}
+
+// AddParameter adds a parameter referred by transition conditions.
+func (m *Machine) AddParameter(name string, typ ParameterType) error {
+	if _, ok := m.parameters[name]; ok {
+		return fmt.Errorf("parameter %q already exists", name)
+	}
+
+	m.parameters[name] = &parameter{typ: typ}
+	return nil
+}
+
+// SetBool sets a value of the bool parameter.
+func (m *Machine) SetBool(name string, v bool) error {
+	return m.set(name, Bool, boolToFloat(v))
+}
+
+// SetFloat sets a value of the float parameter.
+func (m *Machine) SetFloat(name string, v float64) error {
+	return m.set(name, Float, v)
+}
+
+// SetTrigger sets the trigger parameter. It is reset when a transition consumes it.
+func (m *Machine) SetTrigger(name string) error {
+	return m.set(name, Trigger, 1)
+}
+
+// ResetTrigger resets the trigger parameter without making a transition.
+func (m *Machine) ResetTrigger(name string) error {
+	return m.set(name, Trigger, 0)
+}
+
+// Bool gets a value of the bool or trigger parameter.
+func (m *Machine) Bool(name string) bool {
+	p, ok := m.parameters[name]
+	if !ok {
+		return false
+	}
+	return p.bool()
+}
+
+// Float gets a value of the float parameter.
+func (m *Machine) Float(name string) float64 {
+	p, ok := m.parameters[name]
+	if !ok {
+		return 0
+	}
+	return p.value
+}
+
+func (m *Machine) set(name string, typ ParameterType, v float64) error {
+	p, ok := m.parameters[name]
+	if !ok {
+		return fmt.Errorf("parameter %q is not found", name)
+	}
+	if p.typ != typ {
+		return fmt.Errorf("parameter %q has another type", name)
+	}
+
+	p.value = v
+	return nil
+}
+
+// Start enters the state without blending.
+func (m *Machine) Start(name string) error {
+	s, ok := m.states[name]
+	if !ok {
+		return fmt.Errorf("state %q is not found", name)
+	}
+
+	return m.enter(s, 0)
+}
+
+// Current gets the current state. It returns nil before Start is called.
+func (m *Machine) Current() *State {
+	return m.current
+}
+
+// Elapsed gets time in seconds since the current state was entered.
+func (m *Machine) Elapsed() float64 {
+	return m.elapsed
+}
+
+// Update advances the state time and makes a transition if the conditions are satisfied.
+// Call it with the same delta as the mixer update.
+// It returns the transition made in this update, or nil.
+func (m *Machine) Update(delta float64) (*Transition, error) {
+
+	if m.current == nil {
+		return nil, errors.New("state machine is not started")
+	}
+
+	m.elapsed += delta * m.current.speed
+
+	normalized, err := m.normalizedTime()
+	if err != nil {
+		return nil, err
+	}
+
+	// AnyStateからの遷移を優先して評価する
+	candidates := append(append([]*Transition{}, m.anyState...), m.current.transitions...)
+	for _, t := range candidates {
+		if t.from == AnyState && t.to == m.current.name {
+			continue
+		}
+		if !m.satisfied(t, normalized) {
+			continue
+		}
+
+		// 切り替えに失敗した場合はトリガーを残し、次の更新で再び遷移させる
+		if err := m.enter(m.states[t.to], t.duration); err != nil {
+			return nil, err
+		}
+		m.consumeTriggers(t)
+		return t, nil
+	}
+
+	return nil, nil
+}
+
+// normalizedTime gets the elapsed time of the current state divided by its clip duration.
+func (m *Machine) normalizedTime() (float64, error) {
+	d, err := m.mixer.Duration(m.current.clip)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 1, nil
+	}
+
+	return m.elapsed / d, nil
+}
+
+func (m *Machine) satisfied(t *Transition, normalized float64) bool {
+
+	if !t.hasExitTime && len(t.conditions) == 0 {
+		return false
+	}
+	if t.hasExitTime && normalized < t.exitTime {
+		return false
+	}
+
+	for _, c := range t.conditions {
+		p := m.parameters[c.Parameter]
+
+		switch c.Mode {
+		case If:
+			if !p.bool() {
+				return false
+			}
+		case IfNot:
+			if p.bool() {
+				return false
+			}
+		case Greater:
+			if !(p.value > c.Threshold) {
+				return false
+			}
+		case Less:
+			if !(p.value < c.Threshold) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *Machine) consumeTriggers(t *Transition) {
+	for _, c := range t.conditions {
+		if p := m.parameters[c.Parameter]; p.typ == Trigger {
+			p.value = 0
+		}
+	}
+}
+
+func (m *Machine) enter(s *State, duration float64) error {
+
+	if err := m.mixer.CrossFade(m.current, s, duration); err != nil {
+		return err
+	}
+
+	m.current = s
+	m.elapsed = 0
+
+	return nil
+}
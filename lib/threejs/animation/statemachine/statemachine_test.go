@@ -0,0 +1,347 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// fade is a cross fade requested to fakeMixer.
+type fade struct {
+	from     string
+	to       string
+	duration float64
+}
+
+// fakeMixer records cross fades instead of playing clips.
+type fakeMixer struct {
+	durations map[string]float64
+	fades     []fade
+	// err fails cross fades while it is set.
+	err error
+}
+
+func (m *fakeMixer) Duration(clip string) (float64, error) {
+	d, ok := m.durations[clip]
+	if !ok {
+		return 0, fmt.Errorf("clip %q is not found", clip)
+	}
+	return d, nil
+}
+
+func (m *fakeMixer) CrossFade(from *State, to *State, durationInSeconds float64) error {
+	if m.err != nil {
+		return m.err
+	}
+	f := fade{to: to.Name(), duration: durationInSeconds}
+	if from != nil {
+		f.from = from.Name()
+	}
+	m.fades = append(m.fades, f)
+	return nil
+}
+
+// transition is arguments of AddTransition.
+type transition struct {
+	from    string
+	to      string
+	options []TransitionOption
+}
+
+// newMachine creates a machine of idle (2 seconds), dance (4 seconds, played twice as fast) and bow (1 second, not looped),
+// with the bool parameter "dancing", the float parameter "energy" and the trigger "bow", started in idle.
+func newMachine(t *testing.T, transitions []transition) (*Machine, *fakeMixer) {
+
+	mixer := &fakeMixer{durations: map[string]float64{"idle.vmd": 2, "dance.vmd": 4, "bow.vmd": 1}}
+	m := New(mixer)
+
+	for _, s := range []struct {
+		name    string
+		clip    string
+		options []StateOption
+	}{
+		{"idle", "idle.vmd", nil},
+		{"dance", "dance.vmd", []StateOption{Speed(2)}},
+		{"bow", "bow.vmd", []StateOption{Loop(false)}},
+	} {
+		if _, err := m.AddState(s.name, s.clip, s.options...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, typ := range map[string]ParameterType{"dancing": Bool, "energy": Float, "bow": Trigger} {
+		if err := m.AddParameter(name, typ); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tr := range transitions {
+		if _, err := m.AddTransition(tr.from, tr.to, tr.options...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := m.Start("idle"); err != nil {
+		t.Fatal(err)
+	}
+	return m, mixer
+}
+
+func TestStart(t *testing.T) {
+
+	m, mixer := newMachine(t, nil)
+
+	if m.Current().Name() != "idle" || m.Elapsed() != 0 {
+		t.Errorf("started in %q at %v, want idle at 0", m.Current().Name(), m.Elapsed())
+	}
+	want := fade{from: "", to: "idle", duration: 0}
+	if len(mixer.fades) != 1 || mixer.fades[0] != want {
+		t.Errorf("fades = %v, want [%v]", mixer.fades, want)
+	}
+	if err := m.Start("walk"); err == nil {
+		t.Error("Start() of an unknown state succeeded")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		transitions []transition
+		set         func(m *Machine)
+		// deltas are passed to Update in order.
+		deltas []float64
+		want   string
+		fades  []fade
+	}{
+		{
+			name:        "bool condition",
+			transitions: []transition{{"idle", "dance", []TransitionOption{When("dancing", If, 0)}}},
+			set:         func(m *Machine) { m.SetBool("dancing", true) },
+			deltas:      []float64{0.1},
+			want:        "dance",
+			fades:       []fade{{"idle", "dance", defaultBlendDuration}},
+		},
+		{
+			name:        "unsatisfied bool condition",
+			transitions: []transition{{"idle", "dance", []TransitionOption{When("dancing", If, 0)}}},
+			deltas:      []float64{0.1, 10},
+			want:        "idle",
+		},
+		{
+			name:        "bool condition of false",
+			transitions: []transition{{"idle", "dance", []TransitionOption{When("dancing", IfNot, 0), BlendDuration(1)}}},
+			deltas:      []float64{0.1},
+			want:        "dance",
+			fades:       []fade{{"idle", "dance", 1}},
+		},
+		{
+			name: "float conditions",
+			transitions: []transition{
+				{"idle", "bow", []TransitionOption{When("energy", Less, 0.2)}},
+				{"idle", "dance", []TransitionOption{When("energy", Greater, 0.5), When("energy", Less, 0.9)}},
+			},
+			set:    func(m *Machine) { m.SetFloat("energy", 0.7) },
+			deltas: []float64{0.1},
+			want:   "dance",
+			fades:  []fade{{"idle", "dance", defaultBlendDuration}},
+		},
+		{
+			name:        "float above every range",
+			transitions: []transition{{"idle", "dance", []TransitionOption{When("energy", Greater, 0.5), When("energy", Less, 0.9)}}},
+			set:         func(m *Machine) { m.SetFloat("energy", 1) },
+			deltas:      []float64{0.1},
+			want:        "idle",
+		},
+		{
+			name: "exit time",
+			transitions: []transition{
+				{"idle", "bow", []TransitionOption{ExitTime(0.5), BlendDuration(0)}},
+			},
+			// 2秒のクリップの半分で遷移する
+			deltas: []float64{0.5, 0.4, 0.2},
+			want:   "bow",
+			fades:  []fade{{"idle", "bow", 0}},
+		},
+		{
+			name: "exit time with the speed of the state",
+			transitions: []transition{
+				{"idle", "dance", []TransitionOption{When("dancing", If, 0)}},
+				{"dance", "idle", []TransitionOption{ExitTime(1)}},
+			},
+			set: func(m *Machine) { m.SetBool("dancing", true) },
+			// 4秒のクリップを2倍速で再生するため、2秒で終わる
+			deltas: []float64{0.1, 1.5, 0.6},
+			want:   "idle",
+			fades:  []fade{{"idle", "dance", defaultBlendDuration}, {"dance", "idle", defaultBlendDuration}},
+		},
+		{
+			name:        "transition without conditions nor exit time",
+			transitions: []transition{{"idle", "dance", nil}},
+			deltas:      []float64{0.1, 10},
+			want:        "idle",
+		},
+		{
+			name: "trigger is consumed",
+			transitions: []transition{
+				{"idle", "bow", []TransitionOption{When("bow", If, 0)}},
+				{"bow", "idle", []TransitionOption{When("bow", IfNot, 0)}},
+			},
+			set:    func(m *Machine) { m.SetTrigger("bow") },
+			deltas: []float64{0.1, 0.1},
+			want:   "idle",
+			fades:  []fade{{"idle", "bow", defaultBlendDuration}, {"bow", "idle", defaultBlendDuration}},
+		},
+		{
+			name: "any state comes first",
+			transitions: []transition{
+				{"idle", "dance", []TransitionOption{When("bow", If, 0)}},
+				{AnyState, "bow", []TransitionOption{When("bow", If, 0), BlendDuration(0.5)}},
+			},
+			set:    func(m *Machine) { m.SetTrigger("bow") },
+			deltas: []float64{0.1},
+			want:   "bow",
+			fades:  []fade{{"idle", "bow", 0.5}},
+		},
+		{
+			name:        "any state does not enter itself",
+			transitions: []transition{{AnyState, "idle", []TransitionOption{When("dancing", IfNot, 0)}}},
+			deltas:      []float64{0.1, 0.1},
+			want:        "idle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, mixer := newMachine(t, tt.transitions)
+			mixer.fades = nil
+			if tt.set != nil {
+				tt.set(m)
+			}
+
+			for _, d := range tt.deltas {
+				if _, err := m.Update(d); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if m.Current().Name() != tt.want {
+				t.Errorf("state = %q, want %q", m.Current().Name(), tt.want)
+			}
+			if fmt.Sprint(mixer.fades) != fmt.Sprint(tt.fades) {
+				t.Errorf("fades = %v, want %v", mixer.fades, tt.fades)
+			}
+		})
+	}
+}
+
+func TestUpdateReturnsTransition(t *testing.T) {
+
+	m, _ := newMachine(t, []transition{{"idle", "dance", []TransitionOption{When("dancing", If, 0)}}})
+
+	if tr, err := m.Update(0.1); tr != nil || err != nil {
+		t.Fatalf("Update() = %v, %v, want no transition", tr, err)
+	}
+	if m.Elapsed() != 0.1 {
+		t.Errorf("Elapsed() = %v, want 0.1", m.Elapsed())
+	}
+
+	m.SetBool("dancing", true)
+	tr, err := m.Update(0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr == nil || tr.From() != "idle" || tr.To() != "dance" {
+		t.Errorf("Update() = %v, want the transition from idle to dance", tr)
+	}
+	if m.Elapsed() != 0 {
+		t.Errorf("Elapsed() = %v after the transition, want 0", m.Elapsed())
+	}
+}
+
+func TestErrors(t *testing.T) {
+
+	tests := []struct {
+		name string
+		run  func(m *Machine) error
+	}{
+		{"empty state name", func(m *Machine) error { _, err := m.AddState("", "a.vmd"); return err }},
+		{"any state name", func(m *Machine) error { _, err := m.AddState(AnyState, "a.vmd"); return err }},
+		{"duplicate state", func(m *Machine) error { _, err := m.AddState("idle", "a.vmd"); return err }},
+		{"transition to unknown state", func(m *Machine) error { _, err := m.AddTransition("idle", "walk"); return err }},
+		{"transition from unknown state", func(m *Machine) error { _, err := m.AddTransition("walk", "idle"); return err }},
+		{"unknown parameter in condition", func(m *Machine) error {
+			_, err := m.AddTransition("idle", "dance", When("walking", If, 0))
+			return err
+		}},
+		{"duplicate parameter", func(m *Machine) error { return m.AddParameter("energy", Float) }},
+		{"unknown parameter", func(m *Machine) error { return m.SetBool("walking", true) }},
+		{"parameter of another type", func(m *Machine) error { return m.SetFloat("dancing", 1) }},
+		{"trigger set as bool", func(m *Machine) error { return m.SetBool("bow", true) }},
+		{"clip not in the mixer", func(m *Machine) error {
+			if _, err := m.AddState("walk", "walk.vmd"); err != nil {
+				return nil
+			}
+			m.Start("walk")
+			_, err := m.Update(0.1)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newMachine(t, nil)
+			if err := tt.run(m); err == nil {
+				t.Error("succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestUpdateBeforeStart(t *testing.T) {
+	m := New(&fakeMixer{})
+	if _, err := m.Update(0.1); err == nil {
+		t.Error("Update() before Start succeeded, want an error")
+	}
+}
+
+func TestCrossFadeError(t *testing.T) {
+
+	m := New(&fakeMixer{durations: map[string]float64{"idle.vmd": 2}, err: errors.New("cross fade failed")})
+	if _, err := m.AddState("idle", "idle.vmd"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start("idle"); err == nil {
+		t.Fatal("Start() succeeded, want the error of the mixer")
+	}
+	if m.Current() != nil {
+		t.Errorf("Current() = %v after the failed start, want nil", m.Current())
+	}
+}
+
+func TestCrossFadeErrorKeepsTrigger(t *testing.T) {
+
+	m, mixer := newMachine(t, []transition{
+		{"idle", "bow", []TransitionOption{When("bow", If, 0)}},
+	})
+	m.SetTrigger("bow")
+
+	mixer.err = errors.New("cross fade failed")
+	if _, err := m.Update(0.1); err == nil {
+		t.Fatal("Update() succeeded, want the error of the mixer")
+	}
+	if got := m.Current().Name(); got != "idle" {
+		t.Errorf("state = %q after the failed transition, want idle", got)
+	}
+
+	// 失敗した遷移のトリガーは次の更新で使われる
+	mixer.err = nil
+	tr, err := m.Update(0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr == nil || m.Current().Name() != "bow" {
+		t.Fatalf("Update() = %v in %q, want the transition to bow", tr, m.Current().Name())
+	}
+	if m.Bool("bow") {
+		t.Error("trigger is set after the transition, want consumed")
+	}
+}
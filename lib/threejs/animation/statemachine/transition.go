@@ -0,0 +1,80 @@
+package statemachine
+
+// ConditionMode is a comparison mode of a transition condition.
+type ConditionMode int
+
+const (
+	// If is satisfied when the bool or trigger parameter is true.
+	If ConditionMode = iota + 1
+	// IfNot is satisfied when the bool parameter is false.
+	IfNot
+	// Greater is satisfied when the float parameter is greater than the threshold.
+	Greater
+	// Less is satisfied when the float parameter is less than the threshold.
+	Less
+)
+
+// Condition is a condition of a transition.
+type Condition struct {
+	Parameter string
+	Mode      ConditionMode
+	Threshold float64
+}
+
+// Transition is a transition from a state to another.
+type Transition struct {
+	from string
+	to   string
+
+	conditions []Condition
+
+	hasExitTime bool
+	exitTime    float64
+
+	duration float64
+}
+
+// TransitionOption is option for AddTransition method.
+type TransitionOption func(*Transition)
+
+// When adds a condition. All conditions must be satisfied to make the transition.
+func When(parameter string, mode ConditionMode, threshold float64) TransitionOption {
+	return func(t *Transition) {
+		t.conditions = append(t.conditions, Condition{
+			Parameter: parameter,
+			Mode:      mode,
+			Threshold: threshold,
+		})
+	}
+}
+
+// ExitTime sets a normalized time of the source state after which the transition can be made.
+// 1 means the end of the first loop. Values above 1 wait for later loops of a looping state.
+func ExitTime(normalizedTime float64) TransitionOption {
+	return func(t *Transition) {
+		t.hasExitTime = true
+		t.exitTime = normalizedTime
+	}
+}
+
+// BlendDuration sets the cross fade time in seconds. Default is 0.25.
+func BlendDuration(durationInSeconds float64) TransitionOption {
+	return func(t *Transition) {
+		t.duration = durationInSeconds
+	}
+}
+
+// From gets the source state name. AnyState is returned for transitions from any state.
+func (t *Transition) From() string {
+	return t.from
+}
+
+// To gets the destination state name.
+func (t *Transition) To() string {
+	return t.to
+}
+
+// Duration gets the cross fade time in seconds.
+func (t *Transition) Duration() float64 {
+	return t.duration
+}
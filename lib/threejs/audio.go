@@ -0,0 +1,76 @@
+package threejs
+
+import (
+	"syscall/js"
+)
+
+// Audio is a non-positional audio object, such as a song played by MMDAnimationHelper with motions.
+type Audio interface {
+	Object3D
+
+	// SetBuffer sets the decoded audio to play.
+	SetBuffer(buffer AudioBuffer)
+
+	// IsPlaying gets whether the audio is playing.
+	IsPlaying() bool
+
+	// Stop stops playback and rewinds to the start.
+	Stop()
+}
+
+// AudioBuffer is decoded audio of the Web Audio API.
+type AudioBuffer interface {
+	JSValue() js.Value
+
+	// Duration gets the length in seconds.
+	Duration() float64
+}
+
+type audioImp struct {
+	Object3D
+}
+
+type audioBufferImp struct {
+	js.Value
+}
+
+// NewAudio creates Audio heard by the listener.
+func NewAudio(listener AudioListener) Audio {
+	return &audioImp{
+		NewObject3DFromJSValue(Threejs("Audio").New(listener.JSValue())),
+	}
+}
+
+// NewAudioBufferFromJSValue creates AudioBuffer with js.Value.
+func NewAudioBufferFromJSValue(v js.Value) AudioBuffer {
+	return &audioBufferImp{
+		Value: v,
+	}
+}
+
+// SetBuffer sets the decoded audio to play.
+func (c *audioImp) SetBuffer(buffer AudioBuffer) {
+	c.JSValue().Call("setBuffer", buffer.JSValue())
+}
+
+// IsPlaying gets whether the audio is playing.
+func (c *audioImp) IsPlaying() bool {
+	return c.JSValue().Get("isPlaying").Bool()
+}
+
+// Stop stops playback and rewinds to the start.
+func (c *audioImp) Stop() {
+	if c.IsPlaying() {
+		c.JSValue().Call("stop")
+	}
+}
+
+// JSValue is ...
+func (c *audioBufferImp) JSValue() js.Value {
+	return c.Value
+}
+
+// Duration gets the length in seconds.
+func (c *audioBufferImp) Duration() float64 {
+	return c.Get("duration").Float()
+}
@@ -0,0 +1,28 @@
+package threejs
+
+// AudioListener is a virtual listener of all audio in the scene. Add it to the camera.
+type AudioListener interface {
+	Object3D
+
+	// Resume resumes the AudioContext, which browsers suspend until a user gesture. Call it in event handlers.
+	Resume()
+}
+
+type audioListenerImp struct {
+	Object3D
+}
+
+// NewAudioListener creates AudioListener.
+func NewAudioListener() AudioListener {
+	return &audioListenerImp{
+		NewObject3DFromJSValue(Threejs("AudioListener").New()),
+	}
+}
+
+// Resume resumes the AudioContext, which browsers suspend until a user gesture.
+func (c *audioListenerImp) Resume() {
+	context := c.JSValue().Get("context")
+	if context.Get("state").String() == "suspended" {
+		context.Call("resume")
+	}
+}
@@ -0,0 +1,76 @@
+package threejs
+
+import (
+	"syscall/js"
+)
+
+// Box3 represents an axis-aligned bounding box (AABB) in 3D space.
+type Box3 struct {
+	js.Value
+}
+
+// NewBox3 creates an empty Box3.
+func NewBox3() *Box3 {
+	return &Box3{Value: Threejs("Box3").New()}
+}
+
+// NewBox3FromJSValue is ...
+func NewBox3FromJSValue(v js.Value) *Box3 {
+	return &Box3{Value: v}
+}
+
+// JSValue is ...
+func (b *Box3) JSValue() js.Value {
+	return b.Value
+}
+
+// Min gets lower (x, y, z) boundary of the box.
+func (b *Box3) Min() *Vector3 {
+	return NewVector3FromJSValue(b.Get("min"))
+}
+
+// Max gets upper (x, y, z) boundary of the box.
+func (b *Box3) Max() *Vector3 {
+	return NewVector3FromJSValue(b.Get("max"))
+}
+
+// IsEmpty returns true if this box includes zero points within its bounds.
+func (b *Box3) IsEmpty() bool {
+	return b.Call("isEmpty").Bool()
+}
+
+// MakeEmpty makes this box empty.
+func (b *Box3) MakeEmpty() *Box3 {
+	b.Call("makeEmpty")
+	return b
+}
+
+// SetFromObject computes the world-axis-aligned bounding box of an Object3D (including its children),
+// accounting for the object's, and children's, world transforms.
+func (b *Box3) SetFromObject(object Object3D) *Box3 {
+	b.Call("setFromObject", object.JSValue())
+	return b
+}
+
+// ExpandByObject expands the boundaries of this box to include object and its children,
+// accounting for the object's, and children's, world transforms.
+func (b *Box3) ExpandByObject(object Object3D) *Box3 {
+	b.Call("expandByObject", object.JSValue())
+	return b
+}
+
+// ExpandByScalar expands each dimension of the box by scalar. If negative, the dimensions of the box will be contracted.
+func (b *Box3) ExpandByScalar(scalar float64) *Box3 {
+	b.Call("expandByScalar", scalar)
+	return b
+}
+
+// Center gets the center point of the box.
+func (b *Box3) Center() *Vector3 {
+	return NewVector3FromJSValue(b.Call("getCenter", NewVector3(0, 0, 0).JSValue()))
+}
+
+// Size gets the width, height and depth of this box.
+func (b *Box3) Size() *Vector3 {
+	return NewVector3FromJSValue(b.Call("getSize", NewVector3(0, 0, 0).JSValue()))
+}
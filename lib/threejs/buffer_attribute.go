@@ -1,6 +1,10 @@
 package threejs
 
-import "syscall/js"
+import (
+	"encoding/binary"
+	"math"
+	"syscall/js"
+)
 
 // BufferAttribute stores data for an attribute (such as vertex positions, face indices, normals, colors, UVs, and any custom attributes ) associated with a BufferGeometry, which allows for more efficient passing of data to the GPU. See that page for details and a usage example.
 //
@@ -19,6 +23,23 @@ func NewFloat32BufferAttribute(arrayLength int, itemSize int) *BufferAttribute {
 	}
 }
 
+// NewFloat32BufferAttributeFromSlice creates BufferAttribute with values. len(values) must be a multiple of itemSize.
+func NewFloat32BufferAttributeFromSlice(values []float32, itemSize int) *BufferAttribute {
+
+	// 要素ごとにJSを呼ぶと遅いため、バイト列としてまとめてコピーする
+	b := make([]byte, len(values)*4)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(b[i*4:], math.Float32bits(v))
+	}
+	u8 := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(u8, b)
+	ar := js.Global().Get("Float32Array").New(u8.Get("buffer"))
+
+	return &BufferAttribute{
+		Value: Threejs("BufferAttribute").New(ar, itemSize),
+	}
+}
+
 // ItemSize gets the length of vectors that are being stored in the array.
 func (c *BufferAttribute) ItemSize() int {
 	return c.Get("itemSize").Int()
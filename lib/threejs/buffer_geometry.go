@@ -17,6 +17,12 @@ type BufferGeometry interface {
 	// SetAttribute sets an attribute to this geometry. Use this rather than the attributes property, because an internal hashmap of .attributes is maintained to speed up iterating over attributes.
 	SetAttribute(name string, attribute *BufferAttribute)
 
+	// AddGroup adds a group to this geometry, which is drawn with the material of materialIndex.
+	AddGroup(start int, count int, materialIndex int)
+
+	// ComputeVertexNormals computes vertex normals by averaging face normals.
+	ComputeVertexNormals()
+
 	// Disposes the object from memory.
 	// You need to call this when you want the BufferGeometry removed while the application is running.
 	Dispose()
@@ -47,6 +53,14 @@ func (c *bufferGeometryImpl) SetAttribute(name string, attribute *BufferAttribut
 
 }
 
+func (c *bufferGeometryImpl) AddGroup(start int, count int, materialIndex int) {
+	c.Call("addGroup", start, count, materialIndex)
+}
+
+func (c *bufferGeometryImpl) ComputeVertexNormals() {
+	c.Call("computeVertexNormals")
+}
+
 func (c *bufferGeometryImpl) Dispose() {
 	c.Call("dispose")
 }
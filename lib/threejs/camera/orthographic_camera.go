@@ -21,6 +21,14 @@ type OrthographicCamera interface {
 	SetTop(v float64)
 	Bottom() float64
 	SetBottom(v float64)
+	// Near gets camera frustum near plane. Default is 0.1.
+	Near() float64
+	// SetNear sets camera frustum near plane. Default is 0.1.
+	SetNear(v float64)
+	// Far gets camera frustum far plane. Default is 2000.
+	Far() float64
+	// SetFar sets camera frustum far plane. Default is 2000.
+	SetFar(v float64)
 	// Zoom gets the zoom factor of the camera. Default is 1.
 	Zoom() float64
 	// SetZoom sets the zoom factor of the camera. Default is 1.
@@ -493,3 +501,23 @@ func (oc *orthographicCameraImp) SetZoom(v float64) {
 // func (oc *OrthographicCamera) WorldToLocal(vector *Vector3) *Vector3 {
 // 	return &Vector3{Value: oc.JSValue().Call("worldToLocal", vector)}
 // }
+
+// Near is ...
+func (oc *orthographicCameraImp) Near() float64 {
+	return oc.JSValue().Get("near").Float()
+}
+
+// SetNear is ...
+func (oc *orthographicCameraImp) SetNear(v float64) {
+	oc.JSValue().Set("near", v)
+}
+
+// Far is ...
+func (oc *orthographicCameraImp) Far() float64 {
+	return oc.JSValue().Get("far").Float()
+}
+
+// SetFar is ...
+func (oc *orthographicCameraImp) SetFar(v float64) {
+	oc.JSValue().Set("far", v)
+}
@@ -78,9 +78,15 @@ type OrbitControls interface {
 	// SetMaxAzimuthAngle sets how far you can orbit horizontally, upper limit. If set, the interval [ min, max ] must be a sub-interval of [ - 2 PI, 2 PI ], with ( max - min < 2 PI ). Default is Infinity.
 	SetMaxAzimuthAngle(v float64)
 
+	// MaxDistance gets how far you can dolly out ( PerspectiveCamera only ). Default is Infinity.
+	MaxDistance() float64
+
 	// SetMaxDistance sets how far you can dolly out ( PerspectiveCamera only ). Default is Infinity.
 	SetMaxDistance(v float64)
 
+	// MaxPolarAngle gets how far you can orbit vertically, upper limit. Range is 0 to Math.PI radians, and default is Math.PI.
+	MaxPolarAngle() float64
+
 	// SetMaxPolarAngle sets how far you can orbit vertically, upper limit. Range is 0 to Math.PI radians, and default is Math.PI.
 	SetMaxPolarAngle(v float64)
 
@@ -90,9 +96,15 @@ type OrbitControls interface {
 	// SetMinAzimuthAngle sets how far you can orbit horizontally, lower limit. If set, the interval [ min, max ] must be a sub-interval of [ - 2 PI, 2 PI ], with ( max - min < 2 PI ). Default is Infinity.
 	SetMinAzimuthAngle(v float64)
 
+	// MinDistance gets how far you can dolly in ( PerspectiveCamera only ). Default is 0.
+	MinDistance() float64
+
 	// SetMinDistance sets how far you can dolly in ( PerspectiveCamera only ). Default is 0.
 	SetMinDistance(v float64)
 
+	// MinPolarAngle gets how far you can orbit vertically, lower limit. Range is 0 to Math.PI radians, and default is 0.
+	MinPolarAngle() float64
+
 	// SetMinPolarAngle sets how far you can orbit vertically, lower limit. Range is 0 to Math.PI radians, and default is 0.
 	SetMinPolarAngle(v float64)
 
@@ -226,11 +238,21 @@ func (c *orbitControlsImp) SetMaxAzimuthAngle(v float64) {
 	c.Set("maxAzimuthAngle", v)
 }
 
+// MaxDistance gets how far you can dolly out ( PerspectiveCamera only ). Default is Infinity.
+func (c *orbitControlsImp) MaxDistance() float64 {
+	return c.Get("maxDistance").Float()
+}
+
 // SetMaxDistance sets how far you can dolly out ( PerspectiveCamera only ). Default is Infinity.
 func (c *orbitControlsImp) SetMaxDistance(v float64) {
 	c.Set("maxDistance", v)
 }
 
+// MaxPolarAngle gets how far you can orbit vertically, upper limit. Range is 0 to Math.PI radians, and default is Math.PI.
+func (c *orbitControlsImp) MaxPolarAngle() float64 {
+	return c.Get("maxPolarAngle").Float()
+}
+
 // SetMaxPolarAngle sets how far you can orbit vertically, upper limit. Range is 0 to Math.PI radians, and default is Math.PI.
 func (c *orbitControlsImp) SetMaxPolarAngle(v float64) {
 	c.Set("maxPolarAngle", v)
@@ -246,11 +268,21 @@ func (c *orbitControlsImp) SetMinAzimuthAngle(v float64) {
 	c.Set("minAzimuthAngle", v)
 }
 
+// MinDistance gets how far you can dolly in ( PerspectiveCamera only ). Default is 0.
+func (c *orbitControlsImp) MinDistance() float64 {
+	return c.Get("minDistance").Float()
+}
+
 // SetMinDistance sets how far you can dolly in ( PerspectiveCamera only ). Default is 0.
 func (c *orbitControlsImp) SetMinDistance(v float64) {
 	c.Set("minDistance", v)
 }
 
+// MinPolarAngle gets how far you can orbit vertically, lower limit. Range is 0 to Math.PI radians, and default is 0.
+func (c *orbitControlsImp) MinPolarAngle() float64 {
+	return c.Get("minPolarAngle").Float()
+}
+
 // SetMinPolarAngle sets how far you can orbit vertically, lower limit. Range is 0 to Math.PI radians, and default is 0.
 func (c *orbitControlsImp) SetMinPolarAngle(v float64) {
 	c.Set("minPolarAngle", v)
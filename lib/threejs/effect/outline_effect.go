@@ -25,8 +25,14 @@ type OutlineEffect struct {
 }
 
 // NewOutlineEffect is ...
-func NewOutlineEffect(renderer threejs.Renderer) *OutlineEffect {
-	return &OutlineEffect{Value: outlineEffectModule.New(renderer.JSValue())}
+func NewOutlineEffect(renderer threejs.Renderer, options ...OutlineEffectOption) *OutlineEffect {
+
+	var param map[string]interface{} = make(map[string]interface{})
+	for _, opt := range options {
+		opt(param)
+	}
+
+	return &OutlineEffect{Value: outlineEffectModule.New(renderer.JSValue(), param)}
 }
 
 // Render is ...
@@ -38,3 +44,24 @@ func (oe *OutlineEffect) Render(scene threejs.Scene, camera threejs.Camera) {
 func (oe *OutlineEffect) SetSize(width float64, height float64, updateStyle bool) {
 	oe.Call("setSize", width, height, updateStyle)
 }
+
+// PixelRatio gets the device pixel ratio of the renderer.
+func (oe *OutlineEffect) PixelRatio() float64 {
+	return oe.Call("getPixelRatio").Float()
+}
+
+// SetPixelRatio sets the device pixel ratio of the renderer.
+func (oe *OutlineEffect) SetPixelRatio(v float64) {
+	oe.Call("setPixelRatio", v)
+}
+
+// Enabled gets whether outlines are rendered. Default is true.
+func (oe *OutlineEffect) Enabled() bool {
+	return oe.Get("enabled").Bool()
+}
+
+// SetEnabled sets whether outlines are rendered. Default is true.
+// If false, Render renders the scene without outlines.
+func (oe *OutlineEffect) SetEnabled(b bool) {
+	oe.Set("enabled", b)
+}
@@ -0,0 +1,46 @@
+package effect
+
+import "app/lib/threejs"
+
+// OutlineEffectOption is functional parameter option for OutlineEffect.
+type OutlineEffectOption func(map[string]interface{}) error
+
+// DefaultThickness sets outline thickness of materials without outline parameters. Default is 0.003.
+func DefaultThickness(v float64) OutlineEffectOption {
+	return func(m map[string]interface{}) error {
+
+		m["defaultThickness"] = v
+
+		return nil
+	}
+}
+
+// DefaultColor sets outline color of materials without outline parameters. Default is black.
+func DefaultColor(c threejs.Color) OutlineEffectOption {
+	return func(m map[string]interface{}) error {
+
+		m["defaultColor"] = []interface{}{c.R(), c.G(), c.B()}
+
+		return nil
+	}
+}
+
+// DefaultAlpha sets outline alpha of materials without outline parameters. Default is 1.0.
+func DefaultAlpha(a float64) OutlineEffectOption {
+	return func(m map[string]interface{}) error {
+
+		m["defaultAlpha"] = a
+
+		return nil
+	}
+}
+
+// DefaultKeepAlive sets whether outline materials are kept after the original materials are no longer rendered.
+func DefaultKeepAlive(b bool) OutlineEffectOption {
+	return func(m map[string]interface{}) error {
+
+		m["defaultKeepAlive"] = b
+
+		return nil
+	}
+}
@@ -0,0 +1,64 @@
+package effect
+
+import (
+	"app/lib/threejs"
+)
+
+// OutlineParameters is outline settings of a material.
+// OutlineEffect reads them from material.userData.outlineParameters on every render.
+type OutlineParameters struct {
+	// Thickness is outline width in clip space.
+	Thickness float64
+	// Color is RGB in [0, 1].
+	Color [3]float64
+	Alpha float64
+	// Visible is false when the material has no outline.
+	Visible bool
+	// KeepAlive keeps the outline material after the original material is no longer rendered.
+	KeepAlive bool
+}
+
+// MaterialOutlineParameters gets the outline parameters of the material.
+// It returns false if the material has none, and OutlineEffect uses its defaults then.
+func MaterialOutlineParameters(m threejs.Material) (OutlineParameters, bool) {
+
+	v := m.UserData().Get("outlineParameters")
+	if v.IsUndefined() || v.IsNull() {
+		return OutlineParameters{}, false
+	}
+
+	// 未設定の項目はOutlineEffectの既定値と同じにする
+	p := OutlineParameters{
+		Thickness: 0.003,
+		Alpha:     1.0,
+		Visible:   true,
+	}
+	if t := v.Get("thickness"); !t.IsUndefined() {
+		p.Thickness = t.Float()
+	}
+	if c := v.Get("color"); !c.IsUndefined() && c.Length() >= 3 {
+		p.Color = [3]float64{c.Index(0).Float(), c.Index(1).Float(), c.Index(2).Float()}
+	}
+	if a := v.Get("alpha"); !a.IsUndefined() {
+		p.Alpha = a.Float()
+	}
+	if b := v.Get("visible"); !b.IsUndefined() {
+		p.Visible = b.Bool()
+	}
+	if b := v.Get("keepAlive"); !b.IsUndefined() {
+		p.KeepAlive = b.Bool()
+	}
+
+	return p, true
+}
+
+// SetMaterialOutlineParameters sets the outline parameters of the material.
+func SetMaterialOutlineParameters(m threejs.Material, p OutlineParameters) {
+	m.UserData().Set("outlineParameters", map[string]interface{}{
+		"thickness": p.Thickness,
+		"color":     []interface{}{p.Color[0], p.Color[1], p.Color[2]},
+		"alpha":     p.Alpha,
+		"visible":   p.Visible,
+		"keepAlive": p.KeepAlive,
+	})
+}
@@ -0,0 +1,55 @@
+package threejs
+
+import (
+	"syscall/js"
+)
+
+// FileLoader is a low level class for loading resources with Fetch, used internally by most loaders.
+// It can also be used directly to load any file type that does not have a loader.
+type FileLoader interface {
+	Loader
+
+	// SetResponseType sets the expected response type. "arraybuffer", "blob", "document", "json" or "" (text).
+	SetResponseType(v string)
+
+	// Load loads the URL and pass the response to the onLoad function.
+	Load(url string, onLoad js.Func, onProgress js.Func, onError js.Func)
+}
+
+type fileLoaderImp struct {
+	js.Value
+}
+
+// NewFileLoader is constructor.
+func NewFileLoader() FileLoader {
+	return &fileLoaderImp{
+		Value: Threejs("FileLoader").New(),
+	}
+}
+
+// NewFileLoaderWithManager is constructor.
+func NewFileLoaderWithManager(manager LoadingManager) FileLoader {
+	return &fileLoaderImp{
+		Value: Threejs("FileLoader").New(manager.JSValue()),
+	}
+}
+
+// JSValue is ...
+func (c *fileLoaderImp) JSValue() js.Value {
+	return c.Value
+}
+
+// SetPath is ...
+func (c *fileLoaderImp) SetPath(path string) {
+	c.Call("setPath", path)
+}
+
+// SetResponseType is ...
+func (c *fileLoaderImp) SetResponseType(v string) {
+	c.Call("setResponseType", v)
+}
+
+// Load is ...
+func (c *fileLoaderImp) Load(url string, onLoad js.Func, onProgress js.Func, onError js.Func) {
+	c.Call("load", url, onLoad, onProgress, onError)
+}
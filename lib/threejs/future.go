@@ -0,0 +1,258 @@
+package threejs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"syscall/js"
+)
+
+// Future is a progress or a result of loading files, sent to the channels of all loaders in lib/threejs.
+// T is the type of the loaded objects, such as threejs.SkinnedMesh of MMDLoader.
+//
+// Each Future carries the status of all files of the load, so that the progress of several files is shown as one.
+type Future[T any] struct {
+	url   string
+	value T
+	err   error
+	done  bool
+	files []FileStatus
+}
+
+// FileStatus is the state of a file being loaded.
+type FileStatus struct {
+	URL string
+	// Loaded is loaded bytes.
+	Loaded uint
+	// Total is the size of the file, or 0 until it is known.
+	Total uint
+	// Done is true when the file is loaded or failed.
+	Done bool
+	Err  error
+}
+
+// URL gets the file this Future is about.
+func (c *Future[T]) URL() string {
+	return c.url
+}
+
+// Value gets the loaded object. It is the zero value of T, such as nil, for progress and failures.
+func (c *Future[T]) Value() T {
+	return c.value
+}
+
+// Err returns the error of the file. If error is not happened, return nil.
+func (c *Future[T]) Err() error {
+	return c.err
+}
+
+// Done gets whether this is the result of the file, not progress.
+func (c *Future[T]) Done() bool {
+	return c.done
+}
+
+// Loaded gets loaded bytes of all files.
+func (c *Future[T]) Loaded() uint {
+	var n uint
+	for _, f := range c.files {
+		n += f.Loaded
+	}
+	return n
+}
+
+// Total gets estimated total bytes of all files. Loaded bytes are counted for files whose sizes are not known yet.
+func (c *Future[T]) Total() uint {
+	var n uint
+	for _, f := range c.files {
+		if f.Total > f.Loaded {
+			n += f.Total
+		} else {
+			n += f.Loaded
+		}
+	}
+	return n
+}
+
+// Files gets the status of each file, in the order of the URLs given to the loader.
+func (c *Future[T]) Files() []FileStatus {
+	return c.files
+}
+
+// Stream sends Futures of loading files to a channel. Loaders report progress and results of each file to it.
+type Stream[T any] struct {
+	ctx    context.Context
+	result chan *Future[T]
+
+	mu     sync.Mutex
+	files  []FileStatus
+	closed bool
+}
+
+// NewStream creates Stream of the files. The channel is closed by Close.
+func NewStream[T any](ctx context.Context, urls []string) *Stream[T] {
+	s := &Stream[T]{
+		ctx:    ctx,
+		result: make(chan *Future[T]),
+		files:  make([]FileStatus, len(urls)),
+	}
+	for i, url := range urls {
+		s.files[i].URL = url
+	}
+	return s
+}
+
+// Chan gets the channel which Futures are sent to.
+func (s *Stream[T]) Chan() <-chan *Future[T] {
+	return s.result
+}
+
+// Progress reports loaded and total bytes of the file. It returns false if the context is done.
+func (s *Stream[T]) Progress(url string, loaded uint, total uint) bool {
+	var zero T
+	return s.send(url, func(f *FileStatus) {
+		f.Loaded = loaded
+		f.Total = total
+	}, zero, nil, false)
+}
+
+// Done reports the result of the file. value is ignored if err is not nil. It returns false if the context is done.
+func (s *Stream[T]) Done(url string, value T, err error) bool {
+	if err != nil {
+		var zero T
+		value = zero
+	}
+	return s.send(url, func(f *FileStatus) {
+		if f.Total > f.Loaded {
+			f.Loaded = f.Total
+		}
+		f.Done = true
+		f.Err = err
+	}, value, err, true)
+}
+
+// Close closes the channel. Call it after all files are reported.
+// Progress reported after Close, by callbacks of loads aborted with the context, is dropped.
+func (s *Stream[T]) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	close(s.result)
+}
+
+func (s *Stream[T]) send(url string, update func(f *FileStatus), value T, err error, done bool) bool {
+
+	// 送信中に閉じられないよう、送信が終わるまでロックする
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+
+	// 同じURLが複数ある場合は、まだ終わっていない最初のものを更新する
+	for i := range s.files {
+		if f := &s.files[i]; f.URL == url && !f.Done {
+			update(f)
+			break
+		}
+	}
+	files := append([]FileStatus(nil), s.files...)
+
+	select {
+	case <-s.ctx.Done():
+		return false
+	case s.result <- &Future[T]{url: url, value: value, err: err, done: done, files: files}:
+		return true
+	}
+}
+
+// LoadFunc starts loading the file with the callbacks of a three.js loader.
+type LoadFunc func(url string, onLoad js.Func, onProgress js.Func, onError js.Func)
+
+// Load loads the files in parallel with a three.js loader, and sends progress and results to the channel.
+// convert gets the loaded object from the argument of onLoad.
+func Load[T any](ctx context.Context, urls []string, load LoadFunc, convert func(v js.Value) (T, error)) <-chan *Future[T] {
+
+	s := NewStream[T](ctx, urls)
+
+	go func() {
+		LoadEach(ctx, urls, load, func(url string, loaded uint, total uint) {
+			s.Progress(url, loaded, total)
+		}, func(url string, v js.Value, err error) {
+			var value T
+			if err == nil {
+				if value, err = convert(v); err != nil {
+					err = fmt.Errorf("%v: %w", url, err)
+				}
+			}
+			s.Done(url, value, err)
+		})
+		s.Close()
+	}()
+
+	return s.Chan()
+}
+
+// LoadEach loads the files in parallel with a three.js loader, and returns after all of them are loaded or failed.
+// It is for loaders which make one object of several files, such as CubeTextureLoader. Use Load for the others.
+// onLoad gets the argument of onLoad of the loader, or the error of the file.
+func LoadEach(ctx context.Context, urls []string, load LoadFunc, onProgress func(url string, loaded uint, total uint), onLoad func(url string, v js.Value, err error)) {
+
+	var wg sync.WaitGroup
+
+	for _, url := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		url := url
+
+		var jsfnOnLoad, jsfnOnProgress, jsfnOnError js.Func
+		release := func() {
+			jsfnOnLoad.Release()
+			jsfnOnProgress.Release()
+			jsfnOnError.Release()
+		}
+
+		jsfnOnLoad = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			defer wg.Done()
+			defer release()
+
+			onLoad(url, args[0], nil)
+			return nil
+		})
+
+		jsfnOnProgress = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			xhr := args[0]
+			onProgress(url, uint(xhr.Get("loaded").Int()), uint(xhr.Get("total").Int()))
+			return nil
+		})
+
+		jsfnOnError = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			defer wg.Done()
+			defer release()
+
+			onLoad(url, js.Undefined(), fmt.Errorf("%v: %w", url, NewErrorFromJSValue(args[0])))
+			return nil
+		})
+
+		wg.Add(1)
+		load(url, jsfnOnLoad, jsfnOnProgress, jsfnOnError)
+	}
+
+	// 中断されても、呼び出し済みのコールバックが終わるまで待つ
+	wg.Wait()
+}
+
+// NewErrorFromJSValue creates error from an Error of JS. Some loaders pass strings or events instead.
+func NewErrorFromJSValue(v js.Value) error {
+	if v.Type() == js.TypeObject {
+		if message := v.Get("message"); message.Type() == js.TypeString {
+			return errors.New(message.String())
+		}
+		if target := v.Get("target"); target.Type() == js.TypeObject && !target.Get("status").IsUndefined() {
+			return fmt.Errorf("request failed with status %v", target.Get("status").Int())
+		}
+	}
+	return errors.New(v.String())
+}
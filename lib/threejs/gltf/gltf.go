@@ -0,0 +1,97 @@
+package gltf
+
+import (
+	"app/lib/threejs"
+	"app/lib/threejs/animation"
+	"syscall/js"
+)
+
+// GLTF is the result of GLTFLoader: the scenes, animations and cameras of a glTF file.
+type GLTF interface {
+	JSValue() js.Value
+
+	// Scene gets the default scene. Add it to the stage to show the model.
+	Scene() threejs.Object3D
+
+	// Scenes gets all scenes in the file.
+	Scenes() []threejs.Object3D
+
+	// Animations gets the animation clips. Play them with a mixer created by animation.NewMixer with Scene.
+	Animations() []animation.Clip
+
+	// SkinnedMeshes gets the skinned meshes in the default scene, such as characters.
+	SkinnedMeshes() []threejs.SkinnedMesh
+
+	// Cameras gets the cameras in the file.
+	Cameras() []threejs.Object3D
+}
+
+type gltfImp struct {
+	js.Value
+}
+
+// NewGLTFFromJSValue creates GLTF from js.Value.
+func NewGLTFFromJSValue(v js.Value) GLTF {
+	return &gltfImp{
+		Value: v,
+	}
+}
+
+// JSValue is ...
+func (c *gltfImp) JSValue() js.Value {
+	return c.Value
+}
+
+// Scene gets the default scene. Add it to the stage to show the model.
+func (c *gltfImp) Scene() threejs.Object3D {
+	return threejs.NewObject3DFromJSValue(c.Get("scene"))
+}
+
+// Scenes gets all scenes in the file.
+func (c *gltfImp) Scenes() []threejs.Object3D {
+	scenes := c.Get("scenes")
+	res := make([]threejs.Object3D, scenes.Length())
+	for i := range res {
+		res[i] = threejs.NewObject3DFromJSValue(scenes.Index(i))
+	}
+	return res
+}
+
+// Animations gets the animation clips. Play them with a mixer created by animation.NewMixer with Scene.
+func (c *gltfImp) Animations() []animation.Clip {
+	animations := c.Get("animations")
+	res := make([]animation.Clip, 0, animations.Length())
+	for i := 0; i < animations.Length(); i++ {
+		if clip, err := animation.NewClipFromJSValue(animations.Index(i)); err == nil {
+			res = append(res, clip)
+		}
+	}
+	return res
+}
+
+// SkinnedMeshes gets the skinned meshes in the default scene, such as characters.
+func (c *gltfImp) SkinnedMeshes() []threejs.SkinnedMesh {
+
+	var res []threejs.SkinnedMesh
+	fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if o := args[0]; o.Get("isSkinnedMesh").Truthy() {
+			res = append(res, threejs.NewSkinnedMeshFromJSValue(o))
+		}
+		return nil
+	})
+	defer fn.Release()
+
+	// traverseは同期的に呼び出されるため、戻った時点で全て集まっている
+	c.Get("scene").Call("traverse", fn)
+	return res
+}
+
+// Cameras gets the cameras in the file.
+func (c *gltfImp) Cameras() []threejs.Object3D {
+	cameras := c.Get("cameras")
+	res := make([]threejs.Object3D, cameras.Length())
+	for i := range res {
+		res[i] = threejs.NewObject3DFromJSValue(cameras.Index(i))
+	}
+	return res
+}
@@ -0,0 +1,82 @@
+// Package gltf loads glTF 2.0 files (.gltf and .glb) with GLTFLoader of three.js,
+// so that props and characters which are not MMD models share the stage with them.
+package gltf
+
+import (
+	"app/lib/threejs"
+	"context"
+	"log"
+	"syscall/js"
+)
+
+const (
+	modulePath = "./assets/threejs/ex/jsm/loaders/GLTFLoader.js"
+)
+
+var (
+	module js.Value
+)
+
+func init() {
+
+	m := threejs.LoadModule([]string{"GLTFLoader"}, modulePath)
+	if len(m) == 0 {
+		log.Fatal("GLTFLoader module could not be loaded.")
+	}
+	module = m[0]
+
+}
+
+// Loader creates Three.js objects from glTF files. Animations are AnimationClip, which Mixer plays as MMD motions.
+type Loader interface {
+	threejs.Loader
+
+	// LoadModel begin loading a .gltf or .glb file from url and send the parsed glTF to the channel.
+	// Progress of loading is sent before it.
+	//
+	// ctx - Context
+	// url — A string containing the path/URL of the .gltf or .glb file.
+	LoadModel(ctx context.Context, url string) <-chan *threejs.Future[GLTF]
+
+	// LoadModels begin loading .gltf or .glb files from urls. The files are sent in the order loading completes.
+	LoadModels(ctx context.Context, urls []string) <-chan *threejs.Future[GLTF]
+}
+
+type gltfLoaderImp struct {
+	threejs.Loader
+}
+
+// NewLoader creates a new GLTFLoader.
+func NewLoader() Loader {
+	return &gltfLoaderImp{
+		Loader: threejs.NewDefaultLoaderFromJSValue(module.New()),
+	}
+}
+
+// NewLoaderWithManager creates a new GLTFLoader with LoadingManager.
+func NewLoaderWithManager(manager threejs.LoadingManager) Loader {
+	return &gltfLoaderImp{
+		Loader: threejs.NewDefaultLoaderFromJSValue(module.New(manager.JSValue())),
+	}
+}
+
+/*
+
+	Methods
+
+*/
+
+func (c *gltfLoaderImp) LoadModel(ctx context.Context, url string) <-chan *threejs.Future[GLTF] {
+	return c.LoadModels(ctx, []string{url})
+}
+
+func (c *gltfLoaderImp) LoadModels(ctx context.Context, urls []string) <-chan *threejs.Future[GLTF] {
+
+	load := func(url string, onLoad js.Func, onProgress js.Func, onError js.Func) {
+		c.JSValue().Call("load", url, onLoad, onProgress, onError)
+	}
+	return threejs.Load(ctx, urls, load, func(v js.Value) (GLTF, error) {
+		return NewGLTFFromJSValue(v), nil
+	})
+
+}
@@ -0,0 +1,259 @@
+package gltf
+
+import (
+	"app/lib/mmd/vmd"
+	"app/lib/mmd/vrm"
+	"app/lib/threejs"
+	"app/lib/threejs/animation"
+	"app/lib/threejs/mmd"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"syscall/js"
+)
+
+// VRM is a humanoid avatar of a VRM file, a glTF file with the VRM extensions loaded by Loader.
+type VRM interface {
+	GLTF
+
+	// Model gets the humanoid bones, the expressions and the license of the avatar.
+	Model() *vrm.Model
+
+	// Face gets the expressions as facial morphs. They are set by the names of MMD morphs,
+	// which drive the presets mapped by vrm.MMDMorphs, or by the names of the expressions.
+	Face() mmd.Face
+
+	// HumanBone gets the object of the humanoid bone, such as "hips". It returns false if the avatar does not have it.
+	HumanBone(name string) (threejs.Object3D, bool)
+
+	// NewClip retargets the VMD motion to a clip of the avatar, with bones and expressions.
+	// Play it with a mixer created by animation.NewMixer with Scene.
+	NewClip(name string, motion *vmd.Motion, o vrm.Options) (animation.Clip, error)
+}
+
+type vrmImp struct {
+	GLTF
+
+	model *vrm.Model
+	nodes []js.Value
+	// meshes are the objects with morph targets by glTF mesh, since three.js splits primitives into meshes.
+	meshes map[int][]js.Value
+	face   *vrmFaceImp
+}
+
+// NewVRM reads the VRM extensions of the loaded glTF file.
+// It waits for the objects of the nodes from GLTFLoader, so call it in a goroutine, not in callbacks of JS.
+func NewVRM(g GLTF) (VRM, error) {
+
+	parser := g.JSValue().Get("parser")
+	if parser.IsUndefined() {
+		return nil, errors.New("glTF file has no parser")
+	}
+	data := js.Global().Get("JSON").Call("stringify", parser.Get("json")).String()
+	model, err := vrm.Parse([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// シーンに追加されたノードと同じオブジェクトがキャッシュから返る
+	nodes, err := await(parser.Call("getDependencies", "node"))
+	if err != nil {
+		return nil, err
+	}
+
+	v := &vrmImp{
+		GLTF:   g,
+		model:  model,
+		nodes:  make([]js.Value, nodes.Length()),
+		meshes: make(map[int][]js.Value),
+	}
+	for i := range v.nodes {
+		v.nodes[i] = nodes.Index(i)
+	}
+	for i, n := range model.Nodes {
+		if n.Mesh < 0 || i >= len(v.nodes) {
+			continue
+		}
+		o := v.nodes[i]
+		if o.Get("isMesh").Truthy() {
+			v.meshes[n.Mesh] = append(v.meshes[n.Mesh], o)
+			continue
+		}
+		// 複数のプリミティブを持つメッシュはGroupの子になる
+		children := o.Get("children")
+		for j := 0; j < children.Length(); j++ {
+			if c := children.Index(j); c.Get("isMesh").Truthy() && !c.Get("morphTargetInfluences").IsUndefined() {
+				v.meshes[n.Mesh] = append(v.meshes[n.Mesh], c)
+			}
+		}
+	}
+
+	v.face = &vrmFaceImp{vrm: v, weights: make(map[*vrm.Expression]float64)}
+	return v, nil
+}
+
+// Model gets the humanoid bones, the expressions and the license of the avatar.
+func (c *vrmImp) Model() *vrm.Model {
+	return c.model
+}
+
+// Face gets the expressions as facial morphs.
+func (c *vrmImp) Face() mmd.Face {
+	return c.face
+}
+
+// HumanBone gets the object of the humanoid bone, such as "hips".
+func (c *vrmImp) HumanBone(name string) (threejs.Object3D, bool) {
+	i, ok := c.model.HumanBones[name]
+	if !ok || i >= len(c.nodes) {
+		return nil, false
+	}
+	return threejs.NewObject3DFromJSValue(c.nodes[i]), true
+}
+
+// NewClip retargets the VMD motion to a clip of the avatar, with bones and expressions.
+func (c *vrmImp) NewClip(name string, motion *vmd.Motion, o vrm.Options) (animation.Clip, error) {
+
+	a, err := vrm.Retarget(c.model, motion, o)
+	if err != nil {
+		return nil, err
+	}
+
+	// 名前が重複することがあるため、トラックはUUIDでオブジェクトを指す
+	var tracks []animation.Track
+	for _, t := range a.Nodes {
+		if t.Node >= len(c.nodes) {
+			continue
+		}
+		uuid := c.nodes[t.Node].Get("uuid").String()
+
+		values := make([]float64, 0, len(t.Rotations)*4)
+		for _, q := range t.Rotations {
+			values = append(values, q.X, q.Y, q.Z, q.W)
+		}
+		tracks = append(tracks, animation.NewQuaternionTrack(uuid+".quaternion", t.Times, values))
+
+		if t.Translations != nil {
+			values := make([]float64, 0, len(t.Translations)*3)
+			for _, p := range t.Translations {
+				values = append(values, p.X, p.Y, p.Z)
+			}
+			tracks = append(tracks, animation.NewVectorTrack(uuid+".position", t.Times, values))
+		}
+	}
+	for _, t := range a.Morphs {
+		for _, o := range c.meshes[t.Mesh] {
+			property := fmt.Sprintf("%s.morphTargetInfluences[%d]", o.Get("uuid").String(), t.Index)
+			tracks = append(tracks, animation.NewNumberTrack(property, t.Times, t.Weights))
+		}
+	}
+
+	return animation.NewClip(name, a.Duration, tracks), nil
+}
+
+// vrmFaceImp is Face of the expressions of a VRM avatar.
+type vrmFaceImp struct {
+	vrm     *vrmImp
+	weights map[*vrm.Expression]float64
+}
+
+// Names gets the names of the expressions, and the names of MMD morphs mapped to the presets.
+func (f *vrmFaceImp) Names() []string {
+	var res []string
+	for _, e := range f.vrm.model.Expressions {
+		res = append(res, e.Name)
+		if name, ok := vrm.MMDMorphs[e.Name]; ok && e.Preset {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// Weight gets the weight of the expressions the morph drives, or 0 if the avatar does not have them.
+func (f *vrmFaceImp) Weight(name string) float64 {
+	var res float64
+	for _, e := range f.vrm.model.ExpressionsFor(name) {
+		res = math.Max(res, f.weights[e])
+	}
+	return res
+}
+
+// SetWeight sets the weight of the expressions the morph drives. It returns false if the avatar does not have them.
+func (f *vrmFaceImp) SetWeight(name string, weight float64) bool {
+
+	expressions := f.vrm.model.ExpressionsFor(name)
+	if len(expressions) == 0 {
+		return false
+	}
+	for _, e := range expressions {
+		f.weights[e] = e.Weight(weight)
+	}
+	f.update()
+	return true
+}
+
+// update sets the morph targets bound by the expressions to the sums of the weights.
+func (f *vrmFaceImp) update() {
+
+	type target struct {
+		mesh  int
+		index int
+	}
+	sums := make(map[target]float64)
+	for _, e := range f.vrm.model.Expressions {
+		for _, b := range e.Binds {
+			k := target{mesh: b.Mesh, index: b.Index}
+			sums[k] += f.weights[e] * b.Weight
+		}
+	}
+
+	// 同じ順で書き込むよう並べる
+	keys := make([]target, 0, len(sums))
+	for k := range sums {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].mesh != keys[j].mesh {
+			return keys[i].mesh < keys[j].mesh
+		}
+		return keys[i].index < keys[j].index
+	})
+	for _, k := range keys {
+		for _, o := range f.vrm.meshes[k.mesh] {
+			if influences := o.Get("morphTargetInfluences"); k.index < influences.Length() {
+				influences.SetIndex(k.index, math.Min(sums[k], 1))
+			}
+		}
+	}
+}
+
+// await waits for the promise and gets the result.
+func await(promise js.Value) (js.Value, error) {
+
+	result := make(chan js.Value, 1)
+	failure := make(chan error, 1)
+
+	onResolve := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		result <- args[0]
+		return nil
+	})
+	defer onResolve.Release()
+	onReject := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		message := args[0].String()
+		if args[0].Type() == js.TypeObject {
+			message = args[0].Get("message").String()
+		}
+		failure <- errors.New(message)
+		return nil
+	})
+	defer onReject.Release()
+
+	promise.Call("then", onResolve, onReject)
+	select {
+	case v := <-result:
+		return v, nil
+	case err := <-failure:
+		return js.Undefined(), err
+	}
+}
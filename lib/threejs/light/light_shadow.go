@@ -17,11 +17,18 @@ type LightShadow interface {
 	// The default is 0. Very tiny adjustments here (in the order of 0.0001) may help reduce artefacts in shadows
 	SetBias(bias float64)
 
-	// NormalBias() float64
-	// SetNormalBias(bias float64)
+	// NormalBias gets how much the position used to query the shadow map is offset along the object normal.
+	// The default is 0. Increasing this value can be used to reduce shadow acne especially in large scenes.
+	NormalBias() float64
 
-	// Radius() float64
-	// SetRadius(radius float64)
+	// SetNormalBias sets how much the position used to query the shadow map is offset along the object normal.
+	SetNormalBias(bias float64)
+
+	// Radius gets blur radius of the shadow. Default is 1. It has no effect with PCFSoftShadowMap.
+	Radius() float64
+
+	// SetRadius sets blur radius of the shadow. Default is 1. It has no effect with PCFSoftShadowMap.
+	SetRadius(radius float64)
 
 	// MapSize is a Vector2 defining the width and height of the shadow map.
 	//
@@ -31,7 +38,9 @@ type LightShadow interface {
 	// The default is ( 512, 512 ).
 	MapSize() *threejs.Vector2
 
-	// SetMapSize(v *threejs.Vector2)
+	// SetMapSize sets the width and height of the shadow map.
+	// The shadow map is recreated on the next render.
+	SetMapSize(width int, height int)
 
 	// AutoUpdate() bool
 	// needsUpdate() bool
@@ -60,3 +69,34 @@ func (d *defaultLightShadowImp) SetBias(bias float64) {
 func (d *defaultLightShadowImp) MapSize() *threejs.Vector2 {
 	return &threejs.Vector2{Value: d.Get("mapSize")}
 }
+
+// NormalBias is ...
+func (d *defaultLightShadowImp) NormalBias() float64 {
+	return d.Get("normalBias").Float()
+}
+
+// SetNormalBias is ...
+func (d *defaultLightShadowImp) SetNormalBias(bias float64) {
+	d.Set("normalBias", bias)
+}
+
+// Radius is ...
+func (d *defaultLightShadowImp) Radius() float64 {
+	return d.Get("radius").Float()
+}
+
+// SetRadius is ...
+func (d *defaultLightShadowImp) SetRadius(radius float64) {
+	d.Set("radius", radius)
+}
+
+// SetMapSize is ...
+func (d *defaultLightShadowImp) SetMapSize(width int, height int) {
+	d.Get("mapSize").Call("set", width, height)
+
+	// サイズ変更を反映するため、既存のシャドウマップを破棄する
+	if m := d.Get("map"); !m.IsUndefined() && !m.IsNull() {
+		m.Call("dispose")
+		d.Set("map", js.Null())
+	}
+}
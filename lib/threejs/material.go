@@ -15,6 +15,16 @@ type MaterialParameters interface {
 type Material interface {
 	JSValue() js.Value
 
+	// Name gets optional name of the material. Default is an empty string.
+	Name() string
+
+	// SetName sets optional name of the material. Default is an empty string.
+	SetName(v string)
+
+	// UserData gets an object that can be used to store custom data about the Material.
+	// It should not hold references to functions as these will not be cloned.
+	UserData() js.Value
+
 	DepthTest() bool
 	SetDepthTest(b bool)
 
@@ -97,6 +107,21 @@ func (m *defaultMaterialImpl) JSValue() js.Value {
 	return m.Value
 }
 
+// Name is ...
+func (m *defaultMaterialImpl) Name() string {
+	return m.Get("name").String()
+}
+
+// SetName is ...
+func (m *defaultMaterialImpl) SetName(v string) {
+	m.Set("name", v)
+}
+
+// UserData is ...
+func (m *defaultMaterialImpl) UserData() js.Value {
+	return m.Get("userData")
+}
+
 // DepthTest is ...
 func (m *defaultMaterialImpl) DepthTest() bool {
 	return m.Get("depthTest").Bool()
@@ -2,6 +2,7 @@ package material
 
 import (
 	"app/lib/threejs"
+	"syscall/js"
 )
 
 // MeshPhongMaterialParameters is ...
@@ -19,6 +20,15 @@ type MeshPhongMaterial interface {
 	threejs.Material
 
 	Color() threejs.Color
+
+	// Specular gets specular color of the material. Default is a Color set to 0x111111.
+	Specular() threejs.Color
+
+	// Emissive gets emissive (light) color of the material, essentially a solid color unaffected by other lighting. Default is black.
+	Emissive() threejs.Color
+
+	// SetMap sets the color map. If nil, the map is removed.
+	SetMap(tx threejs.Texture)
 }
 
 // meshPhongMaterialImp is a implementation of MeshPhongMaterial.
@@ -43,6 +53,31 @@ func (c *meshPhongMaterialImp) Color() threejs.Color {
 	)
 }
 
+// Specular is ...
+func (c *meshPhongMaterialImp) Specular() threejs.Color {
+	return threejs.NewColorFromJSValue(
+		c.JSValue().Get("specular"),
+	)
+}
+
+// Emissive is ...
+func (c *meshPhongMaterialImp) Emissive() threejs.Color {
+	return threejs.NewColorFromJSValue(
+		c.JSValue().Get("emissive"),
+	)
+}
+
+// SetMap is ...
+func (c *meshPhongMaterialImp) SetMap(tx threejs.Texture) {
+	if tx == nil {
+		c.JSValue().Set("map", js.Null())
+	} else {
+		c.JSValue().Set("map", tx.JSValue())
+	}
+	// テクスチャの有無でシェーダーが変わるため再コンパイルする
+	c.SetNeedsUpdate(true)
+}
+
 // func (mpm *MeshPhongMaterial) AlphaMap() *Texture {
 // 	return &Texture{Value: mpm.Get("alphaMap")}
 // }
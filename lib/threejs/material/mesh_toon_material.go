@@ -0,0 +1,87 @@
+package material
+
+import (
+	"app/lib/threejs"
+	"syscall/js"
+)
+
+// MeshToonMaterialParameters is ...
+type MeshToonMaterialParameters interface {
+}
+
+// MeshToonMaterial is a material implementing toon shading.
+// The shading is quantized by the gradient map instead of being smooth.
+// MMDLoader creates this material for each PMX/PMD material, with the toon texture as the gradient map.
+type MeshToonMaterial interface {
+	threejs.Material
+
+	// Color gets color of the material, by default set to white (0xffffff).
+	// MMDLoader sets the diffuse color of the MMD material.
+	Color() threejs.Color
+
+	// Emissive gets emissive (light) color of the material, essentially a solid color unaffected by other lighting. Default is black.
+	// MMDLoader sets the ambient color of the MMD material.
+	Emissive() threejs.Color
+
+	// SetMap sets the color map. If nil, the map is removed.
+	SetMap(tx threejs.Texture)
+
+	// SetGradientMap sets gradient map for toon shading. If nil, default gradient is used.
+	// It's required to set Texture.minFilter and Texture.magFilter to THREE.NearestFilter when using this type of texture.
+	SetGradientMap(tx threejs.Texture)
+}
+
+// meshToonMaterialImp is a implementation of MeshToonMaterial.
+type meshToonMaterialImp struct {
+	threejs.Material
+}
+
+// NewMeshToonMaterial is constructor.
+// parameters - (optional) an object with one or more properties defining the material's appearance. Any property of the material (including any property inherited from Material) can be passed in here.
+// The exception is the property color, which can be passed in as a hexadecimal string and is 0xffffff (white) by default. Color.set( color ) is called internally.
+func NewMeshToonMaterial(parameters MeshToonMaterialParameters) MeshToonMaterial {
+	return &meshToonMaterialImp{
+		threejs.NewDefaultMaterialFromJSValue(threejs.Threejs("MeshToonMaterial").New(parameters)),
+	}
+}
+
+// NewMeshToonMaterialFromJSValue creates MeshToonMaterial from js.Value.
+func NewMeshToonMaterialFromJSValue(value js.Value) MeshToonMaterial {
+	return &meshToonMaterialImp{
+		threejs.NewDefaultMaterialFromJSValue(value),
+	}
+}
+
+// Color of the material, by default set to white (0xffffff).
+func (c *meshToonMaterialImp) Color() threejs.Color {
+	return threejs.NewColorFromJSValue(
+		c.JSValue().Get("color"),
+	)
+}
+
+// Emissive is ...
+func (c *meshToonMaterialImp) Emissive() threejs.Color {
+	return threejs.NewColorFromJSValue(
+		c.JSValue().Get("emissive"),
+	)
+}
+
+// SetMap is ...
+func (c *meshToonMaterialImp) SetMap(tx threejs.Texture) {
+	c.setTexture("map", tx)
+}
+
+// SetGradientMap is ...
+func (c *meshToonMaterialImp) SetGradientMap(tx threejs.Texture) {
+	c.setTexture("gradientMap", tx)
+}
+
+func (c *meshToonMaterialImp) setTexture(name string, tx threejs.Texture) {
+	if tx == nil {
+		c.JSValue().Set(name, js.Null())
+	} else {
+		c.JSValue().Set(name, tx.JSValue())
+	}
+	// テクスチャの有無でシェーダーが変わるため再コンパイルする
+	c.SetNeedsUpdate(true)
+}
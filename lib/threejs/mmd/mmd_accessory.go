@@ -0,0 +1,217 @@
+package mmd
+
+import (
+	"app/lib/mmd/xfile"
+	"app/lib/threejs"
+	"app/lib/threejs/material"
+	"app/lib/threejs/texture"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"syscall/js"
+)
+
+// NewAccessoryMesh builds a mesh of the .x model with a MeshPhongMaterial for each material.
+// Textures are loaded from resourcePath, the directory of the .x file.
+// Sphere maps are not drawn.
+func NewAccessoryMesh(model *xfile.Model, resourcePath string) threejs.Mesh {
+
+	t := model.Triangles()
+
+	geometry := threejs.NewBufferGeometry()
+	geometry.SetAttribute("position", threejs.NewFloat32BufferAttributeFromSlice(t.Positions, 3))
+	if len(t.UVs) > 0 {
+		geometry.SetAttribute("uv", threejs.NewFloat32BufferAttributeFromSlice(t.UVs, 2))
+	}
+	if len(t.Normals) > 0 {
+		geometry.SetAttribute("normal", threejs.NewFloat32BufferAttributeFromSlice(t.Normals, 3))
+	} else {
+		geometry.ComputeVertexNormals()
+	}
+	for _, g := range t.Groups {
+		geometry.AddGroup(g.Start, g.Count, g.Material)
+	}
+
+	loader := texture.NewLoader()
+	materials := make([]threejs.Material, len(t.Materials))
+	for i, v := range t.Materials {
+		materials[i] = accessoryMaterial(v, loader, resourcePath)
+	}
+
+	mesh := threejs.NewMeshWithMultiMaterial(geometry, materials)
+	return mesh
+}
+
+func accessoryMaterial(v xfile.Material, loader texture.Loader, resourcePath string) threejs.Material {
+
+	m := material.NewMeshPhongMaterial(map[string]interface{}{
+		"name":        v.Name,
+		"opacity":     v.Diffuse[3],
+		"transparent": v.Diffuse[3] < 1,
+		"shininess":   v.Power,
+	})
+	m.Color().SetRGB(float64(v.Diffuse[0]), float64(v.Diffuse[1]), float64(v.Diffuse[2]))
+	m.Specular().SetRGB(float64(v.Specular[0]), float64(v.Specular[1]), float64(v.Specular[2]))
+	m.Emissive().SetRGB(float64(v.Emissive[0]), float64(v.Emissive[1]), float64(v.Emissive[2]))
+
+	if v.Texture != "" {
+		m.SetMap(loader.LoadSimply(resourcePath + v.Texture))
+	}
+
+	return m
+}
+
+// Accessory is a result of LoadAccessories.
+type Accessory struct {
+	// Mesh is the mesh built from the .x file.
+	Mesh threejs.Mesh
+	// Placement is the .vac file. It is nil if a .x file is loaded directly.
+	Placement *xfile.Placement
+}
+
+// LoadAccessories loads .x files, or .vac files and the .x files they place.
+// Only the text format of .x files is supported.
+func LoadAccessories(ctx context.Context, urls []string) <-chan *threejs.Future[*Accessory] {
+
+	s := threejs.NewStream[*Accessory](ctx, urls)
+
+	go func() {
+		defer s.Close()
+
+		loader := threejs.NewFileLoader()
+		loader.SetResponseType("arraybuffer")
+
+		for _, url := range urls {
+
+			v, err := loadAccessory(ctx, loader, s, url)
+			if err == context.Canceled || err == context.DeadlineExceeded {
+				return
+			}
+			if !s.Done(url, v, err) {
+				return
+			}
+		}
+	}()
+
+	return s.Chan()
+}
+
+func loadAccessory(ctx context.Context, loader threejs.FileLoader, s *threejs.Stream[*Accessory], url string) (*Accessory, error) {
+
+	var placement *xfile.Placement
+	var size uint
+
+	// .vacファイルの場合は配置を読み、同じディレクトリの.xファイルを読む
+	// 進捗は両方のファイルを合わせて指定されたURLに報告する
+	origin := url
+	progress := func(loaded uint, total uint) {
+		s.Progress(origin, size+loaded, size+total)
+	}
+
+	if strings.HasSuffix(strings.ToLower(url), ".vac") {
+		b, err := loadBytes(ctx, loader, url, progress)
+		if err != nil {
+			return nil, err
+		}
+		if placement, err = xfile.DecodePlacement(bytes.NewReader(b)); err != nil {
+			return nil, fmt.Errorf("%v: %w", url, err)
+		}
+		size += uint(len(b))
+		url = directory(url) + placement.File
+	}
+
+	b, err := loadBytes(ctx, loader, url, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := xfile.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", url, err)
+	}
+
+	return &Accessory{Mesh: NewAccessoryMesh(model, directory(url)), Placement: placement}, nil
+}
+
+// loadBytes loads the file as bytes. It returns the error of ctx if ctx is done.
+// onProgress is called with loaded and total bytes while loading, if it is not nil.
+func loadBytes(ctx context.Context, loader threejs.FileLoader, url string, onProgress func(loaded uint, total uint)) ([]byte, error) {
+
+	type response struct {
+		b   []byte
+		err error
+	}
+	done := make(chan response, 1)
+
+	jsfnOnLoad := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		// ArrayBufferをGoのバイト列にコピーする
+		data := js.Global().Get("Uint8Array").New(args[0])
+		b := make([]byte, data.Length())
+		js.CopyBytesToGo(b, data)
+
+		done <- response{b: b}
+		return nil
+	})
+	jsfnOnProgress := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if onProgress != nil {
+			xhr := args[0]
+			onProgress(uint(xhr.Get("loaded").Int()), uint(xhr.Get("total").Int()))
+		}
+		return nil
+	})
+	jsfnOnError := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- response{err: fmt.Errorf("file %v could not be loaded", url)}
+		return nil
+	})
+
+	loader.Load(url, jsfnOnLoad, jsfnOnProgress, jsfnOnError)
+
+	select {
+	case <-ctx.Done():
+		// コールバックが後から呼ばれる可能性があるため、関数は解放しない
+		return nil, ctx.Err()
+	case r := <-done:
+		jsfnOnLoad.Release()
+		jsfnOnProgress.Release()
+		jsfnOnError.Release()
+		return r.b, r.err
+	}
+}
+
+func directory(url string) string {
+	return url[:strings.LastIndex(url, "/")+1]
+}
+
+// PlaceAccessory places the mesh as the .vac file does.
+// If the placement has a bone, the mesh is attached to the bone with attachments.
+// Otherwise the transform is set to the mesh, which the caller adds to the scene.
+func PlaceAccessory(mesh threejs.Object3D, placement *xfile.Placement, attachments *BoneAttachments) error {
+
+	// MMDの左手系から右手系に変換する
+	const toRadians = math.Pi / 180
+	p := placement.Position
+	r := placement.Rotation
+	position := [3]float64{float64(p[0]), float64(p[1]), -float64(p[2])}
+	rotation := [3]float64{-float64(r[0]) * toRadians, -float64(r[1]) * toRadians, float64(r[2]) * toRadians}
+	scale := float64(placement.Scale)
+
+	if placement.Bone != "" {
+		if attachments == nil {
+			return errors.New("accessory is placed on a bone but no model is given")
+		}
+		_, err := attachments.Attach(placement.Bone, mesh,
+			AttachPosition(position[0], position[1], position[2]),
+			AttachRotation(rotation[0], rotation[1], rotation[2]),
+			AttachScale(scale),
+		)
+		return err
+	}
+
+	mesh.Position().Set2(position[0], position[1], position[2])
+	mesh.Rotation().Set2(rotation[0], rotation[1], rotation[2], "XYZ")
+	mesh.Scale().Set2(scale, scale, scale)
+	return nil
+}
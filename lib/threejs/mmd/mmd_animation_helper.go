@@ -49,6 +49,16 @@ func (c *AnimationHelper) Update(delta float64) {
 	c.Call("update", delta)
 }
 
+// UpdatePose applies IK, Grant, and physics to objects added to helper without advancing time.
+// Unlike Update(0), it doesn't let the audio manager start the paused audio.
+func (c *AnimationHelper) UpdatePose() {
+	// AudioManagerは止まっている音声を再生するため、更新の間だけ外す
+	manager := c.Get("audioManager")
+	c.Set("audioManager", js.Null())
+	c.Call("update", 0)
+	c.Set("audioManager", manager)
+}
+
 // Meshes gets meshes in AnimationHelper.
 func (c *AnimationHelper) Meshes() []threejs.SkinnedMesh {
 
@@ -79,6 +89,63 @@ func (c *AnimationHelper) RemoveMesh(mesh threejs.Mesh) {
 	c.Call("remove", mesh.JSValue())
 }
 
+// AddCamera add a camera to helper and setup camera animation. Only one camera can be added.
+func (c *AnimationHelper) AddCamera(camera threejs.Camera, options ...AnimationHelperAddOption) {
+
+	var param map[string]interface{} = make(map[string]interface{})
+	for _, opt := range options {
+		opt(param)
+	}
+
+	c.Call("add", camera.JSValue(), param)
+}
+
+// RemoveCamera remove camera.
+func (c *AnimationHelper) RemoveCamera(camera threejs.Camera) {
+	c.Call("remove", camera.JSValue())
+}
+
+// AddAudio add an audio to helper, which is played with the motions after the delay set by DelayTime.
+// Only one audio can be added.
+func (c *AnimationHelper) AddAudio(audio threejs.Audio, options ...AnimationHelperAddOption) {
+
+	var param map[string]interface{} = make(map[string]interface{})
+	for _, opt := range options {
+		opt(param)
+	}
+
+	c.Call("add", audio.JSValue(), param)
+}
+
+// RemoveAudio stops and removes audio.
+func (c *AnimationHelper) RemoveAudio(audio threejs.Audio) {
+	audio.Stop()
+	c.Call("remove", audio.JSValue())
+}
+
+// HasAudio gets whether an audio is added to helper.
+func (c *AnimationHelper) HasAudio() bool {
+	audio := c.Get("audio")
+	return !audio.IsNull() && !audio.IsUndefined()
+}
+
+// HasCamera gets whether a camera is added to helper.
+func (c *AnimationHelper) HasCamera() bool {
+	camera := c.Get("camera")
+	return !camera.IsNull() && !camera.IsUndefined()
+}
+
+// CameraTarget gets the object which the animated camera looks at.
+// Its position is the MMD camera center in world coordinates.
+func (c *AnimationHelper) CameraTarget() (threejs.Object3D, error) {
+	target := c.Get("cameraTarget")
+	if target.IsNull() || target.IsUndefined() {
+		return nil, errors.New("camera target is not defined")
+	}
+
+	return threejs.NewObject3DFromJSValue(target), nil
+}
+
 // Mixer gets mixer object in animation.
 func (c *AnimationHelper) Mixer(mesh threejs.Mesh) (animation.Mixer, error) {
 	m := c.Get("objects").Call("get", mesh.JSValue())
@@ -105,3 +172,119 @@ func (c *AnimationHelper) Pose(mesh threejs.Mesh, vpd Vpd, options ...AnimationH
 	c.Call("pose", mesh.JSValue(), vpd.JSValue(), param)
 
 }
+
+// AnimationHelperFeature is a key of features which can be turned on and off in AnimationHelper.
+type AnimationHelperFeature string
+
+const (
+	// FeatureAnimation is animation of meshes by mixers.
+	FeatureAnimation AnimationHelperFeature = "animation"
+	// FeatureIK is inverse kinematics.
+	FeatureIK AnimationHelperFeature = "ik"
+	// FeatureGrant is grant (付与) of bones.
+	FeatureGrant AnimationHelperFeature = "grant"
+	// FeaturePhysics is physics by Ammo.js.
+	FeaturePhysics AnimationHelperFeature = "physics"
+	// FeatureCameraAnimation is camera animation.
+	FeatureCameraAnimation AnimationHelperFeature = "cameraAnimation"
+)
+
+// Enable enables/disables a feature.
+func (c *AnimationHelper) Enable(key AnimationHelperFeature, enabled bool) {
+	c.Call("enable", string(key), enabled)
+}
+
+// Enabled gets whether a feature is enabled.
+func (c *AnimationHelper) Enabled(key AnimationHelperFeature) bool {
+	return c.Get("enabled").Get(string(key)).Bool()
+}
+
+// ResetPhysics resets rigid bodies of mesh to current bone transforms and steps physics warmup times.
+// Use it after the pose is changed discontinuously, for example by seeking.
+func (c *AnimationHelper) ResetPhysics(mesh threejs.Mesh, warmup int) error {
+	m := c.Get("objects").Call("get", mesh.JSValue())
+	if m.IsNull() || m.IsUndefined() {
+		return errors.New("mesh is not registered or nil")
+	}
+
+	physics := m.Get("physics")
+	if physics.IsNull() || physics.IsUndefined() {
+		return errors.New("physics is not defined or nil")
+	}
+
+	physics.Call("reset")
+	if warmup > 0 {
+		physics.Call("warmup", warmup)
+	}
+
+	return nil
+}
+
+// CameraMixer gets mixer object of the camera added to helper.
+func (c *AnimationHelper) CameraMixer() (animation.Mixer, error) {
+	camera := c.Get("camera")
+	if camera.IsNull() || camera.IsUndefined() {
+		return nil, errors.New("camera is not registered")
+	}
+
+	m := c.Get("objects").Call("get", camera)
+	if m.IsNull() || m.IsUndefined() {
+		return nil, errors.New("camera is not registered")
+	}
+
+	return animation.NewMixerFromJSValue(m.Get("mixer"))
+}
+
+// SeekAudio moves playback position of the audio added to helper.
+// t is time in seconds on the motion timeline. The delay time of the audio is considered.
+func (c *AnimationHelper) SeekAudio(t float64) error {
+	manager := c.Get("audioManager")
+	if manager.IsNull() || manager.IsUndefined() {
+		return errors.New("audio is not registered")
+	}
+
+	audio := manager.Get("audio")
+	offset := t - manager.Get("delayTime").Float()
+	manager.Set("currentTime", t)
+
+	if audio.Get("isPlaying").Bool() {
+		audio.Call("stop")
+	}
+	if offset < 0 {
+		// 遅延時間内の場合、AudioManagerの制御で再生が開始される
+		audio.Set("offset", 0)
+		return nil
+	}
+
+	audio.Set("offset", offset)
+	audio.Call("play")
+
+	return nil
+}
+
+// PauseAudio pauses the audio added to helper.
+func (c *AnimationHelper) PauseAudio() error {
+	manager := c.Get("audioManager")
+	if manager.IsNull() || manager.IsUndefined() {
+		return errors.New("audio is not registered")
+	}
+
+	audio := manager.Get("audio")
+	if audio.Get("isPlaying").Bool() {
+		audio.Call("pause")
+	}
+
+	return nil
+}
+
+// SetAudioPlaybackRate changes speed of the audio added to helper.
+func (c *AnimationHelper) SetAudioPlaybackRate(rate float64) error {
+	manager := c.Get("audioManager")
+	if manager.IsNull() || manager.IsUndefined() {
+		return errors.New("audio is not registered")
+	}
+
+	manager.Get("audio").Call("setPlaybackRate", rate)
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package mmd
+
+import (
+	"app/lib/mmd/beat"
+	"app/lib/threejs"
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"syscall/js"
+)
+
+// Song is a song of a motion, loaded once for playback and beat detection.
+type Song struct {
+	// Buffer is the audio decoded by the browser for Audio, or nil if the browser cannot decode the format.
+	Buffer threejs.AudioBuffer
+	// Audio is the samples for beat detection.
+	Audio *beat.Audio
+}
+
+// LoadSongs loads songs of motions.
+// Each file is downloaded once and decoded by the browser with decodeAudioData, and the samples are analyzed from the decoded audio.
+// WAV and Ogg Vorbis files which the browser cannot decode, such as Ogg on Safari, are decoded in Go for beat detection only.
+func LoadSongs(ctx context.Context, urls []string) <-chan *threejs.Future[*Song] {
+
+	s := threejs.NewStream[*Song](ctx, urls)
+
+	go func() {
+		defer s.Close()
+
+		loader := threejs.NewFileLoader()
+		loader.SetResponseType("arraybuffer")
+
+		for _, url := range urls {
+
+			v, err := loadSong(ctx, loader, s, url)
+			if err == context.Canceled || err == context.DeadlineExceeded {
+				return
+			}
+			if !s.Done(url, v, err) {
+				return
+			}
+		}
+	}()
+
+	return s.Chan()
+}
+
+func loadSong(ctx context.Context, loader threejs.FileLoader, s *threejs.Stream[*Song], url string) (*Song, error) {
+
+	b, err := loadBytes(ctx, loader, url, func(loaded uint, total uint) {
+		s.Progress(url, loaded, total)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buffer, err := decodeAudio(ctx, b)
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return nil, err
+	}
+	if err == nil {
+		return &Song{Buffer: buffer, Audio: newAudioFromBuffer(buffer)}, nil
+	}
+
+	// ブラウザが対応しない形式でも、拍の検出はできるようにする
+	var audio *beat.Audio
+	switch strings.ToLower(path.Ext(url)) {
+	case ".wav":
+		audio, err = beat.DecodeWAV(bytes.NewReader(b))
+	case ".ogg", ".oga":
+		audio, err = beat.DecodeOgg(bytes.NewReader(b))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", url, err)
+	}
+
+	return &Song{Audio: audio}, nil
+}
+
+// newAudioFromBuffer mixes the channels of the decoded audio.
+func newAudioFromBuffer(buffer threejs.AudioBuffer) *beat.Audio {
+
+	v := buffer.JSValue()
+	channels := make([][]float32, v.Get("numberOfChannels").Int())
+	for i := range channels {
+		channels[i] = floats(v.Call("getChannelData", i))
+	}
+	return beat.NewAudioFromChannels(v.Get("sampleRate").Int(), channels)
+}
+
+// decodeAudio decodes the audio file with the AudioContext shared by three.js.
+func decodeAudio(ctx context.Context, b []byte) (threejs.AudioBuffer, error) {
+
+	type response struct {
+		buffer threejs.AudioBuffer
+		err    error
+	}
+	done := make(chan response, 1)
+
+	// decodeAudioDataは渡したArrayBufferを切り離すため、コピーを渡す
+	data := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(data, b)
+
+	// コールバックは中断後に呼ばれることもあるため、呼ばれたときに解放する
+	var jsfnOnLoad, jsfnOnError js.Func
+	release := func() {
+		jsfnOnLoad.Release()
+		jsfnOnError.Release()
+	}
+
+	jsfnOnLoad = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer release()
+
+		done <- response{buffer: threejs.NewAudioBufferFromJSValue(args[0])}
+		return nil
+	})
+	jsfnOnError = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer release()
+
+		done <- response{err: fmt.Errorf("audio could not be decoded")}
+		return nil
+	})
+
+	threejs.Threejs("AudioContext").Call("getContext").Call("decodeAudioData", data.Get("buffer"), jsfnOnLoad, jsfnOnError)
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-done:
+		return r.buffer, r.err
+	}
+}
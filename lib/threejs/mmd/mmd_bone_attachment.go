@@ -0,0 +1,172 @@
+package mmd
+
+import (
+	"app/lib/threejs"
+	"fmt"
+)
+
+// BoneAttachments parents props such as microphones, fans and hats to bones of an MMD model.
+//
+// The props are added as children of the bones, so they follow the animation, IK and physics
+// updated by AnimationHelper without any per-frame work.
+// Bones of MMDLoader are not rotated in the rest pose, so offsets are in the axes of the model.
+type BoneAttachments struct {
+	mesh        threejs.SkinnedMesh
+	attachments []*Attachment
+}
+
+// Attachment is a prop attached to a bone.
+type Attachment struct {
+	bone   threejs.Object3D
+	object threejs.Object3D
+
+	position *threejs.Vector3
+	rotation *threejs.Euler
+	scale    *threejs.Vector3
+
+	// Transform of the object before it is attached, restored by Detach.
+	originalPosition *threejs.Vector3
+	originalRotation *threejs.Euler
+	originalScale    *threejs.Vector3
+}
+
+// AttachmentOption is option for BoneAttachments.Attach.
+type AttachmentOption func(*Attachment)
+
+// AttachPosition sets the offset from the bone in MMD units.
+func AttachPosition(x float64, y float64, z float64) AttachmentOption {
+	return func(a *Attachment) {
+		a.position.Set2(x, y, z)
+	}
+}
+
+// AttachRotation sets the rotation relative to the bone in radians (XYZ order).
+func AttachRotation(x float64, y float64, z float64) AttachmentOption {
+	return func(a *Attachment) {
+		a.rotation.Set2(x, y, z, "XYZ")
+	}
+}
+
+// AttachScale sets the scale relative to the bone.
+func AttachScale(s float64) AttachmentOption {
+	return func(a *Attachment) {
+		a.scale.Set2(s, s, s)
+	}
+}
+
+// NewBoneAttachments creates BoneAttachments for the mesh loaded by Loader.
+func NewBoneAttachments(mesh threejs.SkinnedMesh) *BoneAttachments {
+	return &BoneAttachments{
+		mesh: mesh,
+	}
+}
+
+// Attach parents the object to the bone with the local offset.
+// The object may be another MMD model used as an accessory.
+// If the object is already attached, it is moved to the bone.
+func (b *BoneAttachments) Attach(boneName string, object threejs.Object3D, options ...AttachmentOption) (*Attachment, error) {
+
+	skeleton, err := b.mesh.Skeleton()
+	if err != nil {
+		return nil, err
+	}
+	bone, err := skeleton.BoneByName(boneName)
+	if err != nil {
+		return nil, err
+	}
+
+	b.Detach(object)
+
+	a := &Attachment{
+		bone:             bone,
+		object:           object,
+		position:         threejs.NewVector3(0, 0, 0),
+		rotation:         threejs.NewEuler(0, 0, 0, "XYZ"),
+		scale:            threejs.NewVector3(1, 1, 1),
+		originalPosition: object.Position().Clone(),
+		originalRotation: object.Rotation().Clone(),
+		originalScale:    object.Scale().Clone(),
+	}
+	for _, opt := range options {
+		opt(a)
+	}
+
+	// 別の親に追加されていれば取り除かれる
+	bone.Add(object)
+	a.apply()
+
+	b.attachments = append(b.attachments, a)
+	return a, nil
+}
+
+// Detach removes the object from the bone and restores its transform.
+// The object is not disposed, and it can be added to the scene again.
+func (b *BoneAttachments) Detach(object threejs.Object3D) {
+
+	for i, a := range b.attachments {
+		if a.object.JSValue().Equal(object.JSValue()) {
+			a.detach()
+			b.attachments = append(b.attachments[:i], b.attachments[i+1:]...)
+			return
+		}
+	}
+}
+
+// DetachAll removes all objects from the bones.
+// Call it before the model is disposed so that DisposeAll does not reach the props.
+func (b *BoneAttachments) DetachAll() {
+	for _, a := range b.attachments {
+		a.detach()
+	}
+	b.attachments = nil
+}
+
+// Attachments gets the attached props in the order of attachment.
+func (b *BoneAttachments) Attachments() []*Attachment {
+	return b.attachments
+}
+
+// Attachment gets the attachment of the object.
+func (b *BoneAttachments) Attachment(object threejs.Object3D) (*Attachment, error) {
+	for _, a := range b.attachments {
+		if a.object.JSValue().Equal(object.JSValue()) {
+			return a, nil
+		}
+	}
+	return nil, fmt.Errorf("object %q is not attached", object.Name())
+}
+
+// Bone gets the bone the object is attached to.
+func (a *Attachment) Bone() threejs.Object3D {
+	return a.bone
+}
+
+// Object gets the attached object.
+func (a *Attachment) Object() threejs.Object3D {
+	return a.object
+}
+
+// SetOffset changes the local offset from the bone.
+func (a *Attachment) SetOffset(options ...AttachmentOption) {
+	for _, opt := range options {
+		opt(a)
+	}
+	a.apply()
+}
+
+// apply sets the offset to the local transform of the object.
+func (a *Attachment) apply() {
+	a.object.Position().Copy(a.position)
+	a.object.Rotation().Copy(a.rotation)
+	a.object.Scale().Copy(a.scale)
+	a.object.UpdateMatrix()
+}
+
+func (a *Attachment) detach() {
+	a.bone.Remove(a.object)
+
+	a.object.Position().Copy(a.originalPosition)
+	a.object.Rotation().Copy(a.originalRotation)
+	a.object.Scale().Copy(a.originalScale)
+	a.object.UpdateMatrix()
+}
@@ -0,0 +1,185 @@
+package mmd
+
+import (
+	"app/lib/threejs"
+	"app/lib/threejs/animation"
+	"app/lib/threejs/camera"
+	"app/lib/threejs/control"
+	"math"
+	"syscall/js"
+)
+
+// CameraDirector switches the view camera between VMD camera motion and OrbitControls.
+//
+// While a camera motion is playing, AnimationHelper moves the camera and OrbitControls is not updated.
+// When the user grabs the view, the control is handed back to OrbitControls,
+// easing the camera roll, FOV, and the control limits back within the handback duration.
+type CameraDirector struct {
+	helper  *AnimationHelper
+	camera  camera.PerspectiveCamera
+	control control.OrbitControls
+
+	playing bool
+
+	// Values of the view camera before the camera motion is played.
+	fov           float64
+	up            *threejs.Vector3
+	minDistance   float64
+	maxDistance   float64
+	minPolarAngle float64
+	maxPolarAngle float64
+
+	handback        float64
+	handbackElapsed float64
+	handingBack     bool
+
+	onStart js.Func
+}
+
+// NewCameraDirector creates CameraDirector.
+//
+// handback — Time in seconds to ease the view back to OrbitControls settings.
+func NewCameraDirector(helper *AnimationHelper, camera camera.PerspectiveCamera, control control.OrbitControls, handback float64) *CameraDirector {
+
+	c := &CameraDirector{
+		helper:   helper,
+		camera:   camera,
+		control:  control,
+		handback: handback,
+	}
+
+	// ユーザーが視点を掴んだらOrbitControlsに制御を戻す
+	c.onStart = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if c.playing {
+			c.Stop()
+		}
+		return nil
+	})
+	control.AddEventListener("start", c.onStart)
+
+	return c
+}
+
+// Play starts the camera motion. The clip must be loaded by Loader.LoadCameraAnimation.
+// It plays from the current time of the motion, so call it with the dance to keep them in sync.
+func (c *CameraDirector) Play(clip animation.Clip) {
+
+	if !c.playing {
+		c.saveView()
+	}
+
+	if c.helper.HasCamera() {
+		c.helper.RemoveCamera(c.camera)
+	}
+	c.helper.AddCamera(c.camera, AnimationClip(clip))
+	c.helper.Enable(FeatureCameraAnimation, true)
+
+	c.playing = true
+	c.handingBack = false
+}
+
+// Stop stops the camera motion and hands the view back to OrbitControls.
+func (c *CameraDirector) Stop() {
+
+	if !c.playing {
+		return
+	}
+
+	c.helper.Enable(FeatureCameraAnimation, false)
+	c.playing = false
+
+	// 現在の注視点から操作を再開する
+	if target, err := c.helper.CameraTarget(); err == nil {
+		c.control.Target().Copy(target.Position())
+	}
+
+	// 範囲外の視点から急に戻らないよう、制限を現在の視点を含む範囲に広げてから徐々に戻す
+	offset := c.camera.Position().Clone().Sub(c.control.Target())
+	distance := offset.Length()
+	polar := 0.0
+	if distance > 0 {
+		polar = math.Acos(math.Max(-1, math.Min(1, offset.Y()/distance)))
+	}
+	c.control.SetMinDistance(math.Min(c.minDistance, distance))
+	c.control.SetMaxDistance(math.Max(c.maxDistance, distance))
+	c.control.SetMinPolarAngle(math.Min(c.minPolarAngle, polar))
+	c.control.SetMaxPolarAngle(math.Max(c.maxPolarAngle, polar))
+
+	c.handingBack = true
+	c.handbackElapsed = 0
+}
+
+// Playing gets whether the camera motion is playing.
+func (c *CameraDirector) Playing() bool {
+	return c.playing
+}
+
+// Update updates OrbitControls unless the camera motion is playing. Call it in the render loop.
+//
+// delta — number in second
+func (c *CameraDirector) Update(delta float64) {
+
+	if c.playing {
+		return
+	}
+
+	if c.handingBack {
+		c.handbackElapsed += delta
+		k := 1.0
+		if c.handback > 0 {
+			k = math.Min(1, c.handbackElapsed/c.handback)
+		}
+		c.ease(k)
+
+		if k >= 1 {
+			c.handingBack = false
+		}
+	}
+
+	c.control.Update()
+}
+
+// Dispose removes the event listener from OrbitControls and the camera from helper.
+func (c *CameraDirector) Dispose() {
+
+	c.Stop()
+	if c.helper.HasCamera() {
+		c.helper.RemoveCamera(c.camera)
+	}
+
+	c.control.RemoveEventListener("start", c.onStart)
+	c.onStart.Release()
+}
+
+func (c *CameraDirector) saveView() {
+	c.fov = c.camera.Fov()
+	c.up = c.camera.Up().Clone()
+	c.minDistance = c.control.MinDistance()
+	c.maxDistance = c.control.MaxDistance()
+	c.minPolarAngle = c.control.MinPolarAngle()
+	c.maxPolarAngle = c.control.MaxPolarAngle()
+}
+
+// ease moves the view toward the values saved before the camera motion. k is progress in [0, 1].
+func (c *CameraDirector) ease(k float64) {
+
+	c.camera.SetFov(lerp(c.camera.Fov(), c.fov, k))
+	c.camera.UpdateProjectionMatrix()
+	c.camera.Up().Lerp(c.up, k)
+
+	c.control.SetMinDistance(lerp(c.control.MinDistance(), c.minDistance, k))
+	c.control.SetMaxDistance(lerp(c.control.MaxDistance(), c.maxDistance, k))
+	c.control.SetMinPolarAngle(lerp(c.control.MinPolarAngle(), c.minPolarAngle, k))
+	c.control.SetMaxPolarAngle(lerp(c.control.MaxPolarAngle(), c.maxPolarAngle, k))
+}
+
+// lerp interpolates from a to b. Infinite limits of OrbitControls are set directly at the end.
+func lerp(a float64, b float64, k float64) float64 {
+	if k >= 1 || math.IsInf(a, 0) || math.IsInf(b, 0) {
+		if k >= 1 {
+			return b
+		}
+		return a
+	}
+	return a + (b-a)*k
+}
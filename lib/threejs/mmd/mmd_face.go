@@ -0,0 +1,83 @@
+package mmd
+
+import (
+	"app/lib/threejs"
+	"math"
+	"syscall/js"
+)
+
+// Face sets facial morphs by the names of MMD morphs, such as "あ" and "まばたき".
+// MMD models and VRM avatars implement it, so that lip sync and expressions drive both of them.
+type Face interface {
+	// Names gets the names of the morphs. Morphs hidden by others of the same name have empty names.
+	Names() []string
+
+	// Weight gets the weight of the morph, or 0 if the model does not have it.
+	Weight(name string) float64
+
+	// SetWeight sets the weight of the morph from 0 to 1. It returns false if the model does not have it.
+	SetWeight(name string, weight float64) bool
+}
+
+type faceImp struct {
+	mesh  threejs.Mesh
+	names []string
+}
+
+// NewFace creates Face of the morphs of the MMD model, morphTargetDictionary of the mesh.
+func NewFace(mesh threejs.Mesh) Face {
+
+	f := &faceImp{mesh: mesh}
+	dictionary := mesh.JSValue().Get("morphTargetDictionary")
+	influences := mesh.JSValue().Get("morphTargetInfluences")
+	if dictionary.IsUndefined() || dictionary.IsNull() || influences.IsUndefined() || influences.IsNull() {
+		return f
+	}
+
+	// 同名のモーフは辞書に1つしか残らないため、モーフの数は影響度の配列から得る
+	f.names = make([]string, influences.Length())
+	keys := js.Global().Get("Object").Call("keys", dictionary)
+	for i := 0; i < keys.Length(); i++ {
+		name := keys.Index(i).String()
+		if n := dictionary.Get(name).Int(); n >= 0 && n < len(f.names) {
+			f.names[n] = name
+		}
+	}
+	return f
+}
+
+// Names gets the names of the morphs.
+func (f *faceImp) Names() []string {
+	return f.names
+}
+
+// Weight gets the weight of the morph, or 0 if the model does not have it.
+func (f *faceImp) Weight(name string) float64 {
+	i, ok := f.index(name)
+	if !ok {
+		return 0
+	}
+	return f.mesh.JSValue().Get("morphTargetInfluences").Index(i).Float()
+}
+
+// SetWeight sets the weight of the morph from 0 to 1. It returns false if the model does not have it.
+func (f *faceImp) SetWeight(name string, weight float64) bool {
+	i, ok := f.index(name)
+	if !ok {
+		return false
+	}
+	f.mesh.JSValue().Get("morphTargetInfluences").SetIndex(i, math.Max(0, math.Min(weight, 1)))
+	return true
+}
+
+func (f *faceImp) index(name string) (int, bool) {
+	if name == "" {
+		return 0, false
+	}
+	for i, n := range f.names {
+		if n == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,173 @@
+package mmd
+
+import (
+	"app/lib/mmd/vmd"
+	"app/lib/threejs/light"
+	"math"
+)
+
+// LightDirector plays VMD light keyframes on a DirectionalLight.
+//
+// The light keeps its distance from its target and only the direction is changed,
+// so it works together with SelfShadow which moves the light to fit the shadow.
+type LightDirector struct {
+	light light.DirectionalLight
+
+	// scale is the intensity of the light for the color (1, 1, 1) of MMD.
+	scale float64
+
+	hemisphere light.HemisphereLight
+	// blend is the ratio of the hemisphere light intensity kept while the light keyframes are played.
+	blend float64
+
+	keyframes []vmd.LightFrame
+
+	// Values of the lights before the keyframes are played.
+	color               [3]float64
+	intensity           float64
+	direction           [3]float64
+	hemisphereIntensity float64
+}
+
+// LightDirectorOption is option for NewLightDirector.
+type LightDirectorOption func(*LightDirector)
+
+// LightScale sets the intensity of the light for the color (1, 1, 1) of MMD.
+// Default makes the initial light of MMD as bright as the current intensity of the light.
+func LightScale(v float64) LightDirectorOption {
+	return func(d *LightDirector) {
+		d.scale = v
+	}
+}
+
+// HemisphereBlend sets the hemisphere light of the scene and the ratio of its intensity kept
+// while the light keyframes are played. 0 turns it off, and 1 keeps it as it is.
+func HemisphereBlend(hemisphere light.HemisphereLight, blend float64) LightDirectorOption {
+	return func(d *LightDirector) {
+		d.hemisphere = hemisphere
+		d.blend = math.Max(0, math.Min(1, blend))
+	}
+}
+
+// NewLightDirector creates LightDirector.
+func NewLightDirector(directionalLight light.DirectionalLight, options ...LightDirectorOption) *LightDirector {
+
+	d := &LightDirector{
+		light: directionalLight,
+		scale: directionalLight.Intensity() / float64(vmd.DefaultLight.Color[0]),
+		blend: 1,
+	}
+	for _, opt := range options {
+		opt(d)
+	}
+
+	d.save()
+	return d
+}
+
+// SetHemisphereBlend changes the ratio of the hemisphere light intensity kept while the light keyframes are played.
+func (d *LightDirector) SetHemisphereBlend(blend float64) {
+	d.blend = math.Max(0, math.Min(1, blend))
+}
+
+// HemisphereBlend gets the ratio of the hemisphere light intensity kept while the light keyframes are played.
+func (d *LightDirector) HemisphereBlend() float64 {
+	return d.blend
+}
+
+// SetKeyframes sets VMD light keyframes sorted by frame.
+// If empty, the lights are restored to the values before the keyframes were played.
+func (d *LightDirector) SetKeyframes(frames []vmd.LightFrame) {
+
+	if len(d.keyframes) > 0 && len(frames) == 0 {
+		d.restore()
+	}
+	if len(d.keyframes) == 0 && len(frames) > 0 {
+		d.save()
+	}
+
+	d.keyframes = frames
+}
+
+// Playing gets whether light keyframes are set.
+func (d *LightDirector) Playing() bool {
+	return len(d.keyframes) > 0
+}
+
+// Update sets the light at the motion frame. Call it before SelfShadow.Update.
+func (d *LightDirector) Update(frame float64) {
+
+	f, ok := vmd.LightAt(d.keyframes, frame)
+	if !ok {
+		return
+	}
+
+	// 色の明るさを強度に、色相を色に分けて設定する
+	r, g, b := float64(f.Color[0]), float64(f.Color[1]), float64(f.Color[2])
+	max := math.Max(r, math.Max(g, b))
+	if max > 0 {
+		d.light.Color().SetRGB(r/max, g/max, b/max)
+	}
+	d.light.SetIntensity(d.scale * max)
+
+	// MMDの座標系からthree.jsの座標系に変換する
+	d.setDirection(float64(f.Direction[0]), float64(f.Direction[1]), -float64(f.Direction[2]))
+
+	if d.hemisphere != nil {
+		d.hemisphere.SetIntensity(d.hemisphereIntensity * d.blend)
+	}
+}
+
+// setDirection moves the light so that it travels in the direction to its target.
+func (d *LightDirector) setDirection(x float64, y float64, z float64) {
+
+	l := math.Sqrt(x*x + y*y + z*z)
+	if l < 1e-9 {
+		return
+	}
+
+	position := d.light.Position()
+	target := d.light.Target().Position()
+
+	dx, dy, dz := position.X()-target.X(), position.Y()-target.Y(), position.Z()-target.Z()
+	distance := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	if distance < 1e-9 {
+		distance = 1
+	}
+
+	position.Set2(
+		target.X()-x/l*distance,
+		target.Y()-y/l*distance,
+		target.Z()-z/l*distance,
+	)
+}
+
+func (d *LightDirector) save() {
+
+	c := d.light.Color()
+	d.color = [3]float64{c.R(), c.G(), c.B()}
+	d.intensity = d.light.Intensity()
+
+	position := d.light.Position()
+	target := d.light.Target().Position()
+	d.direction = [3]float64{
+		target.X() - position.X(),
+		target.Y() - position.Y(),
+		target.Z() - position.Z(),
+	}
+
+	if d.hemisphere != nil {
+		d.hemisphereIntensity = d.hemisphere.Intensity()
+	}
+}
+
+func (d *LightDirector) restore() {
+
+	d.light.Color().SetRGB(d.color[0], d.color[1], d.color[2])
+	d.light.SetIntensity(d.intensity)
+	d.setDirection(d.direction[0], d.direction[1], d.direction[2])
+
+	if d.hemisphere != nil {
+		d.hemisphere.SetIntensity(d.hemisphereIntensity)
+	}
+}
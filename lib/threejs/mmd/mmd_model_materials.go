@@ -0,0 +1,150 @@
+package mmd
+
+import (
+	"app/lib/threejs"
+	"app/lib/threejs/material"
+	"fmt"
+	"syscall/js"
+)
+
+// sphereMapProperties are properties MMDLoader sets sphere maps (.sph / .spa) to.
+// It depends on the three.js version which one is used.
+var sphereMapProperties = []string{"envMap", "matcap"}
+
+// ModelMaterials controls toon ramps and sphere maps of an MMD model.
+//
+// Loader creates a MeshToonMaterial for each PMX material, with the toon texture as the gradient map
+// and the sphere map as the environment map.
+// ModelMaterials keeps them and swaps them with the model and per-material overrides.
+type ModelMaterials struct {
+	materials []material.MeshToonMaterial
+
+	// toons and spheres are the textures set by Loader, in the order of materials.
+	toons   []js.Value
+	spheres []map[string]js.Value
+
+	sphereMapEnabled bool
+	toon             threejs.Texture
+	toonOverrides    map[string]threejs.Texture
+}
+
+// NewModelMaterials creates ModelMaterials for the mesh loaded by Loader.
+func NewModelMaterials(mesh threejs.Mesh) (*ModelMaterials, error) {
+
+	materials, err := mesh.Materials()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ModelMaterials{
+		materials:        make([]material.MeshToonMaterial, len(materials)),
+		toons:            make([]js.Value, len(materials)),
+		spheres:          make([]map[string]js.Value, len(materials)),
+		sphereMapEnabled: true,
+		toonOverrides:    make(map[string]threejs.Texture),
+	}
+
+	for i, v := range materials {
+		m.materials[i] = material.NewMeshToonMaterialFromJSValue(v.JSValue())
+		m.toons[i] = v.JSValue().Get("gradientMap")
+
+		m.spheres[i] = make(map[string]js.Value)
+		for _, p := range sphereMapProperties {
+			if tx := v.JSValue().Get(p); !tx.IsUndefined() && !tx.IsNull() {
+				m.spheres[i][p] = tx
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// Materials gets the materials in the order of the PMX file.
+func (m *ModelMaterials) Materials() []material.MeshToonMaterial {
+	return m.materials
+}
+
+// Material gets the material by name.
+func (m *ModelMaterials) Material(name string) (material.MeshToonMaterial, error) {
+	for _, v := range m.materials {
+		if v.Name() == name {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("material %q is not found", name)
+}
+
+// SphereMapEnabled gets whether sphere maps are drawn.
+func (m *ModelMaterials) SphereMapEnabled() bool {
+	return m.sphereMapEnabled
+}
+
+// SetSphereMapEnabled sets whether sphere maps are drawn.
+func (m *ModelMaterials) SetSphereMapEnabled(b bool) {
+	if m.sphereMapEnabled == b {
+		return
+	}
+
+	m.sphereMapEnabled = b
+	m.apply()
+}
+
+// SetToon replaces toon ramps of all materials. If nil, toon ramps of the model are restored.
+// Load the texture with LoadToonTextures.
+func (m *ModelMaterials) SetToon(tx threejs.Texture) {
+	m.toon = tx
+	m.apply()
+}
+
+// SetMaterialToon replaces toon ramp of the material. It takes precedence over SetToon.
+func (m *ModelMaterials) SetMaterialToon(name string, tx threejs.Texture) error {
+
+	if _, err := m.Material(name); err != nil {
+		return err
+	}
+
+	m.toonOverrides[name] = tx
+	m.apply()
+	return nil
+}
+
+// ResetMaterialToon clears the toon ramp set by SetMaterialToon.
+func (m *ModelMaterials) ResetMaterialToon(name string) {
+	delete(m.toonOverrides, name)
+	m.apply()
+}
+
+// Reset restores toon ramps and sphere maps set by Loader.
+func (m *ModelMaterials) Reset() {
+	m.sphereMapEnabled = true
+	m.toon = nil
+	m.toonOverrides = make(map[string]threejs.Texture)
+	m.apply()
+}
+
+// apply sets the textures to the materials.
+func (m *ModelMaterials) apply() {
+
+	for i, v := range m.materials {
+
+		toon := m.toons[i]
+		if m.toon != nil {
+			toon = m.toon.JSValue()
+		}
+		if tx, ok := m.toonOverrides[v.Name()]; ok && tx != nil {
+			toon = tx.JSValue()
+		}
+		v.JSValue().Set("gradientMap", toon)
+
+		for p, tx := range m.spheres[i] {
+			if m.sphereMapEnabled {
+				v.JSValue().Set(p, tx)
+			} else {
+				v.JSValue().Set(p, js.Null())
+			}
+		}
+
+		// テクスチャの有無でシェーダーが変わるため再コンパイルする
+		v.SetNeedsUpdate(true)
+	}
+}
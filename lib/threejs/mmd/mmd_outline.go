@@ -0,0 +1,163 @@
+package mmd
+
+import (
+	"app/lib/threejs"
+	"app/lib/threejs/effect"
+	"fmt"
+)
+
+// ModelOutline adjusts outlines of an MMD model drawn by effect.OutlineEffect.
+//
+// Loader sets the edge color, edge size and edge flag of each PMX material as its outline parameters.
+// ModelOutline keeps them and rewrites the parameters with the model and per-material overrides.
+type ModelOutline struct {
+	materials []threejs.Material
+	// pmx is the outline parameters set by Loader, in the order of materials.
+	pmx []effect.OutlineParameters
+
+	enabled        bool
+	thicknessScale float64
+	color          *[3]float64
+
+	overrides map[string]effect.OutlineParameters
+}
+
+// NewModelOutline creates ModelOutline for the mesh loaded by Loader.
+func NewModelOutline(mesh threejs.Mesh) (*ModelOutline, error) {
+
+	materials, err := mesh.Materials()
+	if err != nil {
+		return nil, err
+	}
+
+	o := &ModelOutline{
+		materials:      materials,
+		pmx:            make([]effect.OutlineParameters, len(materials)),
+		enabled:        true,
+		thicknessScale: 1,
+		overrides:      make(map[string]effect.OutlineParameters),
+	}
+
+	for i, m := range materials {
+		p, ok := effect.MaterialOutlineParameters(m)
+		if !ok {
+			// エッジ設定のないマテリアルは輪郭線を描画しない
+			p = effect.OutlineParameters{Alpha: 1}
+		}
+		o.pmx[i] = p
+	}
+
+	return o, nil
+}
+
+// Enabled gets whether outlines of the model are drawn.
+func (o *ModelOutline) Enabled() bool {
+	return o.enabled
+}
+
+// SetEnabled sets whether outlines of the model are drawn.
+// Materials with the edge flag off are not drawn even if it is true.
+func (o *ModelOutline) SetEnabled(b bool) {
+	o.enabled = b
+	o.apply()
+}
+
+// ThicknessScale gets the scale multiplied to the thickness of all materials. Default is 1.
+func (o *ModelOutline) ThicknessScale() float64 {
+	return o.thicknessScale
+}
+
+// SetThicknessScale sets the scale multiplied to the thickness of all materials. Default is 1.
+func (o *ModelOutline) SetThicknessScale(v float64) error {
+	if v < 0 {
+		return fmt.Errorf("thickness scale %v must not be negative", v)
+	}
+
+	o.thicknessScale = v
+	o.apply()
+	return nil
+}
+
+// SetColor overrides the edge color of all materials. The edge alpha of each material is kept.
+func (o *ModelOutline) SetColor(c threejs.Color) {
+	o.color = &[3]float64{c.R(), c.G(), c.B()}
+	o.apply()
+}
+
+// ClearColor restores the edge color of each material.
+func (o *ModelOutline) ClearColor() {
+	o.color = nil
+	o.apply()
+}
+
+// MaterialNames gets names of the materials in the order of the PMX file.
+func (o *ModelOutline) MaterialNames() []string {
+	names := make([]string, len(o.materials))
+	for i, m := range o.materials {
+		names[i] = m.Name()
+	}
+	return names
+}
+
+// MaterialOutline gets the outline parameters of the material in effect, before the model overrides are applied.
+func (o *ModelOutline) MaterialOutline(name string) (effect.OutlineParameters, error) {
+
+	if p, ok := o.overrides[name]; ok {
+		return p, nil
+	}
+
+	for i, m := range o.materials {
+		if m.Name() == name {
+			return o.pmx[i], nil
+		}
+	}
+
+	return effect.OutlineParameters{}, fmt.Errorf("material %q is not found", name)
+}
+
+// SetMaterialOutline overrides the outline parameters of the material.
+// The model overrides are still applied on top of them.
+func (o *ModelOutline) SetMaterialOutline(name string, p effect.OutlineParameters) error {
+
+	if _, err := o.MaterialOutline(name); err != nil {
+		return err
+	}
+
+	o.overrides[name] = p
+	o.apply()
+	return nil
+}
+
+// ResetMaterialOutline restores the PMX edge settings of the material.
+func (o *ModelOutline) ResetMaterialOutline(name string) {
+	delete(o.overrides, name)
+	o.apply()
+}
+
+// Reset restores the PMX edge settings of all materials and clears the model overrides.
+func (o *ModelOutline) Reset() {
+	o.enabled = true
+	o.thicknessScale = 1
+	o.color = nil
+	o.overrides = make(map[string]effect.OutlineParameters)
+	o.apply()
+}
+
+// apply writes the outline parameters to the materials. OutlineEffect reads them on the next render.
+func (o *ModelOutline) apply() {
+
+	for i, m := range o.materials {
+		p := o.pmx[i]
+		if v, ok := o.overrides[m.Name()]; ok {
+			p = v
+		}
+
+		p.Thickness *= o.thicknessScale
+		p.Visible = p.Visible && o.enabled && p.Thickness > 0
+		if o.color != nil {
+			p.Color = *o.color
+		}
+
+		effect.SetMaterialOutlineParameters(m, p)
+	}
+}
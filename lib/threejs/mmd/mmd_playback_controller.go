@@ -0,0 +1,207 @@
+package mmd
+
+import (
+	"app/lib/threejs/animation"
+	"errors"
+	"math"
+)
+
+// FramesPerSecond is frame rate of MMD motions.
+const FramesPerSecond = 30.0
+
+// PlaybackController controls timeline of AnimationHelper as pause, resume, seek, speed, and A-B loop.
+// Call Update instead of AnimationHelper.Update in the render loop.
+type PlaybackController struct {
+	helper *AnimationHelper
+
+	time     float64
+	duration float64
+	speed    float64
+	paused   bool
+
+	loop      bool
+	loopStart float64
+	loopEnd   float64
+
+	// actions are played once by the timeline, and paused by three.js at the end.
+	actions []animation.Action
+
+	warmup int
+}
+
+// NewPlaybackController creates PlaybackController.
+//
+// warmup — Number of physics steps after seeking. It avoids hair and skirts popping.
+func NewPlaybackController(helper *AnimationHelper, warmup int) *PlaybackController {
+	return &PlaybackController{
+		helper: helper,
+		speed:  1,
+		warmup: warmup,
+	}
+}
+
+// Update advances the timeline and updates the animations of objects added to helper.
+//
+// delta — number in second
+func (c *PlaybackController) Update(delta float64) {
+
+	if c.paused {
+		// IK, Grantを適用するため時間を進めずに更新する
+		c.helper.UpdatePose()
+		return
+	}
+
+	d := delta * c.speed
+	c.helper.Update(d)
+	c.time += d
+	if c.duration > 0 {
+		c.time = math.Min(c.time, c.duration)
+	}
+
+	if c.loop && (c.time >= c.loopEnd || c.time < c.loopStart) {
+		c.Seek(c.loopStart)
+	}
+}
+
+// Restart rewinds the timeline for a new motion.
+//
+// duration — Duration of the motion in seconds.
+//
+// actions — Actions of the motion played once. Seek resumes them after they are paused at the end.
+func (c *PlaybackController) Restart(duration float64, actions ...animation.Action) {
+	c.time = 0
+	c.duration = duration
+	c.loop = false
+	c.actions = actions
+}
+
+// Time gets the current position on the timeline in seconds.
+func (c *PlaybackController) Time() float64 {
+	return c.time
+}
+
+// Frame gets the current position on the timeline in MMD frame.
+func (c *PlaybackController) Frame() int {
+	return int(math.Floor(c.time * FramesPerSecond))
+}
+
+// Duration gets the duration of the timeline in seconds.
+func (c *PlaybackController) Duration() float64 {
+	return c.duration
+}
+
+// Paused gets whether the timeline is paused.
+func (c *PlaybackController) Paused() bool {
+	return c.paused
+}
+
+// Pause pauses the timeline and the audio.
+func (c *PlaybackController) Pause() {
+	if c.paused {
+		return
+	}
+
+	c.paused = true
+	c.helper.PauseAudio()
+}
+
+// Resume resumes the timeline and the audio.
+func (c *PlaybackController) Resume() {
+	if !c.paused {
+		return
+	}
+
+	c.paused = false
+	c.helper.SeekAudio(c.time)
+}
+
+// Speed gets the playback speed.
+func (c *PlaybackController) Speed() float64 {
+	return c.speed
+}
+
+// SetSpeed sets the playback speed. 1 is normal speed.
+func (c *PlaybackController) SetSpeed(s float64) error {
+	if s <= 0 {
+		return errors.New("speed must be positive, use Pause instead")
+	}
+
+	c.speed = s
+	c.helper.SetAudioPlaybackRate(s)
+
+	return nil
+}
+
+// Seek moves the timeline to t seconds.
+// Meshes, the camera, and the audio are moved together, and physics is reset to the new pose.
+func (c *PlaybackController) Seek(t float64) {
+
+	t = math.Max(0, t)
+	if c.duration > 0 {
+		t = math.Min(t, c.duration)
+	}
+
+	// 終端でclampWhenFinishedにより停止したアクションは、SetTimeで時間が進まない
+	for _, action := range c.actions {
+		action.SetPaused(false)
+		action.SetEnabled(true)
+	}
+
+	for _, mesh := range c.helper.Meshes() {
+		mixer, err := c.helper.Mixer(mesh)
+		if err != nil {
+			continue
+		}
+		mixer.SetTime(t)
+	}
+
+	if mixer, err := c.helper.CameraMixer(); err == nil {
+		mixer.SetTime(t)
+	}
+
+	// 新しいポーズでIK, Grantを解決してから物理演算をリセットする
+	c.helper.UpdatePose()
+	for _, mesh := range c.helper.Meshes() {
+		c.helper.ResetPhysics(mesh, c.warmup)
+	}
+
+	if c.paused {
+		c.helper.PauseAudio()
+	} else {
+		c.helper.SeekAudio(t)
+	}
+
+	c.time = t
+}
+
+// SeekFrame moves the timeline to MMD frame.
+func (c *PlaybackController) SeekFrame(frame int) {
+	c.Seek(float64(frame) / FramesPerSecond)
+}
+
+// SetLoopRange sets A-B loop range in seconds. The timeline goes back to start when it reaches end.
+func (c *PlaybackController) SetLoopRange(start float64, end float64) error {
+	if end <= start {
+		return errors.New("loop end must be after loop start")
+	}
+
+	c.loop = true
+	c.loopStart = start
+	c.loopEnd = end
+
+	if c.time < start || c.time >= end {
+		c.Seek(start)
+	}
+
+	return nil
+}
+
+// ClearLoopRange clears A-B loop range.
+func (c *PlaybackController) ClearLoopRange() {
+	c.loop = false
+}
+
+// LoopRange gets A-B loop range in seconds. ok is false if it is not set.
+func (c *PlaybackController) LoopRange() (start float64, end float64, ok bool) {
+	return c.loopStart, c.loopEnd, c.loop
+}
@@ -0,0 +1,85 @@
+package mmd
+
+import (
+	"app/lib/mmd/pmx"
+	"app/lib/threejs"
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// LoadPMXs loads PMX files and decodes them in Go, for processing in Go such as package pose.
+// Use Loader to show models.
+func LoadPMXs(ctx context.Context, urls []string) <-chan *threejs.Future[*pmx.Model] {
+
+	s := threejs.NewStream[*pmx.Model](ctx, urls)
+
+	go func() {
+		defer s.Close()
+
+		loader := threejs.NewFileLoader()
+		loader.SetResponseType("arraybuffer")
+
+		for _, url := range urls {
+
+			b, err := loadBytes(ctx, loader, url, func(loaded uint, total uint) {
+				s.Progress(url, loaded, total)
+			})
+			if err == context.Canceled || err == context.DeadlineExceeded {
+				return
+			}
+
+			var m *pmx.Model
+			if err == nil {
+				if m, err = pmx.Decode(bytes.NewReader(b)); err != nil {
+					err = fmt.Errorf("%v: %w", url, err)
+				}
+			}
+
+			if !s.Done(url, m, err) {
+				return
+			}
+		}
+	}()
+
+	return s.Chan()
+}
+
+// LoadReadmes loads readme files distributed with models and finds the author and the terms of use in them.
+func LoadReadmes(ctx context.Context, urls []string) <-chan *threejs.Future[*pmx.Credits] {
+
+	s := threejs.NewStream[*pmx.Credits](ctx, urls)
+
+	go func() {
+		defer s.Close()
+
+		loader := threejs.NewFileLoader()
+		loader.SetResponseType("arraybuffer")
+
+		for _, url := range urls {
+
+			b, err := loadBytes(ctx, loader, url, func(loaded uint, total uint) {
+				s.Progress(url, loaded, total)
+			})
+			if err == context.Canceled || err == context.DeadlineExceeded {
+				return
+			}
+
+			var c *pmx.Credits
+			if err == nil {
+				credits, derr := pmx.DecodeReadme(bytes.NewReader(b))
+				if derr != nil {
+					err = fmt.Errorf("%v: %w", url, derr)
+				} else {
+					c = &credits
+				}
+			}
+
+			if !s.Done(url, c, err) {
+				return
+			}
+		}
+	}()
+
+	return s.Chan()
+}
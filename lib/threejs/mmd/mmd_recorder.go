@@ -0,0 +1,294 @@
+package mmd
+
+import (
+	"app/lib/mmd/vmd"
+	"app/lib/threejs"
+	"encoding/binary"
+	"errors"
+	"math"
+	"syscall/js"
+)
+
+// Recorder samples bones and morphs of an MMD model on screen and records them as a VMD motion.
+//
+// The recorded pose includes blended motions, IK results and, optionally, bones driven by physics,
+// so the motion plays identically without them.
+type Recorder struct {
+	helper *AnimationHelper
+	mesh   threejs.SkinnedMesh
+
+	bakePhysics bool
+	reduce      bool
+	fit         bool
+	tolerance   vmd.Tolerance
+	report      vmd.FitReport
+
+	bones  []recordedBone
+	morphs []string
+	iks    []string
+
+	// buffer receives positions and quaternions of the bones from JS.
+	buffer js.Value
+
+	recording bool
+	elapsed   float64
+	last      int
+	motion    *vmd.Motion
+}
+
+// recordedBone is a bone to record.
+type recordedBone struct {
+	index int
+	name  string
+	// rest is the position relative to the parent in the rest pose.
+	rest [3]float64
+	// granted is true for bones which the grant solver rotates.
+	// Their poses before the grant are recorded, since the grant is applied again when played.
+	granted bool
+	// physics is true for bones which rigid bodies move.
+	physics bool
+}
+
+// RecorderOption is option for NewRecorder.
+type RecorderOption func(*Recorder)
+
+// BakePhysics records bones moved by physics, such as hair and skirts. Default is false,
+// and physics moves them again when the motion is played.
+func BakePhysics(b bool) RecorderOption {
+	return func(r *Recorder) {
+		r.bakePhysics = b
+	}
+}
+
+// ReduceKeys removes keyframes which interpolation reproduces within the tolerance when recording stops.
+func ReduceKeys(t vmd.Tolerance) RecorderOption {
+	return func(r *Recorder) {
+		r.reduce = true
+		r.tolerance = t
+	}
+}
+
+// FitKeys replaces keyframes with fewer ones whose Bezier curves reproduce the motion within the tolerance
+// when recording stops. It reduces more than ReduceKeys. See Report for the result.
+func FitKeys(t vmd.Tolerance) RecorderOption {
+	return func(r *Recorder) {
+		r.fit = true
+		r.tolerance = t
+	}
+}
+
+// NewRecorder creates Recorder of the mesh added to the helper.
+func NewRecorder(helper *AnimationHelper, mesh threejs.SkinnedMesh, options ...RecorderOption) (*Recorder, error) {
+
+	geometry, err := mesh.Geometry()
+	if err != nil {
+		return nil, err
+	}
+	data := geometry.JSValue().Get("userData").Get("MMD")
+	if data.IsUndefined() || data.IsNull() {
+		return nil, errors.New("mesh is not loaded by MMDLoader")
+	}
+
+	r := &Recorder{
+		helper: helper,
+		mesh:   mesh,
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+
+	bones := data.Get("bones")
+	r.bones = make([]recordedBone, bones.Length())
+	for i := range r.bones {
+		b := bones.Index(i)
+		pos := b.Get("pos")
+		r.bones[i] = recordedBone{
+			index: i,
+			name:  b.Get("name").String(),
+			rest:  [3]float64{pos.Index(0).Float(), pos.Index(1).Float(), pos.Index(2).Float()},
+		}
+	}
+
+	grants := data.Get("grants")
+	for i := 0; i < grants.Length(); i++ {
+		r.bones[grants.Index(i).Get("index").Int()].granted = true
+	}
+
+	// 剛体のタイプ0はボーン追従で、それ以外は物理演算がボーンを動かす
+	rigidBodies := data.Get("rigidBodies")
+	for i := 0; i < rigidBodies.Length(); i++ {
+		b := rigidBodies.Index(i)
+		if index := b.Get("boneIndex").Int(); index >= 0 && index < len(r.bones) && b.Get("type").Int() != 0 {
+			r.bones[index].physics = true
+		}
+	}
+
+	iks := data.Get("iks")
+	for i := 0; i < iks.Length(); i++ {
+		r.iks = append(r.iks, r.bones[iks.Index(i).Get("target").Int()].name)
+	}
+
+	dictionary := mesh.JSValue().Get("morphTargetDictionary")
+	if !dictionary.IsUndefined() && !dictionary.IsNull() {
+		keys := js.Global().Get("Object").Call("keys", dictionary)
+		r.morphs = make([]string, keys.Length())
+		for i := range r.morphs {
+			name := keys.Index(i).String()
+			r.morphs[dictionary.Get(name).Int()] = name
+		}
+	}
+
+	r.buffer = js.Global().Get("Float32Array").New(len(r.bones) * 7)
+
+	return r, nil
+}
+
+// Recording gets whether the recorder is recording.
+func (r *Recorder) Recording() bool {
+	return r.recording
+}
+
+// Start starts recording a new motion. The current pose is recorded at frame 0.
+func (r *Recorder) Start() {
+	r.recording = true
+	r.elapsed = 0
+	r.last = -1
+	r.motion = &vmd.Motion{ModelName: r.mesh.Name()}
+
+	// IKの結果を記録するため、再生時はIKを無効にする
+	if len(r.iks) > 0 {
+		f := vmd.IKFrame{Frame: 0, Show: true}
+		for _, name := range r.iks {
+			f.IKs = append(f.IKs, vmd.IKState{Name: name, Enabled: false})
+		}
+		r.motion.IKs = append(r.motion.IKs, f)
+	}
+
+	r.sample(0)
+}
+
+// Stop stops recording and returns the recorded motion. It returns nil if the recorder is not recording.
+func (r *Recorder) Stop() *vmd.Motion {
+	if !r.recording {
+		return nil
+	}
+	r.recording = false
+
+	m := r.motion
+	r.motion = nil
+
+	switch {
+	case r.fit:
+		r.report = m.Fit(r.tolerance)
+	case r.reduce:
+		m.Reduce(r.tolerance)
+	default:
+		m.Sort()
+	}
+	return m
+}
+
+// Report gets the compression ratio and the errors of the last motion stopped with FitKeys.
+func (r *Recorder) Report() vmd.FitReport {
+	return r.report
+}
+
+// Update advances the recording time by delta seconds and samples the pose at 30 fps.
+// Call it after AnimationHelper.Update in each frame.
+// Frames are skipped when rendering is slower than 30 fps, and interpolation fills them when played.
+func (r *Recorder) Update(delta float64) {
+
+	if !r.recording {
+		return
+	}
+
+	r.elapsed += delta
+	frame := int(math.Floor(r.elapsed*vmd.FramesPerSecond + 1e-6))
+	if frame <= r.last {
+		return
+	}
+	r.sample(frame)
+}
+
+// sample records the current pose at the frame.
+func (r *Recorder) sample(frame int) {
+
+	r.last = frame
+
+	final := r.read()
+	// 付与前の姿勢はAnimationHelperがIKと付与の前に保存している
+	backup := final
+	if objects := r.helper.Get("objects").Call("get", r.mesh.JSValue()); !objects.IsUndefined() {
+		if b := objects.Get("backupBones"); !b.IsUndefined() && b.Length() == len(r.bones)*7 {
+			backup = floats(b)
+		}
+	}
+
+	for _, b := range r.bones {
+		if b.physics && !r.bakePhysics {
+			continue
+		}
+
+		v := final[b.index*7 : b.index*7+7]
+		if b.granted && !b.physics {
+			v = backup[b.index*7 : b.index*7+7]
+		}
+
+		f := vmd.BoneFrame{
+			Name:  b.name,
+			Frame: uint32(frame),
+			// 右手系から左手系に変換する
+			Position: [3]float32{
+				float32(float64(v[0]) - b.rest[0]),
+				float32(float64(v[1]) - b.rest[1]),
+				-float32(float64(v[2]) - b.rest[2]),
+			},
+			Rotation: [4]float32{-v[3], -v[4], v[5], v[6]},
+		}
+		for ch := vmd.ChannelX; ch <= vmd.ChannelRotation; ch++ {
+			f.SetInterpolation(ch, vmd.LinearBezier)
+		}
+		r.motion.Bones = append(r.motion.Bones, f)
+	}
+
+	influences := r.mesh.JSValue().Get("morphTargetInfluences")
+	for i, name := range r.morphs {
+		r.motion.Morphs = append(r.motion.Morphs, vmd.MorphFrame{
+			Name:   name,
+			Frame:  uint32(frame),
+			Weight: float32(influences.Index(i).Float()),
+		})
+	}
+}
+
+// read gets local positions and quaternions of the bones.
+func (r *Recorder) read() []float32 {
+
+	skeleton, err := r.mesh.Skeleton()
+	if err != nil {
+		return make([]float32, len(r.bones)*7)
+	}
+
+	bones := skeleton.JSValue().Get("bones")
+	for i := range r.bones {
+		b := bones.Index(i)
+		b.Get("position").Call("toArray", r.buffer, i*7)
+		b.Get("quaternion").Call("toArray", r.buffer, i*7+3)
+	}
+
+	return floats(r.buffer)
+}
+
+// floats copies Float32Array to Go.
+func floats(array js.Value) []float32 {
+
+	u8 := js.Global().Get("Uint8Array").New(array.Get("buffer"), array.Get("byteOffset"), array.Get("byteLength"))
+	b := make([]byte, u8.Length())
+	js.CopyBytesToGo(b, u8)
+
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return v
+}
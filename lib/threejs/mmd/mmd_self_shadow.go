@@ -0,0 +1,217 @@
+package mmd
+
+import (
+	"app/lib/mmd/vmd"
+	"app/lib/threejs"
+	"app/lib/threejs/light"
+	"math"
+)
+
+// SelfShadowRangeScale converts Distance of VMD self shadow keyframes to the radius of the shadow range in MMD units.
+// MMD does not document the conversion, so it is tuned to cover a model and its feet at the default distance.
+var SelfShadowRangeScale = 2000.0
+
+// SelfShadow casts shadows of MMD models with a DirectionalLight.
+//
+// It fits the shadow camera to the bounding boxes of the casters,
+// or follows VMD self shadow keyframes when they are set.
+type SelfShadow struct {
+	renderer threejs.Renderer
+	light    light.DirectionalLight
+
+	casters []threejs.Object3D
+	enabled bool
+	margin  float64
+
+	keyframes []vmd.SelfShadowFrame
+}
+
+// SelfShadowOption is option for NewSelfShadow.
+type SelfShadowOption func(*SelfShadow)
+
+// ShadowMapType sets the filtering type of shadow maps. Default is PCFSoftShadowMap.
+func ShadowMapType(t threejs.ShadowMapType) SelfShadowOption {
+	return func(s *SelfShadow) {
+		s.renderer.ShadowMap().SetType(t)
+	}
+}
+
+// ShadowMapSize sets width and height of the shadow map. Default is 2048.
+func ShadowMapSize(size int) SelfShadowOption {
+	return func(s *SelfShadow) {
+		s.light.Shadow().SetMapSize(size, size)
+	}
+}
+
+// ShadowMargin sets the margin in MMD units added to the bounding boxes of the casters. Default is 2.
+func ShadowMargin(margin float64) SelfShadowOption {
+	return func(s *SelfShadow) {
+		s.margin = margin
+	}
+}
+
+// NewSelfShadow enables shadow maps of the renderer and makes the light cast shadows.
+// The direction of the light is kept, and its position and target are moved to fit the casters.
+func NewSelfShadow(renderer threejs.Renderer, directionalLight light.DirectionalLight, options ...SelfShadowOption) *SelfShadow {
+
+	s := &SelfShadow{
+		renderer: renderer,
+		light:    directionalLight,
+		enabled:  true,
+		margin:   2,
+	}
+
+	renderer.ShadowMap().SetEnabled(true)
+	renderer.ShadowMap().SetType(threejs.PCFSoftShadowMap)
+	directionalLight.Shadow().SetMapSize(2048, 2048)
+	directionalLight.Shadow().SetBias(-0.0005)
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	directionalLight.SetCastShadow(true)
+
+	return s
+}
+
+// Enabled gets whether shadows are cast.
+func (s *SelfShadow) Enabled() bool {
+	return s.enabled
+}
+
+// SetEnabled sets whether shadows are cast.
+// VMD keyframes of mode off turn shadows off even if it is true.
+func (s *SelfShadow) SetEnabled(b bool) {
+	s.enabled = b
+	// レンダラーのシャドウマップを切り替えるとマテリアルの再コンパイルが必要なため、ライト側で切り替える
+	s.light.SetCastShadow(b)
+}
+
+// AddCaster makes the object cast shadows. The object also receives shadows, as MMD self shadow does.
+func (s *SelfShadow) AddCaster(object threejs.Object3D) {
+	object.SetCastShadow(true)
+	object.SetReceiveShadow(true)
+	s.casters = append(s.casters, object)
+}
+
+// RemoveCaster stops the object casting shadows.
+func (s *SelfShadow) RemoveCaster(object threejs.Object3D) {
+	object.SetCastShadow(false)
+	object.SetReceiveShadow(false)
+
+	for i, v := range s.casters {
+		if v.JSValue().Equal(object.JSValue()) {
+			s.casters = append(s.casters[:i], s.casters[i+1:]...)
+			return
+		}
+	}
+}
+
+// AddReceiver makes the object, such as the ground, receive shadows.
+func (s *SelfShadow) AddReceiver(object threejs.Object3D) {
+	object.SetReceiveShadow(true)
+}
+
+// SetKeyframes sets VMD self shadow keyframes sorted by frame. If empty, the shadow fits the casters.
+func (s *SelfShadow) SetKeyframes(frames []vmd.SelfShadowFrame) {
+	s.keyframes = frames
+	// 前のキーフレームでオフになった影を戻す
+	s.light.SetCastShadow(s.enabled)
+}
+
+// Update fits the shadow camera at the motion frame.
+// focus is the point the view camera looks at, used by keyframes of mode 1.
+func (s *SelfShadow) Update(frame float64, focus *threejs.Vector3) {
+
+	if !s.enabled {
+		return
+	}
+
+	state, ok := vmd.SelfShadowAt(s.keyframes, frame)
+	if !ok {
+		s.Fit()
+		return
+	}
+
+	switch state.Mode {
+	case vmd.SelfShadowOff:
+		s.light.SetCastShadow(false)
+	case vmd.SelfShadowMode1:
+		s.light.SetCastShadow(true)
+		s.fit(focus.X(), focus.Y(), focus.Z(), float64(state.Distance)*SelfShadowRangeScale)
+	default:
+		// モード2はモデルに合わせ、範囲はキーフレームの距離で制限する
+		s.light.SetCastShadow(true)
+		x, y, z, r, ok := s.bounds()
+		if !ok {
+			return
+		}
+		s.fit(x, y, z, math.Min(r, float64(state.Distance)*SelfShadowRangeScale))
+	}
+}
+
+// Fit fits the shadow camera to the bounding boxes of the casters.
+// It turns shadows back on after keyframes of mode off unless they are disabled by SetEnabled.
+func (s *SelfShadow) Fit() {
+	s.light.SetCastShadow(s.enabled)
+	x, y, z, r, ok := s.bounds()
+	if !ok {
+		return
+	}
+	s.fit(x, y, z, r)
+}
+
+// bounds gets the bounding sphere of the casters.
+func (s *SelfShadow) bounds() (x float64, y float64, z float64, radius float64, ok bool) {
+
+	if len(s.casters) == 0 {
+		return 0, 0, 0, 0, false
+	}
+
+	box := threejs.NewBox3()
+	for _, v := range s.casters {
+		box.ExpandByObject(v)
+	}
+	if box.IsEmpty() {
+		return 0, 0, 0, 0, false
+	}
+	box.ExpandByScalar(s.margin)
+
+	c := box.Center()
+	size := box.Size()
+	return c.X(), c.Y(), c.Z(), size.Length() / 2, true
+}
+
+// fit moves the light to look at the center and covers the sphere with the shadow camera.
+func (s *SelfShadow) fit(x float64, y float64, z float64, radius float64) {
+
+	if radius <= 0 {
+		return
+	}
+
+	position := s.light.Position()
+	target := s.light.Target().Position()
+
+	// ライトの向きを保ったまま、球を囲む距離に移動する
+	dx, dy, dz := position.X()-target.X(), position.Y()-target.Y(), position.Z()-target.Z()
+	l := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	if l < 1e-9 {
+		dx, dy, dz, l = 0, 1, 0, 1
+	}
+	d := radius * 2
+	target.Set2(x, y, z)
+	position.Set2(x+dx/l*d, y+dy/l*d, z+dz/l*d)
+
+	// ターゲットはシーンに追加されていないため、行列を手動で更新する
+	s.light.Target().UpdateMatrixWorld(false)
+
+	cam := s.light.Shadow().Camera()
+	cam.SetLeft(-radius)
+	cam.SetRight(radius)
+	cam.SetTop(radius)
+	cam.SetBottom(-radius)
+	cam.SetNear(math.Max(0.1, d-radius))
+	cam.SetFar(d + radius)
+	cam.UpdateProjectionMatrix()
+}
@@ -0,0 +1,172 @@
+package mmd
+
+import (
+	"app/lib/mmd/math3d"
+	"app/lib/mmd/skeleton"
+	"app/lib/mmd/springbone"
+	"app/lib/threejs"
+	"errors"
+	"syscall/js"
+)
+
+// SpringBones moves bones of an MMD model, which rigid bodies move with Ammo.js, by springbone.Solver instead.
+// Add the mesh to AnimationHelper without physics, and call Update after AnimationHelper.Update in each frame.
+type SpringBones struct {
+	mesh   threejs.SkinnedMesh
+	solver *springbone.Solver
+
+	// joints are indices of bones the solver rotates.
+	joints []int
+
+	local []skeleton.Transform
+	// buffer receives positions and quaternions of the bones from JS.
+	buffer js.Value
+
+	// input and output are rotations of the joints before and after the last update.
+	// If nothing animates a joint, its output is left on the bone, and is replaced by the input.
+	input  []math3d.Quaternion
+	output []math3d.Quaternion
+}
+
+// NewSpringBones creates SpringBones of the mesh.
+// If config is nil, joints and colliders are made from rigid bodies and joints of the model.
+func NewSpringBones(mesh threejs.SkinnedMesh, config *springbone.Config) (*SpringBones, error) {
+
+	geometry, err := mesh.Geometry()
+	if err != nil {
+		return nil, err
+	}
+	data := geometry.JSValue().Get("userData").Get("MMD")
+	if data.IsUndefined() || data.IsNull() {
+		return nil, errors.New("mesh is not loaded by MMDLoader")
+	}
+
+	bones := data.Get("bones")
+	b := make([]skeleton.Bone, bones.Length())
+	for i := range b {
+		bone := bones.Index(i)
+		b[i] = skeleton.Bone{
+			Name:     bone.Get("name").String(),
+			Parent:   bone.Get("parent").Int(),
+			Position: vector3(bone.Get("pos")),
+		}
+	}
+	s, err := skeleton.New(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if config == nil {
+		c := springbone.NewConfigFromRigidBodies(s, rigidBodies(data), constraints(data))
+		config = &c
+	}
+
+	solver, err := springbone.NewSolver(s, *config)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := &SpringBones{
+		mesh:   mesh,
+		solver: solver,
+		local:  s.RestPose(),
+		buffer: js.Global().Get("Float32Array").New(len(b) * 7),
+	}
+	for _, j := range config.Joints {
+		sb.joints = append(sb.joints, j.Bone)
+	}
+	sb.input = make([]math3d.Quaternion, len(sb.joints))
+	sb.output = make([]math3d.Quaternion, len(sb.joints))
+
+	return sb, nil
+}
+
+// Update advances the springs by delta seconds and rotates the joint bones.
+func (c *SpringBones) Update(delta float64) {
+
+	sk, err := c.mesh.Skeleton()
+	if err != nil {
+		return
+	}
+	bones := sk.JSValue().Get("bones")
+
+	for i := range c.local {
+		b := bones.Index(i)
+		b.Get("position").Call("toArray", c.buffer, i*7)
+		b.Get("quaternion").Call("toArray", c.buffer, i*7+3)
+	}
+	v := floats(c.buffer)
+	for i := range c.local {
+		c.local[i] = skeleton.Transform{
+			Position: math3d.NewVector3(float64(v[i*7]), float64(v[i*7+1]), float64(v[i*7+2])),
+			Rotation: math3d.Quaternion{X: float64(v[i*7+3]), Y: float64(v[i*7+4]), Z: float64(v[i*7+5]), W: float64(v[i*7+6])},
+		}
+	}
+
+	// 前回の結果がそのまま残っている場合は、前回の入力をアニメーションの姿勢とする
+	for n, i := range c.joints {
+		if float32q(c.local[i].Rotation) == float32q(c.output[n]) {
+			c.local[i].Rotation = c.input[n]
+		}
+		c.input[n] = c.local[i].Rotation
+	}
+
+	c.solver.Update(c.local, delta)
+
+	for n, i := range c.joints {
+		q := c.local[i].Rotation
+		c.output[n] = q
+		bones.Index(i).Get("quaternion").Call("set", q.X, q.Y, q.Z, q.W)
+	}
+}
+
+// Reset makes the joints follow the next pose at rest. Call it when the pose jumps, such as when a motion is seeked.
+func (c *SpringBones) Reset() {
+	c.solver.Reset()
+}
+
+// rigidBodies reads rigid bodies of the model.
+// MMDLoader keeps positions of bodies relative to their bones, as PMD does, so they are used as they are.
+func rigidBodies(data js.Value) []springbone.RigidBody {
+
+	bodies := data.Get("rigidBodies")
+	r := make([]springbone.RigidBody, bodies.Length())
+	for i := range r {
+		b := bodies.Index(i)
+		r[i] = springbone.RigidBody{
+			Bone:           b.Get("boneIndex").Int(),
+			Type:           springbone.RigidBodyType(b.Get("type").Int()),
+			Shape:          springbone.RigidBodyShape(b.Get("shapeType").Int()),
+			Size:           [3]float64{b.Get("width").Float(), b.Get("height").Float(), b.Get("depth").Float()},
+			Position:       vector3(b.Get("position")),
+			AngularDamping: b.Get("rotationDamping").Float(),
+		}
+	}
+	return r
+}
+
+// constraints reads joints of the model.
+func constraints(data js.Value) []springbone.Constraint {
+
+	joints := data.Get("constraints")
+	c := make([]springbone.Constraint, joints.Length())
+	for i := range c {
+		j := joints.Index(i)
+		spring := j.Get("springRotation")
+		c[i] = springbone.Constraint{
+			BodyA:          j.Get("rigidBodyIndex1").Int(),
+			BodyB:          j.Get("rigidBodyIndex2").Int(),
+			SpringRotation: [3]float64{spring.Index(0).Float(), spring.Index(1).Float(), spring.Index(2).Float()},
+		}
+	}
+	return c
+}
+
+func vector3(v js.Value) math3d.Vector3 {
+	return math3d.NewVector3(v.Index(0).Float(), v.Index(1).Float(), v.Index(2).Float())
+}
+
+// float32q rounds the quaternion as Float32Array holds it.
+func float32q(q math3d.Quaternion) [4]float32 {
+	return [4]float32{float32(q.X), float32(q.Y), float32(q.Z), float32(q.W)}
+}
@@ -0,0 +1,66 @@
+package mmd
+
+import (
+	"app/lib/threejs"
+	"app/lib/threejs/texture"
+	"context"
+	"fmt"
+	"math"
+	"syscall/js"
+)
+
+// SharedToonDirectory is the directory of the shared toon textures toon01.bmp - toon10.bmp which come with MMD.
+var SharedToonDirectory = "./assets/models/mmd/toon/"
+
+// SharedToonCount is the number of the shared toon textures.
+const SharedToonCount = 10
+
+// SharedToonPath gets the path of the shared toon texture n (1 - 10).
+func SharedToonPath(n int) (string, error) {
+	if n < 1 || n > SharedToonCount {
+		return "", fmt.Errorf("shared toon %d is out of range", n)
+	}
+
+	return fmt.Sprintf("%vtoon%02d.bmp", SharedToonDirectory, n), nil
+}
+
+// LoadToonTextures loads images as toon ramps for MeshToonMaterial.SetGradientMap.
+//
+// MMD toon textures are vertical gradients, while gradientMap is read horizontally.
+// The images are rotated in the same way as MMDLoader does for toon textures of models.
+func LoadToonTextures(ctx context.Context, urls []string) <-chan *threejs.Future[threejs.Texture] {
+
+	loader := texture.NewLoader()
+
+	load := func(url string, onLoad js.Func, onProgress js.Func, onError js.Func) {
+		loader.Load(url, onLoad, onProgress, onError)
+	}
+	return threejs.Load(ctx, urls, load, func(v js.Value) (threejs.Texture, error) {
+		tx := threejs.NewDefaultTextureFromJSValue(v)
+		rotateToonImage(v)
+		tx.SetMagFilter(threejs.NearestFilter)
+		tx.SetMinFilter(threejs.NearestFilter)
+		tx.SetNeedsUpdate(true)
+		return tx, nil
+	})
+}
+
+// rotateToonImage rotates the image of the texture by 90 degrees.
+func rotateToonImage(tx js.Value) {
+
+	image := tx.Get("image")
+	width := image.Get("width").Float()
+	height := image.Get("height").Float()
+
+	canvas := texture.NewCanvas()
+	canvas.SetSize(int(width), int(height))
+
+	c2d := canvas.Context2D()
+	c2d.Call("clearRect", 0, 0, width, height)
+	c2d.Call("translate", width/2.0, height/2.0)
+	c2d.Call("rotate", 0.5*math.Pi)
+	c2d.Call("translate", -width/2.0, -height/2.0)
+	c2d.Call("drawImage", image, 0, 0)
+
+	tx.Set("image", c2d.Call("getImageData", 0, 0, width, height))
+}
@@ -0,0 +1,50 @@
+package mmd
+
+import (
+	"app/lib/mmd/vmd"
+	"app/lib/threejs"
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// LoadVMDs loads VMD files and decodes them in Go.
+//
+// MMDLoader converts VMD files to AnimationClip and drops keyframes which three.js does not use,
+// such as self shadow and light. Use this to read them.
+func LoadVMDs(ctx context.Context, urls []string) <-chan *threejs.Future[*vmd.Motion] {
+
+	s := threejs.NewStream[*vmd.Motion](ctx, urls)
+
+	go func() {
+		defer s.Close()
+
+		loader := threejs.NewFileLoader()
+		loader.SetResponseType("arraybuffer")
+
+		for _, url := range urls {
+
+			b, err := loadBytes(ctx, loader, url, func(loaded uint, total uint) {
+				s.Progress(url, loaded, total)
+			})
+			if err == context.Canceled || err == context.DeadlineExceeded {
+				return
+			}
+
+			var m *vmd.Motion
+			if err == nil {
+				if m, err = vmd.Decode(bytes.NewReader(b)); err != nil {
+					err = fmt.Errorf("%v: %w", url, err)
+				} else {
+					m.Sort()
+				}
+			}
+
+			if !s.Done(url, m, err) {
+				return
+			}
+		}
+	}()
+
+	return s.Chan()
+}
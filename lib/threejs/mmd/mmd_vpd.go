@@ -1,6 +1,9 @@
 package mmd
 
-import "syscall/js"
+import (
+	"app/lib/mmd/vpd"
+	"syscall/js"
+)
 
 type Vpd interface {
 	JSValue() js.Value
@@ -15,3 +18,27 @@ func NewVpdFromJSValue(v js.Value) Vpd {
 		Value: v,
 	}
 }
+
+// NewVpdFromPose creates Vpd, which AnimationHelper.Pose takes, from the pose decoded in Go.
+// Morphs are not included, since AnimationHelper does not use them.
+func NewVpdFromPose(p *vpd.Pose) Vpd {
+
+	bones := make([]interface{}, len(p.Bones))
+	for i, b := range p.Bones {
+		// 左手系から右手系に変換する
+		bones[i] = map[string]interface{}{
+			"name":        b.Name,
+			"translation": []interface{}{b.Position[0], b.Position[1], -b.Position[2]},
+			"quaternion":  []interface{}{-b.Rotation[0], -b.Rotation[1], b.Rotation[2], b.Rotation[3]},
+		}
+	}
+
+	return NewVpdFromJSValue(js.ValueOf(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"modelName":        p.ModelFile,
+			"coordinateSystem": "right",
+			"boneCount":        len(p.Bones),
+		},
+		"bones": bones,
+	}))
+}
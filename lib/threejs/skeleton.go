@@ -2,6 +2,7 @@ package threejs
 
 import (
 	"errors"
+	"fmt"
 	"syscall/js"
 )
 
@@ -15,6 +16,12 @@ type Skeleton interface {
 	// BoneTextureSize gets The size of the .boneTexture.
 	BoneTextureSize() int
 
+	// Bones gets the array of bones.
+	Bones() []Object3D
+
+	// BoneByName searches through the skeleton's bone array and returns the first with a matching name.
+	BoneByName(name string) (Object3D, error)
+
 	// Pose returns the skeleton to the base pose.
 	Pose()
 
@@ -59,6 +66,30 @@ func (c *skeletonImp) BoneTextureSize() int {
 	return c.Get("boneTextureSize").Int()
 }
 
+// Bones gets the array of bones.
+func (c *skeletonImp) Bones() []Object3D {
+
+	b := c.Get("bones")
+	l := b.Length()
+	bones := make([]Object3D, l)
+	for i := 0; i < l; i++ {
+		bones[i] = NewObject3DFromJSValue(b.Index(i))
+	}
+
+	return bones
+}
+
+// BoneByName searches through the skeleton's bone array and returns the first with a matching name.
+func (c *skeletonImp) BoneByName(name string) (Object3D, error) {
+
+	b := c.Call("getBoneByName", name)
+	if b.IsUndefined() || b.IsNull() {
+		return nil, fmt.Errorf("bone %q is not found", name)
+	}
+
+	return NewObject3DFromJSValue(b), nil
+}
+
 // Pose returns the skeleton to the base pose.
 func (c *skeletonImp) Pose() {
 	c.Call("pose")
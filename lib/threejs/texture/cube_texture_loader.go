@@ -2,6 +2,7 @@ package texture
 
 import (
 	"app/lib/threejs"
+	"context"
 	"syscall/js"
 )
 
@@ -12,6 +13,10 @@ type CubeLoader interface {
 
 	Load(urls []string, onLoad js.Func, onProgress js.Func, onError js.Func) CubeTexture
 	LoadSimply(urls []string) CubeTexture
+
+	// LoadCube loads the six images of the faces (px, nx, py, ny, pz, nz) in parallel.
+	// Each image is sent to the channel as it is loaded, and the cube texture is sent with the last one.
+	LoadCube(ctx context.Context, urls []string) <-chan *threejs.Future[CubeTexture]
 }
 
 type cubeTextureLoaderImp struct {
@@ -55,3 +60,53 @@ func (c *cubeTextureLoaderImp) LoadSimply(urls []string) CubeTexture {
 		c.JSValue().Call("load", jsImages),
 	)
 }
+
+func (c *cubeTextureLoaderImp) LoadCube(ctx context.Context, urls []string) <-chan *threejs.Future[CubeTexture] {
+
+	if len(urls) > 6 {
+		urls = urls[:6] // index を最大 0 - 5 の範囲でデータ入れる
+	}
+
+	// CubeTextureLoaderと同じく、ImageLoaderで面ごとに読み込む
+	images := threejs.Threejs("ImageLoader").New(c.JSValue().Get("manager"))
+	images.Call("setCrossOrigin", c.JSValue().Get("crossOrigin"))
+	images.Call("setPath", c.JSValue().Get("path"))
+	load := func(url string, onLoad js.Func, onProgress js.Func, onError js.Func) {
+		images.Call("load", url, onLoad, onProgress, onError)
+	}
+
+	s := threejs.NewStream[CubeTexture](ctx, urls)
+
+	go func() {
+		faces := make([]interface{}, len(urls))
+		loaded := 0
+
+		threejs.LoadEach(ctx, urls, load, func(url string, n uint, total uint) {
+			s.Progress(url, n, total)
+		}, func(url string, v js.Value, err error) {
+			if err != nil {
+				s.Done(url, nil, err)
+				return
+			}
+
+			// 同じURLが複数ある場合は、空いている最初の面に入れる
+			for i, u := range urls {
+				if u == url && faces[i] == nil {
+					faces[i] = v
+					break
+				}
+			}
+			loaded++
+
+			var cube CubeTexture
+			if loaded == len(urls) {
+				cube = NewCubeTextureFromJSValue(threejs.Threejs("CubeTexture").New(faces))
+				cube.SetNeedsUpdate(true)
+			}
+			s.Done(url, cube, nil)
+		})
+		s.Close()
+	}()
+
+	return s.Chan()
+}
@@ -2,6 +2,7 @@ package texture
 
 import (
 	"app/lib/threejs"
+	"context"
 	"syscall/js"
 )
 
@@ -12,6 +13,10 @@ type Loader interface {
 
 	Load(url string, onLoad js.Func, onProgress js.Func, onError js.Func) threejs.Texture
 	LoadSimply(url string) threejs.Texture
+
+	// LoadTextures loads the images in parallel and sends the textures to the channel in the order loading completes.
+	// Progress of loading is sent before them.
+	LoadTextures(ctx context.Context, urls []string) <-chan *threejs.Future[threejs.Texture]
 }
 
 type textureLoaderImp struct {
@@ -58,3 +63,16 @@ func (c *textureLoaderImp) LoadSimply(url string) threejs.Texture {
 		c.JSValue().Call("load", url),
 	)
 }
+
+// LoadTextures loads the images in parallel and sends the textures to the channel in the order loading completes.
+// Progress of loading is sent before them.
+func (c *textureLoaderImp) LoadTextures(ctx context.Context, urls []string) <-chan *threejs.Future[threejs.Texture] {
+
+	load := func(url string, onLoad js.Func, onProgress js.Func, onError js.Func) {
+		c.JSValue().Call("load", url, onLoad, onProgress, onError)
+	}
+	return threejs.Load(ctx, urls, load, func(v js.Value) (threejs.Texture, error) {
+		return threejs.NewDefaultTextureFromJSValue(v), nil
+	})
+
+}
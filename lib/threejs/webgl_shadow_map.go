@@ -68,13 +68,63 @@ func (wglsm *WebGLShadowMap) SetRenderReverseSided(v bool) {
 	wglsm.Set("renderReverseSided", v)
 }
 
-// //
-// func (wglsm *WebGLShadowMap) Type() ShadowMapType {
-// 	return ShadowMapType(wglsm.Get("type"))
-// }
-// func (wglsm *WebGLShadowMap) SetType(v ShadowMapType) {
-// 	wglsm.Set("type", v)
-// }
+// Type is ..
+func (wglsm *WebGLShadowMap) Type() ShadowMapType {
+	return ShadowMapTypeOf(wglsm.Get("type"))
+}
+
+// SetType is ..
+func (wglsm *WebGLShadowMap) SetType(v ShadowMapType) {
+	wglsm.Set("type", v.JSValue())
+}
+
 // func (wglsm *WebGLShadowMap) Render(scene Scene, camera Camera) {
 // 	wglsm.Call("render", scene.JSValue(), camera.JSValue())
 // }
+
+// ShadowMapType defines shadow map filtering type.
+type ShadowMapType int
+
+const (
+	// BasicShadowMap gives unfiltered shadow maps - fastest, but lowest quality.
+	BasicShadowMap ShadowMapType = iota
+	// PCFShadowMap filters shadow maps using the Percentage-Closer Filtering (PCF) algorithm (default).
+	PCFShadowMap
+	// PCFSoftShadowMap filters shadow maps using the PCF algorithm with better soft shadows especially when using low-resolution shadow maps.
+	PCFSoftShadowMap
+	// VSMShadowMap filters shadow maps using the Variance Shadow Map (VSM) algorithm.
+	// When using VSMShadowMap all shadow receivers will also cast shadows.
+	VSMShadowMap
+)
+
+var shadowMapTypeDic map[ShadowMapType]js.Value = make(map[ShadowMapType]js.Value)
+
+func getShadowMapTypeDictionary() map[ShadowMapType]js.Value {
+	if len(shadowMapTypeDic) == 0 {
+		shadowMapTypeDic[BasicShadowMap] = Threejs("BasicShadowMap")
+		shadowMapTypeDic[PCFShadowMap] = Threejs("PCFShadowMap")
+		shadowMapTypeDic[PCFSoftShadowMap] = Threejs("PCFSoftShadowMap")
+		shadowMapTypeDic[VSMShadowMap] = Threejs("VSMShadowMap")
+	}
+	return shadowMapTypeDic
+}
+
+// JSValue return js.Value for ShadowMapType
+func (c ShadowMapType) JSValue() js.Value {
+	dic := getShadowMapTypeDictionary()
+	if v, ok := dic[c]; ok {
+		return v
+	}
+	return js.Null()
+}
+
+// ShadowMapTypeOf converts js.Value to ShadowMapType constants.
+func ShadowMapTypeOf(v js.Value) ShadowMapType {
+	dic := getShadowMapTypeDictionary()
+	for key, val := range dic {
+		if val.Equal(v) {
+			return key
+		}
+	}
+	return PCFShadowMap
+}